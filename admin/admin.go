@@ -0,0 +1,375 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements an HTTP API to manage a Cron.
+
+// Package admin serves an HTTP API to inspect and manage a running cron.Cron,
+// so that operators can change its entries without redeploying:
+//
+// 	http.Handle("/cron/", http.StripPrefix("/cron", admin.NewHandler(c)))
+//
+// It serves JSON:
+//
+// 	GET    /entries           the entries, with their stats, ordered by next
+// 	                          activation; the parameters label=k=v,
+// 	                          prefix, sort=name, offset and limit select,
+// 	                          order and page them
+// 	POST   /entries           add an entry of a registered job, from
+// 	                          {"spec", "job", "payload", "name", "labels"}
+// 	GET    /entries/{id}      an entry, with its stats and history
+// 	PUT    /entries/{id}      change the spec of an entry, from {"spec"}
+// 	DELETE /entries/{id}      remove an entry
+// 	POST   /entries/{id}/run  run the job of an entry now
+// 	GET    /status            the state of the scheduler
+// 	POST   /pause             stop the scheduler
+// 	POST   /resume            start the scheduler again
+//
+// Errors are returned as {"error"}.  The handler does not authenticate
+// requests; it should be served behind whatever protects the service's other
+// administrative endpoints.
+package admin
+
+import (
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net/http"
+  "net/url"
+  "strconv"
+  "strings"
+  "time"
+
+  "github.com/kiranbond/cron"
+)
+
+// handler is the http.Handler of the API of a Cron.
+type handler struct {
+  cron *cron.Cron
+}
+
+// NewHandler returns an http.Handler serving the API of c.
+func NewHandler(c *cron.Cron) http.Handler {
+  return &handler{cron: c}
+}
+
+// entry is the JSON form of a cron.Entry.
+type entry struct {
+  ID      string            `json:"id"`
+  Name    string            `json:"name,omitempty"`
+  Spec    string            `json:"spec,omitempty"`
+  Labels  map[string]string `json:"labels,omitempty"`
+  Job     string            `json:"job,omitempty"`
+  Payload string            `json:"payload,omitempty"`
+  Next    time.Time         `json:"next"`
+  Prev    time.Time         `json:"prev"`
+  Runs    int               `json:"runs"`
+  Stats   stats             `json:"stats"`
+  History []run             `json:"history,omitempty"`
+}
+
+// stats is the JSON form of cron.EntryStats.
+type stats struct {
+  Runs                int       `json:"runs"`
+  Failures            int       `json:"failures"`
+  Skipped             int       `json:"skipped"`
+  ConsecutiveFailures int       `json:"consecutiveFailures"`
+  MinDuration         string    `json:"minDuration"`
+  AvgDuration         string    `json:"avgDuration"`
+  MaxDuration         string    `json:"maxDuration"`
+  LastError           string    `json:"lastError,omitempty"`
+  LastFailure         time.Time `json:"lastFailure,omitempty"`
+}
+
+// run is the JSON form of a cron.RunRecord.
+type run struct {
+  Scheduled time.Time `json:"scheduled"`
+  Started   time.Time `json:"started,omitempty"`
+  Duration  string    `json:"duration,omitempty"`
+  Outcome   string    `json:"outcome"`
+  Error     string    `json:"error,omitempty"`
+  Output    string    `json:"output,omitempty"`
+}
+
+// status is the JSON form of cron.DebugInfo.
+type status struct {
+  Running  bool      `json:"running"`
+  Entries  int       `json:"entries"`
+  NextWake time.Time `json:"nextWake"`
+  InFlight int       `json:"inFlight"`
+  Queued   int       `json:"queued"`
+  Waiting  int       `json:"waiting"`
+  Skipped  int       `json:"skipped"`
+  Dropped  int       `json:"dropped"`
+}
+
+// addRequest is the body of a request adding an entry.
+type addRequest struct {
+  Spec    string            `json:"spec"`
+  Job     string            `json:"job"`
+  Payload json.RawMessage   `json:"payload,omitempty"`
+  Name    string            `json:"name,omitempty"`
+  Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// updateRequest is the body of a request changing the spec of an entry.
+type updateRequest struct {
+  Spec string `json:"spec"`
+}
+
+// errMethod is the error of requests whose method a path does not allow.
+var errMethod = errors.New("method not allowed")
+
+// ServeHTTP implements http.Handler.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+  path := strings.Trim(r.URL.Path, "/")
+  parts := strings.Split(path, "/")
+  switch {
+  case path == "entries":
+    switch r.Method {
+    case http.MethodGet:
+      h.list(w, r)
+    case http.MethodPost:
+      h.add(w, r)
+    default:
+      writeError(w, http.StatusMethodNotAllowed, errMethod)
+    }
+  case len(parts) == 2 && parts[0] == "entries":
+    h.serveEntry(w, r, parts[1])
+  case len(parts) == 3 && parts[0] == "entries" && parts[2] == "run":
+    if r.Method != http.MethodPost {
+      writeError(w, http.StatusMethodNotAllowed, errMethod)
+      return
+    }
+    h.runNow(w, parts[1])
+  case path == "status" && r.Method == http.MethodGet:
+    h.status(w)
+  case (path == "pause" || path == "resume") && r.Method == http.MethodPost:
+    if path == "pause" {
+      h.cron.Stop()
+    } else {
+      h.cron.Start()
+    }
+    h.status(w)
+  case path == "status" || path == "pause" || path == "resume":
+    writeError(w, http.StatusMethodNotAllowed, errMethod)
+  default:
+    writeError(w, http.StatusNotFound, fmt.Errorf("no such path: %s",
+      r.URL.Path))
+  }
+}
+
+// serveEntry serves a request for the entry with the given ID.
+func (h *handler) serveEntry(w http.ResponseWriter, r *http.Request,
+  id string) {
+  e, ok := h.cron.Entry(id)
+  if !ok {
+    writeError(w, http.StatusNotFound, fmt.Errorf("no entry with id %s", id))
+    return
+  }
+  switch r.Method {
+  case http.MethodGet:
+    v := toEntry(&e)
+    for _, record := range e.History {
+      v.History = append(v.History, toRun(record))
+    }
+    writeJSON(w, http.StatusOK, v)
+  case http.MethodPut:
+    var req updateRequest
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+      writeError(w, http.StatusBadRequest, err)
+      return
+    }
+    if err := h.cron.UpdateJob(id, req.Spec); err != nil {
+      writeError(w, http.StatusBadRequest, err)
+      return
+    }
+    e, _ = h.cron.Entry(id)
+    writeJSON(w, http.StatusOK, toEntry(&e))
+  case http.MethodDelete:
+    h.cron.DeleteJob(id)
+    w.WriteHeader(http.StatusNoContent)
+  default:
+    writeError(w, http.StatusMethodNotAllowed, errMethod)
+  }
+}
+
+// list serves the entries the query of the request selects.
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+  opts, err := entriesOptions(r.URL.Query())
+  if err != nil {
+    writeError(w, http.StatusBadRequest, err)
+    return
+  }
+  entries := []entry{}
+  for _, e := range h.cron.Entries(opts...) {
+    entries = append(entries, toEntry(e))
+  }
+  writeJSON(w, http.StatusOK, entries)
+}
+
+// entriesOptions returns the options of Entries that the query parameters of
+// a request listing entries ask for.
+func entriesOptions(query url.Values) ([]cron.EntriesOption, error) {
+  var opts []cron.EntriesOption
+  if labels := query["label"]; len(labels) > 0 {
+    m := map[string]string{}
+    for _, label := range labels {
+      k, v, ok := strings.Cut(label, "=")
+      if !ok {
+        return nil, fmt.Errorf("invalid label: %s", label)
+      }
+      m[k] = v
+    }
+    opts = append(opts, cron.FilterLabels(m))
+  }
+  if prefix := query.Get("prefix"); prefix != "" {
+    opts = append(opts, cron.FilterNamePrefix(prefix))
+  }
+  switch sort := query.Get("sort"); sort {
+  case "", "next":
+  case "name":
+    opts = append(opts, cron.SortByName())
+  default:
+    return nil, fmt.Errorf("invalid sort: %s", sort)
+  }
+  var offset, limit int
+  for name, n := range map[string]*int{"offset": &offset, "limit": &limit} {
+    if value := query.Get(name); value != "" {
+      var err error
+      if *n, err = strconv.Atoi(value); err != nil || *n < 0 {
+        return nil, fmt.Errorf("invalid %s: %s", name, value)
+      }
+    }
+  }
+  return append(opts, cron.Page(offset, limit)), nil
+}
+
+// add adds the entry of the request.
+func (h *handler) add(w http.ResponseWriter, r *http.Request) {
+  var req addRequest
+  if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+    writeError(w, http.StatusBadRequest, err)
+    return
+  }
+  var opts []cron.EntryOption
+  if req.Name != "" {
+    opts = append(opts, cron.WithName(req.Name))
+  }
+  if req.Labels != nil {
+    opts = append(opts, cron.WithLabels(req.Labels))
+  }
+  // A JSON string payload is passed as is, and other values as JSON.
+  var payload []byte
+  var s string
+  if err := json.Unmarshal(req.Payload, &s); err == nil {
+    payload = []byte(s)
+  } else if len(req.Payload) > 0 {
+    payload = req.Payload
+  }
+  id, err := h.cron.AddRegistered(req.Spec, req.Job, payload, opts...)
+  if err == cron.ErrDuplicateName {
+    writeError(w, http.StatusConflict, fmt.Errorf("%s: %s", err, id))
+    return
+  }
+  if err != nil {
+    writeError(w, http.StatusBadRequest, err)
+    return
+  }
+  e, _ := h.cron.Entry(id)
+  writeJSON(w, http.StatusCreated, toEntry(&e))
+}
+
+// runNow runs the job of the entry with the given ID.
+func (h *handler) runNow(w http.ResponseWriter, id string) {
+  if err := h.cron.RunNow(id); err != nil {
+    writeError(w, http.StatusNotFound, err)
+    return
+  }
+  w.WriteHeader(http.StatusAccepted)
+}
+
+// status serves the state of the scheduler.
+func (h *handler) status(w http.ResponseWriter) {
+  info := h.cron.Debug()
+  writeJSON(w, http.StatusOK, status{
+    Running:  info.Running,
+    Entries:  info.Entries,
+    NextWake: info.NextWake,
+    InFlight: info.InFlight,
+    Queued:   info.Queued,
+    Waiting:  info.Waiting,
+    Skipped:  info.Skipped,
+    Dropped:  info.Dropped,
+  })
+}
+
+// toEntry returns the JSON form of the entry.
+func toEntry(e *cron.Entry) entry {
+  s := e.Stats
+  v := entry{
+    ID:      e.ID,
+    Name:    e.Name,
+    Spec:    e.Spec,
+    Labels:  e.Labels,
+    Job:     e.JobName,
+    Payload: string(e.Payload),
+    Next:    e.Next,
+    Prev:    e.Prev,
+    Runs:    e.Runs,
+    Stats: stats{
+      Runs:                s.Runs,
+      Failures:            s.Failures,
+      Skipped:             s.Skipped,
+      ConsecutiveFailures: s.ConsecutiveFailures,
+      MinDuration:         s.MinDuration.String(),
+      AvgDuration:         s.AvgDuration.String(),
+      MaxDuration:         s.MaxDuration.String(),
+      LastFailure:         s.LastFailure,
+    },
+  }
+  if s.LastError != nil {
+    v.Stats.LastError = s.LastError.Error()
+  }
+  return v
+}
+
+// toRun returns the JSON form of the run.
+func toRun(r cron.RunRecord) run {
+  v := run{
+    Scheduled: r.Scheduled,
+    Started:   r.Started,
+    Outcome:   r.Outcome.String(),
+    Output:    r.Output,
+  }
+  if r.Outcome != cron.RunSkipped {
+    v.Duration = r.Duration.String()
+  }
+  if r.Err != nil {
+    v.Error = r.Err.Error()
+  }
+  return v
+}
+
+// writeJSON writes v as the JSON body of a response with the given status.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+  w.Header().Set("Content-Type", "application/json")
+  w.WriteHeader(code)
+  json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes the error as the body of a response with the given
+// status.
+func writeError(w http.ResponseWriter, code int, err error) {
+  writeJSON(w, code, map[string]string{"error": err.Error()})
+}
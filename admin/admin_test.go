@@ -0,0 +1,228 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the HTTP API.
+
+package admin
+
+import (
+  "encoding/json"
+  "net/http"
+  "net/http/httptest"
+  "reflect"
+  "strings"
+  "testing"
+  "time"
+
+  "github.com/kiranbond/cron"
+)
+
+// runs receives the payloads of the runs of "admin-echo" jobs.
+var runs = make(chan string, 10)
+
+func init() {
+  cron.RegisterJob("admin-echo", func(payload []byte) (cron.Job, error) {
+    return cron.FuncJob(func() { runs <- string(payload) }), nil
+  })
+}
+
+// do sends the request to the server, and decodes the response into v unless
+// it is nil.
+func do(t *testing.T, server *httptest.Server, method, path, body string,
+  v interface{}) int {
+  t.Helper()
+  req, err := http.NewRequest(method, server.URL+path,
+    strings.NewReader(body))
+  if err != nil {
+    t.Fatal(err)
+  }
+  resp, err := http.DefaultClient.Do(req)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer resp.Body.Close()
+  if v != nil {
+    if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+      t.Fatalf("%s %s: %s", method, path, err)
+    }
+  }
+  return resp.StatusCode
+}
+
+func TestEntries(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  server := httptest.NewServer(NewHandler(c))
+  defer server.Close()
+
+  var added entry
+  code := do(t, server, "POST", "/entries", `{"spec": "@hourly",
+    "job": "admin-echo", "payload": "hello", "name": "greet",
+    "labels": {"team": "infra"}}`, &added)
+  if code != http.StatusCreated || added.ID == "" || added.Name != "greet" ||
+    added.Job != "admin-echo" || added.Payload != "hello" ||
+    added.Labels["team"] != "infra" {
+    t.Fatalf("unexpected %d %+v", code, added)
+  }
+
+  var list []entry
+  if do(t, server, "GET", "/entries", "", &list); len(list) != 1 ||
+    list[0].ID != added.ID || list[0].Spec != "@hourly" ||
+    list[0].Next.IsZero() {
+    t.Errorf("unexpected entries %+v", list)
+  }
+
+  var updated entry
+  code = do(t, server, "PUT", "/entries/"+added.ID, `{"spec": "@daily"}`,
+    &updated)
+  if code != http.StatusOK || updated.Spec != "@daily" {
+    t.Errorf("unexpected %d %+v", code, updated)
+  }
+
+  if code := do(t, server, "POST", "/entries/"+added.ID+"/run", "",
+    nil); code != http.StatusAccepted {
+    t.Errorf("expected %d, got %d", http.StatusAccepted, code)
+  }
+  select {
+  case payload := <-runs:
+    if payload != "hello" {
+      t.Errorf("expected hello, got %s", payload)
+    }
+  case <-time.After(5 * time.Second):
+    t.Fatal("expected the job to run")
+  }
+
+  // The run shows in the history of the entry once it finished.
+  var got entry
+  deadline := time.Now().Add(5 * time.Second)
+  for {
+    do(t, server, "GET", "/entries/"+added.ID, "", &got)
+    if len(got.History) > 0 {
+      break
+    }
+    if time.Now().After(deadline) {
+      t.Fatal("expected the run in the history")
+    }
+    time.Sleep(10 * time.Millisecond)
+  }
+  if r := got.History[0]; r.Outcome != "RunCompleted" || got.Stats.Runs != 1 {
+    t.Errorf("unexpected history %+v and stats %+v", got.History, got.Stats)
+  }
+
+  if code := do(t, server, "DELETE", "/entries/"+added.ID, "",
+    nil); code != http.StatusNoContent {
+    t.Errorf("expected %d, got %d", http.StatusNoContent, code)
+  }
+  if do(t, server, "GET", "/entries", "", &list); len(list) != 0 {
+    t.Errorf("expected no entries, got %+v", list)
+  }
+}
+
+func TestListQuery(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  server := httptest.NewServer(NewHandler(c))
+  defer server.Close()
+  for _, name := range []string{"db-c", "web", "db-a", "db-b"} {
+    c.AddRegistered("@hourly", "admin-echo", nil, cron.WithName(name),
+      cron.WithLabels(map[string]string{"team": name[:2]}))
+  }
+
+  tests := []struct {
+    query    string
+    expected []string
+  }{
+    {"prefix=db&sort=name&offset=1&limit=1", []string{"db-b"}},
+    {"label=team=we", []string{"web"}},
+    {"sort=name&offset=2", []string{"db-c", "web"}},
+    {"prefix=app", nil},
+  }
+  for _, test := range tests {
+    var list []entry
+    if code := do(t, server, "GET", "/entries?"+test.query, "",
+      &list); code != http.StatusOK {
+      t.Errorf("%s: unexpected %d", test.query, code)
+    }
+    var names []string
+    for _, e := range list {
+      names = append(names, e.Name)
+    }
+    if !reflect.DeepEqual(names, test.expected) {
+      t.Errorf("%s: (expected) %v != %v (actual)", test.query, test.expected,
+        names)
+    }
+  }
+}
+
+func TestErrors(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  server := httptest.NewServer(NewHandler(c))
+  defer server.Close()
+  c.AddRegistered("@hourly", "admin-echo", nil, cron.WithName("taken"))
+
+  tests := []struct {
+    method, path, body string
+    code               int
+  }{
+    {"POST", "/entries", `{"spec": "bad", "job": "admin-echo"}`,
+      http.StatusBadRequest},
+    {"POST", "/entries", `{"spec": "@hourly", "job": "unknown"}`,
+      http.StatusBadRequest},
+    {"POST", "/entries", `{`, http.StatusBadRequest},
+    {"POST", "/entries", `{"spec": "@hourly", "job": "admin-echo",
+      "name": "taken"}`, http.StatusConflict},
+    {"GET", "/entries/missing", "", http.StatusNotFound},
+    {"PUT", "/entries/missing", `{"spec": "@daily"}`, http.StatusNotFound},
+    {"DELETE", "/entries/missing", "", http.StatusNotFound},
+    {"POST", "/entries/missing/run", "", http.StatusNotFound},
+    {"DELETE", "/entries", "", http.StatusMethodNotAllowed},
+    {"GET", "/entries?sort=size", "", http.StatusBadRequest},
+    {"GET", "/entries?limit=-1", "", http.StatusBadRequest},
+    {"GET", "/entries?label=team", "", http.StatusBadRequest},
+    {"GET", "/pause", "", http.StatusMethodNotAllowed},
+    {"GET", "/unknown", "", http.StatusNotFound},
+  }
+  for _, test := range tests {
+    var body map[string]string
+    code := do(t, server, test.method, test.path, test.body, &body)
+    if code != test.code || body["error"] == "" {
+      t.Errorf("%s %s: expected %d and an error, got %d %v", test.method,
+        test.path, test.code, code, body)
+    }
+  }
+
+  var list []entry
+  if do(t, server, "GET", "/entries", "", &list); len(list) != 1 {
+    t.Errorf("expected only the taken entry, got %+v", list)
+  }
+}
+
+func TestPauseResume(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  server := httptest.NewServer(NewHandler(c))
+  defer server.Close()
+
+  var s status
+  if do(t, server, "POST", "/resume", "", &s); !s.Running {
+    t.Errorf("expected the cron running, got %+v", s)
+  }
+  if do(t, server, "POST", "/pause", "", &s); s.Running {
+    t.Errorf("expected the cron paused, got %+v", s)
+  }
+  if do(t, server, "GET", "/status", "", &s); s.Running || s.Entries != 0 {
+    t.Errorf("unexpected status %+v", s)
+  }
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the AMQP Publisher.
+
+package amqppub
+
+import (
+  "context"
+  "testing"
+
+  amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AMQP channels are Channels.
+var _ Channel = (*amqp.Channel)(nil)
+
+// published is a message published by a fakeChannel.
+type published struct {
+  exchange, key string
+  msg           amqp.Publishing
+}
+
+// fakeChannel is a Channel recording the messages it publishes.
+type fakeChannel struct {
+  published []published
+}
+
+func (c *fakeChannel) PublishWithContext(ctx context.Context, exchange,
+  key string, mandatory, immediate bool, msg amqp.Publishing) error {
+  c.published = append(c.published, published{exchange, key, msg})
+  return nil
+}
+
+func TestPublish(t *testing.T) {
+  ch := &fakeChannel{}
+  p := New(ch, "ticks")
+  p.Publish(context.Background(), "report", []byte("one"))
+  p.ContentType, p.Persistent = "application/json", true
+  p.Publish(context.Background(), "report", []byte(`{}`))
+
+  if len(ch.published) != 2 {
+    t.Fatalf("expected 2 messages, got %d", len(ch.published))
+  }
+  first, second := ch.published[0], ch.published[1]
+  if first.exchange != "ticks" || first.key != "report" ||
+    string(first.msg.Body) != "one" || first.msg.ContentType != "" ||
+    first.msg.DeliveryMode == amqp.Persistent || first.msg.Timestamp.IsZero() {
+    t.Errorf("unexpected message %+v", first)
+  }
+  if second.msg.ContentType != "application/json" ||
+    second.msg.DeliveryMode != amqp.Persistent {
+    t.Errorf("unexpected message %+v", second)
+  }
+}
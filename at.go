@@ -0,0 +1,33 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements one-shot schedule.
+
+package cron
+
+import "time"
+
+// AtSchedule represents a schedule that activates exactly once, at Time.
+type AtSchedule struct {
+  Time time.Time `json:"time"`
+}
+
+// Next returns Time if it is later than the given time, and the zero time
+// otherwise, since the schedule never activates again.
+func (schedule AtSchedule) Next(t time.Time) time.Time {
+  if schedule.Time.After(t) {
+    return schedule.Time
+  }
+  return time.Time{}
+}
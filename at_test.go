@@ -0,0 +1,59 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements one-shot schedule test.
+
+package cron
+
+import "testing"
+
+func TestAtNext(t *testing.T) {
+  tests := []struct {
+    time, spec string
+    expected   string
+  }{
+    {"2012-07-09T14:45:00-0000", "@at 2012-07-09T15:00:00Z", "2012-07-09T15:00:00-0000"},
+    {"2012-07-09T14:59:59-0000", "@at 2012-07-09T15:00:00Z", "2012-07-09T15:00:00-0000"},
+    {"2012-07-09T10:59:59-0400", "@at 2012-07-09T15:00:00Z", "2012-07-09T15:00:00-0000"},
+    {"2012-07-09T15:00:00-0000", "@at 2012-07-09T15:00:00Z", ""},
+    {"2012-07-09T16:00:00-0000", "@at 2012-07-09T15:00:00Z", ""},
+  }
+
+  for _, c := range tests {
+    sched, err := Parse(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    actual := sched.Next(getTime(c.time))
+    expected := getTime(c.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s, \"%s\": (expected) %v != %v (actual)", c.time, c.spec, expected, actual)
+    }
+  }
+}
+
+func TestAtErrors(t *testing.T) {
+  invalidSpecs := []string{
+    "@at",
+    "@at tomorrow",
+    "@at 2012-07-09 15:00",
+  }
+  for _, spec := range invalidSpecs {
+    _, err := Parse(spec)
+    if err == nil {
+      t.Error("expected an error parsing: ", spec)
+    }
+  }
+}
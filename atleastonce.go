@@ -0,0 +1,189 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements running the runs of stored entries at least once.
+
+package cron
+
+import (
+  "time"
+)
+
+// WithAtLeastOnce makes an entry added with AddRegistered run each activation
+// at least once, even if the process exits while it runs: each run is claimed
+// in the Cron's EntryStore before it starts, and acknowledged once it returns,
+// and Restore runs the claimed runs that were not acknowledged again.  Runs
+// that failed are acknowledged too, and the jobs of such entries should cope
+// with running twice.
+func WithAtLeastOnce() EntryOption {
+  return func(e *Entry) {
+    e.AtLeastOnce = true
+  }
+}
+
+// ClaimPolicy decides what happens to the claimed runs of AtLeastOnce entries
+// that were abandoned, because the Cron running them exited before they
+// returned.
+type ClaimPolicy int
+
+const (
+  // ClaimRerun runs the abandoned runs again.  It is the default.
+  ClaimRerun ClaimPolicy = iota
+
+  // ClaimSkip records the abandoned runs as skipped with ErrAbandoned, and
+  // releases their claims.
+  ClaimSkip
+)
+
+// WithClaimPolicy sets what the Cron does with the abandoned runs it finds,
+// in Restore or, WithClaimTimeout, in the claims of other Crons.
+func WithClaimPolicy(policy ClaimPolicy) Option {
+  return func(c *Cron) {
+    c.claimPolicy = policy
+  }
+}
+
+// WithInstanceID sets the ID the Cron stores its claims under, which tells
+// them apart from those of the other Crons sharing its EntryStore.  It must be
+// unique among them, and is random by default.
+func WithInstanceID(id string) Option {
+  return func(c *Cron) {
+    c.instanceID = id
+  }
+}
+
+// WithClaimTimeout makes the Cron detect the runs that other Crons sharing its
+// EntryStore claimed, as passed to Apply, e.g. by etcdstore's Watch, and did
+// not acknowledge within timeout of their scheduled times.  Those are
+// considered abandoned, and handled according to the ClaimPolicy, when Apply
+// sees them or when the entry next activates, by the Cron that runs the entry.
+// The timeout should exceed the longest runs of the jobs, and the time a
+// Locker keeps their locks.
+func WithClaimTimeout(timeout time.Duration) Option {
+  return func(c *Cron) {
+    c.claimTimeout = timeout
+  }
+}
+
+// claim records the entry's run scheduled for the given time in the store,
+// before it starts.  It is called from the goroutine of the run.
+func (c *Cron) claim(e *Entry, scheduled time.Time) {
+  c.mu.Lock()
+  // The store is saved when c.mu is unlocked.
+  defer c.unlock()
+  for _, t := range e.claims {
+    if t.Equal(scheduled) {
+      return
+    }
+  }
+  e.claims = append(e.claims, scheduled)
+  if c.ids[e.ID] == e {
+    c.persist(e)
+  }
+}
+
+// acknowledge records that the entry's run scheduled for the given time
+// returned.  It is called from the goroutine of the run.
+func (c *Cron) acknowledge(e *Entry, scheduled time.Time) {
+  c.mu.Lock()
+  defer c.unlock()
+  c.unclaim(e, scheduled)
+}
+
+// unclaim releases the claim of the entry's run scheduled for the given time.
+// c.mu must be held.
+func (c *Cron) unclaim(e *Entry, scheduled time.Time) {
+  claims := e.claims[:0]
+  for _, t := range e.claims {
+    if !t.Equal(scheduled) {
+      claims = append(claims, t)
+    }
+  }
+  e.claims = claims
+  if c.ids[e.ID] == e {
+    c.persist(e)
+  }
+}
+
+// abandonClaims handles the claimed runs of the entry with the given id,
+// restored after the Cron that claimed them exited.
+func (c *Cron) abandonClaims(id string, claimed []time.Time) {
+  c.mu.Lock()
+  defer c.unlock()
+  if e := c.ids[id]; e != nil {
+    c.abandon(e, claimed)
+  }
+}
+
+// abandon runs the entry's abandoned runs again, or skips them, according to
+// the Cron's ClaimPolicy.  c.mu must be held.
+func (c *Cron) abandon(e *Entry, claimed []time.Time) {
+  for _, scheduled := range claimed {
+    if c.claimPolicy == ClaimSkip {
+      c.logger.Infof("skipping job %s, whose run at %v did not finish", e.ID,
+        scheduled)
+      c.skip(e, scheduled, ErrAbandoned)
+      c.unclaim(e, scheduled)
+      continue
+    }
+    c.logger.Infof("running job %s again, whose run at %v did not finish",
+      e.ID, scheduled)
+    c.startJob(e, scheduled)
+  }
+}
+
+// observeClaims records the runs of the stored entry claimed by another Cron,
+// replacing those it claimed before, and handles those it abandoned.
+func (c *Cron) observeClaims(s StoredEntry) {
+  if c.claimTimeout <= 0 || !s.AtLeastOnce || s.ClaimedBy == "" ||
+    s.ClaimedBy == c.instanceID {
+    return
+  }
+  c.mu.Lock()
+  defer c.unlock()
+  e := c.ids[s.ID]
+  if e == nil {
+    return
+  }
+  if e.foreignClaims == nil {
+    e.foreignClaims = map[string][]time.Time{}
+  }
+  e.foreignClaims[s.ClaimedBy] = append([]time.Time(nil), s.Claimed...)
+  c.recoverClaims(e, c.now())
+}
+
+// recoverClaims handles the runs of the entry claimed by other Crons that were
+// not acknowledged within the claim timeout, if the entry runs on this Cron.
+// c.mu must be held.
+func (c *Cron) recoverClaims(e *Entry, now time.Time) {
+  if len(e.foreignClaims) == 0 || !c.runsHere(e) {
+    return
+  }
+  for owner, claims := range e.foreignClaims {
+    var pending, abandoned []time.Time
+    for _, t := range claims {
+      if now.Sub(t) >= c.claimTimeout {
+        abandoned = append(abandoned, t)
+      } else {
+        pending = append(pending, t)
+      }
+    }
+    if len(pending) > 0 {
+      e.foreignClaims[owner] = pending
+    } else {
+      delete(e.foreignClaims, owner)
+    }
+    c.abandon(e, abandoned)
+  }
+}
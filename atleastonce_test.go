@@ -0,0 +1,213 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for running runs at least once.
+
+package cron
+
+import (
+  "context"
+  "reflect"
+  "sort"
+  "testing"
+  "time"
+)
+
+// blockStarted receives the scheduled times of the runs of "test-block" jobs,
+// which return once the blockRelease they were created with is closed.
+var (
+  blockStarted = make(chan time.Time, 10)
+  blockRelease chan struct{}
+)
+
+func init() {
+  RegisterJob("test-block", func(payload []byte) (Job, error) {
+    release := blockRelease
+    return ContextFuncJob(func(ctx context.Context) {
+      info, _ := RunInfoFrom(ctx)
+      blockStarted <- info.Scheduled
+      <-release
+    }), nil
+  })
+}
+
+func TestAtLeastOnce(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  store := NewMemoryStore()
+  blockRelease = make(chan struct{})
+  cron := New(WithClock(clock), WithLocation(time.UTC), WithStore(store))
+  id, err := cron.AddRegistered("@hourly", "test-block", nil,
+    WithAtLeastOnce())
+  if err != nil {
+    t.Fatal(err)
+  }
+  cron.Start()
+
+  // claimed returns the claimed runs of the stored entry.
+  claimed := func() []time.Time {
+    entries, _ := store.Load()
+    return entries[0].Claimed
+  }
+  clock.Advance(time.Hour)
+  if scheduled := <-blockStarted; !scheduled.Equal(start.Add(time.Hour)) {
+    t.Errorf("expected the run at %v, got %v", start.Add(time.Hour),
+      scheduled)
+  }
+  if expected := []time.Time{start.Add(time.Hour)}; !reflect.DeepEqual(
+    claimed(), expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, claimed())
+  }
+
+  // The process exits during the run, and another restores the entry.
+  cron.Stop()
+  restored := New(WithClock(clock), WithLocation(time.UTC), WithStore(store))
+  defer restored.Stop()
+  if err := restored.Restore(); err != nil {
+    t.Fatal(err)
+  }
+  if e, _ := restored.Entry(id); !e.AtLeastOnce {
+    t.Error("expected the restored entry to run at least once")
+  }
+  select {
+  case scheduled := <-blockStarted:
+    if !scheduled.Equal(start.Add(time.Hour)) {
+      t.Errorf("expected the run at %v again, got %v", start.Add(time.Hour),
+        scheduled)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatal("expected the unfinished run to run again")
+  }
+
+  close(blockRelease)
+  waitFor(t, func() bool { return len(claimed()) == 0 })
+}
+
+func TestAtMostOnce(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  store := NewMemoryStore()
+  cron := New(WithClock(clock), WithLocation(time.UTC), WithStore(store))
+  cron.AddRegistered("@hourly", "test-echo", []byte("once"))
+  cron.Start()
+  defer cron.Stop()
+
+  clock.Advance(time.Hour)
+  <-storeRuns
+  entries, _ := store.Load()
+  if entries[0].AtLeastOnce || entries[0].Claimed != nil {
+    t.Errorf("expected no claims, got %+v", entries[0])
+  }
+}
+
+// TestAbandonedClaims checks that a Cron sharing a store with one that exited
+// during a run runs it again, or skips it, once its claim times out.
+func TestAbandonedClaims(t *testing.T) {
+  for _, policy := range []ClaimPolicy{ClaimRerun, ClaimSkip} {
+    start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+    clock := NewFakeClock(start)
+    store := NewMemoryStore()
+    blockRelease = make(chan struct{})
+    dead := New(WithClock(clock), WithLocation(time.UTC), WithStore(store),
+      WithInstanceID("dead"))
+    dead.AddRegistered("@hourly", "test-block", nil, WithAtLeastOnce())
+    dead.Start()
+    clock.Advance(time.Hour)
+    <-blockStarted
+    dead.Stop()
+
+    peer := New(WithClock(clock), WithLocation(time.UTC), WithStore(store),
+      WithInstanceID("peer"), WithClaimTimeout(10*time.Minute),
+      WithClaimPolicy(policy))
+    skipped := make(chan Event, 10)
+    peer.Subscribe(func(e Event) {
+      if e.Type == RunSkipped {
+        skipped <- e
+      }
+    })
+    entries, _ := store.Load()
+    if entries[0].ClaimedBy != "dead" {
+      t.Errorf("expected the run claimed by dead, got %+v", entries[0])
+    }
+    // The claim is recent, so the run may still be running.
+    if err := peer.Apply(entries[0]); err != nil {
+      t.Fatal(err)
+    }
+    peer.Start()
+    select {
+    case scheduled := <-blockStarted:
+      t.Fatalf("%v: expected no run before the timeout, got %v", policy,
+        scheduled)
+    case <-skipped:
+      t.Fatalf("%v: expected no skip before the timeout", policy)
+    case <-time.After(10 * time.Millisecond):
+    }
+
+    // The claim times out by the next activation.
+    clock.Advance(time.Hour)
+    var runs []time.Time
+    for len(runs) < 2 && policy == ClaimRerun ||
+      len(runs) < 1 && policy == ClaimSkip {
+      select {
+      case scheduled := <-blockStarted:
+        runs = append(runs, scheduled)
+      case <-time.After(cOneSecond):
+        t.Fatalf("%v: expected more runs than %v", policy, runs)
+      }
+    }
+    if policy == ClaimSkip {
+      select {
+      case e := <-skipped:
+        if e.Err != ErrAbandoned || !e.Scheduled.Equal(start.Add(time.Hour)) {
+          t.Errorf("expected the run at %v skipped with %v, got %+v",
+            start.Add(time.Hour), ErrAbandoned, e)
+        }
+      case <-time.After(cOneSecond):
+        t.Fatal("expected the abandoned run to be skipped")
+      }
+    } else {
+      sort.Slice(runs, func(i, j int) bool { return runs[i].Before(runs[j]) })
+      expected := []time.Time{start.Add(time.Hour), start.Add(2 * time.Hour)}
+      if !reflect.DeepEqual(runs, expected) {
+        t.Errorf("(expected) %v != %v (actual)", expected, runs)
+      }
+    }
+    close(blockRelease)
+    peer.Stop()
+  }
+}
+
+func TestRestoreClaimSkip(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  store := NewMemoryStore()
+  store.Save(StoredEntry{ID: "a", Spec: "@hourly", Job: "test-echo",
+    AtLeastOnce: true, Claimed: []time.Time{start}, ClaimedBy: "dead"})
+  cron := New(WithClock(NewFakeClock(start.Add(time.Hour))),
+    WithLocation(time.UTC), WithStore(store), WithClaimPolicy(ClaimSkip))
+  if err := cron.Restore(); err != nil {
+    t.Fatal(err)
+  }
+  entries, _ := store.Load()
+  if len(entries[0].Claimed) != 0 {
+    t.Errorf("expected the claim released, got %v", entries[0].Claimed)
+  }
+  if entry, _ := cron.Entry("a"); entry.Stats.Skipped != 1 {
+    t.Errorf("expected the run skipped, got %+v", entry.Stats)
+  }
+  select {
+  case payload := <-storeRuns:
+    t.Errorf("expected no run, got %s", payload)
+  case <-time.After(10 * time.Millisecond):
+  }
+}
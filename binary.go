@@ -0,0 +1,216 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements binary encoding of schedules and entries.
+
+package cron
+
+import (
+  "bytes"
+  "encoding/binary"
+  "encoding/gob"
+  "fmt"
+  "time"
+)
+
+// binaryVersion is the first byte of every binary encoding, so the format can
+// change without misreading old snapshots.
+const binaryVersion byte = 1
+
+func init() {
+  // Allow Schedule values in gob streams.
+  gob.Register(&SpecSchedule{})
+  gob.Register(ConstantDelaySchedule{})
+  gob.Register(AtSchedule{})
+}
+
+// specScheduleBinaryLen is the length of a binary SpecSchedule, not counting
+// the trailing location name.
+const specScheduleBinaryLen = 1 + 7*8
+
+// MarshalBinary implements encoding.BinaryMarshaler.  The BusinessDays,
+// Calendar and DST policies are not encoded, and the Location is encoded by
+// name.
+func (s *SpecSchedule) MarshalBinary() ([]byte, error) {
+  b := make([]byte, 1, specScheduleBinaryLen)
+  b[0] = binaryVersion
+  for _, bits := range []uint64{s.Second, s.Minute, s.Hour, s.Dom, s.Month,
+    s.Dow, s.BusinessDay} {
+    b = binary.BigEndian.AppendUint64(b, bits)
+  }
+  if s.Location != nil {
+    b = append(b, s.Location.String()...)
+  }
+  return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (s *SpecSchedule) UnmarshalBinary(b []byte) error {
+  if err := checkBinary(b, specScheduleBinaryLen); err != nil {
+    return err
+  }
+  for i, bits := range []*uint64{&s.Second, &s.Minute, &s.Hour, &s.Dom,
+    &s.Month, &s.Dow, &s.BusinessDay} {
+    *bits = binary.BigEndian.Uint64(b[1+i*8:])
+  }
+  s.Location = nil
+  if name := string(b[specScheduleBinaryLen:]); name != "" {
+    loc, err := time.LoadLocation(name)
+    if err != nil {
+      return err
+    }
+    s.Location = loc
+  }
+  return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.  The Delay is followed by
+// the Precision.
+func (schedule ConstantDelaySchedule) MarshalBinary() ([]byte, error) {
+  b := []byte{binaryVersion}
+  b = binary.BigEndian.AppendUint64(b, uint64(schedule.Delay))
+  return binary.BigEndian.AppendUint64(b, uint64(schedule.Precision)), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.  Encodings without a
+// Precision, as written by earlier versions, have the default precision.
+func (schedule *ConstantDelaySchedule) UnmarshalBinary(b []byte) error {
+  if err := checkBinary(b, 1+8); err != nil {
+    return err
+  }
+  schedule.Delay = time.Duration(binary.BigEndian.Uint64(b[1:]))
+  schedule.Precision = 0
+  if len(b) >= 1+2*8 {
+    schedule.Precision = time.Duration(binary.BigEndian.Uint64(b[1+8:]))
+  }
+  return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (schedule AtSchedule) MarshalBinary() ([]byte, error) {
+  t, err := schedule.Time.MarshalBinary()
+  if err != nil {
+    return nil, err
+  }
+  return append([]byte{binaryVersion}, t...), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (schedule *AtSchedule) UnmarshalBinary(b []byte) error {
+  if len(b) == 0 || b[0] != binaryVersion {
+    return fmt.Errorf("unsupported binary encoding")
+  }
+  return schedule.Time.UnmarshalBinary(b[1:])
+}
+
+// checkBinary returns an error unless b is a binary encoding in the current
+// version of at least the given length.
+func checkBinary(b []byte, length int) error {
+  if len(b) == 0 || b[0] != binaryVersion {
+    return fmt.Errorf("unsupported binary encoding")
+  }
+  if len(b) < length {
+    return fmt.Errorf("binary encoding has %d bytes, expected %d", len(b),
+      length)
+  }
+  return nil
+}
+
+// entryBinary is the gob encoded form of an Entry.
+type entryBinary struct {
+  ID           string
+  Spec         string
+  ScheduleType string
+  Schedule     []byte
+  Next         time.Time
+  Prev         time.Time
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.  The schedule is included
+// if it is a SpecSchedule, ConstantDelaySchedule or AtSchedule; unlike with
+// MarshalJSON, other schedules need the spec they were parsed from.  The Job is
+// not encoded.
+func (e *Entry) MarshalBinary() ([]byte, error) {
+  v := entryBinary{ID: e.ID, Spec: e.Spec, Next: e.Next, Prev: e.Prev}
+  var err error
+  switch schedule := e.Schedule.(type) {
+  case *SpecSchedule:
+    v.ScheduleType = specScheduleType
+    v.Schedule, err = schedule.MarshalBinary()
+  case ConstantDelaySchedule:
+    v.ScheduleType = constantDelayScheduleType
+    v.Schedule, err = schedule.MarshalBinary()
+  case AtSchedule:
+    v.ScheduleType = atScheduleType
+    v.Schedule, err = schedule.MarshalBinary()
+  default:
+    if e.Spec == "" {
+      return nil, fmt.Errorf("cannot marshal schedule of type %T without a "+
+        "spec", e.Schedule)
+    }
+  }
+  if err != nil {
+    return nil, err
+  }
+
+  var buf bytes.Buffer
+  buf.WriteByte(binaryVersion)
+  if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+    return nil, err
+  }
+  return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.  The Job is left
+// unset.
+func (e *Entry) UnmarshalBinary(b []byte) error {
+  if len(b) == 0 || b[0] != binaryVersion {
+    return fmt.Errorf("unsupported binary encoding")
+  }
+  var v entryBinary
+  if err := gob.NewDecoder(bytes.NewReader(b[1:])).Decode(&v); err != nil {
+    return err
+  }
+
+  var schedule Schedule
+  var err error
+  switch v.ScheduleType {
+  case specScheduleType:
+    s := &SpecSchedule{}
+    err = s.UnmarshalBinary(v.Schedule)
+    schedule = s
+  case constantDelayScheduleType:
+    var s ConstantDelaySchedule
+    err = s.UnmarshalBinary(v.Schedule)
+    schedule = s
+  case atScheduleType:
+    var s AtSchedule
+    err = s.UnmarshalBinary(v.Schedule)
+    schedule = s
+  case "":
+    schedule, err = Parse(v.Spec)
+  default:
+    err = fmt.Errorf("unrecognized schedule type: %s", v.ScheduleType)
+  }
+  if err != nil {
+    return err
+  }
+
+  e.ID = v.ID
+  e.Spec = v.Spec
+  e.Schedule = schedule
+  e.Next = v.Next
+  e.Prev = v.Prev
+  return nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements binary encoding test.
+
+package cron
+
+import (
+  "bytes"
+  "encoding/gob"
+  "reflect"
+  "testing"
+  "time"
+)
+
+func TestEntryBinary(t *testing.T) {
+  next := getTime("Mon Jul 9 15:00 2012")
+  prev := getTime("Mon Jul 9 14:00 2012")
+  specs := []struct {
+    spec string
+    loc  *time.Location
+  }{
+    {"0 0 * * * *", time.UTC},
+    {"0 0 0 3B,LB * ?", time.UTC},
+    {"@every 1h", time.UTC},
+    {"@at 2012-07-09T15:00:00Z", time.UTC},
+    {"@hourly || 0 30 * * * *", nil},
+  }
+
+  for _, c := range specs {
+    spec := c.spec
+    schedule, err := ParseInLocation(spec, c.loc)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    entry := &Entry{ID: "id", Spec: spec, Schedule: schedule, Next: next, Prev: prev}
+    b, err := entry.MarshalBinary()
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+
+    var actual Entry
+    if err := actual.UnmarshalBinary(b); err != nil {
+      t.Error(err)
+      continue
+    }
+    if !actual.Next.Equal(next) || !actual.Prev.Equal(prev) {
+      t.Errorf("%s => (expected) %v, %v != %v, %v (actual)", spec, next, prev,
+        actual.Next, actual.Prev)
+    }
+    actual.Next, actual.Prev = next, prev
+    if !reflect.DeepEqual(&actual, entry) {
+      t.Errorf("%s => (expected) %+v != %+v (actual)", spec, entry, actual)
+    }
+  }
+
+  if _, err := (&Entry{Schedule: &UnionSchedule{}}).MarshalBinary(); err == nil {
+    t.Error("expected an error marshaling a union schedule without a spec")
+  }
+  if err := (&Entry{}).UnmarshalBinary([]byte{0}); err == nil {
+    t.Error("expected an error unmarshaling an unknown version")
+  }
+}
+
+// TestScheduleGob checks that Schedule values can be sent in gob streams.
+func TestScheduleGob(t *testing.T) {
+  schedules := []Schedule{}
+  for _, spec := range []string{"0 0 * * * *", "@every 1h", "@at 2012-07-09T15:00:00Z"} {
+    schedule, err := Parse(spec)
+    if err != nil {
+      t.Fatal(err)
+    }
+    schedules = append(schedules, schedule)
+  }
+
+  var buf bytes.Buffer
+  if err := gob.NewEncoder(&buf).Encode(schedules); err != nil {
+    t.Fatal(err)
+  }
+  var actual []Schedule
+  if err := gob.NewDecoder(&buf).Decode(&actual); err != nil {
+    t.Fatal(err)
+  }
+
+  now := getTime("Mon Jul 9 14:45 2012")
+  for i := range schedules {
+    if !actual[i].Next(now).Equal(schedules[i].Next(now)) {
+      t.Errorf("%v => (expected) %v != %v (actual)", schedules[i],
+        schedules[i].Next(now), actual[i].Next(now))
+    }
+  }
+}
+
+func TestConstantDelayBinary(t *testing.T) {
+  schedule := EveryWithPrecision(250*time.Millisecond, time.Millisecond)
+  b, err := schedule.MarshalBinary()
+  if err != nil {
+    t.Fatal(err)
+  }
+  var actual ConstantDelaySchedule
+  if err := actual.UnmarshalBinary(b); err != nil {
+    t.Fatal(err)
+  }
+  if actual != schedule {
+    t.Errorf("(expected) %+v != %+v (actual)", schedule, actual)
+  }
+
+  // Encodings without a precision have the default one.
+  if err := actual.UnmarshalBinary(b[:1+8]); err != nil {
+    t.Fatal(err)
+  }
+  expected := ConstantDelaySchedule{Delay: 250 * time.Millisecond}
+  if actual != expected {
+    t.Errorf("(expected) %+v != %+v (actual)", expected, actual)
+  }
+}
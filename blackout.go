@@ -0,0 +1,166 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements blackout windows, during which activations do not run.
+
+package cron
+
+import (
+  "sync/atomic"
+  "time"
+)
+
+// Window is a set of periods of time, e.g. a maintenance window.
+type Window interface {
+  // Covers returns whether t is within one of the periods, and if so when the
+  // period ends.
+  Covers(t time.Time) (end time.Time, ok bool)
+}
+
+// Between returns a Window from start, inclusive, to end, exclusive.
+func Between(start, end time.Time) Window {
+  return oneOffWindow{start, end}
+}
+
+// oneOffWindow is a Window created by Between.
+type oneOffWindow struct {
+  start, end time.Time
+}
+
+// Covers returns whether t is between the start and the end.
+func (w oneOffWindow) Covers(t time.Time) (time.Time, bool) {
+  if t.Before(w.start) || !t.Before(w.end) {
+    return time.Time{}, false
+  }
+  return w.end, true
+}
+
+// Recurring returns a Window of periods of duration d starting at each
+// activation of schedule, e.g. two hours every Sunday at 2am:
+//
+// 	cron.Recurring(cron.MustParse("0 0 2 * * SUN"), 2*time.Hour)
+func Recurring(schedule Schedule, d time.Duration) Window {
+  return recurringWindow{schedule, d}
+}
+
+// recurringWindow is a Window created by Recurring.
+type recurringWindow struct {
+  schedule Schedule
+  duration time.Duration
+}
+
+// Covers returns whether the schedule activated within the duration up to t,
+// and if so when the period of its last activation ends.
+func (w recurringWindow) Covers(t time.Time) (time.Time, bool) {
+  var end time.Time
+  for start := w.schedule.Next(t.Add(-w.duration)); !start.IsZero() &&
+    !start.After(t); start = w.schedule.Next(start) {
+    end = start.Add(w.duration)
+  }
+  return end, !end.IsZero()
+}
+
+// BlackoutPolicy decides what happens to the activations of an entry within a
+// blackout window.
+type BlackoutPolicy int
+
+const (
+  // BlackoutSkip skips the activations, emitting RunSkipped events with
+  // ErrBlackout.  It is the default.
+  BlackoutSkip BlackoutPolicy = iota
+
+  // BlackoutDefer runs the job once, when the window ends, for all the
+  // activations within it.
+  BlackoutDefer
+)
+
+// Blackout is a window during which activations do not run on time.
+type Blackout struct {
+  Window Window
+  Policy BlackoutPolicy
+}
+
+// WithBlackout suppresses the activations of every entry of the Cron within
+// the window, according to the policy.
+func WithBlackout(w Window, policy BlackoutPolicy) Option {
+  return func(c *Cron) {
+    c.blackouts = append(c.blackouts, &Blackout{w, policy})
+  }
+}
+
+// BlackoutDuring suppresses the activations of the entry within the window,
+// according to the policy.
+func BlackoutDuring(w Window, policy BlackoutPolicy) EntryOption {
+  return func(e *Entry) {
+    e.Blackouts = append(e.Blackouts, Blackout{w, policy})
+  }
+}
+
+// AddBlackout suppresses the activations of every entry of the Cron within the
+// window, according to the policy, until the returned function is called, e.g.
+// to silence all jobs during a deploy:
+//
+// 	end := c.AddBlackout(cron.Between(start, start.Add(time.Hour)),
+// 		cron.BlackoutSkip)
+// 	defer end()
+func (c *Cron) AddBlackout(w Window, policy BlackoutPolicy) (remove func()) {
+  b := &Blackout{w, policy}
+  c.mu.Lock()
+  c.blackouts = append(c.blackouts, b)
+  c.unlock()
+
+  var removed int32
+  return func() {
+    if !atomic.CompareAndSwapInt32(&removed, 0, 1) {
+      return
+    }
+    c.mu.Lock()
+    defer c.unlock()
+    for i, other := range c.blackouts {
+      if other == b {
+        c.blackouts = append(c.blackouts[:i:i], c.blackouts[i+1:]...)
+        break
+      }
+    }
+    c.wakeup()
+  }
+}
+
+// blackedOut returns whether the entry's activation at t is within a blackout
+// window, and if so the policy that applies: if any of the windows covering t
+// skip it the activation is skipped, and otherwise deferred until the last of
+// them ends.  c.mu must be held.
+func (c *Cron) blackedOut(e *Entry, t time.Time) (policy BlackoutPolicy,
+  end time.Time, ok bool) {
+  check := func(b Blackout) {
+    windowEnd, covers := b.Window.Covers(t)
+    if !covers {
+      return
+    }
+    if !ok || b.Policy == BlackoutSkip {
+      policy = b.Policy
+    }
+    if windowEnd.After(end) {
+      end = windowEnd
+    }
+    ok = true
+  }
+  for _, b := range c.blackouts {
+    check(*b)
+  }
+  for _, b := range e.Blackouts {
+    check(b)
+  }
+  return policy, end, ok
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for blackout windows.
+
+package cron
+
+import (
+  "testing"
+  "time"
+)
+
+func TestWindowCovers(t *testing.T) {
+  oneOff := Between(getTime("Mon Jul 9 14:00 2012"),
+    getTime("Mon Jul 9 16:00 2012"))
+  sundays := Recurring(MustParse("0 0 2 * * SUN"), 2*time.Hour)
+  hourly := Recurring(MustParse("@hourly"), 90*time.Minute)
+
+  tests := []struct {
+    window Window
+    time   string
+    end    string
+  }{
+    {oneOff, "Mon Jul 9 13:59:59 2012", ""},
+    {oneOff, "Mon Jul 9 14:00 2012", "Mon Jul 9 16:00 2012"},
+    {oneOff, "Mon Jul 9 15:59:59 2012", "Mon Jul 9 16:00 2012"},
+    {oneOff, "Mon Jul 9 16:00 2012", ""},
+
+    {sundays, "Sun Jul 8 01:59:59 2012", ""},
+    {sundays, "Sun Jul 8 02:00 2012", "Sun Jul 8 04:00 2012"},
+    {sundays, "Sun Jul 8 03:30 2012", "Sun Jul 8 04:00 2012"},
+    {sundays, "Sun Jul 8 04:00 2012", ""},
+    {sundays, "Mon Jul 9 03:00 2012", ""},
+
+    // Overlapping periods end with the last one.
+    {hourly, "Mon Jul 9 14:15 2012", "Mon Jul 9 15:30 2012"},
+    {hourly, "Mon Jul 9 15:15 2012", "Mon Jul 9 16:30 2012"},
+  }
+
+  for _, c := range tests {
+    end, ok := c.window.Covers(getTime(c.time))
+    if ok != (c.end != "") || !end.Equal(getTime(c.end)) {
+      t.Errorf("%s: (expected) %v != %v (actual)", c.time, c.end, end)
+    }
+  }
+}
+
+// blackoutCron returns a started Cron on a FakeClock at midnight, with an
+// hourly job whose runs are sent on the returned channel, and a channel of the
+// job's skipped runs.
+func blackoutCron(t *testing.T, opts []Option, entryOpts ...EntryOption) (
+  *Cron, *FakeClock, chan time.Time, chan Event) {
+  clock := NewFakeClock(time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC))
+  cron := New(append([]Option{WithClock(clock), WithLocation(time.UTC)},
+    opts...)...)
+  ran := make(chan time.Time, 10)
+  skipped := make(chan Event, 10)
+  cron.Subscribe(func(e Event) {
+    if e.Type == RunSkipped {
+      skipped <- e
+    }
+  })
+  cron.AddFunc("@hourly", func() { ran <- clock.Now() }, entryOpts...)
+  cron.Start()
+  t.Cleanup(cron.Stop)
+  return cron, clock, ran, skipped
+}
+
+// expectRun fails the test unless the job runs at the given time.
+func expectRun(t *testing.T, ran chan time.Time, at time.Time) {
+  t.Helper()
+  select {
+  case actual := <-ran:
+    if !actual.Equal(at) {
+      t.Errorf("expected run at %v, ran at %v", at, actual)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatalf("expected run at %v", at)
+  }
+}
+
+// expectSkip fails the test unless the run at the given time is skipped.
+func expectSkip(t *testing.T, skipped chan Event, at time.Time) {
+  t.Helper()
+  select {
+  case e := <-skipped:
+    if e.Err != ErrBlackout || !e.Scheduled.Equal(at) {
+      t.Errorf("expected run at %v skipped with %v, got %+v", at, ErrBlackout,
+        e)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatalf("expected run at %v to be skipped", at)
+  }
+}
+
+func TestBlackoutSkip(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  _, clock, ran, skipped := blackoutCron(t, []Option{WithBlackout(
+    Between(start.Add(time.Hour), start.Add(3*time.Hour)), BlackoutSkip)})
+
+  clock.Advance(time.Hour)
+  expectSkip(t, skipped, start.Add(time.Hour))
+  clock.Advance(time.Hour)
+  expectSkip(t, skipped, start.Add(2*time.Hour))
+  clock.Advance(time.Hour)
+  expectRun(t, ran, start.Add(3*time.Hour))
+}
+
+func TestBlackoutDefer(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  end := start.Add(150 * time.Minute)
+  cron, clock, ran, _ := blackoutCron(t, nil, WithName("job"), BlackoutDuring(
+    Between(start.Add(30*time.Minute), end), BlackoutDefer))
+
+  clock.Advance(time.Hour)
+  id, _ := cron.EntryID("job")
+  for wait := time.Now(); ; time.Sleep(time.Millisecond) {
+    if e, _ := cron.Entry(id); e.Next.Equal(end) {
+      break
+    }
+    if time.Since(wait) > cOneSecond {
+      t.Fatalf("expected the run to be deferred to %v", end)
+    }
+  }
+
+  clock.Set(end)
+  expectRun(t, ran, end)
+  clock.Set(start.Add(3 * time.Hour))
+  expectRun(t, ran, start.Add(3*time.Hour))
+  select {
+  case at := <-ran:
+    t.Errorf("unexpected run at %v", at)
+  default:
+  }
+}
+
+func TestAddBlackout(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  cron, clock, ran, skipped := blackoutCron(t, nil)
+
+  remove := cron.AddBlackout(Recurring(MustParse("@hourly"), time.Minute),
+    BlackoutSkip)
+  clock.Advance(time.Hour)
+  expectSkip(t, skipped, start.Add(time.Hour))
+
+  remove()
+  remove()
+  clock.Advance(time.Hour)
+  expectRun(t, ran, start.Add(2*time.Hour))
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements an EntryStore in a bbolt database.
+
+// Package boltstore implements a cron.EntryStore in a bbolt database file, so
+// that single-node deployments keep their entries and last activations across
+// restarts without external infrastructure:
+//
+// 	store, err := boltstore.Open("/var/lib/myapp/cron.db")
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	defer store.Close()
+// 	c := cron.New(cron.WithStore(store))
+// 	c.Restore()
+package boltstore
+
+import (
+  "encoding/json"
+  "fmt"
+  "time"
+
+  "github.com/kiranbond/cron"
+  bolt "go.etcd.io/bbolt"
+)
+
+// DefaultBucket is the bucket Open keeps entries in.
+const DefaultBucket = "cron"
+
+// Store is a cron.EntryStore keeping each entry as a JSON document, keyed by
+// its ID, in a bucket of a bbolt database.
+type Store struct {
+  db     *bolt.DB
+  bucket []byte
+
+  // owned is whether Close closes the database.
+  owned bool
+}
+
+// Open opens the database file at path, creating it if it does not exist, and
+// returns a Store keeping entries in its DefaultBucket.  bbolt locks the file,
+// so only one process may open it at a time.
+func Open(path string) (*Store, error) {
+  db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+  if err != nil {
+    return nil, err
+  }
+  s, err := New(db, DefaultBucket)
+  if err != nil {
+    db.Close()
+    return nil, err
+  }
+  s.owned = true
+  return s, nil
+}
+
+// New returns a Store keeping entries in the named bucket of db, creating it
+// if it does not exist.  The caller remains responsible for closing db.
+func New(db *bolt.DB, bucket string) (*Store, error) {
+  s := &Store{db: db, bucket: []byte(bucket)}
+  err := db.Update(func(tx *bolt.Tx) error {
+    _, err := tx.CreateBucketIfNotExists(s.bucket)
+    return err
+  })
+  if err != nil {
+    return nil, fmt.Errorf("cannot create bucket %s: %s", bucket, err)
+  }
+  return s, nil
+}
+
+// Close closes the database, if the Store was opened with Open.
+func (s *Store) Close() error {
+  if !s.owned {
+    return nil
+  }
+  return s.db.Close()
+}
+
+// Save implements cron.EntryStore.
+func (s *Store) Save(entry cron.StoredEntry) error {
+  b, err := json.Marshal(entry)
+  if err != nil {
+    return err
+  }
+  return s.db.Update(func(tx *bolt.Tx) error {
+    return tx.Bucket(s.bucket).Put([]byte(entry.ID), b)
+  })
+}
+
+// Load implements cron.EntryStore, returning the entries ordered by ID.
+func (s *Store) Load() ([]cron.StoredEntry, error) {
+  var entries []cron.StoredEntry
+  err := s.db.View(func(tx *bolt.Tx) error {
+    return tx.Bucket(s.bucket).ForEach(func(k, v []byte) error {
+      var entry cron.StoredEntry
+      if err := json.Unmarshal(v, &entry); err != nil {
+        return fmt.Errorf("cannot decode entry %s: %s", k, err)
+      }
+      entries = append(entries, entry)
+      return nil
+    })
+  })
+  return entries, err
+}
+
+// Delete implements cron.EntryStore.
+func (s *Store) Delete(id string) error {
+  return s.db.Update(func(tx *bolt.Tx) error {
+    return tx.Bucket(s.bucket).Delete([]byte(id))
+  })
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the bbolt EntryStore.
+
+package boltstore
+
+import (
+  "path/filepath"
+  "reflect"
+  "testing"
+  "time"
+
+  "github.com/kiranbond/cron"
+)
+
+func TestStore(t *testing.T) {
+  path := filepath.Join(t.TempDir(), "cron.db")
+  s, err := Open(path)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  prev := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  a := cron.StoredEntry{ID: "a", Name: "report", Spec: "@daily",
+    Labels: map[string]string{"team": "infra"}, Job: "report",
+    Payload: []byte(`{"to":"ops"}`), Prev: prev, Next: prev.Add(24 * time.Hour)}
+  b := cron.StoredEntry{ID: "b", Spec: "@hourly", Job: "ping"}
+  for _, e := range []cron.StoredEntry{b, a, b} {
+    if err := s.Save(e); err != nil {
+      t.Fatal(err)
+    }
+  }
+  if err := s.Delete("c"); err != nil {
+    t.Error(err)
+  }
+  if err := s.Close(); err != nil {
+    t.Fatal(err)
+  }
+
+  // The entries survive reopening the file.
+  s, err = Open(path)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer s.Close()
+  entries, err := s.Load()
+  if err != nil {
+    t.Fatal(err)
+  }
+  if expected := []cron.StoredEntry{a, b}; !reflect.DeepEqual(entries,
+    expected) {
+    t.Errorf("(expected) %+v != %+v (actual)", expected, entries)
+  }
+
+  if err := s.Delete("a"); err != nil {
+    t.Fatal(err)
+  }
+  if entries, _ := s.Load(); len(entries) != 1 || entries[0].ID != "b" {
+    t.Errorf("expected only b, got %+v", entries)
+  }
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements fluent schedule builder.
+
+package cron
+
+import (
+  "fmt"
+  "time"
+)
+
+// The days of the week, for OnWeekdays.
+const (
+  Sun = time.Sunday
+  Mon = time.Monday
+  Tue = time.Tuesday
+  Wed = time.Wednesday
+  Thu = time.Thursday
+  Fri = time.Friday
+  Sat = time.Saturday
+)
+
+// Builder builds a SpecSchedule from structured input, without formatting and
+// parsing a spec, e.g.
+//
+// 	s, err := cron.Build().AtHour(9).OnWeekdays(cron.Mon, cron.Wed).Schedule()
+//
+// Fields that are not set match every value, except for the second, minute
+// and hour fields finer than the finest one set, which are zero.  So the above
+// activates at 9:00:00, and Build().AtHour(9).EverySeconds(30) activates every
+// 30 seconds between 9:00:00 and 9:59:30.
+//
+// The first invalid value passed to a Builder is reported by Schedule.
+type Builder struct {
+  s   SpecSchedule
+  err error
+}
+
+// Build returns a new Builder.
+func Build() *Builder {
+  return &Builder{}
+}
+
+// AtSecond restricts the schedule to the given seconds.
+func (b *Builder) AtSecond(values ...int) *Builder {
+  b.s.Second |= b.values(values, seconds)
+  return b
+}
+
+// AtMinute restricts the schedule to the given minutes.
+func (b *Builder) AtMinute(values ...int) *Builder {
+  b.s.Minute |= b.values(values, minutes)
+  return b
+}
+
+// AtHour restricts the schedule to the given hours.
+func (b *Builder) AtHour(values ...int) *Builder {
+  b.s.Hour |= b.values(values, hours)
+  return b
+}
+
+// EverySeconds restricts the schedule to every n seconds, starting at 0.
+func (b *Builder) EverySeconds(n int) *Builder {
+  b.s.Second |= b.every(n, seconds)
+  return b
+}
+
+// EveryMinutes restricts the schedule to every n minutes, starting at 0.
+func (b *Builder) EveryMinutes(n int) *Builder {
+  b.s.Minute |= b.every(n, minutes)
+  return b
+}
+
+// EveryHours restricts the schedule to every n hours, starting at 0.
+func (b *Builder) EveryHours(n int) *Builder {
+  b.s.Hour |= b.every(n, hours)
+  return b
+}
+
+// OnDays restricts the schedule to the given days of the month.
+func (b *Builder) OnDays(values ...int) *Builder {
+  b.s.Dom |= b.values(values, dom)
+  return b
+}
+
+// OnLastDay restricts the schedule to the last day of the month.
+func (b *Builder) OnLastDay() *Builder {
+  b.s.Dom |= lastDomBit
+  return b
+}
+
+// InMonths restricts the schedule to the given months.
+func (b *Builder) InMonths(values ...time.Month) *Builder {
+  for _, month := range values {
+    b.s.Month |= b.values([]int{int(month)}, months)
+  }
+  return b
+}
+
+// OnWeekdays restricts the schedule to the given days of the week.  If the
+// days of the month are also restricted, days matching either are selected.
+func (b *Builder) OnWeekdays(values ...time.Weekday) *Builder {
+  for _, weekday := range values {
+    b.s.Dow |= b.values([]int{int(weekday)}, dow)
+  }
+  return b
+}
+
+// In binds the schedule to the given location.
+func (b *Builder) In(loc *time.Location) *Builder {
+  b.s.Location = loc
+  return b
+}
+
+// WithDST sets the schedule's policies for wall clock times that daylight
+// saving time transitions skip or repeat.
+func (b *Builder) WithDST(gap DSTGapPolicy, overlap DSTOverlapPolicy) *Builder {
+  b.s.DSTGap = gap
+  b.s.DSTOverlap = overlap
+  return b
+}
+
+// Schedule returns the built schedule, or the first invalid value given to the
+// builder.
+func (b *Builder) Schedule() (*SpecSchedule, error) {
+  if b.err != nil {
+    return nil, b.err
+  }
+
+  s := b.s
+  finer := false
+  for _, field := range []struct {
+    bits *uint64
+    r    bounds
+  }{
+    {&s.Second, seconds},
+    {&s.Minute, minutes},
+    {&s.Hour, hours},
+  } {
+    switch {
+    case *field.bits != 0:
+      finer = true
+    case finer:
+      *field.bits = all(field.r)
+    default:
+      *field.bits = 1 << field.r.min
+    }
+  }
+  if s.Dom == 0 {
+    s.Dom = all(dom)
+  }
+  if s.Month == 0 {
+    s.Month = all(months)
+  }
+  if s.Dow == 0 {
+    s.Dow = all(dow)
+  }
+  return &s, nil
+}
+
+// values returns the bits of the given values, recording an error if any is
+// outside the bounds.
+func (b *Builder) values(values []int, r bounds) uint64 {
+  var bits uint64
+  for _, v := range values {
+    if v < int(r.min) || v > int(r.max) {
+      b.fail(fmt.Errorf("value %d outside of range %d-%d", v, r.min, r.max))
+      continue
+    }
+    bits |= 1 << uint(v)
+  }
+  return bits
+}
+
+// every returns the bits of every n values, starting at the minimum, as for
+// "*/n", recording an error if n is not positive.
+func (b *Builder) every(n int, r bounds) uint64 {
+  if n <= 0 {
+    b.fail(fmt.Errorf("step %d should be positive", n))
+    return 0
+  }
+  return getBits(r.min, r.max, uint(n)) | starBit
+}
+
+// fail records the first error.
+func (b *Builder) fail(err error) {
+  if b.err == nil {
+    b.err = err
+  }
+}
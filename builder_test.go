@@ -0,0 +1,76 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements fluent schedule builder test.
+
+package cron
+
+import (
+  "reflect"
+  "testing"
+  "time"
+)
+
+func TestBuilder(t *testing.T) {
+  tests := []struct {
+    builder *Builder
+    spec    string
+  }{
+    {Build(), "0 0 0 * * *"},
+    {Build().AtHour(9), "0 0 9 * * *"},
+    {Build().AtHour(9).AtMinute(30), "0 30 9 * * *"},
+    {Build().AtHour(9).OnWeekdays(Mon, Wed), "0 0 9 * * MON,WED"},
+    {Build().AtHour(9).OnWeekdays(Mon, Wed).EverySeconds(30), "*/30 * 9 * * MON,WED"},
+    {Build().EveryMinutes(15), "0 */15 * * * *"},
+    {Build().AtMinute(5).EveryHours(6), "0 5 */6 * * *"},
+    {Build().AtHour(0).OnDays(1, 15).InMonths(time.January, time.July), "0 0 0 1,15 Jan,Jul *"},
+    {Build().AtHour(23).OnLastDay(), "0 0 23 L * *"},
+  }
+
+  for _, c := range tests {
+    actual, err := c.builder.Schedule()
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    expected, err := Parse(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    if !reflect.DeepEqual(actual, expected) {
+      t.Errorf("%s => (expected) %v != %v (actual)", c.spec, expected, actual)
+    }
+  }
+
+  ny, _ := time.LoadLocation("America/New_York")
+  s, err := Build().AtHour(9).In(ny).Schedule()
+  if err != nil || s.Location != ny {
+    t.Errorf("(expected) %v != %v (actual), %v", ny, s, err)
+  }
+
+  invalid := []*Builder{
+    Build().AtHour(24),
+    Build().AtMinute(-1),
+    Build().OnDays(0),
+    Build().EverySeconds(0).AtHour(9),
+    Build().OnWeekdays(time.Weekday(7)),
+    Build().InMonths(time.Month(13)),
+  }
+  for _, b := range invalid {
+    if _, err := b.Schedule(); err == nil {
+      t.Error("expected an error building: ", b.s)
+    }
+  }
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements calendar systems for spec schedules.
+
+package cron
+
+import "time"
+
+// Calendar decomposes days into months and days of the month, so that spec
+// schedules can be evaluated against fiscal periods, retail calendars, or
+// non-Gregorian calendars.  Days themselves (and so hours, minutes, seconds and
+// weekdays) are always those of t's location.
+type Calendar interface {
+  // Date returns the month (1-12) and the day of the month (1-31) of t.
+  Date(t time.Time) (month, day int)
+
+  // DaysInMonth returns the number of days in the month containing t.
+  DaysInMonth(t time.Time) int
+}
+
+// Gregorian is the Gregorian calendar used by time.Time.
+var Gregorian Calendar = gregorian{}
+
+type gregorian struct{}
+
+func (gregorian) Date(t time.Time) (month, day int) {
+  return int(t.Month()), t.Day()
+}
+
+func (gregorian) DaysInMonth(t time.Time) int {
+  return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+// FiscalCalendar is a Gregorian calendar whose year starts on the first day of
+// Start, so that month 1 is Start, month 2 the month after it, and so on.  For
+// example, with Start October, "@monthly" would be unchanged but a Month field
+// of 1 would match October.
+type FiscalCalendar struct {
+  Start time.Month
+}
+
+// Date returns the fiscal month and the day of the month of t.
+func (c FiscalCalendar) Date(t time.Time) (month, day int) {
+  return (int(t.Month())-int(c.Start)+12)%12 + 1, t.Day()
+}
+
+// DaysInMonth returns the number of days in the month containing t.
+func (c FiscalCalendar) DaysInMonth(t time.Time) int {
+  return Gregorian.DaysInMonth(t)
+}
+
+// calendar returns the schedule's calendar.
+func (s *SpecSchedule) calendar() Calendar {
+  if s.Calendar == nil {
+    return Gregorian
+  }
+  return s.Calendar
+}
+
+// nextInCalendar is Next for schedules with a Calendar.  It walks forward a
+// day at a time, matching the month and day through the calendar, and then
+// looks for the first matching time of day.
+func (s *SpecSchedule) nextInCalendar(t time.Time) time.Time {
+  // Start at the earliest possible time (the upcoming second).
+  t = t.Add(1*time.Second - time.Duration(t.Nanosecond())*time.Nanosecond)
+
+  // If no time is found within five years, return zero.
+  limit := t.AddDate(5, 0, 0)
+
+  day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+  for ; day.Before(limit); day = day.AddDate(0, 0, 1) {
+    month, _ := s.Calendar.Date(day)
+    if 1<<uint(month)&s.Month == 0 || !dayMatches(s, day) {
+      continue
+    }
+    if next := s.nextInDay(day, t); !next.IsZero() {
+      return next
+    }
+  }
+  return time.Time{}
+}
+
+// nextInDay returns the first time on the given day, no earlier than t, that
+// matches the schedule's hour, minute and second, or the zero time if there is
+// none.
+func (s *SpecSchedule) nextInDay(day, t time.Time) time.Time {
+  for hour := hours.min; hour <= hours.max; hour++ {
+    if 1<<hour&s.Hour == 0 {
+      continue
+    }
+    for minute := minutes.min; minute <= minutes.max; minute++ {
+      if 1<<minute&s.Minute == 0 {
+        continue
+      }
+      for second := seconds.min; second <= seconds.max; second++ {
+        if 1<<second&s.Second == 0 {
+          continue
+        }
+        next := time.Date(day.Year(), day.Month(), day.Day(), int(hour),
+          int(minute), int(second), 0, day.Location())
+        // Skip times that fall in a daylight savings gap, and those already
+        // passed.
+        if next.Hour() != int(hour) || next.Before(t) {
+          continue
+        }
+        return next
+      }
+    }
+  }
+  return time.Time{}
+}
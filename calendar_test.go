@@ -0,0 +1,82 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements calendar test.
+
+package cron
+
+import (
+  "testing"
+  "time"
+)
+
+// TestGregorianCalendar checks that an explicit Gregorian calendar gives the
+// same results as the default one.
+func TestGregorianCalendar(t *testing.T) {
+  runs := []struct {
+    time, spec string
+  }{
+    {"Mon Jul 9 14:45 2012", "0 0/15 * * *"},
+    {"Mon Jul 9 23:35:51 2012", "15/35 20-35/15 1/2 */2 * *"},
+    {"Mon Jul 9 23:35 2012", "0 0 0 */5 Apr,Aug,Oct Mon"},
+    {"Mon Jul 9 23:35 2012", "0 0 0 29 Feb ?"},
+    {"Mon Jul 9 23:35 2012", "0 0 0 L-2 * ?"},
+    {"Sun Jul 1 00:00 2012", "0 0 0 LB * ?"},
+    {"Mon Jul 9 23:35 2012", "0 0 0 30 Feb ?"},
+    {"2012-03-11T00:00:00-0500", "0 0 * * * ?"},
+    {"2012-03-11T00:00:00-0500", "0 0 2 * * ?"},
+    {"2012-11-04T00:00:00-0400", "0 0 3 * * ?"},
+  }
+
+  for _, c := range runs {
+    sched, err := Parse(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    expected := sched.Next(getTime(c.time))
+    sched.(*SpecSchedule).Calendar = Gregorian
+    actual := sched.Next(getTime(c.time))
+    if !actual.Equal(expected) {
+      t.Errorf("%s, \"%s\": (expected) %v != %v (actual)", c.time, c.spec, expected, actual)
+    }
+  }
+}
+
+func TestFiscalCalendar(t *testing.T) {
+  runs := []struct {
+    time, spec string
+    expected   string
+  }{
+    // The first month of the fiscal year starting in October.
+    {"Mon Jul 9 14:45 2012", "0 0 0 1 1 ?", "Mon Oct 1 00:00 2012"},
+    // The last day of each quarter.
+    {"Mon Jul 9 14:45 2012", "0 0 0 L 3,6,9,12 ?", "Sun Sep 30 00:00 2012"},
+    {"Sun Sep 30 00:00 2012", "0 0 0 L 3,6,9,12 ?", "Mon Dec 31 00:00 2012"},
+  }
+
+  for _, c := range runs {
+    sched, err := Parse(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    sched.(*SpecSchedule).Calendar = FiscalCalendar{Start: time.October}
+    actual := sched.Next(getTime(c.time))
+    expected := getTime(c.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s, \"%s\": (expected) %v != %v (actual)", c.time, c.spec, expected, actual)
+    }
+  }
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements job wrappers and chains of them.
+
+package cron
+
+import (
+  "context"
+  "fmt"
+  "sync"
+  "time"
+)
+
+// JobWrapper decorates the given Job with some behavior, such as logging or
+// metrics.  Wrappers that pass the context of each run on should return a
+// ContextJob, e.g. a ContextFuncJob, that calls RunContext on wrapped
+// ContextJobs and Run on other jobs.
+type JobWrapper func(Job) Job
+
+// Chain is a sequence of JobWrappers that decorates submitted jobs with
+// cross-cutting behaviors like logging or synchronization.
+type Chain struct {
+  wrappers []JobWrapper
+}
+
+// NewChain returns a Chain consisting of the given JobWrappers.
+func NewChain(c ...JobWrapper) Chain {
+  return Chain{c}
+}
+
+// Then decorates the given job with all JobWrappers in the chain.  The first
+// wrapper is the outermost, so
+//
+// 	NewChain(m1, m2, m3).Then(job)
+//
+// is equivalent to
+//
+// 	m1(m2(m3(job)))
+func (c Chain) Then(j Job) Job {
+  for i := range c.wrappers {
+    j = c.wrappers[len(c.wrappers)-i-1](j)
+  }
+  return j
+}
+
+// runJob runs the job, passing the context to ContextJobs.
+func runJob(ctx context.Context, j Job) {
+  if cj, ok := j.(ContextJob); ok {
+    cj.RunContext(ctx)
+    return
+  }
+  j.Run()
+}
+
+// DelayIfStillRunning serializes runs of a job, delaying each run until the
+// previous one has finished.  The returned jobs are DelayedJobs, which record
+// the accumulated delay; delays of over a minute are also logged.  DelayedJobs
+// are ErrorJobs, whose runs fail with the job's error.
+func DelayIfStillRunning() JobWrapper {
+  return func(j Job) Job {
+    return &DelayedJob{job: j}
+  }
+}
+
+// DelayedJob is a Job whose runs are serialized by DelayIfStillRunning.
+type DelayedJob struct {
+  job Job
+
+  // running is held while the job runs.
+  running sync.Mutex
+
+  // mu guards delay, the accumulated delay.
+  mu    sync.Mutex
+  delay time.Duration
+}
+
+// Run runs the job once the previous run has finished.
+func (d *DelayedJob) Run() { d.RunContext(context.Background()) }
+
+// RunContext runs the job with the context once the previous run has
+// finished, logging its error.
+func (d *DelayedJob) RunContext(ctx context.Context) {
+  if err := d.RunError(ctx); err != nil {
+    loggerFrom(ctx).Warningf("job failed: %v", err)
+  }
+}
+
+// RunError runs the job with the context once the previous run has finished,
+// and returns its error, if it is an ErrorJob.
+func (d *DelayedJob) RunError(ctx context.Context) error {
+  start := time.Now()
+  d.running.Lock()
+  defer d.running.Unlock()
+
+  delay := time.Since(start)
+  d.mu.Lock()
+  d.delay += delay
+  d.mu.Unlock()
+  if delay > time.Minute {
+    loggerFrom(ctx).Warningf("job delayed %v by its previous run", delay)
+  }
+  if ej, ok := d.job.(ErrorJob); ok {
+    return ej.RunError(ctx)
+  }
+  runJob(ctx, d.job)
+  return nil
+}
+
+// Delay returns the total time runs of the job have waited for previous runs.
+func (d *DelayedJob) Delay() time.Duration {
+  d.mu.Lock()
+  defer d.mu.Unlock()
+  return d.delay
+}
+
+// Timeout cancels the context of each run of a job once it has run for d.
+// Jobs that are not ContextJobs, or ignore their context, are not stopped, but
+// their runs still fail.  The returned job is an ErrorJob whose runs fail with
+// the job's error or, if it returns none, because the run timed out.
+func Timeout(d time.Duration) JobWrapper {
+  return func(j Job) Job {
+    return &timeoutJob{job: j, timeout: d}
+  }
+}
+
+// timeoutJob is a Job wrapped by Timeout.
+type timeoutJob struct {
+  job     Job
+  timeout time.Duration
+}
+
+// Run runs the job with a background context.
+func (t *timeoutJob) Run() { t.RunContext(context.Background()) }
+
+// RunContext runs the job, logging its error.
+func (t *timeoutJob) RunContext(ctx context.Context) {
+  if err := t.RunError(ctx); err != nil {
+    loggerFrom(ctx).Warningf("job failed: %v", err)
+  }
+}
+
+// RunError runs the job with a context canceled after the timeout.
+func (t *timeoutJob) RunError(ctx context.Context) error {
+  ctx, cancel := context.WithTimeout(ctx, t.timeout)
+  defer cancel()
+  var err error
+  if ej, ok := t.job.(ErrorJob); ok {
+    err = ej.RunError(ctx)
+  } else {
+    runJob(ctx, t.job)
+  }
+  if err == nil && ctx.Err() == context.DeadlineExceeded {
+    err = fmt.Errorf("job timed out after %v", t.timeout)
+  }
+  return err
+}
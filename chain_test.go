@@ -0,0 +1,203 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for job wrappers.
+
+package cron
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "reflect"
+  "sync"
+  "testing"
+  "time"
+)
+
+// appendingWrapper returns a wrapper that appends name to calls when run.
+func appendingWrapper(calls *[]string, name string) JobWrapper {
+  return func(j Job) Job {
+    return ContextFuncJob(func(ctx context.Context) {
+      *calls = append(*calls, name)
+      runJob(ctx, j)
+    })
+  }
+}
+
+func TestChain(t *testing.T) {
+  var calls []string
+  job := FuncJob(func() { calls = append(calls, "job") })
+  NewChain(
+    appendingWrapper(&calls, "first"),
+    appendingWrapper(&calls, "second"),
+    appendingWrapper(&calls, "third"),
+  ).Then(job).Run()
+
+  expected := []string{"first", "second", "third", "job"}
+  if !reflect.DeepEqual(calls, expected) {
+    t.Errorf("(expected) %q != %q (actual)", expected, calls)
+  }
+}
+
+func TestChainEmpty(t *testing.T) {
+  job := FuncJob(func() {})
+  if actual := NewChain().Then(job); reflect.ValueOf(actual).Pointer() !=
+    reflect.ValueOf(job).Pointer() {
+    t.Error("expected an empty chain to return the job")
+  }
+}
+
+func TestChainPassesContext(t *testing.T) {
+  type key struct{}
+  var value interface{}
+  var calls []string
+  job := ContextFuncJob(func(ctx context.Context) { value = ctx.Value(key{}) })
+
+  ctx := context.WithValue(context.Background(), key{}, "value")
+  runJob(ctx, NewChain(appendingWrapper(&calls, "wrapper")).Then(job))
+  if value != "value" {
+    t.Errorf("expected the context to be passed through, got %v", value)
+  }
+}
+
+func TestDelayIfStillRunning(t *testing.T) {
+  var (
+    mu       sync.Mutex
+    running  int
+    overlaps int
+    wg       sync.WaitGroup
+  )
+  job := DelayIfStillRunning()(FuncJob(func() {
+    mu.Lock()
+    running++
+    if running > 1 {
+      overlaps++
+    }
+    mu.Unlock()
+    time.Sleep(50 * time.Millisecond)
+    mu.Lock()
+    running--
+    mu.Unlock()
+  }))
+
+  for i := 0; i < 3; i++ {
+    wg.Add(1)
+    go func() {
+      defer wg.Done()
+      job.Run()
+    }()
+  }
+  wg.Wait()
+
+  if overlaps != 0 {
+    t.Errorf("expected runs not to overlap, got %d overlaps", overlaps)
+  }
+  // The second and third runs wait for about 50ms and 100ms.
+  if delay := job.(*DelayedJob).Delay(); delay < 140*time.Millisecond {
+    t.Errorf("expected an accumulated delay of about 150ms, got %v", delay)
+  }
+}
+
+// TestDelayIfStillRunningError checks that the runner sees the errors of
+// delayed jobs.
+func TestDelayIfStillRunningError(t *testing.T) {
+  clock := NewFakeClock(time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC))
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  failed := make(chan error, 1)
+  cron.Subscribe(func(e Event) {
+    if e.Type == RunFailed {
+      failed <- e.Err
+    }
+  })
+  errFailed := errors.New("failed")
+  id, _ := cron.AddErrorFunc("@hourly", func(context.Context) error {
+    return errFailed
+  }, WithWrappers(DelayIfStillRunning()))
+  cron.Start()
+  defer cron.Stop()
+
+  clock.Advance(time.Hour)
+  select {
+  case err := <-failed:
+    if err != errFailed {
+      t.Errorf("(expected) %v != %v (actual)", errFailed, err)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatal("expected the run to fail")
+  }
+  waitFor(t, func() bool {
+    entry, _ := cron.Entry(id)
+    return entry.Stats.Failures == 1
+  })
+}
+
+func TestWithWrappers(t *testing.T) {
+  var calls []string
+  cron := New(WithChain(appendingWrapper(&calls, "chain")))
+  id, _ := cron.AddJob("@hourly", FuncJob(func() {
+    calls = append(calls, "job")
+  }), WithWrappers(appendingWrapper(&calls, "first"),
+    appendingWrapper(&calls, "second")))
+
+  e, _ := cron.Entry(id)
+  e.Job.Run()
+  expected := []string{"chain", "first", "second", "job"}
+  if !reflect.DeepEqual(calls, expected) {
+    t.Errorf("(expected) %q != %q (actual)", expected, calls)
+  }
+
+  // Replaced jobs are wrapped too.
+  calls = nil
+  cron.ReplaceJob(id, FuncJob(func() { calls = append(calls, "replaced") }))
+  e, _ = cron.Entry(id)
+  e.Job.Run()
+  expected = []string{"chain", "first", "second", "replaced"}
+  if !reflect.DeepEqual(calls, expected) {
+    t.Errorf("(expected) %q != %q (actual)", expected, calls)
+  }
+}
+
+func TestTimeout(t *testing.T) {
+  failed := errors.New("failed")
+  tests := []struct {
+    name string
+    job  Job
+    err  string
+  }{
+    {"fast", FuncJob(func() {}), ""},
+    {"canceled", ContextFuncJob(func(ctx context.Context) {
+      <-ctx.Done()
+    }), "job timed out after 20ms"},
+    {"ignores context", FuncJob(func() {
+      time.Sleep(40 * time.Millisecond)
+    }), "job timed out after 20ms"},
+    {"error", ErrorFuncJob(func(ctx context.Context) error {
+      <-ctx.Done()
+      return failed
+    }), "failed"},
+    {"context error", ErrorFuncJob(func(ctx context.Context) error {
+      <-ctx.Done()
+      return ctx.Err()
+    }), context.DeadlineExceeded.Error()},
+  }
+  for _, test := range tests {
+    err := Timeout(20 * time.Millisecond)(test.job).(ErrorJob).RunError(
+      context.Background())
+    if actual := fmt.Sprint(err); err == nil && test.err != "" ||
+      err != nil && actual != test.err {
+      t.Errorf("%s: expected %q, got %v", test.name, test.err, err)
+    }
+  }
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements the clocks a Cron reads the time from.
+
+package cron
+
+import (
+  "sync"
+  "time"
+)
+
+// Clock is the source of time for a Cron.
+type Clock interface {
+  // Now returns the current time.
+  Now() time.Time
+  // Timer returns a timer that fires once the clock reaches t, immediately if
+  // it already has.
+  Timer(t time.Time) Timer
+}
+
+// Timer is a single-use timer created by a Clock.
+type Timer interface {
+  // C returns the channel the current time is sent on when the timer fires.
+  C() <-chan time.Time
+  // Stop prevents the timer from firing.  It returns false if the timer has
+  // already fired or been stopped.
+  Stop() bool
+}
+
+// realClock is the Clock of the time package.
+type realClock struct{}
+
+// Now returns time.Now().
+func (realClock) Now() time.Time { return time.Now() }
+
+// Timer returns a time.Timer firing at t.
+func (realClock) Timer(t time.Time) Timer {
+  return realTimer{time.NewTimer(time.Until(t))}
+}
+
+// realTimer adapts a time.Timer to Timer.
+type realTimer struct {
+  *time.Timer
+}
+
+// C returns the channel of the time.Timer.
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+// FakeClock is a Clock that only moves when told to, so that tests can drive a
+// Cron deterministically:
+//
+// 	clock := cron.NewFakeClock(start)
+// 	c := cron.New(cron.WithClock(clock))
+// 	c.AddFunc("@every 1m", job)
+// 	c.Start()
+// 	clock.Advance(time.Minute) // job runs
+//
+// It is safe for concurrent use.
+type FakeClock struct {
+  mu     sync.Mutex
+  now    time.Time
+  timers []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock set to t.
+func NewFakeClock(t time.Time) *FakeClock {
+  return &FakeClock{now: t}
+}
+
+// Now returns the time the clock is set to.
+func (f *FakeClock) Now() time.Time {
+  f.mu.Lock()
+  defer f.mu.Unlock()
+  return f.now
+}
+
+// Timer returns a timer that fires when the clock is advanced to t.
+func (f *FakeClock) Timer(t time.Time) Timer {
+  f.mu.Lock()
+  defer f.mu.Unlock()
+  timer := &fakeTimer{clock: f, when: t, c: make(chan time.Time, 1)}
+  if !t.After(f.now) {
+    timer.c <- f.now
+    return timer
+  }
+  f.timers = append(f.timers, timer)
+  return timer
+}
+
+// Advance moves the clock forward by d, firing the timers that are due.
+func (f *FakeClock) Advance(d time.Duration) {
+  f.mu.Lock()
+  defer f.mu.Unlock()
+  f.set(f.now.Add(d))
+}
+
+// Set moves the clock to t, firing the timers that are due.  t may be earlier
+// than the current time, e.g. to simulate the wall clock being reset.
+func (f *FakeClock) Set(t time.Time) {
+  f.mu.Lock()
+  defer f.mu.Unlock()
+  f.set(t)
+}
+
+// set moves the clock to t.  f.mu must be held.
+func (f *FakeClock) set(t time.Time) {
+  f.now = t
+  pending := f.timers[:0]
+  for _, timer := range f.timers {
+    if timer.when.After(t) {
+      pending = append(pending, timer)
+      continue
+    }
+    timer.c <- t
+  }
+  f.timers = pending
+}
+
+// stop removes timer from the pending timers, returning whether it was.
+func (f *FakeClock) stop(timer *fakeTimer) bool {
+  f.mu.Lock()
+  defer f.mu.Unlock()
+  for i, pending := range f.timers {
+    if pending == timer {
+      f.timers = append(f.timers[:i], f.timers[i+1:]...)
+      return true
+    }
+  }
+  return false
+}
+
+// fakeTimer is a Timer of a FakeClock.
+type fakeTimer struct {
+  clock *FakeClock
+  when  time.Time
+  c     chan time.Time
+}
+
+// C returns the channel the timer fires on.
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+// Stop removes the timer from its clock.
+func (t *fakeTimer) Stop() bool { return t.clock.stop(t) }
@@ -0,0 +1,87 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for clocks.
+
+package cron
+
+import (
+  "testing"
+  "time"
+)
+
+// fired returns whether timer has fired, and when.
+func fired(timer Timer) (time.Time, bool) {
+  select {
+  case t := <-timer.C():
+    return t, true
+  default:
+    return time.Time{}, false
+  }
+}
+
+func TestFakeClock(t *testing.T) {
+  start := getTime("Mon Jul 9 12:00 2012")
+  clock := NewFakeClock(start)
+
+  past := clock.Timer(start.Add(-time.Minute))
+  if at, ok := fired(past); !ok || !at.Equal(start) {
+    t.Errorf("expected past timer to fire at %v, got %v %v", start, at, ok)
+  }
+
+  minute := clock.Timer(start.Add(time.Minute))
+  hour := clock.Timer(start.Add(time.Hour))
+  stopped := clock.Timer(start.Add(time.Minute))
+  if !stopped.Stop() {
+    t.Error("expected Stop to stop a pending timer")
+  }
+
+  clock.Advance(30 * time.Second)
+  if _, ok := fired(minute); ok {
+    t.Error("expected timer not to fire early")
+  }
+
+  clock.Advance(time.Minute)
+  expected := start.Add(90 * time.Second)
+  if !clock.Now().Equal(expected) {
+    t.Errorf("expected clock at %v, got %v", expected, clock.Now())
+  }
+  if at, ok := fired(minute); !ok || !at.Equal(expected) {
+    t.Errorf("expected timer to fire at %v, got %v %v", expected, at, ok)
+  }
+  if minute.Stop() {
+    t.Error("expected Stop to fail on a fired timer")
+  }
+  if _, ok := fired(stopped); ok {
+    t.Error("expected stopped timer not to fire")
+  }
+  if _, ok := fired(hour); ok {
+    t.Error("expected timer not to fire early")
+  }
+
+  clock.Set(start.Add(2 * time.Hour))
+  if _, ok := fired(hour); !ok {
+    t.Error("expected timer to fire")
+  }
+}
+
+func TestRealClock(t *testing.T) {
+  var clock Clock = realClock{}
+  timer := clock.Timer(clock.Now().Add(10 * time.Millisecond))
+  select {
+  case <-timer.C():
+  case <-time.After(cOneSecond):
+    t.Error("expected timer to fire")
+  }
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements splitting the entries of a cluster of Crons between
+// its members.
+
+package cron
+
+import (
+  "context"
+  "hash/fnv"
+  "reflect"
+  "sort"
+  "time"
+)
+
+// DefaultMemberTTL is how long the members of a cluster stay members after
+// their last heartbeat, unless WithCluster is given another TTL.
+const DefaultMemberTTL = 15 * time.Second
+
+// Cluster tracks the members of a cluster of Crons, which share their entries,
+// e.g. by restoring them from the same EntryStore, and split running them.
+// Its methods must be safe for concurrent use.
+type Cluster interface {
+  // Heartbeat records that the member is alive for the given TTL.
+  Heartbeat(member string, ttl time.Duration) error
+
+  // Leave removes the member.
+  Leave(member string) error
+
+  // Members returns the members alive.
+  Members() ([]string, error)
+}
+
+// WithCluster makes the Cron a member of cluster, identified by member, while
+// it is running.  Each entry runs on only one of the members, picked from its
+// ID by rendezvous hashing, so that when members join or leave only the
+// entries of the members that changed move.  On the other members, its
+// activations pass without running, keeping them current.  Members heartbeat
+// every third of the ttl, and run no entries once they could not for the ttl.
+// If ttl is not positive, DefaultMemberTTL is used.
+func WithCluster(cluster Cluster, member string, ttl time.Duration) Option {
+  return func(c *Cron) {
+    c.cluster, c.member, c.memberTTL = cluster, member, ttl
+    if ttl <= 0 {
+      c.memberTTL = DefaultMemberTTL
+    }
+  }
+}
+
+// Members returns the members of the Cron's cluster, as last seen, ordered.
+func (c *Cron) Members() []string {
+  c.mu.RLock()
+  defer c.mu.RUnlock()
+  return append([]string(nil), c.members...)
+}
+
+// Owner returns the member of the Cron's cluster that runs the entry with the
+// given ID, or the empty string if it has no members.
+func (c *Cron) Owner(id string) string {
+  c.mu.RLock()
+  defer c.mu.RUnlock()
+  return c.owner(id)
+}
+
+// owner returns the member that runs the entry with the given ID: the one
+// with the highest hash for it.  c.mu must be held.
+func (c *Cron) owner(id string) string {
+  var (
+    owner string
+    best  uint64
+  )
+  for _, member := range c.members {
+    h := fnv.New64a()
+    h.Write([]byte(member))
+    h.Write([]byte{0})
+    h.Write([]byte(id))
+    if sum := h.Sum64(); owner == "" || sum > best {
+      owner, best = member, sum
+    }
+  }
+  return owner
+}
+
+// runsHere returns whether the Cron runs the activations of the entry: if it
+// leads, and the entry is its share of its cluster.  c.mu must be held.
+func (c *Cron) runsHere(e *Entry) bool {
+  return c.leads() && (c.cluster == nil || c.owner(e.ID) == c.member)
+}
+
+// join heartbeats in the cluster until the Cron stops, or ctx is done, and
+// then leaves it.  c.mu must be held.
+func (c *Cron) join(ctx context.Context) {
+  ctx, leave := context.WithCancel(ctx)
+  c.leave = leave
+  go func() {
+    ticker := time.NewTicker(c.memberTTL / 3)
+    defer ticker.Stop()
+    var last time.Time
+    for {
+      if c.heartbeat(ctx) {
+        last = time.Now()
+      } else if time.Since(last) >= c.memberTTL {
+        // The other members no longer count this one.
+        c.setMembers(ctx, nil)
+      }
+      select {
+      case <-ctx.Done():
+        if err := c.cluster.Leave(c.member); err != nil {
+          c.logger.Warningf("cannot leave cluster: %v", err)
+        }
+        return
+      case <-ticker.C:
+      }
+    }
+  }()
+}
+
+// heartbeat records that the Cron is alive in its cluster, and updates its
+// members.  It returns whether it succeeded.
+func (c *Cron) heartbeat(ctx context.Context) bool {
+  err := c.cluster.Heartbeat(c.member, c.memberTTL)
+  var members []string
+  if err == nil {
+    members, err = c.cluster.Members()
+  }
+  if err != nil {
+    c.logger.Warningf("cannot heartbeat in cluster: %v", err)
+    return false
+  }
+  sort.Strings(members)
+  c.setMembers(ctx, members)
+  return true
+}
+
+// setMembers sets the members of the cluster, unless the Cron left it.
+func (c *Cron) setMembers(ctx context.Context, members []string) {
+  c.mu.Lock()
+  defer c.unlock()
+  if ctx.Err() != nil || reflect.DeepEqual(members, c.members) {
+    return
+  }
+  c.logger.Infof("cluster members changed to %v", members)
+  c.members = members
+}
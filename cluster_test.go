@@ -0,0 +1,130 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for splitting entries between Crons.
+
+package cron
+
+import (
+  "fmt"
+  "reflect"
+  "sync"
+  "testing"
+  "time"
+)
+
+func TestMemoryCluster(t *testing.T) {
+  s := NewMemoryStore()
+  s.Heartbeat("b", time.Minute)
+  s.Heartbeat("a", time.Minute)
+  s.Heartbeat("expired", -time.Second)
+  if members, _ := s.Members(); !reflect.DeepEqual(members,
+    []string{"a", "b"}) {
+    t.Errorf("expected a and b, got %v", members)
+  }
+  s.Leave("a")
+  if members, _ := s.Members(); !reflect.DeepEqual(members, []string{"b"}) {
+    t.Errorf("expected only b, got %v", members)
+  }
+}
+
+func TestOwner(t *testing.T) {
+  cron := New()
+  if owner := cron.Owner("a"); owner != "" {
+    t.Errorf("expected no owner without members, got %q", owner)
+  }
+
+  cron.members = []string{"m1", "m2", "m3"}
+  owners := map[string]string{}
+  counts := map[string]int{}
+  for i := 0; i < 300; i++ {
+    id := fmt.Sprint(i)
+    owners[id] = cron.Owner(id)
+    counts[owners[id]]++
+  }
+  for _, member := range cron.members {
+    if counts[member] < 50 {
+      t.Errorf("expected %s to own about a third of the entries, got %d",
+        member, counts[member])
+    }
+  }
+
+  // Only the entries of the member that left move.
+  cron.members = []string{"m1", "m3"}
+  for id, owner := range owners {
+    if actual := cron.Owner(id); owner != "m2" && actual != owner {
+      t.Errorf("%s: expected to stay on %s, moved to %s", id, owner, actual)
+    }
+  }
+}
+
+func TestCluster(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  store := NewMemoryStore()
+
+  var (
+    mu  sync.Mutex
+    ran = map[string][]string{}
+  )
+  crons := map[string]*Cron{}
+  for _, member := range []string{"a", "b"} {
+    member := member
+    cron := New(WithClock(clock), WithLocation(time.UTC),
+      WithCluster(store, member, 30*time.Millisecond))
+    cron.Subscribe(func(e Event) {
+      if e.Type == RunStarted {
+        mu.Lock()
+        defer mu.Unlock()
+        ran[e.EntryID] = append(ran[e.EntryID], member)
+      }
+    })
+    for i := 0; i < 10; i++ {
+      cron.Apply(StoredEntry{ID: fmt.Sprint(i), Spec: "@hourly",
+        Job: "test-echo"})
+    }
+    cron.Start()
+    defer cron.Stop()
+    crons[member] = cron
+  }
+  waitFor(t, func() bool {
+    return len(crons["a"].Members()) == 2 && len(crons["b"].Members()) == 2
+  })
+
+  // expectRuns fails the test unless each entry runs once, on its owner.
+  expectRuns := func(owner func(id string) string) {
+    t.Helper()
+    clock.Advance(time.Hour)
+    for i := 0; i < 10; i++ {
+      <-storeRuns
+    }
+    mu.Lock()
+    defer mu.Unlock()
+    for id, members := range ran {
+      if len(members) != 1 || members[0] != owner(id) {
+        t.Errorf("%s: expected to run on %s, ran on %v", id, owner(id),
+          members)
+      }
+    }
+    ran = map[string][]string{}
+  }
+  expectRuns(crons["a"].Owner)
+
+  // The entries of b move to a when it leaves.
+  crons["b"].Stop()
+  waitFor(t, func() bool {
+    return reflect.DeepEqual(crons["a"].Members(), []string{"a"})
+  })
+  expectRuns(func(string) string { return "a" })
+}
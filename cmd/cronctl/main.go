@@ -0,0 +1,253 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements a command-line client of the CronService.
+
+// Command cronctl manages a Cron served with the cronrpc package, and checks
+// specs:
+//
+// 	cronctl [-addr host:port] [-timeout duration] command [arguments]
+//
+// The commands are:
+//
+// 	list [-labels k=v,...]          list the entries, by their next run
+// 	validate [-n count] [-standard] spec
+// 	                                check a spec, and print its next runs
+// 	run id                          run the job of an entry now
+// 	tail [-entry id]                print the events of the Cron as they happen
+//
+// validate does not connect to a Cron.  cronctl is also meant as an example
+// of a client of the CronService.
+package main
+
+import (
+  "context"
+  "flag"
+  "fmt"
+  "io"
+  "os"
+  "os/signal"
+  "sort"
+  "strings"
+  "text/tabwriter"
+  "time"
+
+  "github.com/kiranbond/cron"
+  "github.com/kiranbond/cron/cronrpc"
+  "google.golang.org/grpc"
+  "google.golang.org/grpc/credentials/insecure"
+  "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func main() {
+  ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+  defer stop()
+  if err := run(ctx, os.Args[1:], os.Stdout); err != nil {
+    fmt.Fprintf(os.Stderr, "cronctl: %s\n", err)
+    os.Exit(1)
+  }
+}
+
+// usage is the usage of cronctl.
+const usage = `usage: cronctl [-addr host:port] [-timeout duration] command [arguments]
+
+commands:
+  list [-labels k=v,...]          list the entries, by their next run
+  validate [-n count] [-standard] spec
+                                  check a spec, and print its next runs
+  run id                          run the job of an entry now
+  tail [-entry id]                print the events of the Cron as they happen
+`
+
+// run runs the command of the arguments, writing its output to w.
+func run(ctx context.Context, args []string, w io.Writer) error {
+  flags := flag.NewFlagSet("cronctl", flag.ContinueOnError)
+  flags.Usage = func() { fmt.Fprint(flags.Output(), usage) }
+  addr := flags.String("addr", "localhost:8080",
+    "the address of the CronService")
+  timeout := flags.Duration("timeout", 10*time.Second,
+    "how long to wait for the CronService, except when tailing")
+  if err := flags.Parse(args); err != nil {
+    return err
+  }
+  if flags.NArg() == 0 {
+    flags.Usage()
+    return fmt.Errorf("no command")
+  }
+
+  command, args := flags.Arg(0), flags.Args()[1:]
+  if command == "validate" {
+    return validate(args, w)
+  }
+  var do func(context.Context, cronrpc.CronServiceClient, []string,
+    io.Writer) error
+  switch command {
+  case "list":
+    do = list
+  case "run":
+    do = runEntry
+  case "tail":
+    do = tail
+  default:
+    flags.Usage()
+    return fmt.Errorf("unknown command: %s", command)
+  }
+
+  conn, err := grpc.Dial(*addr,
+    grpc.WithTransportCredentials(insecure.NewCredentials()))
+  if err != nil {
+    return err
+  }
+  defer conn.Close()
+  if command != "tail" {
+    var cancel context.CancelFunc
+    ctx, cancel = context.WithTimeout(ctx, *timeout)
+    defer cancel()
+  }
+  return do(ctx, cronrpc.NewCronServiceClient(conn), args, w)
+}
+
+// list lists the entries, by their next run.
+func list(ctx context.Context, client cronrpc.CronServiceClient,
+  args []string, w io.Writer) error {
+  flags := flag.NewFlagSet("list", flag.ContinueOnError)
+  labels := flags.String("labels", "",
+    "only list the entries with these labels, as k=v,...")
+  if err := flags.Parse(args); err != nil {
+    return err
+  }
+  req := &cronrpc.ListEntriesRequest{}
+  if *labels != "" {
+    req.Labels = map[string]string{}
+    for _, label := range strings.Split(*labels, ",") {
+      k, v, ok := strings.Cut(label, "=")
+      if !ok {
+        return fmt.Errorf("invalid label: %s", label)
+      }
+      req.Labels[k] = v
+    }
+  }
+  resp, err := client.ListEntries(ctx, req)
+  if err != nil {
+    return err
+  }
+
+  entries := resp.Entries
+  sort.SliceStable(entries, func(i, j int) bool {
+    a, b := entries[i].Next, entries[j].Next
+    if a == nil || b == nil {
+      return b == nil && a != nil
+    }
+    return a.AsTime().Before(b.AsTime())
+  })
+  tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+  fmt.Fprintln(tw, "ID\tNAME\tSPEC\tNEXT\tPREV\tRUNS\tFAILURES")
+  for _, e := range entries {
+    fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%d\n", e.Id, e.Name, e.Spec,
+      formatTime(e.Next), formatTime(e.Prev), e.Stats.GetRuns(),
+      e.Stats.GetFailures())
+  }
+  return tw.Flush()
+}
+
+// validate parses the spec, printing its warnings and next runs.
+func validate(args []string, w io.Writer) error {
+  flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+  n := flags.Int("n", 5, "the number of runs to print")
+  standard := flags.Bool("standard", false,
+    "read 5 field specs as classic crontab specs, without seconds")
+  if err := flags.Parse(args); err != nil {
+    return err
+  }
+  if flags.NArg() != 1 {
+    return fmt.Errorf("validate takes one spec")
+  }
+
+  parse := cron.ParseWithWarnings
+  if *standard {
+    parse = cron.NewParser(cron.Standard).ParseWithWarnings
+  }
+  schedule, warnings, err := parse(flags.Arg(0))
+  if err != nil {
+    return err
+  }
+  if err := cron.Lint(schedule); err != nil {
+    return err
+  }
+  for _, warning := range warnings {
+    fmt.Fprintf(w, "warning: %s\n", warning)
+  }
+  for t, i := time.Now(), 0; i < *n; i++ {
+    if t = schedule.Next(t); t.IsZero() {
+      break
+    }
+    fmt.Fprintln(w, t.Format(time.RFC3339))
+  }
+  return nil
+}
+
+// runEntry runs the job of the entry now.
+func runEntry(ctx context.Context, client cronrpc.CronServiceClient,
+  args []string, w io.Writer) error {
+  if len(args) != 1 {
+    return fmt.Errorf("run takes one entry id")
+  }
+  _, err := client.RunEntry(ctx, &cronrpc.RunEntryRequest{Id: args[0]})
+  return err
+}
+
+// tail prints the events of the Cron until ctx is done.
+func tail(ctx context.Context, client cronrpc.CronServiceClient,
+  args []string, w io.Writer) error {
+  flags := flag.NewFlagSet("tail", flag.ContinueOnError)
+  entry := flags.String("entry", "", "only print the events of this entry")
+  if err := flags.Parse(args); err != nil {
+    return err
+  }
+  stream, err := client.Events(ctx, &cronrpc.EventsRequest{EntryId: *entry})
+  if err != nil {
+    return err
+  }
+  for {
+    e, err := stream.Recv()
+    if ctx.Err() != nil {
+      return nil
+    }
+    if err != nil {
+      return err
+    }
+    line := fmt.Sprintf("%s %s %s", formatTime(e.Time), e.Type, e.EntryId)
+    if e.Scheduled != nil {
+      line += " scheduled=" + formatTime(e.Scheduled)
+    }
+    if d := e.Duration.AsDuration(); d != 0 {
+      line += " duration=" + d.String()
+    }
+    if e.Error != "" {
+      line += fmt.Sprintf(" error=%q", e.Error)
+    }
+    if e.Output != "" {
+      line += fmt.Sprintf(" output=%q", e.Output)
+    }
+    fmt.Fprintln(w, line)
+  }
+}
+
+// formatTime formats the time, or returns "-" if it is not set.
+func formatTime(t *timestamppb.Timestamp) string {
+  if t == nil {
+    return "-"
+  }
+  return t.AsTime().Local().Format(time.RFC3339)
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for cronctl.
+
+package main
+
+import (
+  "bytes"
+  "context"
+  "net"
+  "strings"
+  "sync"
+  "testing"
+  "time"
+
+  "github.com/kiranbond/cron"
+  "github.com/kiranbond/cron/cronrpc"
+  "google.golang.org/grpc"
+)
+
+// serve serves the CronService of c, returning its address.
+func serve(t *testing.T, c *cron.Cron) string {
+  listener, err := net.Listen("tcp", "127.0.0.1:0")
+  if err != nil {
+    t.Fatal(err)
+  }
+  server := grpc.NewServer()
+  cronrpc.RegisterCronServiceServer(server, cronrpc.NewServer(c))
+  go server.Serve(listener)
+  t.Cleanup(server.Stop)
+  return listener.Addr().String()
+}
+
+func TestList(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  addr := serve(t, c)
+  c.AddFunc("@daily", func() {}, cron.WithName("daily"))
+  c.AddFunc("@hourly", func() {}, cron.WithName("hourly"),
+    cron.WithLabels(map[string]string{"team": "infra"}))
+
+  var out bytes.Buffer
+  if err := run(context.Background(), []string{"-addr", addr, "list"},
+    &out); err != nil {
+    t.Fatal(err)
+  }
+  lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+  if len(lines) != 3 || !strings.HasPrefix(lines[0], "ID") ||
+    !strings.Contains(lines[1], "hourly") ||
+    !strings.Contains(lines[2], "daily") {
+    t.Errorf("expected the entries by their next run, got\n%s", out.String())
+  }
+
+  out.Reset()
+  if err := run(context.Background(), []string{"-addr", addr, "list",
+    "-labels", "team=infra"}, &out); err != nil {
+    t.Fatal(err)
+  }
+  if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(
+    lines) != 2 || !strings.Contains(lines[1], "hourly") {
+    t.Errorf("expected only the hourly entry, got\n%s", out.String())
+  }
+}
+
+func TestValidate(t *testing.T) {
+  tests := []struct {
+    args     []string
+    lines    int
+    contains string
+    err      bool
+  }{
+    {[]string{"@hourly"}, 5, ":00:00", false},
+    {[]string{"-n", "2", "0 30 * * * *"}, 2, ":30:00", false},
+    {[]string{"-standard", "-n", "3", "15 * * * *"}, 3, ":15:00", false},
+    {[]string{"0 0 0 30 2 *"}, 0, "", true},
+    {[]string{"0 0 0 31 * MON"}, 6, "warning:", false},
+    {[]string{"bad"}, 0, "", true},
+    {[]string{}, 0, "", true},
+  }
+  for _, test := range tests {
+    var out bytes.Buffer
+    err := run(context.Background(), append([]string{"validate"},
+      test.args...), &out)
+    if (err != nil) != test.err {
+      t.Errorf("%v: unexpected error %v", test.args, err)
+      continue
+    }
+    if test.err {
+      continue
+    }
+    lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+    if len(lines) != test.lines || !strings.Contains(out.String(),
+      test.contains) {
+      t.Errorf("%v: expected %d lines with %q, got\n%s", test.args,
+        test.lines, test.contains, out.String())
+    }
+  }
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use.
+type lockedBuffer struct {
+  mu  sync.Mutex
+  buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+  b.mu.Lock()
+  defer b.mu.Unlock()
+  return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+  b.mu.Lock()
+  defer b.mu.Unlock()
+  return b.buf.String()
+}
+
+func TestRunAndTail(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  addr := serve(t, c)
+  id, _ := c.AddFunc("@hourly", func() {})
+  c.AddFunc("@hourly", func() {})
+
+  ctx, cancel := context.WithCancel(context.Background())
+  var out lockedBuffer
+  done := make(chan error, 1)
+  go func() {
+    done <- run(ctx, []string{"-addr", addr, "tail", "-entry", id}, &out)
+  }()
+
+  // Run the entry until the tail, once subscribed, prints its events.
+  deadline := time.Now().Add(5 * time.Second)
+  for !strings.Contains(out.String(), "RunCompleted") {
+    if time.Now().After(deadline) {
+      t.Fatalf("expected the events of the run, got\n%s", out.String())
+    }
+    if err := run(context.Background(), []string{"-addr", addr, "run", id},
+      &bytes.Buffer{}); err != nil {
+      t.Fatal(err)
+    }
+    time.Sleep(50 * time.Millisecond)
+  }
+  for _, line := range strings.Split(strings.TrimSpace(out.String()),
+    "\n") {
+    if !strings.Contains(line, " "+id) {
+      t.Errorf("expected only the events of %s, got %s", id, line)
+    }
+  }
+
+  cancel()
+  if err := <-done; err != nil {
+    t.Errorf("expected the tail to end, got %v", err)
+  }
+
+  if err := run(context.Background(), []string{"-addr", addr, "run",
+    "missing"}, &bytes.Buffer{}); err == nil {
+    t.Error("expected an error running a missing entry")
+  }
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements per-entry concurrency policies.
+
+package cron
+
+import (
+  "context"
+  "sync"
+)
+
+// ConcurrencyPolicy decides what happens when an entry is activated while a
+// previous run of its job is still running, as in Kubernetes CronJobs.
+type ConcurrencyPolicy int
+
+const (
+  // AllowConcurrent runs the job alongside the previous runs.  It is the
+  // default.
+  AllowConcurrent ConcurrencyPolicy = iota
+
+  // ForbidConcurrent skips the activation.
+  ForbidConcurrent
+
+  // ReplaceConcurrent cancels the contexts of the previous runs and runs the
+  // job.
+  ReplaceConcurrent
+)
+
+// WithConcurrencyPolicy sets the entry's concurrency policy.
+func WithConcurrencyPolicy(policy ConcurrencyPolicy) EntryOption {
+  return func(e *Entry) {
+    e.Concurrency = policy
+  }
+}
+
+// entryRuns tracks the running jobs of an entry.
+type entryRuns struct {
+  mu      sync.Mutex
+  next    int
+  running map[int]context.CancelFunc
+}
+
+// start records a run canceled by cancel, returning its id, or false if the
+// policy does not allow it to start.
+func (r *entryRuns) start(policy ConcurrencyPolicy,
+  cancel context.CancelFunc) (int, bool) {
+  r.mu.Lock()
+  defer r.mu.Unlock()
+
+  switch {
+  case policy == ForbidConcurrent && len(r.running) > 0:
+    return 0, false
+  case policy == ReplaceConcurrent:
+    for _, cancel := range r.running {
+      cancel()
+    }
+  }
+  if r.running == nil {
+    r.running = make(map[int]context.CancelFunc)
+  }
+  id := r.next
+  r.next++
+  r.running[id] = cancel
+  return id, true
+}
+
+// done records the end of the run with the given id.
+func (r *entryRuns) done(id int) {
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  delete(r.running, id)
+}
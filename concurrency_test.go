@@ -0,0 +1,48 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for concurrency policies.
+
+package cron
+
+import (
+  "testing"
+)
+
+func TestEntryRuns(t *testing.T) {
+  r := &entryRuns{}
+  canceled := 0
+  cancel := func() { canceled++ }
+
+  first, ok := r.start(ForbidConcurrent, cancel)
+  if !ok {
+    t.Fatal("expected the first run to start")
+  }
+  if _, ok := r.start(ForbidConcurrent, cancel); ok {
+    t.Error("expected a second run to be forbidden")
+  }
+  second, ok := r.start(AllowConcurrent, cancel)
+  if !ok || second == first {
+    t.Errorf("expected a second run to be allowed, got %d, %v", second, ok)
+  }
+  if _, ok := r.start(ReplaceConcurrent, cancel); !ok || canceled != 2 {
+    t.Errorf("expected both runs to be replaced, got %d canceled", canceled)
+  }
+
+  r.done(first)
+  r.done(second)
+  if len(r.running) != 1 {
+    t.Errorf("expected 1 running, got %d", len(r.running))
+  }
+}
@@ -0,0 +1,305 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements loading jobs from configuration files.
+
+// Package config defines the jobs of a cron.Cron in YAML or JSON files,
+// rather than in code:
+//
+// 	jobs:
+// 	  - name: nightly-report
+// 	    spec: "0 0 2 * * *"
+// 	    type: report
+// 	    parameters: {to: ops@example.com}
+// 	    labels: {team: infra}
+// 	    timeout: 10m
+// 	    retries: 3
+// 	    retryBackoff: 30s
+// 	    concurrency: forbid
+// 	    misfire: runOnce
+//
+// The type of a job is the name its cron.JobFactory is registered as, and
+// its parameters, encoded as JSON, the payload the factory creates it from.
+// Apply reconciles a Cron with the jobs, e.g. whenever the file changes.
+package config
+
+import (
+  "bytes"
+  "crypto/sha256"
+  "encoding/hex"
+  "encoding/json"
+  "fmt"
+  "os"
+  "time"
+
+  "github.com/kiranbond/cron"
+  "gopkg.in/yaml.v3"
+)
+
+// Label is the label of the entries of a Cron that Apply manages.  Its value
+// is a digest of the policies the entry was added with.
+const Label = "config"
+
+// Config is a set of jobs.
+type Config struct {
+  Jobs []Job `json:"jobs"`
+}
+
+// Job is the definition of a job.
+type Job struct {
+  // Name is the name of the job's entry, which identifies it; it is
+  // required.
+  Name string `json:"name"`
+
+  // Spec is the job's schedule, in any format the Cron parses.
+  Spec string `json:"spec"`
+
+  // Type is the name the job's JobFactory is registered as, and Parameters
+  // what it creates the job from, encoded as JSON.
+  Type       string      `json:"type"`
+  Parameters interface{} `json:"parameters,omitempty"`
+
+  // Labels are added to those of the entry.
+  Labels map[string]string `json:"labels,omitempty"`
+
+  // Timeout, if set, cancels the context of runs that take longer.
+  Timeout Duration `json:"timeout,omitempty"`
+
+  // Retries is the number of times failed runs are retried, after
+  // RetryBackoff, doubled for each retry.
+  Retries      int      `json:"retries,omitempty"`
+  RetryBackoff Duration `json:"retryBackoff,omitempty"`
+
+  // Concurrency is the entry's cron.ConcurrencyPolicy: "allow", the default,
+  // "forbid" or "replace".
+  Concurrency string `json:"concurrency,omitempty"`
+
+  // Misfire is the entry's cron.MisfirePolicy: "runAll", the default,
+  // "runOnce" or "skip".
+  Misfire string `json:"misfire,omitempty"`
+}
+
+// Duration is a time.Duration written as a string, e.g. "1m30s".
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+  var s string
+  if err := json.Unmarshal(data, &s); err != nil {
+    return fmt.Errorf("invalid duration: %s", data)
+  }
+  parsed, err := time.ParseDuration(s)
+  if err != nil {
+    return err
+  }
+  *d = Duration(parsed)
+  return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+  return json.Marshal(time.Duration(d).String())
+}
+
+// concurrencyPolicies are the ConcurrencyPolicies, by name.
+var concurrencyPolicies = map[string]cron.ConcurrencyPolicy{
+  "":        cron.AllowConcurrent,
+  "allow":   cron.AllowConcurrent,
+  "forbid":  cron.ForbidConcurrent,
+  "replace": cron.ReplaceConcurrent,
+}
+
+// misfirePolicies are the MisfirePolicies, by name.
+var misfirePolicies = map[string]cron.MisfirePolicy{
+  "":        cron.MisfireRunAll,
+  "runAll":  cron.MisfireRunAll,
+  "runOnce": cron.MisfireRunOnce,
+  "skip":    cron.MisfireSkip,
+}
+
+// Load reads the jobs of the YAML or JSON file at path.
+func Load(path string) (*Config, error) {
+  data, err := os.ReadFile(path)
+  if err != nil {
+    return nil, err
+  }
+  cfg, err := Parse(data)
+  if err != nil {
+    return nil, fmt.Errorf("%s: %s", path, err)
+  }
+  return cfg, nil
+}
+
+// Parse reads the jobs of a YAML or JSON document, which YAML includes.
+// Fields that Job does not have are errors, so that misspelled policies are
+// not ignored.
+func Parse(data []byte) (*Config, error) {
+  var doc interface{}
+  if err := yaml.Unmarshal(data, &doc); err != nil {
+    return nil, err
+  }
+  encoded, err := json.Marshal(doc)
+  if err != nil {
+    return nil, err
+  }
+  cfg := &Config{}
+  decoder := json.NewDecoder(bytes.NewReader(encoded))
+  decoder.DisallowUnknownFields()
+  if err := decoder.Decode(cfg); err != nil {
+    return nil, err
+  }
+  if err := cfg.validate(); err != nil {
+    return nil, err
+  }
+  return cfg, nil
+}
+
+// validate returns an error if a job is invalid, or two have the same name.
+func (cfg *Config) validate() error {
+  names := map[string]bool{}
+  for i, job := range cfg.Jobs {
+    switch {
+    case job.Name == "":
+      return fmt.Errorf("job %d has no name", i)
+    case names[job.Name]:
+      return fmt.Errorf("job %s is defined twice", job.Name)
+    case job.Spec == "" || job.Type == "":
+      return fmt.Errorf("job %s needs a spec and a type", job.Name)
+    case job.Retries < 0 || job.Timeout < 0 || job.RetryBackoff < 0:
+      return fmt.Errorf("job %s has negative policies", job.Name)
+    }
+    if _, ok := concurrencyPolicies[job.Concurrency]; !ok {
+      return fmt.Errorf("job %s has an invalid concurrency: %s", job.Name,
+        job.Concurrency)
+    }
+    if _, ok := misfirePolicies[job.Misfire]; !ok {
+      return fmt.Errorf("job %s has an invalid misfire policy: %s", job.Name,
+        job.Misfire)
+    }
+    names[job.Name] = true
+  }
+  return nil
+}
+
+// Apply reconciles the Cron with the jobs: it adds the jobs the Cron has no
+// entry for, updates the entries whose definitions changed, keeping their
+// history when only their spec, type, parameters, labels or misfire policy
+// changed, and removes the entries it added for jobs that are no longer
+// defined.  Entries it did not add are left alone, and jobs named like them
+// are errors.  Jobs that cannot be applied, e.g. because their type is not
+// registered, leave their entries as they were, and the first error is
+// returned after the other jobs are applied.
+func (cfg *Config) Apply(c *cron.Cron) error {
+  defined := map[string]bool{}
+  var first error
+  for _, job := range cfg.Jobs {
+    defined[job.Name] = true
+    if err := job.apply(c); err != nil && first == nil {
+      first = fmt.Errorf("cannot apply job %s: %s", job.Name, err)
+    }
+  }
+  c.RemoveIf(func(e cron.Entry) bool {
+    _, managed := e.Labels[Label]
+    return managed && !defined[e.Name]
+  })
+  return first
+}
+
+// apply adds or updates the job's entry.
+func (job *Job) apply(c *cron.Cron) error {
+  payload, err := job.payload()
+  if err != nil {
+    return err
+  }
+  digest, err := job.digest()
+  if err != nil {
+    return err
+  }
+  labels := map[string]string{Label: digest}
+  for k, v := range job.Labels {
+    labels[k] = v
+  }
+
+  id, ok := c.EntryID(job.Name)
+  if ok {
+    e, _ := c.Entry(id)
+    current, managed := e.Labels[Label]
+    if !managed {
+      return fmt.Errorf("entry %s is not managed by the configuration", id)
+    }
+    if current == digest {
+      return c.Apply(cron.StoredEntry{
+        ID:          id,
+        Name:        job.Name,
+        Spec:        job.Spec,
+        Labels:      labels,
+        Job:         job.Type,
+        Payload:     payload,
+        AtLeastOnce: e.AtLeastOnce,
+        Misfire:     misfirePolicies[job.Misfire],
+      })
+    }
+  }
+
+  // The job is new, or its policies changed, so its entry is replaced once
+  // the new one is known to be valid.
+  opts := []cron.EntryOption{
+    cron.WithLabels(labels),
+    cron.WithConcurrencyPolicy(concurrencyPolicies[job.Concurrency]),
+    cron.WithMisfirePolicy(misfirePolicies[job.Misfire]),
+  }
+  var wrappers []cron.JobWrapper
+  if job.Retries > 0 {
+    wrappers = append(wrappers, cron.Retry(cron.RetryPolicy{
+      Retries: job.Retries,
+      Backoff: time.Duration(job.RetryBackoff),
+    }))
+  }
+  if job.Timeout > 0 {
+    wrappers = append(wrappers, cron.Timeout(time.Duration(job.Timeout)))
+  }
+  if len(wrappers) > 0 {
+    opts = append(opts, cron.WithWrappers(wrappers...))
+  }
+  if ok {
+    if err := c.ValidateRegistered(job.Spec, job.Type, payload); err != nil {
+      return err
+    }
+    c.DeleteJob(id)
+  }
+  _, err = c.AddRegistered(job.Spec, job.Type, payload,
+    append(opts, cron.WithName(job.Name))...)
+  return err
+}
+
+// payload returns the job's parameters encoded as JSON, or nil if it has
+// none.
+func (job *Job) payload() ([]byte, error) {
+  if job.Parameters == nil {
+    return nil, nil
+  }
+  return json.Marshal(job.Parameters)
+}
+
+// digest returns a digest of the job's policies, which cannot change without
+// replacing its entry.
+func (job *Job) digest() (string, error) {
+  policies, err := json.Marshal([]interface{}{job.Timeout, job.Retries,
+    job.RetryBackoff, job.Concurrency})
+  if err != nil {
+    return "", err
+  }
+  sum := sha256.Sum256(policies)
+  return hex.EncodeToString(sum[:8]), nil
+}
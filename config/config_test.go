@@ -0,0 +1,220 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for loading jobs from configuration files.
+
+package config
+
+import (
+  "context"
+  "os"
+  "path/filepath"
+  "reflect"
+  "strings"
+  "testing"
+  "time"
+
+  "github.com/kiranbond/cron"
+)
+
+// runs receives the payloads of the runs of "config-echo" jobs.
+var runs = make(chan string, 10)
+
+func init() {
+  cron.RegisterJob("config-echo", func(payload []byte) (cron.Job, error) {
+    return cron.FuncJob(func() { runs <- string(payload) }), nil
+  })
+  cron.RegisterJob("config-wait", func(payload []byte) (cron.Job, error) {
+    return cron.ContextFuncJob(func(ctx context.Context) { <-ctx.Done() }),
+      nil
+  })
+}
+
+func TestParse(t *testing.T) {
+  yamlDoc := `
+jobs:
+  - name: report
+    spec: "0 0 2 * * *"
+    type: config-echo
+    parameters: {to: ops}
+    labels: {team: infra}
+    timeout: 10m
+    retries: 3
+    retryBackoff: 30s
+    concurrency: forbid
+    misfire: runOnce
+`
+  jsonDoc := `{"jobs": [{"name": "report", "spec": "0 0 2 * * *",
+    "type": "config-echo", "parameters": {"to": "ops"},
+    "labels": {"team": "infra"}, "timeout": "10m", "retries": 3,
+    "retryBackoff": "30s", "concurrency": "forbid", "misfire": "runOnce"}]}`
+  expected := []Job{{Name: "report", Spec: "0 0 2 * * *",
+    Type: "config-echo", Parameters: map[string]interface{}{"to": "ops"},
+    Labels:  map[string]string{"team": "infra"},
+    Timeout: Duration(10 * time.Minute), Retries: 3,
+    RetryBackoff: Duration(30 * time.Second), Concurrency: "forbid",
+    Misfire: "runOnce"}}
+  for _, doc := range []string{yamlDoc, jsonDoc} {
+    cfg, err := Parse([]byte(doc))
+    if err != nil {
+      t.Fatal(err)
+    }
+    if !reflect.DeepEqual(cfg.Jobs, expected) {
+      t.Errorf("(expected) %+v != %+v (actual)", expected, cfg.Jobs)
+    }
+  }
+}
+
+func TestParseErrors(t *testing.T) {
+  tests := []struct {
+    doc, err string
+  }{
+    {"jobs: [{spec: '@hourly', type: t}]", "no name"},
+    {"jobs: [{name: a, spec: '@hourly'}]", "needs a spec and a type"},
+    {"jobs: [{name: a, spec: '@hourly', type: t}, " +
+      "{name: a, spec: '@daily', type: t}]", "defined twice"},
+    {"jobs: [{name: a, spec: '@hourly', type: t, retry: 3}]",
+      "unknown field"},
+    {"jobs: [{name: a, spec: '@hourly', type: t, timeout: 10}]",
+      "invalid duration"},
+    {"jobs: [{name: a, spec: '@hourly', type: t, concurrency: never}]",
+      "invalid concurrency"},
+    {"jobs: [{name: a, spec: '@hourly', type: t, misfire: later}]",
+      "invalid misfire"},
+    {"jobs: [", "yaml"},
+  }
+  for _, test := range tests {
+    if _, err := Parse([]byte(test.doc)); err == nil ||
+      !strings.Contains(err.Error(), test.err) {
+      t.Errorf("%s: expected an error with %q, got %v", test.doc, test.err,
+        err)
+    }
+  }
+}
+
+func TestLoad(t *testing.T) {
+  path := filepath.Join(t.TempDir(), "jobs.yaml")
+  os.WriteFile(path, []byte("jobs: [{name: a, spec: '@hourly', type: t}]"),
+    0644)
+  cfg, err := Load(path)
+  if err != nil || len(cfg.Jobs) != 1 || cfg.Jobs[0].Name != "a" {
+    t.Errorf("unexpected %+v, %v", cfg, err)
+  }
+  if _, err := Load(path + ".missing"); err == nil {
+    t.Error("expected an error loading a missing file")
+  }
+}
+
+// mustParse parses the document, failing the test if it is invalid.
+func mustParse(t *testing.T, doc string) *Config {
+  t.Helper()
+  cfg, err := Parse([]byte(doc))
+  if err != nil {
+    t.Fatal(err)
+  }
+  return cfg
+}
+
+func TestApply(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  other, _ := c.AddFunc("@hourly", func() {}, cron.WithName("other"))
+
+  if err := mustParse(t, `
+jobs:
+  - {name: a, spec: "@hourly", type: config-echo, parameters: one}
+  - {name: b, spec: "@daily", type: config-echo, concurrency: forbid}
+`).Apply(c); err != nil {
+    t.Fatal(err)
+  }
+  a, _ := c.EntryID("a")
+  b, _ := c.EntryID("b")
+  if e, _ := c.Entry(b); e.Concurrency != cron.ForbidConcurrent {
+    t.Errorf("expected b to forbid concurrent runs, got %+v", e)
+  }
+  c.RunNow(a)
+  if payload := <-runs; payload != `"one"` {
+    t.Errorf("expected the parameters as JSON, got %s", payload)
+  }
+
+  // a keeps its entry, b is replaced since its policies changed, c is added
+  // and the entries that were not added by the configuration are kept.
+  if err := mustParse(t, `
+jobs:
+  - {name: a, spec: "@daily", type: config-echo, labels: {team: infra}}
+  - {name: b, spec: "@daily", type: config-echo, retries: 1}
+  - {name: c, spec: "@weekly", type: config-echo}
+`).Apply(c); err != nil {
+    t.Fatal(err)
+  }
+  if e, _ := c.Entry(a); e.Spec != "@daily" || e.Labels["team"] != "infra" {
+    t.Errorf("expected a updated in place, got %+v", e)
+  }
+  if id, _ := c.EntryID("b"); id == b {
+    t.Error("expected b replaced")
+  } else if e, _ := c.Entry(id); e.Concurrency != cron.AllowConcurrent {
+    t.Errorf("expected b to allow concurrent runs, got %+v", e)
+  }
+  if _, ok := c.EntryID("c"); !ok {
+    t.Error("expected c added")
+  }
+
+  // Invalid jobs leave their entries as they were, but not the others.
+  err := mustParse(t, `
+jobs:
+  - {name: a, spec: "@daily", type: unregistered, retries: 2}
+  - {name: other, spec: "@daily", type: config-echo}
+`).Apply(c)
+  if err == nil || !strings.Contains(err.Error(), "job a") {
+    t.Errorf("expected an error applying a, got %v", err)
+  }
+  names := map[string]bool{}
+  for _, e := range c.Entries() {
+    names[e.Name] = true
+  }
+  if expected := map[string]bool{"a": true, "other": true}; !reflect.DeepEqual(
+    names, expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, names)
+  }
+  if id, _ := c.EntryID("other"); id != other {
+    t.Error("expected other kept")
+  }
+}
+
+func TestApplyTimeout(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  failed := make(chan error, 1)
+  c.Subscribe(func(e cron.Event) {
+    if e.Type == cron.RunFailed {
+      failed <- e.Err
+    }
+  })
+  if err := mustParse(t, `
+jobs:
+  - {name: wait, spec: "@hourly", type: config-wait, timeout: 10ms}
+`).Apply(c); err != nil {
+    t.Fatal(err)
+  }
+  id, _ := c.EntryID("wait")
+  c.RunNow(id)
+  select {
+  case err := <-failed:
+    if !strings.Contains(err.Error(), "timed out") {
+      t.Errorf("expected the run to time out, got %v", err)
+    }
+  case <-time.After(5 * time.Second):
+    t.Fatal("expected the run to time out")
+  }
+}
@@ -19,9 +19,13 @@ package cron
 import "time"
 
 // ConstantDelaySchedule represents a simple recurring duty cycle, e.g. "Every 5 minutes".
-// It does not support jobs more frequent than once a second.
+// It does not support jobs more frequent than once a Precision.
 type ConstantDelaySchedule struct {
   Delay time.Duration
+
+  // Precision is the resolution of the schedule: activations are aligned to
+  // multiples of it within the second.  Zero means a second.
+  Precision time.Duration
 }
 
 // Every returns a crontab Schedule that activates once every duration.
@@ -36,8 +40,49 @@ func Every(duration time.Duration) ConstantDelaySchedule {
   }
 }
 
+// EveryWithPrecision returns a Schedule that activates once every duration,
+// with the given precision, e.g. every 250ms with a precision of a millisecond.
+// The precision must divide a second; it is at least a millisecond, and at most
+// a second.  Delays of less than the precision round up to it, and any fields
+// less than it are truncated.
+func EveryWithPrecision(duration, precision time.Duration) ConstantDelaySchedule {
+  if precision < time.Millisecond {
+    precision = time.Millisecond
+  }
+  if precision > time.Second || time.Second%precision != 0 {
+    precision = time.Second
+  }
+  if duration < precision {
+    duration = precision
+  }
+  return ConstantDelaySchedule{
+    Delay:     duration - duration%precision,
+    Precision: precision,
+  }
+}
+
 // Next returns the next time this should be run.
-// This rounds so that the next activation time will be on the second.
+// This rounds so that the next activation time will be on a multiple of the
+// precision, by default on the second.  Delays of less than the precision
+// round up to it, so that the next activation is always after t.
 func (schedule ConstantDelaySchedule) Next(t time.Time) time.Time {
-  return t.Add(schedule.Delay - time.Duration(t.Nanosecond())*time.Nanosecond)
+  precision := schedule.precision()
+  delay := schedule.Delay
+  if delay < precision {
+    delay = precision
+  }
+  return t.Add(delay - time.Duration(t.Nanosecond())%precision)
+}
+
+// precision returns the precision of the schedule, defaulting to a second.
+func (schedule ConstantDelaySchedule) precision() time.Duration {
+  if schedule.Precision == 0 {
+    return time.Second
+  }
+  return schedule.Precision
+}
+
+// Times returns a schedule that activates every Delay, but only n times.
+func (schedule ConstantDelaySchedule) Times(n int) *LimitedSchedule {
+  return &LimitedSchedule{Schedule: schedule, Limit: n}
 }
@@ -68,3 +68,48 @@ func TestConstantDelayNext(t *testing.T) {
     }
   }
 }
+
+func TestEveryWithPrecision(t *testing.T) {
+  tests := []struct {
+    time      string
+    delay     time.Duration
+    precision time.Duration
+    expected  string
+  }{
+    // Sub-second delays are kept.
+    {"Mon Jul 9 14:45:00 2012", 250 * time.Millisecond, time.Millisecond, "Mon Jul 9 14:45:00.25 2012"},
+    {"Mon Jul 9 14:45:00.75 2012", 250 * time.Millisecond, time.Millisecond, "Mon Jul 9 14:45:01 2012"},
+
+    // Truncate to the precision on the delay and on the time.
+    {"Mon Jul 9 14:45:00.0051 2012", 250*time.Millisecond + 50*time.Microsecond, time.Millisecond, "Mon Jul 9 14:45:00.255 2012"},
+    {"Mon Jul 9 14:45:00.12 2012", 330 * time.Millisecond, 100 * time.Millisecond, "Mon Jul 9 14:45:00.4 2012"},
+
+    // Round up to the precision if the duration is less.
+    {"Mon Jul 9 14:45:00 2012", 10 * time.Microsecond, time.Millisecond, "Mon Jul 9 14:45:00.001 2012"},
+
+    // Precisions that do not divide a second, or are out of range, are clamped.
+    {"Mon Jul 9 14:45:00.5 2012", 1500 * time.Millisecond, 300 * time.Millisecond, "Mon Jul 9 14:45:01 2012"},
+    {"Mon Jul 9 14:45:00.5 2012", time.Minute, time.Hour, "Mon Jul 9 14:46 2012"},
+    {"Mon Jul 9 14:45:00 2012", time.Microsecond, time.Nanosecond, "Mon Jul 9 14:45:00.001 2012"},
+  }
+
+  for _, c := range tests {
+    actual := EveryWithPrecision(c.delay, c.precision).Next(getTime(c.time))
+    expected := getTime(c.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s, \"%s\" at %s: (expected) %v != %v (actual)", c.time,
+        c.delay, c.precision, expected, actual)
+    }
+  }
+}
+
+// TestConstantDelayNextShortDelay checks that schedules built without Every
+// never activate at or before the given time.
+func TestConstantDelayNextShortDelay(t *testing.T) {
+  schedule := ConstantDelaySchedule{Delay: 250 * time.Millisecond}
+  now := getTime("Mon Jul 9 14:45:00.75 2012")
+  expected := getTime("Mon Jul 9 14:45:01 2012")
+  if actual := schedule.Next(now); !actual.Equal(expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, actual)
+  }
+}
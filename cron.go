@@ -18,34 +18,158 @@
 package cron
 
 import (
+  "context"
+  "errors"
   "fmt"
+  "math/rand"
   "runtime"
   "sort"
+  "sync"
   "time"
 
   "code.google.com/p/go-uuid/uuid"
-  "github.com/golang/glog"
 )
 
 // Cron keeps track of any number of entries, invoking the associated func as
 // specified by the schedule. It may be started, stopped, and the entries may
 // be inspected while running.
 type Cron struct {
-  entries  []*Entry
-  start    chan struct{}
-  stop     chan struct{}
-  add      chan *Entry
-  del      chan string
-  err      chan error
-  snapshot chan []*Entry
-  running  bool
+  // mu guards the entries and the state of the Cron.  Methods that only
+  // inspect them take a read lock, so they do not wait for each other.
+  mu      sync.RWMutex
+  entries entryHeap
+  ids     map[string]*Entry
+  names   map[string]*Entry
+  running bool
+
+  // suspended is true between Suspend and Resume, when no entry runs on
+  // schedule.
+  suspended bool
+
+  // blackouts are the windows during which no entry runs on time.
+  blackouts []*Blackout
+
+  // waiting are the runs waiting for the runs of their dependencies.
+  waiting []*dependentRun
+
+  // queued are the events emitted while mu was held, sent when it is
+  // unlocked.
+  queued []Event
+
+  // wake is signaled when the next activation may have changed, so that the
+  // scheduler goroutine recomputes it.
+  wake chan struct{}
+
+  // quit is closed by Close to terminate the scheduler goroutine, which closes
+  // exited when it has; closed is true in between.
+  quit   chan struct{}
+  exited chan struct{}
+  closed bool
+
+  // ctx is the context jobs run under while running, canceled by Stop.
+  ctx    context.Context
+  cancel context.CancelFunc
+
+  // jobs tracks the jobs started since the Cron was last started.
+  jobs *sync.WaitGroup
+
+  // chain wraps every added job.
+  chain Chain
+
+  // parser parses the specs of added jobs.
+  parser Parser
+
+  // pool starts jobs, limiting how many run at once.
+  pool *pool
+
+  // location is the time zone schedules are evaluated in.
+  location *time.Location
+
+  // clock is the source of the current time.
+  clock Clock
+
+  // logger receives the Cron's diagnostics.
+  logger Logger
+
+  // events holds the subscribers to the Cron's events.
+  events eventHub
+
+  // onError, if set, is called with the errors of failed runs.
+  onError ErrorHandler
+
+  // onPanic, if set, is called with the panics of jobs instead of logging them.
+  onPanic PanicHandler
+
+  // historySize is the number of runs recorded per entry.
+  historySize int
+
+  // skipped counts the scheduled runs that did not start, and dropped those
+  // of them skipped because the pool's queue was full.
+  skipped, dropped int
+
+  // health tracks the progress of the scheduler goroutine, which is stalled
+  // once due runs are stallThreshold late.  onStall, if set, is called by a
+  // watchdog when it stalls.
+  health         health
+  stallThreshold time.Duration
+  onStall        func(error)
+
+  // store, if set, persists the entries added with AddRegistered.  The
+  // changes to it are queued in storeOps while c.mu is held, and applied in
+  // order, holding storeMu, once it is unlocked.
+  store    EntryStore
+  storeOps []storeOp
+  storeMu  sync.Mutex
+
+  // replay is whether restored entries replay the activations they missed.
+  replay bool
+
+  // catchUp is whether restored entries run the last activation they missed
+  // once, if it is at most maxCatchUpAge old, unless that is zero.
+  catchUp       bool
+  maxCatchUpAge time.Duration
+
+  // locker, if set, serializes the runs of entries with other Crons.
+  locker Locker
+
+  // instanceID identifies the Cron in the claims it stores.  claimPolicy is
+  // what it does with abandoned claims, and claimTimeout, if positive, how
+  // long after their scheduled times the claims of other Crons are abandoned.
+  instanceID   string
+  claimPolicy  ClaimPolicy
+  claimTimeout time.Duration
+
+  // elector, if set, elects the Cron that runs jobs among those sharing it.
+  // leading is whether this one does, and resign ends its campaign.
+  elector Elector
+  leading bool
+  resign  context.CancelFunc
+
+  // cluster, if set, splits the entries between its members, of which this
+  // Cron is member while it is running.  members are those last seen, and
+  // leave ends the heartbeats.
+  cluster   Cluster
+  member    string
+  memberTTL time.Duration
+  members   []string
+  leave     context.CancelFunc
 }
 
+// ErrDuplicateName is returned when adding an entry whose name is taken.
+var ErrDuplicateName = errors.New("cron: an entry with the name exists")
+
 // Job is an interface for submitted cron jobs.
 type Job interface {
   Run()
 }
 
+// ContextJob is a Job that accepts a context.  Cron calls RunContext instead of
+// Run, with a context that is canceled when the Cron is stopped.
+type ContextJob interface {
+  Job
+  RunContext(ctx context.Context)
+}
+
 // The Schedule describes a job's duty cycle.
 type Schedule interface {
   // Return the next activation time, later than the given time.
@@ -66,15 +190,179 @@ type Entry struct {
   // been run.
   Prev time.Time
 
-  // The Job to run.
+  // The Job to run, wrapped by the Cron's chain.
   Job Job
 
   // The Job ID.
   ID string
+
+  // The spec the schedule was parsed from, if it was added with AddJob or
+  // AddFunc.
+  Spec string
+
+  // Concurrency decides whether the job runs while a previous run is still
+  // running.
+  Concurrency ConcurrencyPolicy
+
+  // Group is the group of entries whose concurrent runs are limited together.
+  Group string
+
+  // Runs is the number of times the job was started on schedule.
+  Runs int
+
+  // MaxRuns, if positive, is the number of runs after which the entry is
+  // removed.
+  MaxRuns int
+
+  // NotBefore and NotAfter, if set, bound the entry's activations: those
+  // before NotBefore or after NotAfter are skipped.
+  NotBefore, NotAfter time.Time
+
+  // Expired is whether the entry has no activations left before its
+  // NotAfter, and RemoveWhenExpired whether it is then removed.  Expired
+  // entries are otherwise kept, but never run on schedule again.
+  Expired, RemoveWhenExpired bool
+
+  // Misfire decides which activations run when the scheduler wakes up late,
+  // e.g. after the machine was suspended.
+  Misfire MisfirePolicy
+
+  // Location, if set, is the time zone the schedule is evaluated in.
+  Location *time.Location
+
+  // Name, if set, uniquely names the entry within its Cron.
+  Name string
+
+  // Labels are arbitrary key/value pairs describing the entry, e.g. its team.
+  Labels map[string]string
+
+  // OnError, if set, is called with the errors of failed runs instead of the
+  // Cron's error handler.
+  OnError ErrorHandler
+
+  // Priority orders the runs of entries due at the same time, and of those
+  // waiting for the Cron's limits: higher priorities start first.  The default
+  // is 0.
+  Priority int
+
+  // Dependencies are the IDs of the entries whose runs scheduled at the same
+  // time must complete successfully before the job runs.
+  Dependencies []string
+
+  // depth is the length of the entry's longest chain of dependencies, and
+  // dependents the number of entries that depend on it.
+  depth, dependents int
+
+  // last is the run last started, if other entries depend on the entry.
+  last lastRun
+
+  // Blackouts are the windows during which the job does not run on time, in
+  // addition to those of the Cron.
+  Blackouts []Blackout
+
+  // Holidays, if set, are the days the job does not run on, and HolidayPolicy
+  // decides whether their activations are skipped or moved.
+  Holidays      HolidayCalendar
+  HolidayPolicy HolidayPolicy
+
+  // Jitter, if positive, delays every activation by a random duration up to
+  // it.  Next and Prev are the delayed times.
+  Jitter time.Duration
+
+  // JobName and Payload, for entries added with AddRegistered, are the name
+  // of the JobFactory that created the job, and what it created it from.
+  JobName string
+  Payload []byte
+
+  // AtLeastOnce, for stored entries, records each run in the store while it
+  // runs, so that runs interrupted by the process exiting run again when the
+  // entry is restored.  claims are the scheduled times of those runs, and
+  // foreignClaims those of the runs claimed by other Crons, by their instance
+  // IDs, as seen by Apply.
+  AtLeastOnce   bool
+  claims        []time.Time
+  foreignClaims map[string][]time.Time
+
+  // lastSuccess, for stored entries, is the scheduled time of the last run
+  // that succeeded, and resume, if set, the time a restored entry's next
+  // activation follows, instead of now.
+  lastSuccess time.Time
+  resume      time.Time
+
+  // nominal is the activation of the schedule Next was delayed from.
+  nominal time.Time
+
+  // History is the entry's most recent runs, oldest first, in the snapshots
+  // returned by Entries and Entry.
+  History []RunRecord
+
+  // Stats summarizes the entry's runs, in the snapshots returned by Entries
+  // and Entry.
+  Stats EntryStats
+
+  // wrappers decorate the entry's jobs, inside the Cron's chain.
+  wrappers []JobWrapper
+
+  // values is the context added WithContext, whose values the contexts of
+  // the runs carry.
+  values context.Context
+
+  // runOnAdd is true for entries added WithRunOnAdd until their first run
+  // starts, and catchUp is the missed activation a restored entry catches up
+  // with, until its run starts, once the Cron is running.
+  runOnAdd bool
+  catchUp  time.Time
+
+  // runs tracks the running jobs, history records the recent runs and stats
+  // accumulates their statistics.
+  runs    *entryRuns
+  history *runHistory
+  stats   *entryStats
+
+  // index is the position of the entry in the Cron's heap.
+  index int
+}
+
+// next returns the next activation of the entry's schedule after t, evaluated
+// in the entry's location, and avoiding its holidays.
+func (e *Entry) next(t time.Time) time.Time {
+  if t.Before(e.NotBefore) {
+    t = e.NotBefore.Add(-time.Nanosecond)
+  }
+  if e.Location != nil {
+    t = t.In(e.Location)
+  }
+  next := e.Schedule.Next(t)
+  if e.Holidays != nil {
+    next = e.avoidHolidays(next)
+  }
+  if !e.NotAfter.IsZero() && next.After(e.NotAfter) {
+    return time.Time{}
+  }
+  return next
+}
+
+// advance sets Next to the entry's next activation after t, delayed by a
+// random duration up to its Jitter, and whether the entry expired.
+func (e *Entry) advance(t time.Time) {
+  e.nominal = e.next(t)
+  e.Expired = e.nominal.IsZero() && !e.NotAfter.IsZero()
+  e.Next = e.nominal
+  if e.Jitter > 0 && !e.Next.IsZero() {
+    e.Next = e.Next.Add(time.Duration(rand.Int63n(int64(e.Jitter))))
+  }
+}
+
+// activation returns the activation of the schedule Next was delayed from.
+func (e *Entry) activation() time.Time {
+  if e.nominal.IsZero() {
+    return e.Next
+  }
+  return e.nominal
 }
 
 // byTime is a wrapper for sorting the entry array by time
-// (with zero time at the end).
+// (with zero time at the end), and entries due at the same time by priority.
 type byTime []*Entry
 
 func (s byTime) Len() int      { return len(s) }
@@ -89,22 +377,34 @@ func (s byTime) Less(i, j int) bool {
   if s[j].Next.IsZero() {
     return true
   }
-  return s[i].Next.Before(s[j].Next)
+  if !s[i].Next.Equal(s[j].Next) {
+    return s[i].Next.Before(s[j].Next)
+  }
+  return s[i].Priority > s[j].Priority
 }
 
-// New returns a new Cron job runner.
-func New() *Cron {
+// New returns a new Cron job runner, configured by the given options.
+func New(opts ...Option) *Cron {
   c := &Cron{
     entries:  nil,
-    add:      make(chan *Entry),
-    del:      make(chan string),
-    err:      make(chan error),
-    start:    make(chan struct{}),
-    stop:     make(chan struct{}),
-    snapshot: make(chan []*Entry),
+    ids:      map[string]*Entry{},
+    names:    map[string]*Entry{},
     running:  false,
+    wake:     make(chan struct{}, 1),
+    pool:     newPool(),
+    location: time.Local,
+    clock:    realClock{},
+    logger:   DefaultLogger,
+
+    instanceID:     uuid.New(),
+    historySize:    DefaultRunHistory,
+    stallThreshold: DefaultStallThreshold,
+  }
+  for _, opt := range opts {
+    opt(c)
   }
-  go c.run()
+  c.pool.clock = c.clock
+  c.launch()
   return c
 }
 
@@ -114,146 +414,934 @@ type FuncJob func()
 // Run invokes the function.
 func (f FuncJob) Run() { f() }
 
+// ContextFuncJob is a wrapper that turns a func(context.Context) into a
+// cron.ContextJob.
+type ContextFuncJob func(ctx context.Context)
+
+// Run invokes the function with a background context.
+func (f ContextFuncJob) Run() { f(context.Background()) }
+
+// RunContext invokes the function.
+func (f ContextFuncJob) RunContext(ctx context.Context) { f(ctx) }
+
 // AddFunc adds a func to the Cron to be run on the given schedule.
-func (c *Cron) AddFunc(spec string, cmd func()) (string, error) {
-  return c.AddJob(spec, FuncJob(cmd))
+func (c *Cron) AddFunc(spec string, cmd func(), opts ...EntryOption) (string,
+  error) {
+  return c.AddJob(spec, FuncJob(cmd), opts...)
 }
 
-// AddJob adds a Job to the Cron to be run on the given schedule.
-func (c *Cron) AddJob(spec string, cmd Job) (string, error) {
-  schedule, err := Parse(spec)
+// AddJob adds a Job to the Cron to be run on the given schedule.  If the Job
+// is named, with WithName, and the name is taken, it returns the ID of the
+// entry that has it and ErrDuplicateName.
+func (c *Cron) AddJob(spec string, cmd Job, opts ...EntryOption) (string,
+  error) {
+  schedule, err := c.parser.Parse(spec)
   if err != nil {
     return "", err
   }
-  id := c.Schedule(schedule, cmd)
-  return id, nil
+  return c.schedule(spec, schedule, cmd, opts)
 }
 
 // DeleteJob deletes a Job from the Cron.
 func (c *Cron) DeleteJob(id string) error {
-  c.del <- id
-  err := <-c.err
+  _, err := c.RemoveJob(id)
+  return err
+}
+
+// RemoveJob deletes a Job from the Cron, and returns a snapshot of its entry
+// as it was removed, e.g. to record its last run or to add it again later.
+func (c *Cron) RemoveJob(id string) (Entry, error) {
+  return c.remove(entryRef{id: id})
+}
+
+// UpdateJob changes the schedule of the entry with the given id to the one
+// parsed from spec.  The entry keeps its ID, job and history; its next run is
+// recomputed immediately.
+func (c *Cron) UpdateJob(id, spec string) error {
+  schedule, err := c.parser.Parse(spec)
+  if err != nil {
+    return err
+  }
+  return c.updateSchedule(id, spec, schedule)
+}
+
+// UpdateSchedule changes the schedule of the entry with the given id, like
+// UpdateJob.
+func (c *Cron) UpdateSchedule(id string, schedule Schedule) error {
+  return c.updateSchedule(id, "", schedule)
+}
+
+// updateSchedule changes the schedule of the entry with the given id, and the
+// spec it was parsed from.
+func (c *Cron) updateSchedule(id, spec string, schedule Schedule) error {
+  return c.update(id, func(e *Entry) {
+    e.Schedule = schedule
+    e.Spec = spec
+    now := c.now()
+    startLimits(e.Schedule, now)
+    e.advance(now)
+    c.scheduled(e)
+  })
+}
+
+// ReplaceJob replaces the job of the entry with the given id, wrapping it with
+// the entry's wrappers and the Cron's chain, e.g. after its configuration was
+// reloaded.  The entry's schedule and history are unchanged, and runs already
+// started finish with the old job.
+func (c *Cron) ReplaceJob(id string, cmd Job) error {
+  return c.update(id, func(e *Entry) {
+    e.Job = c.wrap(e, cmd)
+  })
+}
+
+// DeleteJobByName deletes the Job of the entry with the given name.
+func (c *Cron) DeleteJobByName(name string) error {
+  _, err := c.remove(entryRef{name: name})
   return err
 }
 
-// Schedule adds a Job to the Cron to be run on the given schedule.
-func (c *Cron) Schedule(schedule Schedule, cmd Job) string {
-  id := uuid.New()
+// RemoveIf deletes the Jobs of the entries for which match returns true, e.g.
+// MatchLabels, in one operation, and returns snapshots of their entries.
+func (c *Cron) RemoveIf(match func(Entry) bool) []*Entry {
+  c.mu.Lock()
+  defer c.unlock()
+  removed := c.deleteEntries(match)
+  c.wakeup()
+  return removed
+}
+
+// RemoveAll deletes every Job from the Cron, and returns snapshots of their
+// entries.
+func (c *Cron) RemoveAll() []*Entry {
+  return c.RemoveIf(func(Entry) bool { return true })
+}
+
+// MatchLabels returns a function matching the entries that have all of the
+// labels, for RemoveIf.
+func MatchLabels(labels map[string]string) func(Entry) bool {
+  return func(e Entry) bool {
+    for k, v := range labels {
+      if value, ok := e.Labels[k]; !ok || value != v {
+        return false
+      }
+    }
+    return true
+  }
+}
+
+// remove deletes the entry ref refers to, returning a snapshot of it.
+func (c *Cron) remove(ref entryRef) (Entry, error) {
+  c.mu.Lock()
+  defer c.unlock()
+  removed := c.deleteEntry(ref)
+  if removed == nil {
+    return Entry{}, ref.notFound()
+  }
+  c.wakeup()
+  return *removed, nil
+}
+
+// EntryID returns the ID of the entry with the given name, if there is one.
+func (c *Cron) EntryID(name string) (string, bool) {
+  c.mu.RLock()
+  defer c.mu.RUnlock()
+  if e := c.entryNamed(name); e != nil {
+    return e.ID, true
+  }
+  return "", false
+}
+
+// At adds a Job to the Cron to be run once, at the given time, after which its
+// entry is removed.  A time that has passed never runs.
+func (c *Cron) At(t time.Time, cmd Job, opts ...EntryOption) string {
+  id, _ := c.schedule("@at "+t.Format(time.RFC3339), AtSchedule{t}, cmd, opts)
+  return id
+}
+
+// RunNow runs the job with the given id immediately, in its own goroutine,
+// whether or not the Cron is running.  The entry's Next and Prev times are not
+// changed.
+func (c *Cron) RunNow(id string) error {
+  c.mu.Lock()
+  defer c.unlock()
+  return c.runEntry(id)
+}
+
+// Schedule adds a Job to the Cron to be run on the given schedule.  If the Job
+// is named, with WithName, and the name is taken, the ID of the entry that has
+// it is returned instead, and if its dependencies are invalid "" is.
+func (c *Cron) Schedule(schedule Schedule, cmd Job, opts ...EntryOption) string {
+  id, _ := c.schedule("", schedule, cmd, opts)
+  return id
+}
+
+// schedule adds a Job to the Cron to be run on the given schedule, recording
+// the spec it was parsed from.  If the entry's name is taken it returns the ID
+// of the entry that has it, and ErrDuplicateName.
+func (c *Cron) schedule(spec string, schedule Schedule, cmd Job,
+  opts []EntryOption) (string, error) {
+  entry := c.newEntry(spec, schedule, cmd, opts)
+  if taken, err := c.add([]*Entry{entry}); err != nil {
+    return taken, err
+  }
+  return entry.ID, nil
+}
+
+// add adds the entries, unless one of their names is taken, in which case it
+// returns the ID of the entry that has it and ErrDuplicateName, or their
+// dependencies are invalid.
+func (c *Cron) add(entries []*Entry) (string, error) {
+  c.mu.Lock()
+  defer c.unlock()
+  if taken := c.nameTaken(entries); taken != "" {
+    return taken, ErrDuplicateName
+  }
+  if err := c.resolveDependencies(entries); err != nil {
+    return "", err
+  }
+  now := c.now()
+  for _, e := range entries {
+    from := now
+    if !e.resume.IsZero() && e.resume.Before(now) {
+      from = e.resume
+    }
+    startLimits(e.Schedule, from)
+    e.advance(from)
+    e.resume = time.Time{}
+    c.entries.push(e)
+    c.ids[e.ID] = e
+    if e.Name != "" {
+      c.names[e.Name] = e
+    }
+    c.emit(EntryAdded, e.ID, time.Time{}, 0, nil)
+    c.scheduled(e)
+    c.persist(e)
+    if c.running {
+      c.startPending(e, now)
+    }
+  }
+  c.wakeup()
+  return "", nil
+}
+
+// newEntry returns a new entry running the Job, wrapped by the entry's
+// wrappers and the Cron's chain, on the given schedule.
+func (c *Cron) newEntry(spec string, schedule Schedule, cmd Job,
+  opts []EntryOption) *Entry {
   entry := &Entry{
     Schedule: schedule,
-    Job:      cmd,
-    ID:       id,
+    ID:       uuid.New(),
+    Spec:     spec,
+    history:  newRunHistory(c.historySize),
+    stats:    &entryStats{},
   }
-  c.add <- entry
-  return id
+  for _, opt := range opts {
+    opt(entry)
+  }
+  entry.Job = c.wrap(entry, cmd)
+  return entry
+}
+
+// wrap returns the job wrapped by the entry's wrappers, inside the Cron's
+// chain.
+func (c *Cron) wrap(e *Entry, cmd Job) Job {
+  return c.chain.Then(NewChain(e.wrappers...).Then(cmd))
+}
+
+// JobSpec describes a Job to add with AddJobs.
+type JobSpec struct {
+  // Spec is the schedule of the Job, in any format accepted by Parse.
+  Spec string
+
+  // Job is the Job to run.
+  Job Job
+
+  // Options configure the Job's entry.
+  Options []EntryOption
+}
+
+// AddJobs adds several Jobs to the Cron at once, returning their IDs in order.
+// Either all of them are added or, if any spec fails to parse, any name is
+// taken or any dependency is invalid, none are, e.g. so that reloading a
+// configuration with one bad line leaves the Cron as it was.  The Jobs may
+// depend on each other.
+func (c *Cron) AddJobs(jobs []JobSpec) ([]string, error) {
+  entries := make([]*Entry, 0, len(jobs))
+  for _, job := range jobs {
+    schedule, err := c.parser.Parse(job.Spec)
+    if err != nil {
+      return nil, err
+    }
+    entries = append(entries, c.newEntry(job.Spec, schedule, job.Job,
+      job.Options))
+  }
+
+  if _, err := c.add(entries); err != nil {
+    return nil, err
+  }
+  ids := make([]string, 0, len(entries))
+  for _, e := range entries {
+    ids = append(ids, e.ID)
+  }
+  return ids, nil
+}
+
+// Entries returns a snapshot of the cron entries, ordered by their next
+// activations, priorities and IDs.  Options may select some of them, order them otherwise, and
+// return a page of them, e.g. for an administrative UI:
+//
+// 	page := c.Entries(cron.FilterLabels(labels), cron.SortByName(),
+// 		cron.Page(100, 50))
+func (c *Cron) Entries(opts ...EntriesOption) []*Entry {
+  var q entriesQuery
+  for _, opt := range opts {
+    opt(&q)
+  }
+  c.mu.RLock()
+  defer c.mu.RUnlock()
+  return c.query(&q)
+}
+
+// Entry returns a snapshot of the entry with the given id, if there is one.
+func (c *Cron) Entry(id string) (Entry, bool) {
+  c.mu.RLock()
+  defer c.mu.RUnlock()
+  e := c.ids[id]
+  if e == nil {
+    return Entry{}, false
+  }
+  return *e.snapshot(), true
 }
 
-// Entries returns a snapshot of the cron entries.
-func (c *Cron) Entries() []*Entry {
-  c.snapshot <- nil
-  x := <-c.snapshot
-  return x
+// IsRunning returns whether the cron scheduler is running, i.e. it has been
+// started and not stopped since.
+func (c *Cron) IsRunning() bool {
+  c.mu.RLock()
+  defer c.mu.RUnlock()
+  return c.running
 }
 
-// Start the cron scheduler in its own go-routine.
+// Start the cron scheduler in its own go-routine.  Starting a running Cron
+// does nothing.
 func (c *Cron) Start() {
-  c.start <- struct{}{}
+  c.StartWithContext(context.Background())
+}
+
+// StartWithContext starts the cron scheduler, which stops when the context is
+// canceled as if Stop had been called.  The contexts passed to ContextJobs are
+// derived from it.  Starting a running Cron does nothing.
+func (c *Cron) StartWithContext(ctx context.Context) {
+  c.mu.Lock()
+  defer c.unlock()
+  if c.closed {
+    // Catch up with the time the Cron was closed for.
+    now := c.now()
+    for _, e := range c.entries {
+      e.advance(now)
+    }
+    c.entries.reset(c.entries)
+    c.launch()
+  }
+  if !c.running {
+    c.running = true
+    c.ctx, c.cancel = context.WithCancel(ctx)
+    c.jobs = &sync.WaitGroup{}
+    if c.elector != nil {
+      c.campaign(c.ctx)
+    }
+    if c.cluster != nil {
+      c.join(c.ctx)
+    }
+    now := c.now()
+    for _, e := range c.entries {
+      c.startPending(e, now)
+    }
+  }
+  c.wakeup()
+}
+
+// Close stops the cron scheduler, as Stop does, and terminates its goroutine,
+// so that a Cron that is no longer needed can be garbage collected.  A closed
+// Cron keeps its entries, and may be started again.  Closing a closed Cron
+// does nothing.
+func (c *Cron) Close() {
+  c.mu.Lock()
+  if c.closed {
+    c.mu.Unlock()
+    return
+  }
+  c.stopRunning(nil)
+  c.closed = true
+  quit, exited := c.quit, c.exited
+  c.unlock()
+
+  close(quit)
+  <-exited
+}
+
+// launch starts the scheduler goroutine.  c.mu must be held, or the Cron not
+// yet shared.
+func (c *Cron) launch() {
+  c.closed = false
+  c.quit, c.exited = make(chan struct{}), make(chan struct{})
+  go c.run(c.quit, c.exited)
+  if c.onStall != nil {
+    go c.watch(c.quit)
+  }
+}
+
+// wakeup signals the scheduler goroutine that the next activation may have
+// changed.
+func (c *Cron) wakeup() {
+  select {
+  case c.wake <- struct{}{}:
+  default:
+  }
+}
+
+// unlock unlocks c.mu, then applies the changes to the store and sends the
+// events queued while it was held.
+func (c *Cron) unlock() {
+  events, ops := c.queued, c.storeOps
+  c.queued, c.storeOps = nil, nil
+  if len(ops) == 0 {
+    c.mu.Unlock()
+  } else {
+    // Changes queued while c.mu was held later are applied after these.
+    c.storeMu.Lock()
+    c.mu.Unlock()
+    c.applyStoreOps(ops)
+    c.storeMu.Unlock()
+  }
+  for _, event := range events {
+    c.events.emit(event)
+  }
+}
+
+// startJob runs the entry's job, scheduled for the given time, in its own
+// goroutine, tracked by the running jobs, if its concurrency policy and the
+// pool's limits allow, and returns true if it did.  Jobs started while the
+// Cron is not running get a background context.
+func (c *Cron) startJob(e *Entry, scheduled time.Time) bool {
+  ctx := context.Background()
+  if c.running {
+    ctx = c.ctx
+  } else {
+    // A GracefulStop may be waiting for the jobs of the last run.
+    c.jobs = &sync.WaitGroup{}
+  }
+
+  if e.runs == nil {
+    e.runs = &entryRuns{}
+  }
+  ctx = withLogger(withValues(ctx, e.values), c.logger)
+  ctx = withRunInfo(ctx, RunInfo{
+    EntryID:   e.ID,
+    Name:      e.Name,
+    Scheduled: scheduled,
+    Labels:    e.Labels,
+  })
+  ctx, output := withRunOutput(ctx)
+  ctx, cancel := context.WithCancel(ctx)
+  id, ok := e.runs.start(e.Concurrency, cancel)
+  if !ok {
+    cancel()
+    c.logger.Infof("skipping job %s, which is still running", e.ID)
+    c.skip(e, scheduled, ErrStillRunning)
+    return false
+  }
+
+  // Replicas jitter the same activation differently, so its run is locked by
+  // the activation it was delayed from.
+  activation := scheduled
+  if scheduled.Equal(e.Next) {
+    activation = e.activation()
+  }
+  jobs, runs, job, onError := c.jobs, e.runs, e.Job, e.OnError
+  history, stats := e.history, e.stats
+  depended := e.dependents > 0
+  stored := c.store != nil && e.JobName != ""
+  claimed := stored && e.AtLeastOnce
+  jobs.Add(1)
+  run := func() {
+    defer jobs.Done()
+    defer runs.done(id)
+    defer cancel()
+    if !c.tryLock(e, scheduled, activation, depended) {
+      return
+    }
+    defer c.releaseLock(e, activation)
+    if claimed {
+      c.claim(e, scheduled)
+      defer c.acknowledge(e, scheduled)
+    }
+    c.emitNow(RunStarted, e.ID, scheduled, 0, nil)
+    start := c.clock.Now()
+    err := c.runWithRecovery(ctx, e.ID, job)
+    duration := c.clock.Now().Sub(start)
+    out := output.get()
+    history.ran(scheduled, start, duration, err, out)
+    stats.ran(start, duration, err)
+    if err != nil {
+      c.logger.Warningf("job %s failed: %v", e.ID, err)
+      c.emitFinished(RunFailed, e.ID, scheduled, duration, err, out)
+      c.handleError(e.ID, onError, err)
+    } else {
+      c.emitFinished(RunCompleted, e.ID, scheduled, duration, nil, out)
+      if stored {
+        c.succeeded(e, scheduled)
+      }
+    }
+    if depended {
+      c.finished(e, scheduled, err)
+    }
+  }
+  if !c.pool.submit(e.Group, e.Priority, run) {
+    jobs.Done()
+    e.runs.done(id)
+    cancel()
+    c.logger.Warningf("skipping job %s, too many jobs are waiting", e.ID)
+    c.skip(e, scheduled, ErrQueueFull)
+    return false
+  }
+  if depended {
+    e.last = lastRun{scheduled: scheduled}
+  }
+  return true
 }
 
-func (c *Cron) runWithRecovery(j Job) {
+// PanicHandler is called with the ID of the entry whose job panicked, the value
+// it panicked with, and the stack of the panic.  It is called from the
+// goroutine of the run, so it must be safe for concurrent use.
+type PanicHandler func(entryID string, recovered interface{}, stack []byte)
+
+// runWithRecovery runs the job of the entry with the given id, passing
+// ContextJobs the context, and returns the error of an ErrorJob, or an error
+// if the job panicked.
+func (c *Cron) runWithRecovery(ctx context.Context, id string,
+  j Job) (err error) {
   defer func() {
     if r := recover(); r != nil {
       const size = 64 << 10
       buf := make([]byte, size)
       buf = buf[:runtime.Stack(buf, false)]
-      glog.Warningf("cron: panic running job: %v\n%s", r, buf)
+      if c.onPanic != nil {
+        c.onPanic(id, r, buf)
+      } else {
+        c.logger.Warningf("panic running job %s: %v\n%s", id, r, buf)
+      }
+      err = &panicError{value: r}
     }
   }()
-  j.Run()
+  if ej, ok := j.(ErrorJob); ok {
+    return ej.RunError(ctx)
+  }
+  runJob(ctx, j)
+  return nil
 }
 
-// Run the scheduler.. this is private just due to the need to synchronize
-// access to the 'running' state variable.
-func (c *Cron) run() {
-  // Figure out the next activation times for each entry.
-  now := time.Now().Local()
-  for _, entry := range c.entries {
-    entry.Next = entry.Schedule.Next(now)
+// scheduled emits the RunScheduled event of the entry's next run, if any.
+func (c *Cron) scheduled(e *Entry) {
+  if !e.Next.IsZero() {
+    c.emit(RunScheduled, e.ID, e.Next, 0, nil)
   }
+}
+
+// run sleeps until the next activation is due, and runs the entries that are,
+// until quit is closed.
+func (c *Cron) run(quit <-chan struct{}, exited chan<- struct{}) {
+  defer close(exited)
+
+  // The timer is kept while the next activation time does not change, so
+  // adding or inspecting entries does not create a timer each time.
+  var (
+    timer   Timer
+    timerAt time.Time
+  )
+  defer func() {
+    if timer != nil {
+      timer.Stop()
+    }
+  }()
 
   for {
-    // Determine the next entry to run.
-    sort.Sort(byTime(c.entries))
-
-    var effective time.Time
-    if !c.running || len(c.entries) == 0 || c.entries[0].Next.IsZero() {
-      // If there are no entries yet, just sleep - it still handles new entries
-      // and stop requests.
-      effective = now.AddDate(10, 0, 0)
-    } else {
-      effective = c.entries[0].Next
+    // Determine the next entry to run.  If there is none, or the Cron is not
+    // running or suspended, just sleep until woken up.
+    var (
+      effective time.Time
+      ctx       context.Context
+      done      <-chan struct{}
+    )
+    c.mu.RLock()
+    if c.running {
+      ctx = c.ctx
+      done = ctx.Done()
+      if len(c.entries) > 0 && !c.suspended {
+        effective = c.entries[0].Next
+      }
+    }
+    c.mu.RUnlock()
+    c.health.wait(effective)
+
+    if timer != nil && !timerAt.Equal(effective) {
+      timer.Stop()
+      timer = nil
+    }
+    var fire <-chan time.Time
+    if !effective.IsZero() {
+      if timer == nil {
+        timer, timerAt = c.clock.Timer(effective), effective
+      }
+      fire = timer.C()
     }
 
     select {
-    case now = <-time.After(effective.Sub(now)):
-      // Run every entry whose next time was this effective time.
-      for _, e := range c.entries {
-        if e.Next != effective {
-          break
-        }
-        go c.runWithRecovery(e.Job)
-        e.Prev = e.Next
-        e.Next = e.Schedule.Next(effective)
+    case now := <-fire:
+      timer = nil
+      c.health.tick(c.clock.Now(), timerAt)
+      c.mu.Lock()
+      if c.running && !c.suspended {
+        c.runDue(now.In(c.location))
       }
-      continue
+      c.unlock()
+      c.health.ticked(c.clock.Now())
 
-    case newEntry := <-c.add:
-      c.entries = append(c.entries, newEntry)
-      newEntry.Next = newEntry.Schedule.Next(time.Now().Local())
+    case <-c.wake:
 
-    case deleteID := <-c.del:
-      c.err <- c.deleteEntry(deleteID)
+    case <-done:
+      c.mu.Lock()
+      if c.running && c.ctx == ctx {
+        c.stopRunning(nil)
+      }
+      c.unlock()
 
-    case <-c.snapshot:
-      c.snapshot <- c.entrySnapshot()
+    case <-quit:
+      return
+    }
+  }
+}
 
-    case <-c.start:
-      c.running = true
+// runDue runs every entry whose next time has come by now, applying their
+// misfire policies to those whose time was missed, unless they run on another
+// Cron.  c.mu must be held.
+func (c *Cron) runDue(now time.Time) {
+  var due []*Entry
+  for len(c.entries) > 0 && !c.entries[0].Next.IsZero() &&
+    !c.entries[0].Next.After(now) {
+    due = append(due, c.entries.pop())
+  }
+  // Dependencies run before the entries that depend on them.
+  sort.SliceStable(due, func(i, j int) bool {
+    return due[i].depth < due[j].depth
+  })
 
-    case <-c.stop:
-      c.running = false
+  for _, e := range due {
+    c.recoverClaims(e, now)
+    effective := e.Next
+    if !c.runsHere(e) {
+      e.advance(now)
+      c.scheduled(e)
+      continue
     }
+    if policy, end, ok := c.blackedOut(e, effective); ok {
+      if policy == BlackoutSkip {
+        c.logger.Infof("skipping job %s, whose run at %v was blacked out",
+          e.ID, effective)
+        c.skip(e, effective, ErrBlackout)
+        e.advance(e.activation())
+      } else {
+        // The deferred run is the activation the next ones follow.
+        e.Next, e.nominal = end, end
+      }
+      c.scheduled(e)
+      continue
+    }
+
+    late := now.Sub(effective) > misfireThreshold
+    if late && e.Misfire == MisfireSkip {
+      c.logger.Infof("skipping job %s, which missed its run at %v", e.ID,
+        effective)
+      c.skip(e, effective, ErrMisfired)
+      e.advance(now)
+      c.scheduled(e)
+      continue
+    }
+
+    if len(e.Dependencies) > 0 {
+      c.startDependent(e, effective)
+    } else if c.startJob(e, effective) {
+      e.Runs++
+    }
+    e.Prev = e.Next
+    if late && e.Misfire == MisfireRunOnce {
+      e.advance(now)
+    } else {
+      e.advance(e.activation())
+    }
+    if e.MaxRuns > 0 && e.Runs >= e.MaxRuns {
+      c.logger.Infof("job %s completed its %d runs", e.ID, e.Runs)
+      e.Next, e.nominal = time.Time{}, time.Time{}
+    }
+    c.scheduled(e)
+  }
 
-    // 'now' should be updated after newEntry and snapshot cases.
-    now = time.Now().Local()
+  // Requeue the entries, except those that have run and whose schedules will
+  // not activate again, such as LimitedSchedules that reached their limit.
+  for _, e := range due {
+    if e.Expired {
+      c.logger.Infof("job %s expired at %v", e.ID, e.NotAfter)
+    }
+    if e.Expired && e.RemoveWhenExpired ||
+      !e.Expired && e.Next.IsZero() && !e.Prev.IsZero() {
+      c.forget(e)
+      c.emit(EntryRemoved, e.ID, time.Time{}, 0, nil)
+      continue
+    }
+    c.entries.push(e)
+    // The Cron running the entry stores its activations.
+    if c.runsHere(e) {
+      c.persist(e)
+    }
   }
 }
 
-// Stop stops the cron scheduler if it is running; otherwise it does nothing.
+// now returns the current time in the Cron's location.
+func (c *Cron) now() time.Time {
+  return c.clock.Now().In(c.location)
+}
+
+// Stop stops the cron scheduler if it is running; otherwise it does nothing, so
+// it is safe to call more than once.  The contexts of running ContextJobs are
+// canceled, but Stop does not wait for the jobs to return.
 func (c *Cron) Stop() {
-  c.stop <- struct{}{}
+  c.mu.Lock()
+  defer c.unlock()
+  c.stopRunning(nil)
+  c.wakeup()
 }
 
-func (c *Cron) deleteEntry(id string) error {
-  for idx, entry := range c.entries {
-    if entry.ID == id {
-      c.entries = append(c.entries[:idx], c.entries[idx+1:]...)
-      return nil
+// GracefulStop stops the cron scheduler if it is running, and returns a context
+// that is done once the jobs already running have returned.  Their contexts are
+// not canceled, so callers can drain them, e.g. before the process exits:
+//
+// 	<-c.GracefulStop().Done()
+func (c *Cron) GracefulStop() context.Context {
+  ctx, cancel := context.WithCancel(context.Background())
+  c.mu.Lock()
+  defer c.unlock()
+  c.stopRunning(cancel)
+  c.wakeup()
+  return ctx
+}
+
+// stopRunning stops the scheduler.  If drained is nil the contexts of running
+// jobs are canceled; otherwise drained is called once the jobs have returned.
+// c.mu must be held.
+func (c *Cron) stopRunning(drained func()) {
+  if c.running {
+    c.running = false
+    if drained == nil {
+      c.cancel()
+    }
+    if c.resign != nil {
+      c.resign()
+      c.resign, c.leading = nil, false
+    }
+    if c.leave != nil {
+      c.leave()
+      c.leave, c.members = nil, nil
+    }
+  }
+  if drained == nil {
+    return
+  }
+
+  jobs, cancel := c.jobs, c.cancel
+  go func() {
+    if jobs != nil {
+      jobs.Wait()
+      cancel()
     }
+    drained()
+  }()
+}
+
+// entryRef refers to an entry by its ID or, if that is empty, by its name.
+type entryRef struct {
+  id, name string
+}
+
+// find returns the entry ref refers to, or nil if there is none.
+func (c *Cron) find(ref entryRef) *Entry {
+  if ref.id == "" {
+    return c.entryNamed(ref.name)
   }
-  return fmt.Errorf("no job with id %s found", id)
+  return c.ids[ref.id]
 }
 
-// entrySnapshot returns a copy of the current cron entry list.
-func (c *Cron) entrySnapshot() []*Entry {
-  entries := []*Entry{}
+// forget removes the entry, which is no longer in the heap, from the indexes.
+func (c *Cron) forget(e *Entry) {
+  c.forgetDependencies(e)
+  delete(c.ids, e.ID)
+  if e.Name != "" && c.names[e.Name] == e {
+    delete(c.names, e.Name)
+  }
+  c.unpersist(e)
+}
+
+// notFound returns the error for a reference to no entry.
+func (ref entryRef) notFound() error {
+  if ref.id == "" {
+    return fmt.Errorf("no job named %s found", ref.name)
+  }
+  return fmt.Errorf("no job with id %s found", ref.id)
+}
+
+// deleteEntry deletes the entry ref refers to, and returns a copy of it, or
+// nil if there is none.
+func (c *Cron) deleteEntry(ref entryRef) *Entry {
+  entry := c.find(ref)
+  if entry == nil {
+    return nil
+  }
+  c.entries.remove(entry)
+  c.forget(entry)
+  c.emit(EntryRemoved, entry.ID, time.Time{}, 0, nil)
+  return entry.snapshot()
+}
+
+// deleteEntries deletes the entries for which match returns true, and returns
+// copies of them.
+func (c *Cron) deleteEntries(match func(Entry) bool) []*Entry {
+  removed := []*Entry{}
+  entries := c.entries[:0]
   for _, e := range c.entries {
-    entries = append(entries, &Entry{
-      Schedule: e.Schedule,
-      Next:     e.Next,
-      Prev:     e.Prev,
-      Job:      e.Job,
-    })
-  }
-  return entries
+    snapshot := e.snapshot()
+    if !match(*snapshot) {
+      entries = append(entries, e)
+      continue
+    }
+    c.forget(e)
+    c.emit(EntryRemoved, e.ID, time.Time{}, 0, nil)
+    removed = append(removed, snapshot)
+  }
+  c.entries.reset(entries)
+  return removed
+}
+
+// update applies the change to the entry with the given id.
+func (c *Cron) update(id string, change func(*Entry)) error {
+  c.mu.Lock()
+  defer c.unlock()
+  e := c.ids[id]
+  if e == nil {
+    return fmt.Errorf("no job with id %s found", id)
+  }
+  change(e)
+  c.entries.fix(e)
+  c.persist(e)
+  c.wakeup()
+  return nil
+}
+
+// nameTaken returns the ID of an entry that has the name of one of the new
+// entries, which may be another of them, or "" if none does.
+func (c *Cron) nameTaken(entries []*Entry) string {
+  names := map[string]string{}
+  for _, e := range entries {
+    if e.Name == "" {
+      continue
+    }
+    if existing := c.entryNamed(e.Name); existing != nil {
+      return existing.ID
+    }
+    if id, ok := names[e.Name]; ok {
+      return id
+    }
+    names[e.Name] = e.ID
+  }
+  return ""
+}
+
+// entryNamed returns the entry with the given name, or nil if there is none
+// or the name is empty.
+func (c *Cron) entryNamed(name string) *Entry {
+  if name == "" {
+    return nil
+  }
+  return c.names[name]
+}
+
+// runEntry starts the job of the entry with the given id.
+func (c *Cron) runEntry(id string) error {
+  entry := c.ids[id]
+  if entry == nil {
+    return fmt.Errorf("no job with id %s found", id)
+  }
+  c.startJob(entry, c.now())
+  return nil
+}
+
+// startPending starts the runs of the entry that wait for the Cron to be
+// running: its first run, if it was added WithRunOnAdd, and the activation it
+// catches up with, if any.  c.mu must be held.
+func (c *Cron) startPending(e *Entry, now time.Time) {
+  if e.runOnAdd {
+    e.runOnAdd = false
+    c.startJob(e, now)
+  }
+  if !e.catchUp.IsZero() {
+    scheduled := e.catchUp
+    e.catchUp = time.Time{}
+    c.startJob(e, scheduled)
+  }
+}
+
+// snapshot returns a copy of the entry.
+func (e *Entry) snapshot() *Entry {
+  return &Entry{
+    Schedule:          e.Schedule,
+    Next:              e.Next,
+    Prev:              e.Prev,
+    Job:               e.Job,
+    ID:                e.ID,
+    Spec:              e.Spec,
+    Concurrency:       e.Concurrency,
+    Group:             e.Group,
+    Runs:              e.Runs,
+    MaxRuns:           e.MaxRuns,
+    NotBefore:         e.NotBefore,
+    NotAfter:          e.NotAfter,
+    Expired:           e.Expired,
+    RemoveWhenExpired: e.RemoveWhenExpired,
+    Misfire:           e.Misfire,
+    Location:          e.Location,
+    OnError:           e.OnError,
+    Priority:          e.Priority,
+    Dependencies:      append([]string(nil), e.Dependencies...),
+    Blackouts:         append([]Blackout(nil), e.Blackouts...),
+    Holidays:          e.Holidays,
+    HolidayPolicy:     e.HolidayPolicy,
+    Jitter:            e.Jitter,
+    JobName:           e.JobName,
+    Payload:           e.Payload,
+    AtLeastOnce:       e.AtLeastOnce,
+    nominal:           e.nominal,
+    Name:              e.Name,
+    Labels:            copyLabels(e.Labels),
+    History:           e.history.list(),
+    Stats:             e.stats.get(),
+  }
+}
+
+// copyLabels returns a copy of the labels, or nil if there are none.
+func copyLabels(labels map[string]string) map[string]string {
+  if labels == nil {
+    return nil
+  }
+  copied := make(map[string]string, len(labels))
+  for k, v := range labels {
+    copied[k] = v
+  }
+  return copied
 }
@@ -17,8 +17,12 @@
 package cron
 
 import (
+  "context"
   "fmt"
+  "runtime"
+  "strings"
   "sync"
+  "sync/atomic"
   "testing"
   "time"
 )
@@ -60,6 +64,129 @@ func TestJobPanicRecovery(t *testing.T) {
   }
 }
 
+func TestPanicHandler(t *testing.T) {
+  type panicked struct {
+    id        string
+    recovered interface{}
+    stack     []byte
+  }
+  panics := make(chan panicked, 1)
+  cron := New(WithPanicHandler(func(id string, r interface{}, stack []byte) {
+    panics <- panicked{id, r, stack}
+  }))
+  id, _ := cron.AddJob("@yearly", DummyJob{})
+  cron.RunNow(id)
+
+  select {
+  case p := <-panics:
+    if p.id != id || p.recovered != "YOLO" {
+      t.Errorf("expected YOLO from %s, got %v from %s", id, p.recovered, p.id)
+    }
+    if !strings.Contains(string(p.stack), "DummyJob.Run") {
+      t.Errorf("expected the stack of DummyJob.Run, got %s", p.stack)
+    }
+  case <-time.After(cOneSecond):
+    t.Error("expected the panic to be handled")
+  }
+}
+
+func TestIsRunning(t *testing.T) {
+  cron := New()
+  if cron.IsRunning() {
+    t.Error("expected a new Cron not to be running")
+  }
+  cron.Start()
+  cron.Start()
+  if !cron.IsRunning() {
+    t.Error("expected a started Cron to be running")
+  }
+  cron.Stop()
+  cron.Stop()
+  if cron.IsRunning() {
+    t.Error("expected a stopped Cron not to be running")
+  }
+
+  ctx, cancel := context.WithCancel(context.Background())
+  cron.StartWithContext(ctx)
+  cancel()
+  for start := time.Now(); cron.IsRunning(); time.Sleep(time.Millisecond) {
+    if time.Since(start) > cOneSecond {
+      t.Fatal("expected canceling the context to stop the Cron")
+    }
+  }
+}
+
+func TestConcurrentAccess(t *testing.T) {
+  cron := New()
+  cron.Start()
+  defer cron.Close()
+
+  var wg sync.WaitGroup
+  for i := 0; i < 8; i++ {
+    wg.Add(1)
+    go func(i int) {
+      defer wg.Done()
+      for j := 0; j < 100; j++ {
+        id, err := cron.AddFunc("* * * * * ?", func() {},
+          WithName(fmt.Sprintf("job-%d-%d", i, j)))
+        if err != nil {
+          t.Error(err)
+          return
+        }
+        cron.Entries()
+        cron.Entry(id)
+        cron.IsRunning()
+        if j%2 == 0 {
+          cron.DeleteJob(id)
+        }
+      }
+    }(i)
+  }
+  wg.Wait()
+
+  if n := len(cron.Entries()); n != 8*50 {
+    t.Errorf("expected %d entries, got %d", 8*50, n)
+  }
+}
+
+func TestClose(t *testing.T) {
+  before := runtime.NumGoroutine()
+  for i := 0; i < 10; i++ {
+    cron := New()
+    cron.AddFunc("* * * * * ?", func() {})
+    cron.Start()
+    cron.Close()
+    cron.Close()
+  }
+  for start := time.Now(); runtime.NumGoroutine() > before; {
+    if time.Since(start) > cOneSecond {
+      t.Fatalf("expected %d goroutines, got %d", before,
+        runtime.NumGoroutine())
+    }
+    time.Sleep(time.Millisecond)
+  }
+}
+
+func TestCloseAndRestart(t *testing.T) {
+  wg := &sync.WaitGroup{}
+  wg.Add(1)
+
+  cron := New()
+  cron.AddFunc("* * * * * ?", func() { wg.Done() })
+  cron.Close()
+  cron.Start()
+  defer cron.Close()
+
+  if !cron.IsRunning() || len(cron.Entries()) != 1 {
+    t.Error("expected a restarted Cron to keep its entries and run")
+  }
+  select {
+  case <-time.After(cOneSecond):
+    t.Error("expected job to run")
+  case <-wait(wg):
+  }
+}
+
 // Start and stop cron with no entries.
 func TestNoEntries(t *testing.T) {
   cron := New()
@@ -90,6 +217,206 @@ func TestStopCausesJobsToNotRun(t *testing.T) {
   }
 }
 
+// Canceling the context passed to StartWithContext stops the cron.
+func TestCancelCausesJobsToNotRun(t *testing.T) {
+  wg := &sync.WaitGroup{}
+  wg.Add(1)
+
+  ctx, cancel := context.WithCancel(context.Background())
+  cron := New()
+  cron.StartWithContext(ctx)
+  cancel()
+  cron.AddFunc("* * * * * ?", func() { wg.Done() })
+
+  select {
+  case <-time.After(cOneSecond):
+    // No job ran!
+  case <-wait(wg):
+    t.FailNow()
+  }
+}
+
+// ContextJobs are passed a context that Stop cancels.
+func TestStopCancelsJobContext(t *testing.T) {
+  started := make(chan struct{})
+  canceled := make(chan struct{})
+
+  cron := New()
+  cron.AddJob("* * * * * ?", ContextFuncJob(func(ctx context.Context) {
+    select {
+    case started <- struct{}{}:
+    default:
+      return
+    }
+    <-ctx.Done()
+    close(canceled)
+  }))
+  cron.Start()
+
+  select {
+  case <-time.After(cOneSecond):
+    t.Fatal("job did not run")
+  case <-started:
+  }
+  cron.Stop()
+
+  select {
+  case <-time.After(cOneSecond):
+    t.Fatal("job context was not canceled")
+  case <-canceled:
+  }
+}
+
+// GracefulStop waits for running jobs, without canceling their contexts.
+func TestGracefulStopWaitsForJobs(t *testing.T) {
+  started := make(chan struct{}, 1)
+  var finished, canceled bool
+
+  cron := New()
+  cron.AddJob("* * * * * ?", ContextFuncJob(func(ctx context.Context) {
+    select {
+    case started <- struct{}{}:
+    default:
+      return
+    }
+    time.Sleep(500 * time.Millisecond)
+    canceled = ctx.Err() != nil
+    finished = true
+  }))
+  cron.Start()
+
+  select {
+  case <-time.After(cOneSecond):
+    t.Fatal("job did not run")
+  case <-started:
+  }
+  ctx := cron.GracefulStop()
+
+  select {
+  case <-ctx.Done():
+    t.Fatal("stopped before the job finished")
+  case <-time.After(100 * time.Millisecond):
+  }
+
+  select {
+  case <-time.After(cOneSecond):
+    t.Fatal("stop did not complete")
+  case <-ctx.Done():
+  }
+  if !finished || canceled {
+    t.Errorf("expected the job to finish uncanceled, got finished %v, "+
+      "canceled %v", finished, canceled)
+  }
+}
+
+// GracefulStop completes immediately without running jobs.
+func TestGracefulStopWithoutJobs(t *testing.T) {
+  cron := New()
+  select {
+  case <-time.After(cOneSecond):
+    t.Fatal("stop did not complete")
+  case <-cron.GracefulStop().Done():
+  }
+}
+
+// RunNow runs a job immediately without changing its next run.
+func TestRunNow(t *testing.T) {
+  wg := &sync.WaitGroup{}
+  wg.Add(1)
+
+  cron := New()
+  id, _ := cron.AddFunc("0 0 0 1 1 ?", func() { wg.Done() })
+  cron.Start()
+  defer cron.Stop()
+  next := cron.Entries()[0].Next
+
+  if err := cron.RunNow(id); err != nil {
+    t.Fatal(err)
+  }
+  select {
+  case <-time.After(cOneSecond):
+    t.Fatal("job did not run")
+  case <-wait(wg):
+  }
+
+  if entry := cron.Entries()[0]; !entry.Next.Equal(next) ||
+    !entry.Prev.IsZero() {
+    t.Errorf("expected next %v and no prev, got %v and %v", next, entry.Next,
+      entry.Prev)
+  }
+  if err := cron.RunNow("unknown"); err == nil {
+    t.Error("expected an error running an unknown job")
+  }
+}
+
+// WithChain wraps every added job.
+func TestWithChain(t *testing.T) {
+  calls := make(chan string, 10)
+  wrapper := func(j Job) Job {
+    return FuncJob(func() {
+      calls <- "wrapper"
+      j.Run()
+    })
+  }
+
+  cron := New(WithChain(wrapper))
+  cron.AddFunc("* * * * * ?", func() { calls <- "job" })
+  cron.Start()
+  defer cron.Stop()
+
+  for _, expected := range []string{"wrapper", "job"} {
+    select {
+    case <-time.After(cOneSecond):
+      t.Fatal("job did not run")
+    case actual := <-calls:
+      if actual != expected {
+        t.Errorf("(expected) %s != %s (actual)", expected, actual)
+      }
+    }
+  }
+}
+
+// Concurrency policies decide whether overlapping runs start.
+func TestConcurrencyPolicy(t *testing.T) {
+  tests := []struct {
+    policy   ConcurrencyPolicy
+    runs     int32
+    canceled int32
+  }{
+    {AllowConcurrent, 3, 0},
+    {ForbidConcurrent, 1, 0},
+    {ReplaceConcurrent, 3, 2},
+  }
+
+  for _, c := range tests {
+    var runs, canceled int32
+    block := make(chan struct{})
+    cron := New()
+    id, _ := cron.AddJob("0 0 0 1 1 ?", ContextFuncJob(
+      func(ctx context.Context) {
+        atomic.AddInt32(&runs, 1)
+        select {
+        case <-ctx.Done():
+          atomic.AddInt32(&canceled, 1)
+        case <-block:
+        }
+      }), WithConcurrencyPolicy(c.policy))
+    cron.Start()
+
+    for i := 0; i < 3; i++ {
+      cron.RunNow(id)
+      time.Sleep(20 * time.Millisecond)
+    }
+    if r, n := atomic.LoadInt32(&runs), atomic.LoadInt32(&canceled); r !=
+      c.runs || n != c.canceled {
+      t.Errorf("policy %d: (expected) %d runs, %d canceled != %d, %d "+
+        "(actual)", c.policy, c.runs, c.canceled, r, n)
+    }
+    close(block)
+    <-cron.GracefulStop().Done()
+  }
+}
+
 // Add a job, start cron, expect it runs.
 func TestAddBeforeRunning(t *testing.T) {
   wg := &sync.WaitGroup{}
@@ -141,6 +468,581 @@ func TestAddWhileRunningWithDelay(t *testing.T) {
   }
 }
 
+// Test that an entry is removed once its schedule is exhausted.
+func TestExhaustedEntryRemoved(t *testing.T) {
+  wg := &sync.WaitGroup{}
+  wg.Add(2)
+
+  cron := New()
+  cron.AddFunc("@every 1s limit 2", func() { wg.Done() })
+  cron.AddFunc("0 0 0 30 Feb ?", func() {})
+  cron.Start()
+  defer cron.Stop()
+
+  select {
+  case <-time.After(2 * cOneSecond):
+    t.FailNow()
+  case <-wait(wg):
+  }
+
+  entries := cron.Entries()
+  if len(entries) != 1 || entries[0].Spec != "0 0 0 30 Feb ?" {
+    t.Errorf("expected only the unsatisfiable entry, got %v", entries)
+  }
+}
+
+// Test that an entry is removed after its maximum number of runs.
+func TestMaxRuns(t *testing.T) {
+  wg := &sync.WaitGroup{}
+  wg.Add(2)
+
+  cron := New()
+  cron.AddFunc("* * * * * ?", func() { wg.Done() }, WithMaxRuns(2))
+  cron.Start()
+  defer cron.Stop()
+
+  select {
+  case <-time.After(2 * cOneSecond):
+    t.FailNow()
+  case <-wait(wg):
+  }
+
+  if entries := cron.Entries(); len(entries) != 0 {
+    t.Errorf("expected the entry to be removed, got %v", entries)
+  }
+}
+
+func TestRunOnAdd(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  defer cron.Stop()
+  runs := make(chan string, 10)
+  expectRun := func(expected string) {
+    t.Helper()
+    select {
+    case name := <-runs:
+      if name != expected {
+        t.Errorf("expected a run of %s, got %s", expected, name)
+      }
+    case <-time.After(cOneSecond):
+      t.Fatalf("expected a run of %s", expected)
+    }
+  }
+  expectNoRun := func() {
+    t.Helper()
+    select {
+    case name := <-runs:
+      t.Errorf("unexpected run of %s", name)
+    case <-time.After(10 * time.Millisecond):
+    }
+  }
+
+  // Entries added before the Cron starts run when it does.
+  cron.AddFunc("@hourly", func() { runs <- "early" }, WithRunOnAdd())
+  expectNoRun()
+  cron.Start()
+  expectRun("early")
+
+  id, _ := cron.AddFunc("@hourly", func() { runs <- "late" }, WithRunOnAdd())
+  expectRun("late")
+  cron.Stop()
+  cron.Start()
+  expectNoRun()
+
+  // The entries then run on schedule.
+  clock.Advance(time.Hour)
+  ran := map[string]bool{}
+  for i := 0; i < 2; i++ {
+    select {
+    case name := <-runs:
+      ran[name] = true
+    case <-time.After(cOneSecond):
+      t.Fatal("expected the scheduled runs")
+    }
+  }
+  if !ran["early"] || !ran["late"] {
+    t.Errorf("expected both entries to run, got %v", ran)
+  }
+  if e, _ := cron.Entry(id); e.Runs != 1 {
+    t.Errorf("expected only the scheduled run counted, got %d", e.Runs)
+  }
+}
+
+func TestValidityWindow(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  runs := make(chan string, 10)
+  window := []EntryOption{WithNotBefore(start.Add(150 * time.Minute)),
+    WithNotAfter(start.Add(4 * time.Hour))}
+  kept, _ := cron.AddFunc("@hourly", func() { runs <- "kept" }, window...)
+  cron.AddFunc("@hourly", func() { runs <- "removed" },
+    append(window, WithRemoveWhenExpired())...)
+  past, _ := cron.AddFunc("@hourly", func() {},
+    WithNotAfter(start.Add(-time.Hour)))
+  cron.Start()
+  defer cron.Stop()
+
+  if e, _ := cron.Entry(past); !e.Expired || !e.Next.IsZero() {
+    t.Errorf("expected the entry past its window expired, got %+v", e)
+  }
+  if e, _ := cron.Entry(kept); !e.Next.Equal(start.Add(3 * time.Hour)) {
+    t.Errorf("expected the first run at 03:00, got %v", e.Next)
+  }
+
+  for hour := 1; hour <= 5; hour++ {
+    clock.Advance(time.Hour)
+    if hour == 3 || hour == 4 {
+      for i := 0; i < 2; i++ {
+        select {
+        case <-runs:
+        case <-time.After(cOneSecond):
+          t.Fatalf("expected the runs at %02d:00", hour)
+        }
+      }
+    }
+  }
+  select {
+  case run := <-runs:
+    t.Errorf("unexpected run of %s", run)
+  case <-time.After(10 * time.Millisecond):
+  }
+
+  e, ok := cron.Entry(kept)
+  if !ok || !e.Expired || !e.Next.IsZero() || e.Runs != 2 {
+    t.Errorf("expected the entry kept expired after 2 runs, got %+v", e)
+  }
+  if entries := cron.Entries(); len(entries) != 2 {
+    t.Errorf("expected the other entry removed, got %d entries",
+      len(entries))
+  }
+}
+
+// Test that a job added with At runs once and is removed.
+func TestAt(t *testing.T) {
+  wg := &sync.WaitGroup{}
+  wg.Add(1)
+
+  cron := New()
+  cron.Start()
+  defer cron.Stop()
+  cron.At(time.Now().Add(500*time.Millisecond), FuncJob(func() { wg.Done() }))
+
+  select {
+  case <-time.After(cOneSecond):
+    t.FailNow()
+  case <-wait(wg):
+  }
+
+  if entries := cron.Entries(); len(entries) != 0 {
+    t.Errorf("expected the entry to be removed, got %v", entries)
+  }
+}
+
+// Test that an entry's schedule is evaluated in its location.
+func TestEntryLocation(t *testing.T) {
+  tokyo, err := time.LoadLocation("Asia/Tokyo")
+  if err != nil {
+    t.Skip(err)
+  }
+
+  cron := New()
+  cron.AddFunc("0 0 9 * * *", func() {}, InLocation(tokyo))
+  cron.Start()
+  defer cron.Stop()
+
+  next := cron.Entries()[0].Next.In(tokyo)
+  if next.Hour() != 9 || next.Minute() != 0 || cron.Entries()[0].Location !=
+    tokyo {
+    t.Errorf("expected nine in Tokyo, got %v", next)
+  }
+}
+
+// Test that schedules are evaluated in the Cron's location.
+func TestWithLocation(t *testing.T) {
+  tokyo, err := time.LoadLocation("Asia/Tokyo")
+  if err != nil {
+    t.Skip(err)
+  }
+
+  cron := New(WithLocation(tokyo))
+  cron.AddFunc("0 0 9 * * *", func() {})
+  cron.AddFunc("0 0 9 * * *", func() {}, InLocation(time.UTC))
+  cron.Start()
+  defer cron.Stop()
+
+  for _, entry := range cron.Entries() {
+    loc := tokyo
+    if entry.Location != nil {
+      loc = entry.Location
+    }
+    if next := entry.Next.In(loc); next.Hour() != 9 {
+      t.Errorf("expected nine in %v, got %v", loc, next)
+    }
+  }
+}
+
+func TestWithClock(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  ran := make(chan time.Time, 1)
+
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  cron.AddFunc("@hourly", func() { ran <- clock.Now() })
+  cron.Start()
+  defer cron.Stop()
+
+  if next := cron.Entries()[0].Next; !next.Equal(start.Add(time.Hour)) {
+    t.Fatalf("expected next run at %v, got %v", start.Add(time.Hour), next)
+  }
+
+  clock.Advance(30 * time.Minute)
+  cron.Entries()
+  select {
+  case at := <-ran:
+    t.Fatalf("job ran early, at %v", at)
+  default:
+  }
+
+  clock.Advance(30 * time.Minute)
+  select {
+  case at := <-ran:
+    if !at.Equal(start.Add(time.Hour)) {
+      t.Errorf("expected job to run at %v, ran at %v", start.Add(time.Hour), at)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatal("expected job to run")
+  }
+
+  entry := cron.Entries()[0]
+  if !entry.Prev.Equal(start.Add(time.Hour)) ||
+    !entry.Next.Equal(start.Add(2*time.Hour)) {
+    t.Errorf("expected prev %v and next %v, got %v and %v",
+      start.Add(time.Hour), start.Add(2*time.Hour), entry.Prev, entry.Next)
+  }
+}
+
+func TestWithParser(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  ran := make(chan time.Time, 1)
+
+  cron := New(WithClock(clock), WithLocation(time.UTC),
+    WithParser(NewParser(SubSecond)))
+  _, err := cron.AddFunc("@every 250ms", func() { ran <- clock.Now() })
+  if err != nil {
+    t.Fatal(err)
+  }
+  cron.Start()
+  defer cron.Stop()
+
+  for i := 1; i <= 4; i++ {
+    clock.Advance(250 * time.Millisecond)
+    expected := start.Add(time.Duration(i) * 250 * time.Millisecond)
+    select {
+    case at := <-ran:
+      if !at.Equal(expected) {
+        t.Errorf("expected job to run at %v, ran at %v", expected, at)
+      }
+    case <-time.After(cOneSecond):
+      t.Fatalf("expected job to run at %v", expected)
+    }
+  }
+}
+
+func TestWithJitter(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  ran := make(chan time.Time, 1)
+
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  id, _ := cron.AddFunc("@hourly", func() { ran <- clock.Now() },
+    WithJitter(10*time.Minute))
+  cron.Start()
+  defer cron.Stop()
+
+  for i := 1; i <= 5; i++ {
+    hour := start.Add(time.Duration(i) * time.Hour)
+    entry, _ := cron.Entry(id)
+    next := entry.Next
+    if next.Before(hour) || !next.Before(hour.Add(10*time.Minute)) {
+      t.Fatalf("expected next run within 10m of %v, got %v", hour, next)
+    }
+
+    clock.Set(next)
+    select {
+    case at := <-ran:
+      if !at.Equal(next) {
+        t.Errorf("expected job to run at %v, ran at %v", next, at)
+      }
+    case <-time.After(cOneSecond):
+      t.Fatalf("expected job to run at %v", next)
+    }
+
+    // Wait for the entry to be rescheduled.
+    for start := time.Now(); ; time.Sleep(time.Millisecond) {
+      if entry, _ = cron.Entry(id); entry.Prev.Equal(next) {
+        break
+      }
+      if time.Since(start) > cOneSecond {
+        t.Fatalf("expected prev %v, got %v", next, entry.Prev)
+      }
+    }
+  }
+}
+
+func TestWithPriority(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  ran := make(chan string, 3)
+
+  cron := New(WithClock(clock), WithLocation(time.UTC), WithMaxConcurrent(1))
+  for _, job := range []struct {
+    name     string
+    priority int
+  }{{"low", -1}, {"high", 10}, {"default", 0}} {
+    name := job.name
+    cron.AddFunc("@hourly", func() { ran <- name }, WithName(name),
+      WithPriority(job.priority))
+  }
+  cron.Start()
+  defer cron.Stop()
+
+  var names []string
+  for _, e := range cron.Entries() {
+    names = append(names, e.Name)
+  }
+  expected := []string{"high", "default", "low"}
+  if strings.Join(names, ",") != strings.Join(expected, ",") {
+    t.Errorf("expected entries %v, got %v", expected, names)
+  }
+
+  clock.Advance(time.Hour)
+  for _, name := range expected {
+    select {
+    case actual := <-ran:
+      if actual != name {
+        t.Errorf("(expected) %s != %s (actual) ran", name, actual)
+      }
+    case <-time.After(cOneSecond):
+      t.Fatalf("expected %s to run", name)
+    }
+  }
+}
+
+func TestNamedEntries(t *testing.T) {
+  cron := New()
+  id, err := cron.AddFunc("@hourly", func() {}, WithName("backup"),
+    WithLabels(map[string]string{"team": "storage"}))
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  dup, err := cron.AddFunc("@daily", func() {}, WithName("backup"))
+  if dup != id || err != ErrDuplicateName {
+    t.Errorf("expected %s and ErrDuplicateName, got %s and %v", id, dup, err)
+  }
+  if s := cron.Schedule(Every(time.Minute), FuncJob(func() {}),
+    WithName("backup")); s != id {
+    t.Errorf("expected %s, got %s", id, s)
+  }
+
+  entries := cron.Entries()
+  if len(entries) != 1 {
+    t.Fatalf("expected 1 entry, got %d", len(entries))
+  }
+  if e := entries[0]; e.Name != "backup" || e.Labels["team"] != "storage" ||
+    e.Spec != "@hourly" {
+    t.Errorf("unexpected entry %+v", e)
+  }
+
+  if found, ok := cron.EntryID("backup"); !ok || found != id {
+    t.Errorf("expected %s, got %s %v", id, found, ok)
+  }
+  if err := cron.DeleteJobByName("backup"); err != nil {
+    t.Error(err)
+  }
+  if _, ok := cron.EntryID("backup"); ok {
+    t.Error("expected the entry to be deleted")
+  }
+  if err := cron.DeleteJobByName("backup"); err == nil {
+    t.Error("expected an error deleting a missing entry")
+  }
+}
+
+func TestEntry(t *testing.T) {
+  cron := New()
+  cron.AddFunc("@hourly", func() {})
+  id, _ := cron.AddFunc("@every 1m", func() {}, WithName("minutely"))
+  cron.Start()
+  defer cron.Stop()
+
+  entry, ok := cron.Entry(id)
+  if !ok {
+    t.Fatalf("expected entry %s", id)
+  }
+  if entry.ID != id || entry.Name != "minutely" || entry.Next.IsZero() {
+    t.Errorf("unexpected entry %+v", entry)
+  }
+  if _, ok := cron.Entry("missing"); ok {
+    t.Error("expected no entry")
+  }
+}
+
+func TestUpdateJob(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  ran := make(chan struct{}, 10)
+  id, _ := cron.AddFunc("@every 1m", func() { ran <- struct{}{} })
+  cron.Start()
+  defer cron.Stop()
+
+  cron.Entries()
+  clock.Advance(time.Minute)
+  select {
+  case <-ran:
+  case <-time.After(cOneSecond):
+    t.Fatal("expected job to run")
+  }
+
+  if err := cron.UpdateJob(id, "@hourly"); err != nil {
+    t.Fatal(err)
+  }
+  entry, _ := cron.Entry(id)
+  if entry.Spec != "@hourly" || !entry.Next.Equal(start.Add(time.Hour)) ||
+    !entry.Prev.Equal(start.Add(time.Minute)) || entry.Runs != 1 {
+    t.Errorf("unexpected entry %+v", entry)
+  }
+
+  if err := cron.UpdateSchedule(id, Every(time.Hour*2)); err != nil {
+    t.Fatal(err)
+  }
+  entry, _ = cron.Entry(id)
+  if entry.Spec != "" || !entry.Next.Equal(start.Add(time.Hour*2+time.Minute)) {
+    t.Errorf("unexpected entry %+v", entry)
+  }
+
+  if err := cron.UpdateJob(id, "bogus"); err == nil {
+    t.Error("expected an error parsing the spec")
+  }
+  if err := cron.UpdateJob("missing", "@hourly"); err == nil {
+    t.Error("expected an error updating a missing entry")
+  }
+}
+
+func TestReplaceJob(t *testing.T) {
+  ran := make(chan string, 1)
+  cron := New()
+  id, _ := cron.AddFunc("@hourly", func() { ran <- "old" })
+  before, _ := cron.Entry(id)
+
+  if err := cron.ReplaceJob(id, FuncJob(func() { ran <- "new" })); err != nil {
+    t.Fatal(err)
+  }
+  after, _ := cron.Entry(id)
+  if !after.Next.Equal(before.Next) || after.Spec != before.Spec {
+    t.Errorf("expected the schedule to be unchanged, got %+v", after)
+  }
+
+  cron.RunNow(id)
+  select {
+  case job := <-ran:
+    if job != "new" {
+      t.Errorf("expected the new job to run, got the %s one", job)
+    }
+  case <-time.After(cOneSecond):
+    t.Error("expected job to run")
+  }
+
+  if err := cron.ReplaceJob("missing", FuncJob(func() {})); err == nil {
+    t.Error("expected an error replacing a missing job")
+  }
+}
+
+func TestRemoveJob(t *testing.T) {
+  cron := New()
+  id, _ := cron.AddFunc("@hourly", func() {}, WithName("hourly"))
+  cron.Start()
+  defer cron.Stop()
+
+  entry, err := cron.RemoveJob(id)
+  if err != nil {
+    t.Fatal(err)
+  }
+  if entry.ID != id || entry.Name != "hourly" || entry.Spec != "@hourly" ||
+    entry.Next.IsZero() {
+    t.Errorf("unexpected entry %+v", entry)
+  }
+  if len(cron.Entries()) != 0 {
+    t.Error("expected the entry to be removed")
+  }
+  if _, err := cron.RemoveJob(id); err == nil {
+    t.Error("expected an error removing a missing job")
+  }
+}
+
+func TestAddJobs(t *testing.T) {
+  cron := New()
+  job := FuncJob(func() {})
+  ids, err := cron.AddJobs([]JobSpec{
+    {Spec: "@hourly", Job: job},
+    {Spec: "@daily", Job: job, Options: []EntryOption{WithName("daily")}},
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(ids) != 2 || len(cron.Entries()) != 2 {
+    t.Fatalf("expected 2 entries, got %v", ids)
+  }
+  if entry, _ := cron.Entry(ids[1]); entry.Spec != "@daily" {
+    t.Errorf("expected the second ID to be the daily job, got %+v", entry)
+  }
+
+  invalid := [][]JobSpec{
+    {{Spec: "@weekly", Job: job}, {Spec: "bogus", Job: job}},
+    {{Spec: "@weekly", Job: job},
+      {Spec: "@daily", Job: job, Options: []EntryOption{WithName("daily")}}},
+    {{Spec: "@weekly", Job: job, Options: []EntryOption{WithName("x")}},
+      {Spec: "@daily", Job: job, Options: []EntryOption{WithName("x")}}},
+  }
+  for _, jobs := range invalid {
+    if ids, err := cron.AddJobs(jobs); err == nil {
+      t.Errorf("expected an error adding %v, got %v", jobs, ids)
+    }
+    if n := len(cron.Entries()); n != 2 {
+      t.Errorf("expected no entries to be added, got %d entries", n)
+    }
+  }
+}
+
+func TestRemoveIf(t *testing.T) {
+  cron := New()
+  storage := WithLabels(map[string]string{"team": "storage"})
+  cron.AddFunc("@hourly", func() {}, storage)
+  cron.AddFunc("@daily", func() {}, storage, WithName("backup"))
+  kept, _ := cron.AddFunc("@weekly", func() {},
+    WithLabels(map[string]string{"team": "web"}))
+
+  removed := cron.RemoveIf(MatchLabels(map[string]string{"team": "storage"}))
+  if len(removed) != 2 {
+    t.Errorf("expected 2 entries to be removed, got %d", len(removed))
+  }
+  entries := cron.Entries()
+  if len(entries) != 1 || entries[0].ID != kept {
+    t.Errorf("expected only %s to be kept, got %v", kept, entries)
+  }
+
+  cron.AddFunc("@daily", func() {}, WithName("backup"))
+  if removed := cron.RemoveAll(); len(removed) != 2 {
+    t.Errorf("expected 2 entries to be removed, got %d", len(removed))
+  }
+  if n := len(cron.Entries()); n != 0 {
+    t.Errorf("expected no entries, got %d", n)
+  }
+}
+
 // Test timing with Entries.
 func TestSnapshotEntries(t *testing.T) {
   wg := &sync.WaitGroup{}
@@ -324,3 +1226,97 @@ func stop(cron *Cron) chan bool {
   }()
   return ch
 }
+
+// benchEntries is the number of entries the benchmarks load a Cron with.
+const benchEntries = 100000
+
+// newBenchCron returns a running Cron on a FakeClock, loaded with n entries
+// that do not activate during the benchmark, and their IDs.
+func newBenchCron(b *testing.B, n int) (*Cron, *FakeClock, []string) {
+  clock := NewFakeClock(time.Date(2012, 1, 2, 0, 0, 0, 0, time.UTC))
+  cron := New(WithClock(clock), WithLocation(time.UTC),
+    WithLogger(DiscardLogger))
+  jobs := make([]JobSpec, n)
+  for i := range jobs {
+    jobs[i] = JobSpec{Spec: "@yearly", Job: FuncJob(func() {})}
+  }
+  ids, err := cron.AddJobs(jobs)
+  if err != nil {
+    b.Fatal(err)
+  }
+  cron.Start()
+  b.Cleanup(cron.Close)
+  return cron, clock, ids
+}
+
+func BenchmarkAddJob(b *testing.B) {
+  cron, _, _ := newBenchCron(b, benchEntries)
+  schedule, job := Every(time.Hour), FuncJob(func() {})
+  b.ResetTimer()
+  for i := 0; i < b.N; i++ {
+    cron.Schedule(schedule, job)
+  }
+}
+
+func BenchmarkDeleteJob(b *testing.B) {
+  cron, _, ids := newBenchCron(b, benchEntries+b.N)
+  b.ResetTimer()
+  for i := 0; i < b.N; i++ {
+    if err := cron.DeleteJob(ids[i]); err != nil {
+      b.Fatal(err)
+    }
+  }
+}
+
+func BenchmarkEntry(b *testing.B) {
+  cron, _, ids := newBenchCron(b, benchEntries)
+  b.ResetTimer()
+  for i := 0; i < b.N; i++ {
+    if _, ok := cron.Entry(ids[i%len(ids)]); !ok {
+      b.Fatal("missing entry")
+    }
+  }
+}
+
+// BenchmarkDispatch measures the time to wake up and start one job among
+// benchEntries entries.
+func BenchmarkDispatch(b *testing.B) {
+  cron, clock, _ := newBenchCron(b, benchEntries)
+  ran := make(chan struct{})
+  cron.Schedule(Every(time.Second), FuncJob(func() { ran <- struct{}{} }))
+  cron.Entries()
+  b.ResetTimer()
+  for i := 0; i < b.N; i++ {
+    clock.Advance(time.Second)
+    <-ran
+  }
+}
+
+// BenchmarkFire measures the time to start benchEntries jobs due at once.
+func BenchmarkFire(b *testing.B) {
+  cron, clock, _ := newBenchCron(b, 0)
+  wg := &sync.WaitGroup{}
+  jobs := make([]JobSpec, benchEntries)
+  for i := range jobs {
+    jobs[i] = JobSpec{Spec: "@every 1m", Job: FuncJob(wg.Done)}
+  }
+  if _, err := cron.AddJobs(jobs); err != nil {
+    b.Fatal(err)
+  }
+  b.ResetTimer()
+  for i := 0; i < b.N; i++ {
+    wg.Add(benchEntries)
+    clock.Advance(time.Minute)
+    wg.Wait()
+  }
+  b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*benchEntries),
+    "ns/job")
+}
+
+func BenchmarkEntries(b *testing.B) {
+  cron, _, _ := newBenchCron(b, benchEntries)
+  b.ResetTimer()
+  for i := 0; i < b.N; i++ {
+    cron.Entries()
+  }
+}
@@ -0,0 +1,506 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file defines the gRPC service that manages a Cron.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: cron.proto
+
+package cronrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	CronService_ListEntries_FullMethodName = "/cron.CronService/ListEntries"
+	CronService_GetEntry_FullMethodName    = "/cron.CronService/GetEntry"
+	CronService_AddEntry_FullMethodName    = "/cron.CronService/AddEntry"
+	CronService_UpdateEntry_FullMethodName = "/cron.CronService/UpdateEntry"
+	CronService_DeleteEntry_FullMethodName = "/cron.CronService/DeleteEntry"
+	CronService_RunEntry_FullMethodName    = "/cron.CronService/RunEntry"
+	CronService_GetStatus_FullMethodName   = "/cron.CronService/GetStatus"
+	CronService_Pause_FullMethodName       = "/cron.CronService/Pause"
+	CronService_Resume_FullMethodName      = "/cron.CronService/Resume"
+	CronService_Events_FullMethodName      = "/cron.CronService/Events"
+)
+
+// CronServiceClient is the client API for CronService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CronServiceClient interface {
+	// ListEntries returns the entries, with their stats.
+	ListEntries(ctx context.Context, in *ListEntriesRequest, opts ...grpc.CallOption) (*ListEntriesResponse, error)
+	// GetEntry returns an entry, with its stats and history.
+	GetEntry(ctx context.Context, in *GetEntryRequest, opts ...grpc.CallOption) (*Entry, error)
+	// AddEntry adds an entry of a registered job.
+	AddEntry(ctx context.Context, in *AddEntryRequest, opts ...grpc.CallOption) (*Entry, error)
+	// UpdateEntry changes the spec of an entry.
+	UpdateEntry(ctx context.Context, in *UpdateEntryRequest, opts ...grpc.CallOption) (*Entry, error)
+	// DeleteEntry removes an entry.
+	DeleteEntry(ctx context.Context, in *DeleteEntryRequest, opts ...grpc.CallOption) (*DeleteEntryResponse, error)
+	// RunEntry runs the job of an entry now.
+	RunEntry(ctx context.Context, in *RunEntryRequest, opts ...grpc.CallOption) (*RunEntryResponse, error)
+	// GetStatus returns the state of the scheduler.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error)
+	// Pause stops the scheduler.
+	Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*Status, error)
+	// Resume starts the scheduler again.
+	Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*Status, error)
+	// Events streams the events of the Cron as they happen.
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (CronService_EventsClient, error)
+}
+
+type cronServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCronServiceClient(cc grpc.ClientConnInterface) CronServiceClient {
+	return &cronServiceClient{cc}
+}
+
+func (c *cronServiceClient) ListEntries(ctx context.Context, in *ListEntriesRequest, opts ...grpc.CallOption) (*ListEntriesResponse, error) {
+	out := new(ListEntriesResponse)
+	err := c.cc.Invoke(ctx, CronService_ListEntries_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cronServiceClient) GetEntry(ctx context.Context, in *GetEntryRequest, opts ...grpc.CallOption) (*Entry, error) {
+	out := new(Entry)
+	err := c.cc.Invoke(ctx, CronService_GetEntry_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cronServiceClient) AddEntry(ctx context.Context, in *AddEntryRequest, opts ...grpc.CallOption) (*Entry, error) {
+	out := new(Entry)
+	err := c.cc.Invoke(ctx, CronService_AddEntry_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cronServiceClient) UpdateEntry(ctx context.Context, in *UpdateEntryRequest, opts ...grpc.CallOption) (*Entry, error) {
+	out := new(Entry)
+	err := c.cc.Invoke(ctx, CronService_UpdateEntry_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cronServiceClient) DeleteEntry(ctx context.Context, in *DeleteEntryRequest, opts ...grpc.CallOption) (*DeleteEntryResponse, error) {
+	out := new(DeleteEntryResponse)
+	err := c.cc.Invoke(ctx, CronService_DeleteEntry_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cronServiceClient) RunEntry(ctx context.Context, in *RunEntryRequest, opts ...grpc.CallOption) (*RunEntryResponse, error) {
+	out := new(RunEntryResponse)
+	err := c.cc.Invoke(ctx, CronService_RunEntry_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cronServiceClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error) {
+	out := new(Status)
+	err := c.cc.Invoke(ctx, CronService_GetStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cronServiceClient) Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*Status, error) {
+	out := new(Status)
+	err := c.cc.Invoke(ctx, CronService_Pause_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cronServiceClient) Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*Status, error) {
+	out := new(Status)
+	err := c.cc.Invoke(ctx, CronService_Resume_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cronServiceClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (CronService_EventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CronService_ServiceDesc.Streams[0], CronService_Events_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cronServiceEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CronService_EventsClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type cronServiceEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *cronServiceEventsClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CronServiceServer is the server API for CronService service.
+// All implementations must embed UnimplementedCronServiceServer
+// for forward compatibility
+type CronServiceServer interface {
+	// ListEntries returns the entries, with their stats.
+	ListEntries(context.Context, *ListEntriesRequest) (*ListEntriesResponse, error)
+	// GetEntry returns an entry, with its stats and history.
+	GetEntry(context.Context, *GetEntryRequest) (*Entry, error)
+	// AddEntry adds an entry of a registered job.
+	AddEntry(context.Context, *AddEntryRequest) (*Entry, error)
+	// UpdateEntry changes the spec of an entry.
+	UpdateEntry(context.Context, *UpdateEntryRequest) (*Entry, error)
+	// DeleteEntry removes an entry.
+	DeleteEntry(context.Context, *DeleteEntryRequest) (*DeleteEntryResponse, error)
+	// RunEntry runs the job of an entry now.
+	RunEntry(context.Context, *RunEntryRequest) (*RunEntryResponse, error)
+	// GetStatus returns the state of the scheduler.
+	GetStatus(context.Context, *GetStatusRequest) (*Status, error)
+	// Pause stops the scheduler.
+	Pause(context.Context, *PauseRequest) (*Status, error)
+	// Resume starts the scheduler again.
+	Resume(context.Context, *ResumeRequest) (*Status, error)
+	// Events streams the events of the Cron as they happen.
+	Events(*EventsRequest, CronService_EventsServer) error
+	mustEmbedUnimplementedCronServiceServer()
+}
+
+// UnimplementedCronServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCronServiceServer struct {
+}
+
+func (UnimplementedCronServiceServer) ListEntries(context.Context, *ListEntriesRequest) (*ListEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEntries not implemented")
+}
+func (UnimplementedCronServiceServer) GetEntry(context.Context, *GetEntryRequest) (*Entry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEntry not implemented")
+}
+func (UnimplementedCronServiceServer) AddEntry(context.Context, *AddEntryRequest) (*Entry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddEntry not implemented")
+}
+func (UnimplementedCronServiceServer) UpdateEntry(context.Context, *UpdateEntryRequest) (*Entry, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateEntry not implemented")
+}
+func (UnimplementedCronServiceServer) DeleteEntry(context.Context, *DeleteEntryRequest) (*DeleteEntryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteEntry not implemented")
+}
+func (UnimplementedCronServiceServer) RunEntry(context.Context, *RunEntryRequest) (*RunEntryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunEntry not implemented")
+}
+func (UnimplementedCronServiceServer) GetStatus(context.Context, *GetStatusRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedCronServiceServer) Pause(context.Context, *PauseRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pause not implemented")
+}
+func (UnimplementedCronServiceServer) Resume(context.Context, *ResumeRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Resume not implemented")
+}
+func (UnimplementedCronServiceServer) Events(*EventsRequest, CronService_EventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedCronServiceServer) mustEmbedUnimplementedCronServiceServer() {}
+
+// UnsafeCronServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CronServiceServer will
+// result in compilation errors.
+type UnsafeCronServiceServer interface {
+	mustEmbedUnimplementedCronServiceServer()
+}
+
+func RegisterCronServiceServer(s grpc.ServiceRegistrar, srv CronServiceServer) {
+	s.RegisterService(&CronService_ServiceDesc, srv)
+}
+
+func _CronService_ListEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CronServiceServer).ListEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CronService_ListEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CronServiceServer).ListEntries(ctx, req.(*ListEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CronService_GetEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CronServiceServer).GetEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CronService_GetEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CronServiceServer).GetEntry(ctx, req.(*GetEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CronService_AddEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CronServiceServer).AddEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CronService_AddEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CronServiceServer).AddEntry(ctx, req.(*AddEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CronService_UpdateEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CronServiceServer).UpdateEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CronService_UpdateEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CronServiceServer).UpdateEntry(ctx, req.(*UpdateEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CronService_DeleteEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CronServiceServer).DeleteEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CronService_DeleteEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CronServiceServer).DeleteEntry(ctx, req.(*DeleteEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CronService_RunEntry_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunEntryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CronServiceServer).RunEntry(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CronService_RunEntry_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CronServiceServer).RunEntry(ctx, req.(*RunEntryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CronService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CronServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CronService_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CronServiceServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CronService_Pause_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CronServiceServer).Pause(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CronService_Pause_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CronServiceServer).Pause(ctx, req.(*PauseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CronService_Resume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CronServiceServer).Resume(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CronService_Resume_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CronServiceServer).Resume(ctx, req.(*ResumeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CronService_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CronServiceServer).Events(m, &cronServiceEventsServer{stream})
+}
+
+type CronService_EventsServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type cronServiceEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *cronServiceEventsServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// CronService_ServiceDesc is the grpc.ServiceDesc for CronService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CronService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cron.CronService",
+	HandlerType: (*CronServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListEntries",
+			Handler:    _CronService_ListEntries_Handler,
+		},
+		{
+			MethodName: "GetEntry",
+			Handler:    _CronService_GetEntry_Handler,
+		},
+		{
+			MethodName: "AddEntry",
+			Handler:    _CronService_AddEntry_Handler,
+		},
+		{
+			MethodName: "UpdateEntry",
+			Handler:    _CronService_UpdateEntry_Handler,
+		},
+		{
+			MethodName: "DeleteEntry",
+			Handler:    _CronService_DeleteEntry_Handler,
+		},
+		{
+			MethodName: "RunEntry",
+			Handler:    _CronService_RunEntry_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _CronService_GetStatus_Handler,
+		},
+		{
+			MethodName: "Pause",
+			Handler:    _CronService_Pause_Handler,
+		},
+		{
+			MethodName: "Resume",
+			Handler:    _CronService_Resume_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Events",
+			Handler:       _CronService_Events_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "cron.proto",
+}
@@ -0,0 +1,297 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements the CronService of a Cron.
+
+// Package cronrpc serves the gRPC CronService, defined in cron.proto, that
+// manages a running cron.Cron, so that a central control plane can drive the
+// schedulers embedded in many processes:
+//
+// 	s := grpc.NewServer()
+// 	cronrpc.RegisterCronServiceServer(s, cronrpc.NewServer(c))
+//
+// It lists, adds, updates, deletes and runs entries, pauses and resumes the
+// scheduler, and streams its events.  Like AddRegistered, AddEntry only adds
+// the jobs of registered JobFactories.
+package cronrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative cron.proto
+
+import (
+  "context"
+  "sync"
+  "time"
+
+  "github.com/kiranbond/cron"
+  "google.golang.org/grpc/codes"
+  "google.golang.org/grpc/status"
+  "google.golang.org/protobuf/types/known/durationpb"
+  "google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultEventBuffer is the default number of events an Events stream buffers
+// for a slow client.
+const DefaultEventBuffer = 1024
+
+// Server implements the CronService of a Cron.
+type Server struct {
+  UnimplementedCronServiceServer
+
+  // EventBuffer is the number of events an Events stream buffers for a slow
+  // client; the stream fails with ResourceExhausted once it overflows, rather
+  // than blocking the Cron.
+  EventBuffer int
+
+  cron *cron.Cron
+}
+
+// NewServer returns a Server managing c.
+func NewServer(c *cron.Cron) *Server {
+  return &Server{EventBuffer: DefaultEventBuffer, cron: c}
+}
+
+// ListEntries implements CronServiceServer.
+func (s *Server) ListEntries(ctx context.Context,
+  req *ListEntriesRequest) (*ListEntriesResponse, error) {
+  resp := &ListEntriesResponse{}
+  for _, e := range s.cron.Entries(cron.FilterLabels(req.Labels)) {
+    resp.Entries = append(resp.Entries, toEntry(e))
+  }
+  return resp, nil
+}
+
+// GetEntry implements CronServiceServer.
+func (s *Server) GetEntry(ctx context.Context,
+  req *GetEntryRequest) (*Entry, error) {
+  e, err := s.entry(req.Id)
+  if err != nil {
+    return nil, err
+  }
+  v := toEntry(&e)
+  for _, r := range e.History {
+    v.History = append(v.History, toRun(r))
+  }
+  return v, nil
+}
+
+// AddEntry implements CronServiceServer.
+func (s *Server) AddEntry(ctx context.Context,
+  req *AddEntryRequest) (*Entry, error) {
+  var opts []cron.EntryOption
+  if req.Name != "" {
+    opts = append(opts, cron.WithName(req.Name))
+  }
+  if req.Labels != nil {
+    opts = append(opts, cron.WithLabels(req.Labels))
+  }
+  id, err := s.cron.AddRegistered(req.Spec, req.Job, req.Payload, opts...)
+  if err == cron.ErrDuplicateName {
+    return nil, status.Errorf(codes.AlreadyExists, "%s: %s", err, id)
+  }
+  if err != nil {
+    return nil, status.Error(codes.InvalidArgument, err.Error())
+  }
+  e, err := s.entry(id)
+  if err != nil {
+    return nil, err
+  }
+  return toEntry(&e), nil
+}
+
+// UpdateEntry implements CronServiceServer.
+func (s *Server) UpdateEntry(ctx context.Context,
+  req *UpdateEntryRequest) (*Entry, error) {
+  if _, err := s.entry(req.Id); err != nil {
+    return nil, err
+  }
+  if err := s.cron.UpdateJob(req.Id, req.Spec); err != nil {
+    return nil, status.Error(codes.InvalidArgument, err.Error())
+  }
+  e, err := s.entry(req.Id)
+  if err != nil {
+    return nil, err
+  }
+  return toEntry(&e), nil
+}
+
+// DeleteEntry implements CronServiceServer.
+func (s *Server) DeleteEntry(ctx context.Context,
+  req *DeleteEntryRequest) (*DeleteEntryResponse, error) {
+  if err := s.cron.DeleteJob(req.Id); err != nil {
+    return nil, status.Error(codes.NotFound, err.Error())
+  }
+  return &DeleteEntryResponse{}, nil
+}
+
+// RunEntry implements CronServiceServer.
+func (s *Server) RunEntry(ctx context.Context,
+  req *RunEntryRequest) (*RunEntryResponse, error) {
+  if err := s.cron.RunNow(req.Id); err != nil {
+    return nil, status.Error(codes.NotFound, err.Error())
+  }
+  return &RunEntryResponse{}, nil
+}
+
+// GetStatus implements CronServiceServer.
+func (s *Server) GetStatus(ctx context.Context,
+  req *GetStatusRequest) (*Status, error) {
+  return s.status(), nil
+}
+
+// Pause implements CronServiceServer.
+func (s *Server) Pause(ctx context.Context, req *PauseRequest) (*Status,
+  error) {
+  s.cron.Stop()
+  return s.status(), nil
+}
+
+// Resume implements CronServiceServer.
+func (s *Server) Resume(ctx context.Context, req *ResumeRequest) (*Status,
+  error) {
+  s.cron.Start()
+  return s.status(), nil
+}
+
+// Events implements CronServiceServer.  It sends the headers of the stream
+// once subscribed to the events of the Cron, so the client may wait for them
+// before causing events.  The stream ends when the client cancels it, or fails
+// if the client falls more than EventBuffer events behind.
+func (s *Server) Events(req *EventsRequest,
+  stream CronService_EventsServer) error {
+  events := make(chan cron.Event, s.EventBuffer)
+  overflow := make(chan struct{})
+  var once sync.Once
+  unsubscribe := s.cron.Subscribe(func(e cron.Event) {
+    if req.EntryId != "" && e.EntryID != req.EntryId {
+      return
+    }
+    select {
+    case events <- e:
+    default:
+      once.Do(func() { close(overflow) })
+    }
+  })
+  defer unsubscribe()
+  // The headers tell the client the stream is subscribed.
+  if err := stream.SendHeader(nil); err != nil {
+    return err
+  }
+
+  for {
+    select {
+    case e := <-events:
+      if err := stream.Send(toEvent(e)); err != nil {
+        return err
+      }
+    case <-overflow:
+      return status.Errorf(codes.ResourceExhausted,
+        "more than %d events behind", s.EventBuffer)
+    case <-stream.Context().Done():
+      return stream.Context().Err()
+    }
+  }
+}
+
+// entry returns a snapshot of the entry with the given ID, or a NotFound
+// error.
+func (s *Server) entry(id string) (cron.Entry, error) {
+  e, ok := s.cron.Entry(id)
+  if !ok {
+    return e, status.Errorf(codes.NotFound, "no entry with id %s", id)
+  }
+  return e, nil
+}
+
+// status returns the state of the scheduler.
+func (s *Server) status() *Status {
+  info := s.cron.Debug()
+  return &Status{
+    Running:  info.Running,
+    Entries:  int64(info.Entries),
+    NextWake: timestamp(info.NextWake),
+    InFlight: int64(info.InFlight),
+    Queued:   int64(info.Queued),
+    Waiting:  int64(info.Waiting),
+    Skipped:  int64(info.Skipped),
+    Dropped:  int64(info.Dropped),
+  }
+}
+
+// toEntry returns the message of the entry.
+func toEntry(e *cron.Entry) *Entry {
+  st := e.Stats
+  return &Entry{
+    Id:      e.ID,
+    Name:    e.Name,
+    Spec:    e.Spec,
+    Labels:  e.Labels,
+    Job:     e.JobName,
+    Payload: e.Payload,
+    Next:    timestamp(e.Next),
+    Prev:    timestamp(e.Prev),
+    Runs:    int64(e.Runs),
+    Stats: &Stats{
+      Runs:                int64(st.Runs),
+      Failures:            int64(st.Failures),
+      Skipped:             int64(st.Skipped),
+      ConsecutiveFailures: int64(st.ConsecutiveFailures),
+      MinDuration:         durationpb.New(st.MinDuration),
+      AvgDuration:         durationpb.New(st.AvgDuration),
+      MaxDuration:         durationpb.New(st.MaxDuration),
+      LastError:           errorString(st.LastError),
+      LastFailure:         timestamp(st.LastFailure),
+    },
+  }
+}
+
+// toRun returns the message of the run.
+func toRun(r cron.RunRecord) *Run {
+  return &Run{
+    Scheduled: timestamp(r.Scheduled),
+    Started:   timestamp(r.Started),
+    Duration:  durationpb.New(r.Duration),
+    Outcome:   r.Outcome.String(),
+    Error:     errorString(r.Err),
+    Output:    r.Output,
+  }
+}
+
+// toEvent returns the message of the event.
+func toEvent(e cron.Event) *Event {
+  return &Event{
+    Type:      e.Type.String(),
+    EntryId:   e.EntryID,
+    Time:      timestamp(e.Time),
+    Scheduled: timestamp(e.Scheduled),
+    Duration:  durationpb.New(e.Duration),
+    Error:     errorString(e.Err),
+    Output:    e.Output,
+  }
+}
+
+// timestamp returns the message of t, or nil if t is zero.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+  if t.IsZero() {
+    return nil
+  }
+  return timestamppb.New(t)
+}
+
+// errorString returns the message of err, or "" if it is nil.
+func errorString(err error) string {
+  if err == nil {
+    return ""
+  }
+  return err.Error()
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the CronService.
+
+package cronrpc
+
+import (
+  "context"
+  "net"
+  "testing"
+  "time"
+
+  "github.com/kiranbond/cron"
+  "google.golang.org/grpc"
+  "google.golang.org/grpc/codes"
+  "google.golang.org/grpc/credentials/insecure"
+  "google.golang.org/grpc/status"
+  "google.golang.org/grpc/test/bufconn"
+)
+
+// runs receives the payloads of the runs of "rpc-echo" jobs.
+var runs = make(chan string, 10)
+
+func init() {
+  cron.RegisterJob("rpc-echo", func(payload []byte) (cron.Job, error) {
+    return cron.FuncJob(func() { runs <- string(payload) }), nil
+  })
+}
+
+// newClient returns a client of the CronService of c, served in memory.
+func newClient(t *testing.T, c *cron.Cron) CronServiceClient {
+  listener := bufconn.Listen(1 << 20)
+  server := grpc.NewServer()
+  RegisterCronServiceServer(server, NewServer(c))
+  go server.Serve(listener)
+  t.Cleanup(server.Stop)
+
+  conn, err := grpc.Dial("bufnet",
+    grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+      return listener.Dial()
+    }),
+    grpc.WithTransportCredentials(insecure.NewCredentials()))
+  if err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(func() { conn.Close() })
+  return NewCronServiceClient(conn)
+}
+
+func TestEntries(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  client := newClient(t, c)
+  ctx := context.Background()
+  c.AddFunc("@hourly", func() {}, cron.WithLabels(map[string]string{
+    "team": "web"}))
+
+  added, err := client.AddEntry(ctx, &AddEntryRequest{Spec: "@hourly",
+    Job: "rpc-echo", Payload: []byte("hello"), Name: "greet",
+    Labels: map[string]string{"team": "infra"}})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if added.Id == "" || added.Name != "greet" || added.Job != "rpc-echo" ||
+    string(added.Payload) != "hello" || added.Next == nil {
+    t.Fatalf("unexpected entry %v", added)
+  }
+
+  list, err := client.ListEntries(ctx, &ListEntriesRequest{
+    Labels: map[string]string{"team": "infra"}})
+  if err != nil {
+    t.Fatal(err)
+  }
+  if len(list.Entries) != 1 || list.Entries[0].Id != added.Id {
+    t.Errorf("expected only %s, got %v", added.Id, list.Entries)
+  }
+  if list, _ := client.ListEntries(ctx, &ListEntriesRequest{}); len(
+    list.Entries) != 2 {
+    t.Errorf("expected 2 entries, got %v", list.Entries)
+  }
+
+  updated, err := client.UpdateEntry(ctx, &UpdateEntryRequest{Id: added.Id,
+    Spec: "@daily"})
+  if err != nil || updated.Spec != "@daily" {
+    t.Errorf("unexpected %v, %v", updated, err)
+  }
+
+  if _, err := client.RunEntry(ctx, &RunEntryRequest{
+    Id: added.Id}); err != nil {
+    t.Fatal(err)
+  }
+  select {
+  case payload := <-runs:
+    if payload != "hello" {
+      t.Errorf("expected hello, got %s", payload)
+    }
+  case <-time.After(5 * time.Second):
+    t.Fatal("expected the job to run")
+  }
+
+  if _, err := client.DeleteEntry(ctx, &DeleteEntryRequest{
+    Id: added.Id}); err != nil {
+    t.Fatal(err)
+  }
+  if _, err := client.GetEntry(ctx, &GetEntryRequest{
+    Id: added.Id}); status.Code(err) != codes.NotFound {
+    t.Errorf("expected NotFound, got %v", err)
+  }
+}
+
+func TestErrors(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  client := newClient(t, c)
+  ctx := context.Background()
+  c.AddRegistered("@hourly", "rpc-echo", nil, cron.WithName("taken"))
+
+  tests := []struct {
+    call func() error
+    code codes.Code
+  }{
+    {func() error {
+      _, err := client.AddEntry(ctx, &AddEntryRequest{Spec: "bad",
+        Job: "rpc-echo"})
+      return err
+    }, codes.InvalidArgument},
+    {func() error {
+      _, err := client.AddEntry(ctx, &AddEntryRequest{Spec: "@hourly",
+        Job: "unknown"})
+      return err
+    }, codes.InvalidArgument},
+    {func() error {
+      _, err := client.AddEntry(ctx, &AddEntryRequest{Spec: "@hourly",
+        Job: "rpc-echo", Name: "taken"})
+      return err
+    }, codes.AlreadyExists},
+    {func() error {
+      _, err := client.UpdateEntry(ctx, &UpdateEntryRequest{Id: "missing",
+        Spec: "@daily"})
+      return err
+    }, codes.NotFound},
+    {func() error {
+      _, err := client.DeleteEntry(ctx, &DeleteEntryRequest{Id: "missing"})
+      return err
+    }, codes.NotFound},
+    {func() error {
+      _, err := client.RunEntry(ctx, &RunEntryRequest{Id: "missing"})
+      return err
+    }, codes.NotFound},
+  }
+  for i, test := range tests {
+    if err := test.call(); status.Code(err) != test.code {
+      t.Errorf("%d: expected %s, got %v", i, test.code, err)
+    }
+  }
+}
+
+func TestPauseResume(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  client := newClient(t, c)
+  ctx := context.Background()
+
+  if s, err := client.Resume(ctx, &ResumeRequest{}); err != nil ||
+    !s.Running {
+    t.Errorf("expected the cron running, got %v, %v", s, err)
+  }
+  if s, err := client.Pause(ctx, &PauseRequest{}); err != nil || s.Running {
+    t.Errorf("expected the cron paused, got %v, %v", s, err)
+  }
+  if s, err := client.GetStatus(ctx, &GetStatusRequest{}); err != nil ||
+    s.Running {
+    t.Errorf("unexpected status %v, %v", s, err)
+  }
+}
+
+func TestEvents(t *testing.T) {
+  c := cron.New()
+  defer c.Stop()
+  client := newClient(t, c)
+  other, _ := c.AddRegistered("@hourly", "rpc-echo", []byte("other"))
+  id, _ := c.AddRegistered("@hourly", "rpc-echo", []byte("watched"))
+
+  ctx, cancel := context.WithCancel(context.Background())
+  defer cancel()
+  stream, err := client.Events(ctx, &EventsRequest{EntryId: id})
+  if err != nil {
+    t.Fatal(err)
+  }
+  // The stream is subscribed once it returned its headers.
+  if _, err := stream.Header(); err != nil {
+    t.Fatal(err)
+  }
+
+  c.RunNow(other)
+  c.RunNow(id)
+  var types []string
+  for len(types) < 2 {
+    e, err := stream.Recv()
+    if err != nil {
+      t.Fatal(err)
+    }
+    if e.EntryId != id {
+      t.Fatalf("expected only events of %s, got %v", id, e)
+    }
+    types = append(types, e.Type)
+  }
+  if types[0] != "RunStarted" || types[1] != "RunCompleted" {
+    t.Errorf("unexpected events %v", types)
+  }
+  for i := 0; i < 2; i++ {
+    <-runs
+  }
+
+  cancel()
+  if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+    t.Errorf("expected the stream canceled, got %v", err)
+  }
+}
@@ -0,0 +1,248 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements keeping the entries of a Cron in sync with a crontab
+// file.
+
+package cron
+
+import (
+  "fmt"
+  "os"
+  "path/filepath"
+  "strings"
+  "time"
+
+  "github.com/fsnotify/fsnotify"
+)
+
+// CrontabLabel is the label of the entries added by WatchFile, whose value is
+// the path of their file.
+const CrontabLabel = "crontab"
+
+// crontabSettle is how long a crontab file must not change for before it is
+// reloaded.
+const crontabSettle = 100 * time.Millisecond
+
+// crontabLine is an entry of a crontab file.
+type crontabLine struct {
+  // number is the number of the line in the file.
+  number int
+
+  // spec is the schedule of the entry, job the name its JobFactory is
+  // registered as, and payload the rest of the line, if any.
+  spec, job string
+  payload   []byte
+
+  // key identifies the line in the file: equal lines have the same key, but
+  // for their order.
+  key string
+}
+
+// parseCrontab parses the lines of a crontab file, each made of a classic 5
+// field crontab spec, or a descriptor, followed by the name of a registered
+// job, and the rest of the line as its payload, e.g.
+//
+// 	# m h dom mon dow job payload
+// 	30 9 * * MON-FRI report {"team":"infra"}
+// 	@every 10m poll
+//
+// Blank lines and comments starting with "#" are ignored, and so are
+// environment assignments, but for CRON_TZ or TZ, whose time zone the specs of
+// the following lines are evaluated in.  Prefixed to a line, they apply to it
+// only.  Unless standard, 5 field specs are
+// converted to start with the second.
+func parseCrontab(data []byte, standard bool) ([]crontabLine, error) {
+  var (
+    lines []crontabLine
+    tz    string
+    seen  = map[string]int{}
+  )
+  for i, text := range strings.Split(string(data), "\n") {
+    text = strings.TrimSpace(text)
+    if text == "" || strings.HasPrefix(text, "#") {
+      continue
+    }
+    all := strings.Fields(text)
+    fields, lineTZ := all, tz
+    if name, value, ok := strings.Cut(fields[0], "="); ok {
+      isTZ := name == "CRON_TZ" || name == "TZ"
+      switch {
+      case len(fields) == 1 && isTZ:
+        tz = value
+        continue
+      case len(fields) == 1:
+        continue
+      case isTZ:
+        // The time zone of this line only.
+        fields, lineTZ = fields[1:], value
+      }
+    }
+
+    n := 5
+    if strings.HasPrefix(fields[0], "@") {
+      n = 1
+      if fields[0] == "@every" {
+        n = 2
+      }
+    }
+    if len(fields) <= n {
+      return nil, fmt.Errorf("line %d: expected a spec and a job: %s", i+1,
+        text)
+    }
+    spec := strings.Join(fields[:n], " ")
+    if n == 5 && !standard {
+      spec = "0 " + spec
+    }
+    if lineTZ != "" {
+      spec = "CRON_TZ=" + lineTZ + " " + spec
+    }
+    line := crontabLine{number: i + 1, spec: spec, job: fields[n]}
+    // The payload is the rest of the line, as written.
+    rest := text
+    for _, f := range all[:len(all)-len(fields)+n+1] {
+      rest = strings.TrimSpace(rest[strings.Index(rest, f)+len(f):])
+    }
+    if rest != "" {
+      line.payload = []byte(rest)
+    }
+    key := spec + "\x00" + line.job + "\x00" + rest
+    seen[key]++
+    line.key = fmt.Sprintf("%s\x00%d", key, seen[key])
+    lines = append(lines, line)
+  }
+  return lines, nil
+}
+
+// crontabFile keeps the entries of a Cron in sync with a crontab file.
+type crontabFile struct {
+  c    *Cron
+  path string
+
+  // ids are the IDs of the entries of the lines, by key.
+  ids map[string]string
+}
+
+// WatchFile adds the entries of the crontab file at path to the Cron, with
+// AddRegistered, and keeps them in sync with the file as it is edited, until
+// the returned function is called.  Each line of the file is made of a
+// classic 5 field crontab spec, or a descriptor, followed by the name of a
+// job registered with RegisterJob, and the rest of the line as its payload:
+//
+// 	# m h dom mon dow job payload
+// 	CRON_TZ=Europe/Paris
+// 	30 9 * * MON-FRI report {"team":"infra"}
+// 	@every 10m poll
+//
+// Lines are identified by their contents: when the file changes, the entries
+// of the lines that are no longer there are removed, and those of new lines
+// added, while the entries of unchanged lines are kept, with their state.
+// The entries are labeled with the path, as CrontabLabel.  A file that cannot
+// be applied, e.g. because it names a job that is not registered, is not
+// applied at all: WatchFile returns the error, and edits with errors are
+// logged, keeping the entries of the last valid file.
+func (c *Cron) WatchFile(path string) (stop func(), err error) {
+  path = filepath.Clean(path)
+  f := &crontabFile{c: c, path: path, ids: map[string]string{}}
+  if err := f.load(); err != nil {
+    return nil, err
+  }
+
+  // The directory is watched, as editors replace the files they save.
+  watcher, err := fsnotify.NewWatcher()
+  if err != nil {
+    return nil, err
+  }
+  if err := watcher.Add(filepath.Dir(path)); err != nil {
+    watcher.Close()
+    return nil, err
+  }
+  done := make(chan struct{})
+  go func() {
+    defer close(done)
+    // The file is reloaded once it settles, so that a file being written is
+    // not read truncated.
+    settled := time.NewTimer(0)
+    <-settled.C
+    defer settled.Stop()
+    for {
+      select {
+      case event, ok := <-watcher.Events:
+        if !ok {
+          return
+        }
+        if filepath.Clean(event.Name) == path &&
+          event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+          settled.Reset(crontabSettle)
+        }
+      case <-settled.C:
+        if err := f.load(); err != nil {
+          c.logger.Warningf("cannot reload %s: %v", path, err)
+        }
+      case err, ok := <-watcher.Errors:
+        if !ok {
+          return
+        }
+        c.logger.Warningf("cannot watch %s: %v", path, err)
+      }
+    }
+  }()
+  return func() {
+    watcher.Close()
+    <-done
+  }, nil
+}
+
+// load applies the contents of the file to the Cron.
+func (f *crontabFile) load() error {
+  data, err := os.ReadFile(f.path)
+  if err != nil {
+    return err
+  }
+  lines, err := parseCrontab(data, f.c.parser.options&Standard != 0)
+  if err != nil {
+    return fmt.Errorf("%s: %s", f.path, err)
+  }
+  for _, line := range lines {
+    if _, err := f.c.parser.Parse(line.spec); err != nil {
+      return fmt.Errorf("%s: line %d: %s", f.path, line.number, err)
+    }
+    if _, err := newJob(line.job, line.payload); err != nil {
+      return fmt.Errorf("%s: line %d: %s", f.path, line.number, err)
+    }
+  }
+
+  keep := map[string]bool{}
+  for _, line := range lines {
+    keep[line.key] = true
+    if id, ok := f.ids[line.key]; ok {
+      if _, ok := f.c.Entry(id); ok {
+        continue
+      }
+    }
+    id, err := f.c.AddRegistered(line.spec, line.job, line.payload,
+      WithLabels(map[string]string{CrontabLabel: f.path}))
+    if err != nil {
+      return fmt.Errorf("%s: line %d: %s", f.path, line.number, err)
+    }
+    f.ids[line.key] = id
+  }
+  for key, id := range f.ids {
+    if !keep[key] {
+      f.c.DeleteJob(id)
+      delete(f.ids, key)
+    }
+  }
+  return nil
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for crontab files.
+
+package cron
+
+import (
+  "os"
+  "path/filepath"
+  "reflect"
+  "sort"
+  "testing"
+)
+
+func TestParseCrontab(t *testing.T) {
+  tests := []struct {
+    crontab  string
+    standard bool
+    expected []crontabLine
+    err      string
+  }{
+    {"", false, nil, ""},
+    {"# comment\n\n  \n", false, nil, ""},
+    {"30 9 * * MON-FRI report {\"team\": \"infra\"}", false,
+      []crontabLine{{number: 1, spec: "0 30 9 * * MON-FRI", job: "report",
+        payload: []byte(`{"team": "infra"}`)}}, ""},
+    {"30 9 * * * report", true,
+      []crontabLine{{number: 1, spec: "30 9 * * *", job: "report"}}, ""},
+    {"MAILTO=ops\n@every 10m poll\n@daily  clean  /tmp  ", false,
+      []crontabLine{
+        {number: 2, spec: "@every 10m", job: "poll"},
+        {number: 3, spec: "@daily", job: "clean", payload: []byte("/tmp")},
+      }, ""},
+    {"CRON_TZ=Europe/Paris\n0 9 * * * a\nTZ=UTC 0 9 * * * b\n@daily c", false,
+      []crontabLine{
+        {number: 2, spec: "CRON_TZ=Europe/Paris 0 0 9 * * *", job: "a"},
+        {number: 3, spec: "CRON_TZ=UTC 0 0 9 * * *", job: "b"},
+        {number: 4, spec: "CRON_TZ=Europe/Paris @daily", job: "c"},
+      }, ""},
+    {"0 9 * * *", false, nil, "line 1: expected a spec and a job: 0 9 * * *"},
+    {"@every 1h", false, nil, "line 1: expected a spec and a job: @every 1h"},
+  }
+
+  for _, c := range tests {
+    lines, err := parseCrontab([]byte(c.crontab), c.standard)
+    if c.err != "" {
+      if err == nil || err.Error() != c.err {
+        t.Errorf("%q: expected error %q, got %v", c.crontab, c.err, err)
+      }
+      continue
+    }
+    if err != nil {
+      t.Errorf("%q: %v", c.crontab, err)
+      continue
+    }
+    // Keys are checked by TestWatchFile.
+    for i := range lines {
+      lines[i].key = ""
+    }
+    if !reflect.DeepEqual(lines, c.expected) {
+      t.Errorf("%q: (expected) %+v != %+v (actual)", c.crontab, c.expected,
+        lines)
+    }
+  }
+}
+
+func TestWatchFile(t *testing.T) {
+  path := filepath.Join(t.TempDir(), "crontab")
+  write := func(crontab string) {
+    t.Helper()
+    if err := os.WriteFile(path, []byte(crontab), 0644); err != nil {
+      t.Fatal(err)
+    }
+  }
+  cron := New()
+  // specs returns the specs and payloads of the entries by ID.
+  specs := func() map[string]string {
+    m := map[string]string{}
+    for _, e := range cron.Entries() {
+      if e.Labels[CrontabLabel] != path {
+        t.Errorf("expected %s labeled with the file", e.ID)
+      }
+      m[e.ID] = e.Spec + " " + string(e.Payload)
+    }
+    return m
+  }
+  // sorted returns the values of m, sorted.
+  sorted := func(m map[string]string) []string {
+    var values []string
+    for _, v := range m {
+      values = append(values, v)
+    }
+    sort.Strings(values)
+    return values
+  }
+
+  write("0 * * * * test-echo kept\n0 0 * * * test-echo changed\n" +
+    "@daily test-echo twice\n@daily test-echo twice\n")
+  stop, err := cron.WatchFile(path)
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer stop()
+  before := specs()
+  expected := []string{"0 0 * * * * kept", "0 0 0 * * * changed",
+    "@daily twice", "@daily twice"}
+  if !reflect.DeepEqual(sorted(before), expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, sorted(before))
+  }
+
+  write("0 * * * * test-echo kept\n0 0 * * * test-echo edited\n" +
+    "@daily test-echo twice\n@hourly test-echo added\n")
+  expected = []string{"0 0 * * * * kept", "0 0 0 * * * edited",
+    "@daily twice", "@hourly added"}
+  waitFor(t, func() bool {
+    return reflect.DeepEqual(sorted(specs()), expected)
+  })
+  after := specs()
+  for id, spec := range before {
+    if spec == "0 0 * * * * kept" && after[id] != spec {
+      t.Errorf("expected the entry of the unchanged line to be kept")
+    }
+  }
+
+  // Invalid edits keep the entries.
+  write("@hourly missing\n")
+  write("@hourly test-echo added\n")
+  waitFor(t, func() bool {
+    return reflect.DeepEqual(sorted(specs()), []string{"@hourly added"})
+  })
+}
+
+func TestWatchFileErrors(t *testing.T) {
+  dir := t.TempDir()
+  cron := New()
+  if _, err := cron.WatchFile(filepath.Join(dir, "missing")); err == nil {
+    t.Error("expected an error watching a missing file")
+  }
+  path := filepath.Join(dir, "crontab")
+  os.WriteFile(path, []byte("@hourly test-echo\n@hourly missing\n"), 0644)
+  if _, err := cron.WatchFile(path); err == nil {
+    t.Error("expected an error for an unregistered job")
+  }
+  if entries := cron.Entries(); len(entries) != 0 {
+    t.Errorf("expected no entries from an invalid file, got %d",
+      len(entries))
+  }
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements exposing the internals of a Cron for debugging.
+
+package cron
+
+import (
+  "expvar"
+  "time"
+)
+
+// DebugInfo is a snapshot of the internals of a Cron, for inspecting running
+// services.
+type DebugInfo struct {
+  // Running is whether the Cron is running.
+  Running bool
+
+  // Entries is the number of entries, and NextWake the earliest time one of
+  // them runs, or the zero time if none will.
+  Entries  int
+  NextWake time.Time
+
+  // InFlight is the number of running jobs, Queued the number of runs waiting
+  // for the limits of WithMaxConcurrent, WithGroupLimit or WithRateLimit, and
+  // Waiting the number waiting for the runs of their dependencies.
+  InFlight, Queued, Waiting int
+
+  // Skipped is the number of scheduled runs that did not start since the Cron
+  // was created, and Dropped the number of those skipped because the queue was
+  // full.
+  Skipped, Dropped int
+}
+
+// Debug returns a snapshot of the internals of the Cron.
+func (c *Cron) Debug() DebugInfo {
+  c.mu.RLock()
+  info := DebugInfo{
+    Running: c.running,
+    Entries: len(c.entries),
+    Waiting: len(c.waiting),
+    Skipped: c.skipped,
+    Dropped: c.dropped,
+  }
+  if len(c.entries) > 0 {
+    info.NextWake = c.entries[0].Next
+  }
+  c.mu.RUnlock()
+
+  c.pool.mu.Lock()
+  info.InFlight, info.Queued = c.pool.running, len(c.pool.queued)
+  c.pool.mu.Unlock()
+  return info
+}
+
+// Publish publishes the Cron's DebugInfo as the expvar variable of the given
+// name, so that it is served as JSON at /debug/vars along with the runtime's
+// variables.  Like expvar.Publish, it panics if the name is taken.
+func (c *Cron) Publish(name string) {
+  expvar.Publish(name, expvar.Func(func() interface{} { return c.Debug() }))
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for exposing the internals of a Cron.
+
+package cron
+
+import (
+  "encoding/json"
+  "expvar"
+  "fmt"
+  "testing"
+  "time"
+)
+
+func TestDebug(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC), WithMaxConcurrent(1),
+    WithMaxQueued(0))
+  if info := cron.Debug(); info != (DebugInfo{}) {
+    t.Errorf("expected an empty Cron, got %+v", info)
+  }
+
+  started := make(chan struct{}, 10)
+  skipped := make(chan struct{}, 10)
+  cron.Subscribe(func(e Event) {
+    switch e.Type {
+    case RunStarted:
+      started <- struct{}{}
+    case RunSkipped:
+      skipped <- struct{}{}
+    }
+  })
+  release := make(chan struct{})
+  defer close(release)
+  cron.AddFunc("@hourly", func() { <-release }, WithPriority(1))
+  cron.AddFunc("@hourly", func() { <-release })
+  cron.Start()
+  defer cron.Stop()
+
+  clock.Advance(time.Hour)
+  for _, ch := range []chan struct{}{started, skipped} {
+    select {
+    case <-ch:
+    case <-time.After(cOneSecond):
+      t.Fatal("expected one run started and one skipped")
+    }
+  }
+
+  expected := DebugInfo{
+    Running:  true,
+    Entries:  2,
+    NextWake: start.Add(2 * time.Hour),
+    InFlight: 1,
+    Skipped:  1,
+    Dropped:  1,
+  }
+  if info := cron.Debug(); info != expected {
+    t.Errorf("(expected) %+v != %+v (actual)", expected, info)
+  }
+
+  // expvar names cannot be reused, so each run of the test needs its own.
+  name := fmt.Sprintf("TestDebug-%p", cron)
+  cron.Publish(name)
+  var published DebugInfo
+  if err := json.Unmarshal([]byte(expvar.Get(name).String()),
+    &published); err != nil {
+    t.Fatal(err)
+  }
+  if published != expected {
+    t.Errorf("(expected) %+v != %+v (published)", expected, published)
+  }
+}
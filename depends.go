@@ -0,0 +1,250 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements running entries after the entries they depend on.
+
+package cron
+
+import (
+  "errors"
+  "fmt"
+  "time"
+)
+
+// ErrDependencyCycle is returned when adding entries that depend on each other,
+// directly or not.
+var ErrDependencyCycle = errors.New("cron: dependency cycle")
+
+// DependsOn makes each run of the entry wait for the runs of the given entries,
+// referred to by ID or name, scheduled at the same time, and start once they
+// have all completed successfully.  If any of them fails, or was not scheduled
+// at that time, the run is skipped with ErrDependencyFailed:
+//
+// 	c.AddFunc("0 0 2 * * *", extract, cron.WithName("extract"))
+// 	c.AddFunc("0 0 2 * * *", load, cron.DependsOn("extract"))
+//
+// The entries depended on must have been added before, or along with the entry
+// by AddJobs.  Runs are matched by their scheduled times, so neither entry
+// should be jittered.  RunNow runs the job without waiting.
+func DependsOn(entries ...string) EntryOption {
+  return func(e *Entry) {
+    e.Dependencies = append(e.Dependencies, entries...)
+  }
+}
+
+// lastRun is the run of an entry last started, recorded if other entries
+// depend on it.
+type lastRun struct {
+  scheduled time.Time
+  done      bool
+  err       error
+}
+
+// dependentRun is a run waiting for the runs of its dependencies.
+type dependentRun struct {
+  entry     *Entry
+  scheduled time.Time
+
+  // pending are the IDs of the dependencies still running.
+  pending map[string]bool
+}
+
+// resolveDependencies replaces the references in the Dependencies of the new
+// entries with the IDs of the entries they refer to, among the Cron's and the
+// new entries, and records the depths of the new entries.  It returns an error,
+// changing nothing, if a reference is unknown or the dependencies form a cycle.
+// c.mu must be held.
+func (c *Cron) resolveDependencies(entries []*Entry) error {
+  added := map[string]*Entry{}
+  for _, e := range entries {
+    added[e.ID] = e
+    if e.Name != "" {
+      added[e.Name] = e
+    }
+  }
+
+  deps := map[*Entry][]*Entry{}
+  for _, e := range entries {
+    deps[e] = nil
+    for _, ref := range e.Dependencies {
+      d := added[ref]
+      if d == nil {
+        d = c.find(entryRef{id: ref})
+      }
+      if d == nil {
+        d = c.entryNamed(ref)
+      }
+      if d == nil {
+        return fmt.Errorf("no job %s to depend on", ref)
+      }
+      deps[e] = append(deps[e], d)
+    }
+  }
+
+  // The depth of an entry is the length of its longest chain of dependencies.
+  // Entries of the Cron have theirs, and cannot depend on the new ones.
+  const visiting = -1
+  depths := map[*Entry]int{}
+  var depth func(e *Entry) (int, error)
+  depth = func(e *Entry) (int, error) {
+    if _, ok := deps[e]; !ok {
+      return e.depth, nil
+    }
+    if d, ok := depths[e]; ok {
+      if d == visiting {
+        return 0, ErrDependencyCycle
+      }
+      return d, nil
+    }
+    depths[e] = visiting
+    max := 0
+    for _, dep := range deps[e] {
+      d, err := depth(dep)
+      if err != nil {
+        return 0, err
+      }
+      if d+1 > max {
+        max = d + 1
+      }
+    }
+    depths[e] = max
+    return max, nil
+  }
+  for _, e := range entries {
+    if _, err := depth(e); err != nil {
+      return err
+    }
+  }
+
+  for _, e := range entries {
+    e.depth = depths[e]
+    ids := map[string]bool{}
+    e.Dependencies = nil
+    for _, dep := range deps[e] {
+      if !ids[dep.ID] {
+        ids[dep.ID] = true
+        e.Dependencies = append(e.Dependencies, dep.ID)
+        dep.dependents++
+      }
+    }
+  }
+  return nil
+}
+
+// forgetDependencies releases the dependencies of the removed entry.  c.mu
+// must be held.
+func (c *Cron) forgetDependencies(e *Entry) {
+  for _, id := range e.Dependencies {
+    if dep := c.ids[id]; dep != nil {
+      dep.dependents--
+    }
+  }
+}
+
+// startDependent starts the entry's run scheduled at the given time if its
+// dependencies' runs have completed, waits for them if they are running, and
+// skips it otherwise.  c.mu must be held.
+func (c *Cron) startDependent(e *Entry, scheduled time.Time) {
+  pending := map[string]bool{}
+  for _, id := range e.Dependencies {
+    dep := c.ids[id]
+    if dep == nil || !dep.last.scheduled.Equal(scheduled) ||
+      dep.last.done && dep.last.err != nil {
+      c.skipDependent(e, scheduled)
+      return
+    }
+    if !dep.last.done {
+      pending[id] = true
+    }
+  }
+  if len(pending) == 0 {
+    c.startAfterDependencies(e, scheduled)
+    return
+  }
+  c.waiting = append(c.waiting, &dependentRun{e, scheduled, pending})
+  if e.dependents > 0 {
+    // The entries depending on this one wait for it in turn.
+    e.last = lastRun{scheduled: scheduled}
+  }
+}
+
+// startAfterDependencies starts the entry's run scheduled at the given time,
+// whose dependencies have completed.  If it does not start, the runs depending
+// on it are skipped.  c.mu must be held.
+func (c *Cron) startAfterDependencies(e *Entry, scheduled time.Time) {
+  if c.startJob(e, scheduled) {
+    e.Runs++
+  } else if e.dependents > 0 {
+    e.last = lastRun{scheduled: scheduled}
+    c.settle(e, scheduled, ErrDependencyFailed)
+  }
+}
+
+// skipDependent skips the entry's run scheduled at the given time, because a
+// dependency did not complete, and the runs depending on it.  c.mu must be
+// held.
+func (c *Cron) skipDependent(e *Entry, scheduled time.Time) {
+  c.logger.Infof("skipping job %s, whose dependencies did not complete", e.ID)
+  c.skip(e, scheduled, ErrDependencyFailed)
+  if e.dependents > 0 {
+    e.last = lastRun{scheduled: scheduled}
+    c.settle(e, scheduled, ErrDependencyFailed)
+  }
+}
+
+// finished records the outcome of the entry's run scheduled at the given
+// time, and starts or skips the runs waiting for it.
+func (c *Cron) finished(e *Entry, scheduled time.Time, err error) {
+  c.mu.Lock()
+  defer c.unlock()
+  c.settle(e, scheduled, err)
+}
+
+// settle records the outcome of the entry's run scheduled at the given time,
+// and starts or skips the runs waiting for it.  c.mu must be held.
+func (c *Cron) settle(e *Entry, scheduled time.Time, err error) {
+  if e.last.scheduled.Equal(scheduled) {
+    e.last.done, e.last.err = true, err
+  }
+
+  var settled []*Entry
+  waiting := c.waiting[:0]
+  for _, w := range c.waiting {
+    if !w.pending[e.ID] || !w.scheduled.Equal(scheduled) {
+      waiting = append(waiting, w)
+      continue
+    }
+    delete(w.pending, e.ID)
+    if err == nil && len(w.pending) > 0 {
+      waiting = append(waiting, w)
+      continue
+    }
+    if c.ids[w.entry.ID] == w.entry {
+      settled = append(settled, w.entry)
+    }
+  }
+  for i := len(waiting); i < len(c.waiting); i++ {
+    c.waiting[i] = nil
+  }
+  c.waiting = waiting
+
+  // Starting or skipping the runs may settle the runs waiting for them.
+  for _, dependent := range settled {
+    if err != nil {
+      c.skipDependent(dependent, scheduled)
+    } else {
+      c.startAfterDependencies(dependent, scheduled)
+    }
+  }
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for dependencies between entries.
+
+package cron
+
+import (
+  "context"
+  "errors"
+  "testing"
+  "time"
+)
+
+// dependencyCron returns a started Cron on a FakeClock, and a channel of the
+// events of its runs.
+func dependencyCron(t *testing.T) (*Cron, *FakeClock, chan Event) {
+  clock := NewFakeClock(time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC))
+  cron := New(WithClock(clock), WithLocation(time.UTC),
+    WithLogger(DiscardLogger))
+  events := make(chan Event, 100)
+  cron.Subscribe(func(e Event) {
+    if e.Type >= RunStarted {
+      events <- e
+    }
+  })
+  cron.Start()
+  t.Cleanup(cron.Stop)
+  return cron, clock, events
+}
+
+// expectEvent fails the test unless the next event has the given type and
+// entry.
+func expectEvent(t *testing.T, events chan Event, typ EventType, id string) {
+  t.Helper()
+  select {
+  case e := <-events:
+    if e.Type != typ || e.EntryID != id {
+      t.Fatalf("(expected) %s of %s != %s of %s (actual)", typ, id, e.Type,
+        e.EntryID)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatalf("expected %s of %s", typ, id)
+  }
+}
+
+func TestDependsOn(t *testing.T) {
+  cron, clock, events := dependencyCron(t)
+  release := make(chan struct{})
+  extract, _ := cron.AddFunc("@hourly", func() { <-release },
+    WithName("extract"))
+  load, _ := cron.AddFunc("@hourly", func() {}, DependsOn("extract"))
+  if e, _ := cron.Entry(load); len(e.Dependencies) != 1 ||
+    e.Dependencies[0] != extract {
+    t.Errorf("expected dependencies [%s], got %v", extract, e.Dependencies)
+  }
+
+  for i := 0; i < 2; i++ {
+    clock.Advance(time.Hour)
+    expectEvent(t, events, RunStarted, extract)
+    select {
+    case e := <-events:
+      t.Fatalf("unexpected %s of %s before its dependency completed", e.Type,
+        e.EntryID)
+    case <-time.After(50 * time.Millisecond):
+    }
+
+    release <- struct{}{}
+    expectEvent(t, events, RunCompleted, extract)
+    expectEvent(t, events, RunStarted, load)
+    expectEvent(t, events, RunCompleted, load)
+  }
+}
+
+func TestDependencyFailed(t *testing.T) {
+  cron, clock, events := dependencyCron(t)
+  extract, _ := cron.AddErrorFunc("@hourly", func(context.Context) error {
+    return errors.New("boom")
+  })
+  daily, _ := cron.AddFunc("@daily", func() {})
+  load, _ := cron.AddFunc("@hourly", func() {}, DependsOn(extract))
+  report, _ := cron.AddFunc("@hourly", func() {}, DependsOn(daily))
+  summary, _ := cron.AddFunc("@hourly", func() {}, DependsOn(load, report))
+
+  // The load's dependency failed, the report's did not run at 1am, and so the
+  // summary's did not complete.
+  clock.Advance(time.Hour)
+  expected := map[string]EventType{
+    extract: RunFailed,
+    load:    RunSkipped,
+    report:  RunSkipped,
+    summary: RunSkipped,
+  }
+  for len(expected) > 0 {
+    select {
+    case e := <-events:
+      if e.Type == RunStarted {
+        continue
+      }
+      if typ, ok := expected[e.EntryID]; !ok || e.Type != typ ||
+        typ == RunSkipped && e.Err != ErrDependencyFailed {
+        t.Fatalf("unexpected %s of %s: %v", e.Type, e.EntryID, e.Err)
+      }
+      delete(expected, e.EntryID)
+    case <-time.After(cOneSecond):
+      t.Fatalf("expected more events: %v", expected)
+    }
+  }
+}
+
+func TestDependencyChain(t *testing.T) {
+  cron, clock, events := dependencyCron(t)
+  ids, err := cron.AddJobs([]JobSpec{
+    {"@hourly", FuncJob(func() {}), []EntryOption{WithName("c"),
+      DependsOn("b"), WithPriority(10)}},
+    {"@hourly", FuncJob(func() {}), []EntryOption{WithName("b"),
+      DependsOn("a")}},
+    {"@hourly", FuncJob(func() {}), []EntryOption{WithName("a")}},
+  })
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  clock.Advance(time.Hour)
+  for _, id := range []string{ids[2], ids[1], ids[0]} {
+    expectEvent(t, events, RunStarted, id)
+    expectEvent(t, events, RunCompleted, id)
+  }
+}
+
+func TestDependencyErrors(t *testing.T) {
+  cron := New()
+  a, _ := cron.AddFunc("@hourly", func() {}, WithName("a"))
+
+  if _, err := cron.AddFunc("@hourly", func() {}, DependsOn("missing")); err ==
+    nil {
+    t.Error("expected an error depending on a missing entry")
+  }
+
+  tests := [][]JobSpec{
+    {{"@hourly", FuncJob(func() {}), []EntryOption{WithName("self"),
+      DependsOn("self")}}},
+    {{"@hourly", FuncJob(func() {}), []EntryOption{WithName("x"),
+      DependsOn("a", "y")}},
+      {"@hourly", FuncJob(func() {}), []EntryOption{WithName("y"),
+        DependsOn("x")}}},
+  }
+  for _, jobs := range tests {
+    if _, err := cron.AddJobs(jobs); err != ErrDependencyCycle {
+      t.Errorf("(expected) %v != %v (actual)", ErrDependencyCycle, err)
+    }
+  }
+
+  if n := len(cron.Entries()); n != 1 {
+    t.Errorf("expected only %s, got %d entries", a, n)
+  }
+}
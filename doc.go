@@ -31,25 +31,373 @@
 // 	..
 // 	// Inspect the cron job entries' next and previous run times.
 // 	inspect(c.Entries())
+// 	inspect(c.Entry(id))
 // 	..
 // 	c.Stop()  // Stop the scheduler (does not stop any jobs already running).
 //
+// A Cron started with StartWithContext stops when the context is canceled.
+// Jobs implementing ContextJob, such as ContextFuncJob, are run with a context
+// that is canceled when the Cron stops, so long-running jobs can stop early.
+//
+// GracefulStop instead lets running jobs finish, and returns a context that is
+// done once they have.
+//
+// A Cron runs a goroutine from New until it is closed with Close, which also
+// stops it; a Cron that is dropped should be closed so it can be garbage
+// collected.
+//
+// Entries may select the entries it returns by label, name prefix or next
+// activation, order them by name, and return a page of them, copying only
+// those:
+//
+// 	page := c.Entries(cron.FilterNamePrefix("db-"), cron.SortByName(),
+// 		cron.Page(50, 50))
+//
+// Entries may be configured with options when they are added; e.g. to skip
+// activations while a previous run is still running:
+//
+// 	c.AddFunc("@every 1m", sync, cron.WithConcurrencyPolicy(cron.ForbidConcurrent))
+//
+// Entries may be named, and labeled, so that tooling can refer to them by name
+// rather than by ID; names are unique within a Cron:
+//
+// 	c.AddFunc("@daily", backup, cron.WithName("backup"),
+// 		cron.WithLabels(map[string]string{"team": "storage"}))
+// 	..
+// 	c.DeleteJobByName("backup")
+//
+// RemoveIf removes every entry matching a predicate at once, e.g. those with a
+// label, and RemoveAll removes every entry:
+//
+// 	c.RemoveIf(cron.MatchLabels(map[string]string{"team": "storage"}))
+//
+// The schedule of an entry may be changed in place with UpdateJob, which keeps
+// its ID, job and history:
+//
+// 	c.UpdateJob(id, "@every 5m")
+//
+// and its job replaced with ReplaceJob, which keeps its schedule.
+//
+// Entries may depend on others, so that pipelines run in order: with DependsOn,
+// each run of an entry waits for the runs of its dependencies scheduled at the
+// same time to complete successfully, and is skipped if they do not:
+//
+// 	c.AddFunc("0 0 2 * * *", extract, cron.WithName("extract"))
+// 	c.AddFunc("0 0 2 * * *", load, cron.DependsOn("extract"))
+//
+// WithJitter delays each activation of an entry by a random duration, so that
+// replicas running the same schedule do not all hit a shared service at once:
+//
+// 	c.AddFunc("@hourly", report, cron.WithJitter(5*time.Minute))
+//
+// WithRunOnAdd also runs the job of an entry once when it is added, or when the
+// Cron starts if it is added before:
+//
+// 	c.AddFunc("@hourly", refresh, cron.WithRunOnAdd())
+//
+// WithNotBefore and WithNotAfter bound the activations of an entry, e.g. those
+// of a campaign.  Once none are left the entry expires: it is kept, unless
+// added WithRemoveWhenExpired, but never runs on schedule again:
+//
+// 	c.AddFunc("0 0 9 * * *", remind, cron.WithNotBefore(launch),
+// 		cron.WithNotAfter(launch.AddDate(0, 0, 14)),
+// 		cron.WithRemoveWhenExpired())
+//
+// Blackout windows, one-off or recurring, suppress activations during e.g.
+// deploys or maintenance, for every entry of a Cron or for one entry.  The
+// activations within a window are skipped, or deferred until it ends:
+//
+// 	end := c.AddBlackout(cron.Between(start, start.Add(time.Hour)),
+// 		cron.BlackoutSkip)
+// 	c.AddFunc("@hourly", sync, cron.BlackoutDuring(
+// 		cron.Recurring(cron.MustParse("0 0 2 * * SUN"), 2*time.Hour),
+// 		cron.BlackoutDefer))
+//
+// If the scheduler wakes up late, e.g. after the machine was suspended, every
+// missed activation runs by default; WithMisfirePolicy may instead run a job
+// once for all of them, or skip them.  Suspend likewise holds back the runs of
+// a Cron, without canceling those already running, until Resume runs the
+// activations missed in between according to the same policies.
+//
+// Simulate lists the activations of the entries within a window, following
+// their blackouts, holidays and validity windows, without running any job,
+// e.g. to check a set of schedules before enabling them:
+//
+// 	for _, a := range c.Simulate(start, start.AddDate(0, 0, 7)) {
+// 		fmt.Println(a.Scheduled, a.EntryID)
+// 	}
+//
+// NextRuns likewise lists the next activations of a single entry, e.g. to
+// show its upcoming runs; it lists none while the Cron is suspended.
+//
+// Overlaps reports the times at which more than a number of entries run at
+// once, and Collisions the activations of two entries within a tolerance of
+// each other, e.g. to plan capacity or spread heavy jobs apart.
+//
+// By default every activation runs in its own goroutine.  WithMaxConcurrent
+// limits how many jobs run at once, queueing further activations, and
+// WithGroupLimit limits the jobs of entries added with InGroup:
+//
+// 	c := cron.New(cron.WithMaxConcurrent(100), cron.WithGroupLimit("db", 4))
+//
+// WithRateLimit limits how often jobs start, deferring activations beyond the
+// rate, e.g. when many schedules align at the top of the hour:
+//
+// 	c := cron.New(cron.WithRateLimit(10, 20))
+//
+// Of the entries due at the same time, and of the runs waiting for these
+// limits, those added with a higher WithPriority start first.
+//
+// Job wrappers
+//
+// Behavior common to many jobs, such as logging or metrics, may be added with
+// JobWrappers.  A Chain applies several of them to a job, and the WithChain
+// option applies them to every job added to a Cron:
+//
+// 	c := cron.New(cron.WithChain(logging, metrics))
+//
+// DelayIfStillRunning serializes the runs of a job, Retry retries failed runs
+// with backoff, and Timeout cancels runs that take too long; jobs report
+// failures by panicking or, as ErrorJobs, by returning an error.  The
+// WithWrappers option applies wrappers to the job of a single entry:
+//
+// 	c.AddJob("@hourly", sync, cron.WithWrappers(cron.Timeout(time.Minute)))
+//
+// The contexts passed to ContextJobs and ErrorJobs carry a RunInfo, giving the
+// ID, name and labels of the entry and the time the run was scheduled for,
+// which RunInfoFrom returns.  The Wrap wrapper of the tracing package uses it
+// to trace each run in an OpenTelemetry span, whose context the job receives.
+// The WithContext option adds the values of a context to those of the runs of
+// an entry, without tying the runs to its cancellation.
+//
+// Jobs that may fail can be added with AddErrorFunc or AddJobWithError.  The
+// errors they return, and panics, are passed to the handler of the
+// WithErrorHandler option, or to that of the entry's OnError option:
+//
+// 	c := cron.New(cron.WithErrorHandler(func(id string, err error) {
+// 		alert(id, err)
+// 	}))
+//
+// Events
+//
+// Subscribe registers a handler for the events in the lifecycle of entries and
+// their runs, such as RunStarted and RunFailed, on which metrics, auditing and
+// dashboards may be built:
+//
+// 	c.Subscribe(func(e cron.Event) {
+// 		if e.Type == cron.RunFailed {
+// 			failures.Inc()
+// 		}
+// 	})
+//
+// The metrics package collects them as Prometheus metrics: runs by outcome,
+// their durations and delays, and the number of entries.
+//
+// Debug returns a snapshot of the scheduler's internals: its entries, the next
+// time it wakes, and its running, queued and skipped runs.  Publish serves it
+// with the expvar package, at /debug/vars:
+//
+// 	c.Publish("cron")
+//
+// Healthy returns an error once the scheduler is stalled, dispatching due runs
+// a minute late or more, e.g. because an event handler blocks, and LastTick
+// returns when it last dispatched them; both suit readiness and liveness
+// probes.  The WithWatchdog option also checks in the background:
+//
+// 	c := cron.New(cron.WithWatchdog(time.Minute, func(err error) {
+// 		log.Fatal(err)
+// 	}))
+//
+// The admin package serves an HTTP API on which operators list the entries
+// with their stats, add, update and delete the entries of registered jobs, run
+// them now, and pause and resume the scheduler:
+//
+// 	http.Handle("/cron/", http.StripPrefix("/cron", admin.NewHandler(c)))
+//
+// The cronrpc package serves the same operations, and a stream of the events,
+// as a gRPC service, so that a control plane can drive many schedulers:
+//
+// 	cronrpc.RegisterCronServiceServer(s, cronrpc.NewServer(c))
+//
+// The cronctl command is its client, and also checks specs:
+//
+// 	cronctl -addr localhost:8080 list
+// 	cronctl validate "0 30 9 * * MON-FRI"
+//
+// Run history
+//
+// Each entry keeps its last DefaultRunHistory runs, including the ones that
+// were skipped, in the History of the snapshots returned by Entries and Entry,
+// with when they were scheduled and started, how long they ran and how they
+// ended.  The WithRunHistory option keeps more, or none:
+//
+// 	entry, _ := c.Entry(id)
+// 	for _, run := range entry.History {
+// 		fmt.Println(run.Scheduled, run.Outcome, run.Err)
+// 	}
+//
+// The Stats of the snapshots summarize all of an entry's runs since it was
+// added: how many finished, failed and were skipped, how many failed in a row,
+// their durations, and the last error.
+//
+// Persistence
+//
+// Entries whose jobs are created by a JobFactory registered with RegisterJob,
+// from a payload, can outlive the process.  The WithStore option saves those
+// added with AddRegistered, and their last and next activations, in an
+// EntryStore, and Restore adds them back after a restart:
+//
+// 	func init() {
+// 		cron.RegisterJob("report", newReport)
+// 	}
+//
+// 	c := cron.New(cron.WithStore(store))
+// 	if err := c.Restore(); err != nil {
+// 		log.Print(err)
+// 	}
+// 	c.AddRegistered("0 0 9 * * *", "report", []byte(`{"team":"infra"}`))
+//
+// Funcs taking a typed payload are registered with RegisterTypedFunc, and
+// their entries added with AddTyped, which encode the payloads as JSON.
+// AddTypedFunc adds an entry running any such func with its payload, but the
+// entry is not stored:
+//
+// 	type Report struct {
+// 		Team string `json:"team"`
+// 	}
+//
+// 	cron.RegisterTypedFunc("report", func(ctx context.Context, r Report) error {
+// 		return send(ctx, r.Team)
+// 	})
+// 	cron.AddTyped(c, "0 0 9 * * *", "report", Report{Team: "infra"})
+//
+// Runs of stored entries run at most once: one interrupted by the process
+// exiting is lost.  Entries added WithAtLeastOnce claim each run in the store
+// until it returns, and Restore runs the claimed runs again.  Crons sharing a
+// store, created WithClaimTimeout, also run again the runs other Crons claimed
+// and did not acknowledge in time, as passed to Apply.  WithClaimPolicy skips
+// abandoned runs instead:
+//
+// 	c := cron.New(cron.WithStore(store), cron.WithInstanceID(hostname),
+// 		cron.WithClaimTimeout(time.Hour))
+//
+// The activations of stored entries missed while no process ran them are not
+// run, unless the Cron is created WithReplay: Restore then schedules the
+// entries from their last successful runs, so that the missed activations run
+// according to their misfire policies.  WithCatchUp instead runs each entry
+// that missed activations once, for the last of them, if it is recent enough,
+// before following its schedule from now:
+//
+// 	c := cron.New(cron.WithStore(store), cron.WithCatchUp(6*time.Hour))
+//
+// MemoryStore is an EntryStore for tests, the boltstore package keeps entries
+// in a local bbolt database file, and the redisstore package shares them
+// between replicas in Redis.  The etcdstore package keeps them in etcd, and
+// its Watch keeps Crons in sync with the entries written there, applying each
+// change with Apply.
+//
+// Replicas scheduling the same entries run each activation once when they
+// share a Locker, which WithLocker consults before each run, skipping those
+// another replica locked with ErrLocked.  The redisstore package's Stores are
+// Lockers, and the sqllock package keeps locks in a SQL database:
+//
+// 	c := cron.New(cron.WithStore(store), cron.WithLocker(store))
+//
+// Alternatively, the WithElector option runs jobs only on the replica elected
+// leader by an Elector, such as those of the etcdstore package, and of the
+// k8slease package, which holds a Kubernetes Lease.  The other replicas let
+// the activations of their entries pass, keeping them current, so that they
+// take over at once when elected.
+//
+// To spread a large set of entries over several replicas instead, the
+// WithCluster option splits them between the members of a Cluster, which
+// heartbeat in a shared store such as the redisstore package's: each entry
+// runs on the member its ID hashes to, and only the entries of members that
+// join or leave move to others.
+//
+// Crontab files
+//
+// WatchFile adds the entries of a crontab file, whose lines name registered
+// jobs instead of commands, and keeps them in sync with it as it is edited:
+//
+// 	# m h dom mon dow job payload
+// 	30 9 * * MON-FRI report {"team":"infra"}
+// 	@every 10m poll
+//
+// 	stop, err := c.WatchFile("/etc/myapp/crontab")
+//
+// ShellJobs run commands, recording their output with SetOutput, which is
+// reported in the History of their entries and their RunCompleted and
+// RunFailed events.  Registering NewShellJob lets crontab files run commands,
+// like crond:
+//
+// 	cron.RegisterJob("shell", cron.NewShellJob)
+//
+// 	# m h dom mon dow job command
+// 	0 2 * * * shell /usr/local/bin/backup --full
+//
+// HTTPJobs send HTTP requests, e.g. to ping an endpoint every five minutes,
+// with a body templated with the RunInfo of each run, and fail on unexpected
+// statuses; NewHTTPJob creates them from JSON payloads.
+//
+// PublishJobs publish a message on each run with a Publisher, e.g. of the
+// natspub, kafkapub or amqppub packages, making the Cron a source of ticks for
+// event-driven pipelines:
+//
+// 	c.AddJob("@every 1m", &cron.PublishJob{
+// 		Publisher: natspub.New(conn),
+// 		Subject:   "ticks.minute",
+// 	})
+//
+// The config package defines jobs, with policies such as their timeouts and
+// retries, in YAML or JSON files, and reconciles a Cron with them:
+//
+// 	cfg, err := config.Load("/etc/myapp/jobs.yaml")
+// 	...
+// 	err = cfg.Apply(c)
+//
+// Logging
+//
+// Cron logs its diagnostics, such as jobs that panicked, through a Logger.
+// DefaultLogger writes problems to standard error; the WithLogger option
+// substitutes another, e.g. one adapting a *slog.Logger with SlogLogger:
+//
+// 	c := cron.New(cron.WithLogger(cron.SlogLogger(slog.Default())))
+//
+// Panics are logged with their stack, unless the WithPanicHandler option is
+// given a PanicHandler, which receives the ID of the entry whose job panicked.
+//
 // CRON Expression Format
 //
 // A cron expression represents a set of times, using 6 space-separated fields.
 //
 // 	Field name   | Mandatory? | Allowed values  | Allowed special characters
 // 	----------   | ---------- | --------------  | --------------------------
-// 	Seconds      | Yes        | 0-59            | * / , -
-// 	Minutes      | Yes        | 0-59            | * / , -
-// 	Hours        | Yes        | 0-23            | * / , -
-// 	Day of month | Yes        | 1-31            | * / , - ?
-// 	Month        | Yes        | 1-12 or JAN-DEC | * / , -
-// 	Day of week  | Yes        | 0-6 or SUN-SAT  | * / , - ?
+// 	Seconds      | Yes        | 0-59            | * / , - ~
+// 	Minutes      | Yes        | 0-59            | * / , - ~
+// 	Hours        | Yes        | 0-23            | * / , - ~
+// 	Day of month | Yes        | 1-31            | * / , - ~ ? L W B
+// 	Month        | Yes        | 1-12 or JAN-DEC | * / , - ~
+// 	Day of week  | Yes        | 0-6 or SUN-SAT  | * / , - ~ ? L
 //
 // Note: Month and Day-of-week field values are case insensitive.  "SUN", "Sun",
 // and "sun" are equally accepted.
 //
+// Parsers created with NewParser(ISOWeekday) instead number the days of the
+// week 1-7 starting on Monday, as in ISO 8601:
+//
+// 	p := cron.NewParser(cron.ISOWeekday)
+// 	schedule, err := p.Parse("0 0 9 * * 1-5") // Nine on weekdays.
+//
+// Parsers created with the Strict option also reject fields that are valid but
+// degenerate, such as duplicate values or steps larger than their range.
+//
+// Classic 5-field crontabs, which start with the minute, must be parsed with
+// ParseStandard (or a Parser with the Standard option); Parse reads their first
+// field as the second.  Their second is always 0.
+//
+// 	s, err := cron.ParseStandard("30 2 * * 1") // 2:30am on Mondays.
+//
 // Special Characters
 //
 // Asterisk ( * )
@@ -77,10 +425,42 @@
 // Hyphens are used to define ranges. For example, 9-17 would indicate every
 // hour between 9am and 5pm inclusive.
 //
+// Ranges in every field but the day of month may wrap around past their end;
+// e.g. 22-2 in the hours field would indicate 10pm, 11pm, midnight, 1am and 2am,
+// and FRI-MON in the day of week field Friday through Monday.
+//
 // Question mark ( ? )
 //
 // Question mark may be used instead of '*' for leaving either day-of-month or
-// day-of-week blank.
+// day-of-week blank.  Parsers created with the QuartzQuestionMark option
+// require it, as Quartz does, in exactly one of those two fields.
+//
+// Tilde ( ~ )
+//
+// Tilde picks a random value when the schedule is parsed.  A bare "~" picks any
+// value of the field, and "30~59" picks a value between 30 and 59 inclusive.
+// For example "~ ~ 3 * * *" runs once a day at a random minute and second past
+// 3am, which spreads identical jobs running in many processes without any
+// coordination.
+//
+// L
+//
+// L stands for "last".  In the day-of-month field, "L" is the last day of the
+// month, and "L-n" is n days before the last day of the month; e.g. "L-2" on
+// the 31st of a 31-day month is the 29th.  "LW" is the last weekday (Monday
+// through Friday) of the month.  These may be combined with other days in a
+// list, e.g. "1,L".
+//
+// In the day-of-week field, "nL" is the last day n of the month; e.g. "5L" or
+// "FRIL" is the last Friday of the month.
+//
+// B
+//
+// B stands for "business day".  In the day-of-month field, "nB" is the nth
+// business day of the month, and "LB" is the last business day of the month;
+// e.g. "3B" is the third business day.  By default Monday through Friday are
+// business days; set SpecSchedule.BusinessDays to use another definition, such
+// as one that excludes holidays.
 //
 // Predefined schedules
 //
@@ -90,6 +470,8 @@
 // 	-----                  | -----------                                | -------------
 // 	@yearly (or @annually) | Run once a year, midnight, Jan. 1st        | 0 0 0 1 1 *
 // 	@monthly               | Run once a month, midnight, first of month | 0 0 0 1 * *
+// 	@end_of_month          | Run once a month, midnight, last of month  | 0 0 0 L * *
+// 	@end_of_quarter        | Run once a quarter, midnight, last day     | 0 0 0 L 3,6,9,12 *
 // 	@weekly                | Run once a week, midnight on Sunday        | 0 0 0 * * 0
 // 	@daily (or @midnight)  | Run once a day, midnight                   | 0 0 0 * * *
 // 	@hourly                | Run once an hour, beginning of hour        | 0 0 * * * *
@@ -107,35 +489,166 @@
 // For example, "@every 1h30m10s" would indicate a schedule that activates every
 // 1 hour, 30 minutes, 10 seconds.
 //
+// An interval may be limited to a number of activations, after which the job is
+// removed from the Cron, e.g. "@every 1h limit 24" or Every(time.Hour).Times(24).
+//
+// Intervals are rounded to whole seconds, and activate on the second.  Jobs
+// that must run more often, such as pollers, may use EveryWithPrecision, or
+// a Parser with the SubSecond option, which keeps intervals to the millisecond:
+//
+// 	c := cron.New(cron.WithParser(cron.NewParser(cron.SubSecond)))
+// 	c.AddFunc("@every 250ms", poll)
+//
 // Note: The interval does not take the job runtime into account.  For example,
 // if a job takes 3 minutes to run, and it is scheduled to run every 5 minutes,
 // it will have only 2 minutes of idle time between each run.
 //
+// One-shot schedules
+//
+// A job may also be scheduled to run exactly once, at an absolute time given in
+// RFC 3339 format:
+//
+//     @at 2025-06-01T03:00:00Z
+//
+// Once that time has passed the schedule never activates again.  Cron.At adds
+// a job on such a schedule, and removes its entry once it has run.
+//
+// Unions
+//
+// Several expressions may be combined with "||" into one schedule that
+// activates whenever any of them does, e.g. nine on weekdays and eleven on
+// Saturdays:
+//
+//     0 0 9 * * MON-FRI || 0 0 11 * * SAT
+//
+// Exclusions
+//
+// The activations of one expression may be removed from another with "except",
+// e.g. to suppress an hourly job during nightly maintenance:
+//
+//     @hourly except 0 0 2-4 * * *
+//
+// "except" binds looser than "||", so "a || b except c" excludes c from both a
+// and b.
+//
+// Previous activations
+//
+// Prev returns the latest activation of a schedule at or before a time, e.g. to
+// check whether a run was due already.  SpecSchedules compute it directly, as
+// PrevSchedules; other schedules are searched backwards with Next:
+//
+// 	due := cron.Prev(schedule, time.Now()).After(lastRun)
+//
+// Solar schedules
+//
+// Jobs may run relative to sunrise or sunset at a latitude and longitude, with
+// an optional offset, e.g. half an hour before sunset in San Francisco:
+//
+//     @sunset 37.7749,-122.4194 -30m
+//
+// Recurrence rules
+//
+// Schedules that cron syntax cannot express, such as the last Friday of every
+// month or every third week, may be given as iCalendar (RFC 5545) recurrence
+// rules with ParseRRule:
+//
+// 	s, err := cron.ParseRRule("FREQ=MONTHLY;BYDAY=-1FR", dtstart)
+// 	c.Schedule(s, job)
+//
+// EventBridge expressions
+//
+// AWS EventBridge (CloudWatch Events) schedule expressions are accepted as-is,
+// so specs can be shared with Lambda-based infrastructure:
+//
+//     cron(0 12 * * ? *)
+//     rate(5 minutes)
+//
+// EventBridge cron expressions have no seconds field (they fire on second
+// zero), number the days of the week 1-7 starting on Sunday, and end with a
+// year field, which must be "*".  Like on EventBridge, they are in UTC, unless
+// parsed in another location.  The day of month accepts "L" and "LW", and the
+// day of week "nL", but the nearest weekday ("15W") and the nth day of the
+// week ("6#3") are rejected.  Rates accept minute(s), hour(s) and day(s).
+//
+// Calendars
+//
+// The month and day-of-month fields are matched against the Gregorian calendar
+// unless SpecSchedule.Calendar is set, in which case that Calendar decomposes
+// each day into its month and day of the month.  FiscalCalendar is provided for
+// fiscal years that do not start in January; other calendars, such as 4-4-5
+// retail calendars, may be plugged in by implementing Calendar.
+//
+// Entries may avoid the holidays of a HolidayCalendar, skipping their
+// activations on them or moving them to the next business day.
+// StaticHolidays holds a fixed set of dates; other sources, such as a holiday
+// service, may be plugged in with HolidayFunc:
+//
+// 	holidays, err := cron.NewStaticHolidays("01-01", "12-25", "2024-11-28")
+// 	..
+// 	c.AddFunc("0 0 9 * * MON-FRI", payroll,
+// 		cron.WithHolidays(holidays, cron.HolidayNextBusinessDay))
+//
+// BusinessDaysExcept makes the business day fields of a SpecSchedule avoid the
+// holidays too.
+//
 // Time zones
 //
 // All interpretation and scheduling is done in the machine's local time zone (as
 // provided by the Go time package (http://www.golang.org/pkg/time).
+// A Cron created with the WithLocation option, e.g. New(WithLocation(time.UTC)),
+// uses that time zone instead.
 //
-// Be aware that jobs scheduled during daylight-savings leap-ahead transitions will
-// not be run!
+// A schedule may instead be bound to a time zone when it is parsed, without
+// embedding the zone in the spec:
+//
+// 	s, err := cron.ParseInLocation("0 0 9 * * *", ny) // Nine in New York.
+//
+// or by prefixing the spec with the zone, or by adding the entry to a Cron with
+// the InLocation option, so that one Cron may serve several time zones:
+//
+// 	c.AddFunc("TZ=America/New_York 0 0 9 * * *", report)
+// 	c.AddFunc("0 0 9 * * *", report, cron.InLocation(tokyo))
+//
+// By default jobs scheduled during daylight-savings leap-ahead transitions will
+// not be run, and jobs scheduled during fall-back transitions will be run twice.
+// The ShiftDSTGaps parse option instead runs the former at the shifted wall
+// time, e.g. 2:30 at 3:30, and RunDSTOverlapsOnce runs the latter only before
+// clocks fall back:
+//
+// 	c := cron.New(cron.WithParser(cron.NewParser(
+// 		cron.ShiftDSTGaps | cron.RunDSTOverlapsOnce)))
+//
+// Clocks
+//
+// A Cron reads the time from the time package unless it is created with the
+// WithClock option.  Tests may use a FakeClock, which only moves when advanced,
+// to run jobs without waiting:
+//
+// 	clock := cron.NewFakeClock(start)
+// 	c := cron.New(cron.WithClock(clock))
+// 	..
+// 	clock.Advance(time.Hour)
 //
 // Thread safety
 //
 // Since the Cron service runs concurrently with the calling code, some amount of
 // care must be taken to ensure proper synchronization.
 //
-// All cron methods are designed to be correctly synchronized as long as the caller
-// ensures that invocations have a clear happens-before ordering between them.
+// All cron methods are safe for concurrent use.  The entries and state of a Cron
+// are guarded by a read-write lock, so methods that only inspect them, such as
+// Entries, Entry and IsRunning, run in parallel with each other, and none of
+// them waits for the scheduler goroutine.  Event handlers are called after the
+// lock is released, so they may call the Cron.
 //
 // Implementation
 //
-// Cron entries are stored in an array, sorted by their next activation time.  Cron
-// sleeps until the next job is due to be run.
+// Cron entries are stored in a min-heap, ordered by their next activation time.
+// Cron sleeps until the next job is due to be run.
 //
-// Upon waking:
-//  - it runs each entry that is active on that second
+// Upon waking, holding the lock:
+//  - it removes each entry that is due from the heap, and runs it
 //  - it calculates the next run times for the jobs that were run
-//  - it re-sorts the array of entries by next activation time.
+//  - it pushes them back onto the heap.
 //  - it goes to sleep until the soonest job.
 //
 
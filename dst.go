@@ -0,0 +1,106 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements the policies for wall clock times that daylight saving
+// time transitions skip or repeat.
+
+package cron
+
+import "time"
+
+// DSTGapPolicy decides what happens to the activations of a SpecSchedule at
+// wall clock times that do not exist because clocks spring forward, e.g. 2:30
+// when clocks go from 2:00 to 3:00.
+type DSTGapPolicy int
+
+const (
+  // DSTSkipGap skips the activations.  It is the default.
+  DSTSkipGap DSTGapPolicy = iota
+
+  // DSTShiftGap runs the activations at the wall time they are shifted to by
+  // the transition, e.g. 2:30 at 3:30, as time.Date does.  An activation
+  // shifted onto another runs once.
+  DSTShiftGap
+)
+
+// DSTOverlapPolicy decides what happens to the activations of a SpecSchedule
+// at wall clock times that occur twice because clocks fall back, e.g. 1:30
+// when clocks go from 2:00 back to 1:00.
+type DSTOverlapPolicy int
+
+const (
+  // DSTRunTwice runs the activations at both times.  It is the default.
+  DSTRunTwice DSTOverlapPolicy = iota
+
+  // DSTRunOnce runs the activations only at the first time, before clocks
+  // fall back.
+  DSTRunOnce
+)
+
+// applyDST returns the schedule's next activation after t, next, adjusted
+// according to its DST policies.
+func (s *SpecSchedule) applyDST(t, next time.Time) time.Time {
+  if s.DSTGap == DSTShiftGap {
+    next = s.shiftGap(t, next)
+  }
+  if s.DSTOverlap == DSTRunOnce {
+    for !next.IsZero() && repeated(next) {
+      next = s.next(next)
+    }
+  }
+  return next
+}
+
+// shiftGap returns the first activation after t at a wall time skipped by
+// clocks springing forward before next, shifted by the transition, or next if
+// there is none.
+func (s *SpecSchedule) shiftGap(t, next time.Time) time.Time {
+  for zone := t; !next.IsZero(); {
+    _, end := zone.ZoneBounds()
+    if end.IsZero() || !end.Before(next) {
+      break
+    }
+    _, before := zone.Zone()
+    _, after := end.Zone()
+    if after > before {
+      // In the offset before the transition the skipped wall times exist, at
+      // the instants they are shifted to.
+      from := end.Add(-time.Second)
+      if from.Before(t) {
+        from = t
+      }
+      gap := time.Duration(after-before) * time.Second
+      shifted := s.next(from.In(time.FixedZone("", before)))
+      if !shifted.IsZero() && shifted.Before(end.Add(gap)) &&
+        shifted.Before(next) {
+        return shifted.In(t.Location())
+      }
+    }
+    zone = end
+  }
+  return next
+}
+
+// repeated returns whether the wall time of t occurred earlier, before clocks
+// fell back.
+func repeated(t time.Time) bool {
+  start, _ := t.ZoneBounds()
+  if start.IsZero() {
+    return false
+  }
+  _, offset := t.Zone()
+  _, before := start.Add(-time.Second).Zone()
+  return before > offset &&
+    t.Sub(start) < time.Duration(before-offset)*time.Second
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for DST policies.
+
+package cron
+
+import (
+  "encoding/json"
+  "testing"
+)
+
+func TestDSTPolicies(t *testing.T) {
+  runs := []struct {
+    time, spec string
+    options    ParseOption
+    expected   string
+  }{
+    // Skipped times are not run by default.
+    {"2012-03-11T00:00:00-0500", "0 30 2 11 Mar ?", 0, "2013-03-11T02:30:00-0400"},
+    {"2012-03-11T00:00:00-0500", "0 0 2 * * ?", 0, "2012-03-12T02:00:00-0400"},
+
+    // Or are shifted by the transition.
+    {"2012-03-11T00:00:00-0500", "0 30 2 11 Mar ?", ShiftDSTGaps, "2012-03-11T03:30:00-0400"},
+    {"2012-03-11T00:00:00-0500", "0 0 2 * * ?", ShiftDSTGaps, "2012-03-11T03:00:00-0400"},
+    {"2012-03-11T03:00:00-0400", "0 0 2 * * ?", ShiftDSTGaps, "2012-03-12T02:00:00-0400"},
+    {"2012-03-11T01:30:00-0500", "0 30 2-3 * * ?", ShiftDSTGaps, "2012-03-11T03:30:00-0400"},
+    {"2012-03-11T03:30:00-0400", "0 30 2-3 * * ?", ShiftDSTGaps, "2012-03-12T02:30:00-0400"},
+    {"2012-03-11T01:00:00-0500", "0 0 * * * ?", ShiftDSTGaps, "2012-03-11T03:00:00-0400"},
+    {"2012-06-01T00:00:00-0400", "0 30 2 10 Mar ?", ShiftDSTGaps, "2013-03-10T03:30:00-0400"},
+
+    // Repeated times are run twice by default.
+    {"2012-11-04T01:00:00-0400", "0 0 1 * * ?", 0, "2012-11-04T01:00:00-0500"},
+    {"2012-11-04T01:45:00-0400", "0 30 1 04 Nov ?", 0, "2012-11-04T01:30:00-0500"},
+
+    // Or once, before clocks fall back.
+    {"2012-11-04T00:00:00-0400", "0 0 1 * * ?", RunDSTOverlapsOnce, "2012-11-04T01:00:00-0400"},
+    {"2012-11-04T01:00:00-0400", "0 0 1 * * ?", RunDSTOverlapsOnce, "2012-11-05T01:00:00-0500"},
+    {"2012-11-04T01:00:00-0400", "0 0 * * * ?", RunDSTOverlapsOnce, "2012-11-04T02:00:00-0500"},
+    {"2012-11-04T01:45:00-0400", "0 30 1 04 Nov ?", RunDSTOverlapsOnce, "2013-11-04T01:30:00-0500"},
+    {"2012-11-04T00:00:00-0400", "0 0 2 * * ?", RunDSTOverlapsOnce, "2012-11-04T02:00:00-0500"},
+  }
+
+  for _, c := range runs {
+    sched, err := NewParser(c.options).Parse(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    actual := sched.Next(getTime(c.time))
+    expected := getTime(c.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s, \"%s\", %d: (expected) %v != %v (actual)", c.time,
+        c.spec, c.options, expected, actual)
+    }
+  }
+}
+
+func TestDSTPoliciesPersist(t *testing.T) {
+  sched, err := Build().AtHour(2).WithDST(DSTShiftGap, DSTRunOnce).Schedule()
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  b, err := json.Marshal(sched)
+  if err != nil {
+    t.Fatal(err)
+  }
+  var decoded SpecSchedule
+  if err := json.Unmarshal(b, &decoded); err != nil {
+    t.Fatal(err)
+  }
+  if !Equal(sched, &decoded) {
+    t.Errorf("expected %+v, got %+v", sched, decoded)
+  }
+
+  if Equal(sched, &SpecSchedule{Second: sched.Second, Minute: sched.Minute,
+    Hour: sched.Hour, Dom: sched.Dom, Month: sched.Month, Dow: sched.Dow}) {
+    t.Error("expected schedules with different DST policies to differ")
+  }
+}
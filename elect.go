@@ -0,0 +1,77 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements running jobs only while leading a group of Crons.
+
+package cron
+
+import (
+  "context"
+)
+
+// Elector elects a leader among the Crons sharing it, e.g. the replicas of a
+// service, so that only one of them runs jobs at a time.
+type Elector interface {
+  // Campaign campaigns for the leadership until ctx is done, calling lead with
+  // true when the Cron becomes the leader, and with false when it stops being
+  // it.  It resigns, and returns, once ctx is done.
+  Campaign(ctx context.Context, lead func(leading bool))
+}
+
+// WithElector runs jobs only while the Cron leads the Crons sharing elector.
+// The Cron campaigns while it is running, and while it does not lead, the
+// activations of its entries pass without running, keeping their next
+// activations current, so that a Cron taking over runs the following ones.
+// Runs in progress when the leadership is lost are not canceled, and RunNow
+// runs jobs regardless.
+func WithElector(elector Elector) Option {
+  return func(c *Cron) {
+    c.elector = elector
+  }
+}
+
+// IsLeader returns whether the Cron runs the activations of its entries: true
+// unless it was created WithElector and does not lead.
+func (c *Cron) IsLeader() bool {
+  c.mu.RLock()
+  defer c.mu.RUnlock()
+  return c.leads()
+}
+
+// leads returns whether the Cron runs the activations of its entries.  c.mu
+// must be held.
+func (c *Cron) leads() bool {
+  return c.elector == nil || c.leading
+}
+
+// campaign campaigns for the leadership until the Cron stops, or ctx is done.
+// c.mu must be held.
+func (c *Cron) campaign(ctx context.Context) {
+  ctx, resign := context.WithCancel(ctx)
+  c.resign = resign
+  go c.elector.Campaign(ctx, func(leading bool) {
+    c.mu.Lock()
+    defer c.unlock()
+    // The Cron stopped leading when it resigned.
+    if ctx.Err() != nil || leading == c.leading {
+      return
+    }
+    c.leading = leading
+    if leading {
+      c.logger.Infof("leading, running jobs")
+    } else {
+      c.logger.Infof("no longer leading, not running jobs")
+    }
+  })
+}
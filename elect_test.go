@@ -0,0 +1,102 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for running jobs only while leading.
+
+package cron
+
+import (
+  "context"
+  "testing"
+  "time"
+)
+
+// fakeElector is an Elector whose campaigns are sent on a channel, so that
+// tests decide the leadership.
+type fakeElector struct {
+  campaigns chan fakeCampaign
+}
+
+// fakeCampaign is a campaign of a fakeElector, which is over when ctx is done.
+type fakeCampaign struct {
+  ctx  context.Context
+  lead func(bool)
+}
+
+func (e *fakeElector) Campaign(ctx context.Context, lead func(bool)) {
+  e.campaigns <- fakeCampaign{ctx, lead}
+  <-ctx.Done()
+}
+
+func TestElector(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  elector := &fakeElector{campaigns: make(chan fakeCampaign, 1)}
+  cron, clock, ran, _ := blackoutCron(t, []Option{WithElector(elector)},
+    WithName("job"))
+  id, _ := cron.EntryID("job")
+  campaign := <-elector.campaigns
+
+  // expectPassed fails the test unless the activation at the given time
+  // passed without running.
+  expectPassed := func(at time.Time) {
+    t.Helper()
+    clock.Advance(time.Hour)
+    waitFor(t, func() bool {
+      e, _ := cron.Entry(id)
+      return e.Next.Equal(at.Add(time.Hour))
+    })
+    select {
+    case actual := <-ran:
+      t.Errorf("expected no run, ran at %v", actual)
+    default:
+    }
+  }
+
+  if cron.IsLeader() {
+    t.Error("expected the Cron not to lead before it is elected")
+  }
+  expectPassed(start.Add(time.Hour))
+
+  campaign.lead(true)
+  if !cron.IsLeader() {
+    t.Error("expected the Cron to lead")
+  }
+  clock.Advance(time.Hour)
+  expectRun(t, ran, start.Add(2*time.Hour))
+
+  campaign.lead(false)
+  expectPassed(start.Add(3 * time.Hour))
+
+  // Stopping resigns, and starting campaigns again.
+  campaign.lead(true)
+  cron.Stop()
+  if campaign.ctx.Err() == nil || cron.IsLeader() {
+    t.Error("expected the Cron to resign when stopped")
+  }
+  campaign.lead(true)
+  if cron.IsLeader() {
+    t.Error("expected the resigned campaign to be ignored")
+  }
+  cron.Start()
+  campaign = <-elector.campaigns
+  campaign.lead(true)
+  clock.Advance(time.Hour)
+  expectRun(t, ran, start.Add(4*time.Hour))
+}
+
+func TestNoElector(t *testing.T) {
+  if cron := New(); !cron.IsLeader() {
+    t.Error("expected a Cron without an Elector to lead")
+  }
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements schedule equality and equivalence checking.
+
+package cron
+
+import (
+  "reflect"
+  "time"
+)
+
+// Equal reports whether two schedules are the same, and so fire at identical
+// times.  SpecSchedules are compared by their fields, ignoring star bits that
+// do not affect matching, so e.g. "0 0 9 * * MON-FRI" and "0 0 9 ? * 1-5" are
+// equal.  Unions are equal if their schedules are equal in the same order.
+//
+// Equal may report false for schedules that fire at the same times but are
+// built differently, such as "@every 24h" and "@daily" in a time zone without
+// daylight saving; use Equivalent to compare those.
+func Equal(a, b Schedule) bool {
+  switch a := a.(type) {
+  case *SpecSchedule:
+    b, ok := b.(*SpecSchedule)
+    return ok && specEqual(a, b)
+  case AtSchedule:
+    b, ok := b.(AtSchedule)
+    return ok && a.Time.Equal(b.Time)
+  case *UnionSchedule:
+    b, ok := b.(*UnionSchedule)
+    if !ok || len(a.Schedules) != len(b.Schedules) {
+      return false
+    }
+    for i := range a.Schedules {
+      if !Equal(a.Schedules[i], b.Schedules[i]) {
+        return false
+      }
+    }
+    return true
+  case *ExceptSchedule:
+    b, ok := b.(*ExceptSchedule)
+    return ok && Equal(a.Schedule, b.Schedule) && Equal(a.Except, b.Except)
+  }
+  return reflect.DeepEqual(a, b)
+}
+
+// specEqual reports whether two SpecSchedules have the same fields.
+func specEqual(a, b *SpecSchedule) bool {
+  // The star bit only matters in the day of month and day of week, where it
+  // selects whether both or either must match.
+  var (
+    aAnd = a.Dom&starBit > 0 || a.Dow&starBit > 0
+    bAnd = b.Dom&starBit > 0 || b.Dow&starBit > 0
+  )
+  if a.Second&^starBit != b.Second&^starBit ||
+    a.Minute&^starBit != b.Minute&^starBit ||
+    a.Hour&^starBit != b.Hour&^starBit ||
+    a.Dom&^starBit != b.Dom&^starBit ||
+    a.Month&^starBit != b.Month&^starBit ||
+    a.Dow&^starBit != b.Dow&^starBit ||
+    a.BusinessDay != b.BusinessDay || aAnd != bAnd ||
+    a.DSTGap != b.DSTGap || a.DSTOverlap != b.DSTOverlap {
+    return false
+  }
+
+  if !sameValue(a.calendar(), b.calendar()) {
+    return false
+  }
+  if a.BusinessDay != 0 && !sameValue(a.businessDays(), b.businessDays()) {
+    return false
+  }
+  if (a.Location == nil) != (b.Location == nil) {
+    return false
+  }
+  return a.Location == nil || a.Location.String() == b.Location.String()
+}
+
+// sameValue reports whether two interface values are the same.  Values that
+// are not comparable, such as funcs, are the same if they are the same func.
+func sameValue(a, b interface{}) bool {
+  if reflect.TypeOf(a) != reflect.TypeOf(b) {
+    return false
+  }
+  if a == nil || reflect.TypeOf(a).Comparable() {
+    return a == b
+  }
+  va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+  if va.Kind() == reflect.Func {
+    return va.Pointer() == vb.Pointer()
+  }
+  return reflect.DeepEqual(a, b)
+}
+
+// Equivalent reports whether two schedules fire at the same times for the
+// given number of activations after from.  Unlike Equal, it works for any
+// pair of schedules, but only samples their activations.
+func Equivalent(a, b Schedule, from time.Time, samples int) bool {
+  t := from
+  for i := 0; i < samples; i++ {
+    next := a.Next(t)
+    if !next.Equal(b.Next(t)) {
+      return false
+    }
+    if next.IsZero() {
+      return true
+    }
+    t = next
+  }
+  return true
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for schedule equality.
+
+package cron
+
+import (
+  "testing"
+  "time"
+)
+
+func TestEqual(t *testing.T) {
+  entries := []struct {
+    a, b     string
+    expected bool
+  }{
+    {"0 0 9 * * MON-FRI", "0 0 9 ? * 1-5", true},
+    {"0 0 9 * * MON-FRI", "0 0 9 * * 1-5", true},
+    {"0 0 9 * * MON-FRI", "0 0 10 * * 1-5", false},
+    {"* * * * * *", "0-59 * * * * *", true},
+    {"@daily", "0 0 0 * * *", true},
+    {"0 0 0 1-31 * *", "0 0 0 * * *", true},
+    {"0 0 0 1-31 * 1", "0 0 0 * * 1", false},
+    {"0 0 0 L * *", "0 0 0 L-1 * *", false},
+    {"0 0 0 3B * *", "0 0 0 3B * *", true},
+    {"0 0 0 3B * *", "0 0 0 LB * *", false},
+    {"@every 1h", "@every 60m", true},
+    {"@every 1h", "0 0 * * * *", false},
+    {"@at 2025-06-01T05:00:00+02:00", "@at 2025-06-01T03:00:00Z", true},
+    {"@hourly || @daily", "@hourly || @daily", true},
+    {"@hourly || @daily", "@daily || @hourly", false},
+    {"@hourly except @daily", "@hourly except 0 0 0 * * *", true},
+  }
+
+  for _, c := range entries {
+    a, err := Parse(c.a)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    b, err := Parse(c.b)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    if actual := Equal(a, b); actual != c.expected {
+      t.Errorf("%s, %s: (expected) %v != %v (actual)", c.a, c.b, c.expected,
+        actual)
+    }
+  }
+}
+
+func TestEqualLocation(t *testing.T) {
+  ny, err := time.LoadLocation("America/New_York")
+  if err != nil {
+    t.Skip(err)
+  }
+  ny2, _ := time.LoadLocation("America/New_York")
+  a, _ := ParseInLocation("0 0 9 * * *", ny)
+  b, _ := ParseInLocation("0 0 9 * * *", ny2)
+  c, _ := Parse("0 0 9 * * *")
+  if !Equal(a, b) {
+    t.Error("expected schedules in the same location to be equal")
+  }
+  if Equal(a, c) {
+    t.Error("expected schedules in different locations to differ")
+  }
+
+  d, _ := Parse("0 0 0 3B * *")
+  e, _ := Parse("0 0 0 3B * *")
+  e.(*SpecSchedule).BusinessDays = BusinessDayFunc(func(time.Time) bool {
+    return true
+  })
+  if Equal(d, e) {
+    t.Error("expected schedules with different business days to differ")
+  }
+  e.(*SpecSchedule).BusinessDays = Weekdays
+  if !Equal(d, e) {
+    t.Error("expected default business days to equal Weekdays")
+  }
+}
+
+func TestEquivalent(t *testing.T) {
+  entries := []struct {
+    a, b     string
+    expected bool
+  }{
+    {"@hourly || @daily", "@daily || @hourly", true},
+    {"0 0 0 1-31 * *", "@daily", true},
+    {"@every 1h", "0 30 * * * *", false},
+    {"0 0 0 * * *", "0 0 0 * * 0-6", true},
+    {"0 0 9 * * MON-FRI", "0 0 9 * * MON-SAT", false},
+  }
+
+  from := getTime("Mon Jul 9 00:00 2012")
+  for _, c := range entries {
+    a, err := Parse(c.a)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    b, err := Parse(c.b)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    if actual := Equivalent(a, b, from, 100); actual != c.expected {
+      t.Errorf("%s, %s: (expected) %v != %v (actual)", c.a, c.b, c.expected,
+        actual)
+    }
+  }
+}
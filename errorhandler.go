@@ -0,0 +1,73 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements handling the errors of failed runs.
+
+package cron
+
+import "context"
+
+// JobWithError is a job whose runs may fail, returning an error.  Unlike an
+// ErrorJob it does not take a context; add it with AddJobWithError.
+type JobWithError interface {
+  Run() error
+}
+
+// ErrorHandler is called with the ID of an entry and the error of a failed
+// run of its job: the error an ErrorJob returned, or one describing a panic.
+// It is called from the goroutine of the run, so it must be safe for
+// concurrent use.
+type ErrorHandler func(id string, err error)
+
+// WithErrorHandler calls handler with the errors of failed runs of the Cron's
+// jobs, except those of entries added with their own OnError handler.
+func WithErrorHandler(handler ErrorHandler) Option {
+  return func(c *Cron) {
+    c.onError = handler
+  }
+}
+
+// OnError calls handler with the errors of failed runs of the entry's job,
+// instead of the Cron's handler.
+func OnError(handler ErrorHandler) EntryOption {
+  return func(e *Entry) {
+    e.OnError = handler
+  }
+}
+
+// AddErrorFunc adds a func whose runs may fail to the Cron to be run on the
+// given schedule.
+func (c *Cron) AddErrorFunc(spec string, cmd func(ctx context.Context) error,
+  opts ...EntryOption) (string, error) {
+  return c.AddJob(spec, ErrorFuncJob(cmd), opts...)
+}
+
+// AddJobWithError adds a JobWithError to the Cron to be run on the given
+// schedule.
+func (c *Cron) AddJobWithError(spec string, cmd JobWithError,
+  opts ...EntryOption) (string, error) {
+  return c.AddErrorFunc(spec, func(context.Context) error { return cmd.Run() },
+    opts...)
+}
+
+// handleError passes the error of a failed run of the job of the entry with
+// the given id to its handler, if any, or the Cron's.
+func (c *Cron) handleError(id string, handler ErrorHandler, err error) {
+  if handler == nil {
+    handler = c.onError
+  }
+  if handler != nil {
+    handler(id, err)
+  }
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for error handlers.
+
+package cron
+
+import (
+  "errors"
+  "strings"
+  "testing"
+  "time"
+)
+
+// failingJob is a JobWithError that always fails.
+type failingJob struct {
+  err error
+}
+
+func (j failingJob) Run() error { return j.err }
+
+// handledError is an error passed to an ErrorHandler.
+type handledError struct {
+  handler, id string
+  err         error
+}
+
+func TestErrorHandler(t *testing.T) {
+  errs := make(chan handledError, 10)
+  handler := func(name string) ErrorHandler {
+    return func(id string, err error) { errs <- handledError{name, id, err} }
+  }
+
+  boom := errors.New("boom")
+  cron := New(WithErrorHandler(handler("cron")), WithLogger(DiscardLogger))
+  failing, _ := cron.AddJobWithError("@yearly", failingJob{boom})
+  own, _ := cron.AddJobWithError("@yearly", failingJob{boom},
+    OnError(handler("entry")))
+  panicking, _ := cron.AddFunc("@yearly", func() { panic("YOLO") })
+  succeeding, _ := cron.AddJobWithError("@yearly", failingJob{nil})
+
+  tests := []struct {
+    id, handler, err string
+  }{
+    {failing, "cron", "boom"},
+    {own, "entry", "boom"},
+    {panicking, "cron", "panic: YOLO"},
+  }
+  for _, test := range tests {
+    cron.RunNow(test.id)
+    select {
+    case e := <-errs:
+      if e.id != test.id || e.handler != test.handler ||
+        !strings.Contains(e.err.Error(), test.err) {
+        t.Errorf("expected %s handler with %s for %s, got %s with %v for %s",
+          test.handler, test.err, test.id, e.handler, e.err, e.id)
+      }
+    case <-time.After(cOneSecond):
+      t.Errorf("expected an error for %s", test.id)
+    }
+  }
+
+  cron.RunNow(succeeding)
+  select {
+  case e := <-errs:
+    t.Errorf("unexpected error %v", e.err)
+  case <-time.After(100 * time.Millisecond):
+  }
+}
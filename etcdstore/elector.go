@@ -0,0 +1,120 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements a cron.Elector with etcd elections.
+
+package etcdstore
+
+import (
+  "context"
+  "time"
+
+  "github.com/kiranbond/cron"
+  clientv3 "go.etcd.io/etcd/client/v3"
+  "go.etcd.io/etcd/client/v3/concurrency"
+)
+
+const (
+  // DefaultSessionTTL is the TTL, in seconds, of the leases of Electors'
+  // sessions: how long the leadership of a leader that died lasts.
+  DefaultSessionTTL = 10
+
+  // DefaultRetryDelay is how long Electors wait before campaigning again after
+  // a campaign failed.
+  DefaultRetryDelay = time.Second
+)
+
+// Elector is a cron.Elector campaigning in an etcd election, whose leadership
+// is bound to the lease of a session, so that it passes on when the leader
+// dies:
+//
+// 	elector := etcdstore.NewElector(client, "/myapp/cron-leader/", hostname)
+// 	c := cron.New(cron.WithElector(elector))
+type Elector struct {
+  client *clientv3.Client
+  prefix string
+  value  string
+
+  // SessionTTL is the TTL of the leases of sessions, in seconds, and
+  // RetryDelay how long to wait after a campaign failed.
+  SessionTTL int
+  RetryDelay time.Duration
+
+  // OnError is called with the errors of campaigns.  By default they are
+  // logged to Logger.
+  OnError func(err error)
+
+  // Logger logs the errors of campaigns, by default to cron.DefaultLogger.
+  Logger cron.Logger
+}
+
+// NewElector returns an Elector campaigning in the election of client under
+// keys starting with prefix, with the given value identifying the candidate,
+// e.g. its host name.
+func NewElector(client *clientv3.Client, prefix, value string) *Elector {
+  e := &Elector{
+    client:     client,
+    prefix:     prefix,
+    value:      value,
+    SessionTTL: DefaultSessionTTL,
+    RetryDelay: DefaultRetryDelay,
+    Logger:     cron.DefaultLogger,
+  }
+  e.OnError = func(err error) {
+    e.Logger.Warningf("campaign failed: %v", err)
+  }
+  return e
+}
+
+// Campaign implements cron.Elector, campaigning in sessions until ctx is done.
+func (e *Elector) Campaign(ctx context.Context, lead func(bool)) {
+  for {
+    if err := e.campaign(ctx, lead); err != nil && ctx.Err() == nil {
+      e.OnError(err)
+    }
+    select {
+    case <-ctx.Done():
+      return
+    case <-time.After(e.RetryDelay):
+    }
+  }
+}
+
+// campaign campaigns in a new session, and leads until ctx is done, or the
+// session expires.
+func (e *Elector) campaign(ctx context.Context, lead func(bool)) error {
+  session, err := concurrency.NewSession(e.client,
+    concurrency.WithTTL(e.SessionTTL), concurrency.WithContext(ctx))
+  if err != nil {
+    return err
+  }
+  // Closing the session revokes its lease, ending its leadership.
+  defer session.Close()
+
+  election := concurrency.NewElection(session, e.prefix)
+  if err := election.Campaign(ctx, e.value); err != nil {
+    return err
+  }
+  lead(true)
+  defer lead(false)
+  select {
+  case <-ctx.Done():
+    resignCtx, cancel := context.WithTimeout(context.Background(),
+      DefaultTimeout)
+    defer cancel()
+    return election.Resign(resignCtx)
+  case <-session.Done():
+    return nil
+  }
+}
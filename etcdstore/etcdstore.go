@@ -0,0 +1,186 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements an EntryStore in etcd, and keeping a Cron in sync with
+// it.
+
+// Package etcdstore implements a cron.EntryStore in etcd, and keeps Crons in
+// sync with the entries in it, so that a fleet of schedulers is managed by
+// writing job definitions to etcd, e.g. from a GitOps pipeline:
+//
+// 	store := etcdstore.New(client, "/myapp/cron/")
+// 	c := cron.New(cron.WithStore(store))
+// 	c.Start()
+// 	go store.Watch(ctx, c)
+//
+// Its Elector elects the one of the schedulers that runs jobs.
+package etcdstore
+
+import (
+  "context"
+  "encoding/json"
+  "fmt"
+  "strings"
+  "time"
+
+  "github.com/kiranbond/cron"
+  clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// DefaultTimeout bounds each request a Store makes, other than watches.
+const DefaultTimeout = 5 * time.Second
+
+// Client is the part of an etcd client used by a Store, implemented by
+// *clientv3.Client.
+type Client interface {
+  clientv3.KV
+  clientv3.Watcher
+}
+
+// Store is a cron.EntryStore keeping each entry as a JSON document in etcd,
+// under the key of its ID prefixed by the Store's prefix.
+type Store struct {
+  client Client
+  prefix string
+
+  // Timeout bounds each request.
+  Timeout time.Duration
+
+  // OnError is called by Watch with the errors of entries it cannot apply,
+  // e.g. whose job is not registered.  By default they are logged to Logger.
+  OnError func(id string, err error)
+
+  // Logger logs the errors of Watch, by default to cron.DefaultLogger.
+  Logger cron.Logger
+}
+
+// New returns a Store keeping entries in client under keys starting with
+// prefix, e.g. "/myapp/cron/".
+func New(client Client, prefix string) *Store {
+  s := &Store{
+    client:  client,
+    prefix:  prefix,
+    Timeout: DefaultTimeout,
+    Logger:  cron.DefaultLogger,
+  }
+  s.OnError = func(id string, err error) {
+    s.Logger.Warningf("cannot apply entry %s: %v", id, err)
+  }
+  return s
+}
+
+// requestContext returns the context of a request.
+func (s *Store) requestContext() (context.Context, context.CancelFunc) {
+  return context.WithTimeout(context.Background(), s.Timeout)
+}
+
+// Save implements cron.EntryStore.
+func (s *Store) Save(entry cron.StoredEntry) error {
+  b, err := json.Marshal(entry)
+  if err != nil {
+    return err
+  }
+  ctx, cancel := s.requestContext()
+  defer cancel()
+  _, err = s.client.Put(ctx, s.prefix+entry.ID, string(b))
+  return err
+}
+
+// Load implements cron.EntryStore, returning the entries ordered by ID.
+func (s *Store) Load() ([]cron.StoredEntry, error) {
+  ctx, cancel := s.requestContext()
+  defer cancel()
+  resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix(),
+    clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+  if err != nil {
+    return nil, err
+  }
+  entries := make([]cron.StoredEntry, 0, len(resp.Kvs))
+  for _, kv := range resp.Kvs {
+    entry, err := s.decode(kv.Key, kv.Value)
+    if err != nil {
+      return nil, err
+    }
+    entries = append(entries, entry)
+  }
+  return entries, nil
+}
+
+// Delete implements cron.EntryStore.
+func (s *Store) Delete(id string) error {
+  ctx, cancel := s.requestContext()
+  defer cancel()
+  _, err := s.client.Delete(ctx, s.prefix+id)
+  return err
+}
+
+// decode returns the entry stored at the key.
+func (s *Store) decode(key, value []byte) (cron.StoredEntry, error) {
+  var entry cron.StoredEntry
+  if err := json.Unmarshal(value, &entry); err != nil {
+    return entry, fmt.Errorf("cannot decode entry %s: %s", key, err)
+  }
+  return entry, nil
+}
+
+// Watch keeps c in sync with the entries of the store until ctx is canceled,
+// or watching fails: it applies the stored entries to c, removing the entries
+// added with AddRegistered that are not stored, and then applies the changes
+// to them as they are made, adding, updating or removing the entries of c.
+// c should be created WithStore(s), so that the state of its entries is
+// stored too.  Watch returns the error that ended it.
+func (s *Store) Watch(ctx context.Context, c *cron.Cron) error {
+  resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix())
+  if err != nil {
+    return err
+  }
+  stored := map[string]bool{}
+  for _, kv := range resp.Kvs {
+    id := s.apply(c, kv.Key, kv.Value)
+    stored[id] = true
+  }
+  c.RemoveIf(func(e cron.Entry) bool {
+    return e.JobName != "" && !stored[e.ID]
+  })
+
+  changes := s.client.Watch(ctx, s.prefix, clientv3.WithPrefix(),
+    clientv3.WithRev(resp.Header.Revision+1))
+  for wresp := range changes {
+    if err := wresp.Err(); err != nil {
+      return err
+    }
+    for _, ev := range wresp.Events {
+      switch ev.Type {
+      case clientv3.EventTypePut:
+        s.apply(c, ev.Kv.Key, ev.Kv.Value)
+      case clientv3.EventTypeDelete:
+        c.DeleteJob(strings.TrimPrefix(string(ev.Kv.Key), s.prefix))
+      }
+    }
+  }
+  return ctx.Err()
+}
+
+// apply applies the entry stored at the key to c, and returns its ID.
+func (s *Store) apply(c *cron.Cron, key, value []byte) string {
+  id := strings.TrimPrefix(string(key), s.prefix)
+  entry, err := s.decode(key, value)
+  if err == nil {
+    err = c.Apply(entry)
+  }
+  if err != nil {
+    s.OnError(id, err)
+  }
+  return id
+}
@@ -0,0 +1,268 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the etcd EntryStore.
+
+package etcdstore
+
+import (
+  "bytes"
+  "context"
+  "encoding/json"
+  "errors"
+  "log"
+  "reflect"
+  "sort"
+  "strings"
+  "sync"
+  "testing"
+  "time"
+
+  "github.com/kiranbond/cron"
+  pb "go.etcd.io/etcd/api/v3/etcdserverpb"
+  "go.etcd.io/etcd/api/v3/mvccpb"
+  clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// runs receives the payloads of the runs of "etcd-echo" jobs.
+var runs = make(chan string, 10)
+
+func init() {
+  cron.RegisterJob("etcd-echo", func(payload []byte) (cron.Job, error) {
+    return cron.FuncJob(func() { runs <- string(payload) }), nil
+  })
+}
+
+// fakeClient is an in-memory Client implementing the requests of a Store.
+type fakeClient struct {
+  clientv3.KV
+  clientv3.Watcher
+
+  mu       sync.Mutex
+  rev      int64
+  kvs      map[string]string
+  watchers map[*fakeWatcher]bool
+}
+
+// fakeWatcher is a watch of a fakeClient.
+type fakeWatcher struct {
+  prefix string
+  ch     chan clientv3.WatchResponse
+}
+
+// newStore returns a Store on a fresh fakeClient.
+func newStore(t *testing.T) *Store {
+  return New(&fakeClient{
+    kvs:      map[string]string{},
+    watchers: map[*fakeWatcher]bool{},
+  }, "/test/")
+}
+
+// notify sends the event to the watchers of its key.  f.mu must be held.
+func (f *fakeClient) notify(typ mvccpb.Event_EventType, key, value string) {
+  f.rev++
+  ev := &clientv3.Event{Type: typ, Kv: &mvccpb.KeyValue{Key: []byte(key),
+    Value: []byte(value), ModRevision: f.rev}}
+  for w := range f.watchers {
+    if strings.HasPrefix(key, w.prefix) {
+      w.ch <- clientv3.WatchResponse{Events: []*clientv3.Event{ev}}
+    }
+  }
+}
+
+func (f *fakeClient) Put(ctx context.Context, key, value string,
+  opts ...clientv3.OpOption) (*clientv3.PutResponse, error) {
+
+  f.mu.Lock()
+  defer f.mu.Unlock()
+  f.kvs[key] = value
+  f.notify(mvccpb.PUT, key, value)
+  return &clientv3.PutResponse{Header: &pb.ResponseHeader{Revision: f.rev}},
+    nil
+}
+
+func (f *fakeClient) Get(ctx context.Context, key string,
+  opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+
+  f.mu.Lock()
+  defer f.mu.Unlock()
+  prefix := len(clientv3.OpGet(key, opts...).RangeBytes()) > 0
+  var keys []string
+  for k := range f.kvs {
+    if k == key || prefix && strings.HasPrefix(k, key) {
+      keys = append(keys, k)
+    }
+  }
+  sort.Strings(keys)
+  resp := &clientv3.GetResponse{Header: &pb.ResponseHeader{Revision: f.rev}}
+  for _, k := range keys {
+    resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: []byte(k),
+      Value: []byte(f.kvs[k])})
+  }
+  return resp, nil
+}
+
+func (f *fakeClient) Delete(ctx context.Context, key string,
+  opts ...clientv3.OpOption) (*clientv3.DeleteResponse, error) {
+
+  f.mu.Lock()
+  defer f.mu.Unlock()
+  if _, ok := f.kvs[key]; ok {
+    delete(f.kvs, key)
+    f.notify(mvccpb.DELETE, key, "")
+  }
+  return &clientv3.DeleteResponse{Header: &pb.ResponseHeader{Revision: f.rev}},
+    nil
+}
+
+// Watch watches the keys starting with key, from the next revision.
+func (f *fakeClient) Watch(ctx context.Context, key string,
+  opts ...clientv3.OpOption) clientv3.WatchChan {
+
+  f.mu.Lock()
+  defer f.mu.Unlock()
+  w := &fakeWatcher{prefix: key, ch: make(chan clientv3.WatchResponse, 100)}
+  f.watchers[w] = true
+  go func() {
+    <-ctx.Done()
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    delete(f.watchers, w)
+    close(w.ch)
+  }()
+  return w.ch
+}
+
+func TestStore(t *testing.T) {
+  s := newStore(t)
+
+  prev := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  a := cron.StoredEntry{ID: "a", Name: "report", Spec: "@daily",
+    Labels: map[string]string{"team": "infra"}, Job: "report",
+    Payload: []byte(`{"to":"ops"}`), Prev: prev, Next: prev.Add(24 * time.Hour)}
+  b := cron.StoredEntry{ID: "b", Spec: "@hourly", Job: "ping"}
+  for _, e := range []cron.StoredEntry{b, a, b} {
+    if err := s.Save(e); err != nil {
+      t.Fatal(err)
+    }
+  }
+  entries, err := s.Load()
+  if err != nil {
+    t.Fatal(err)
+  }
+  if expected := []cron.StoredEntry{a, b}; !reflect.DeepEqual(entries,
+    expected) {
+    t.Errorf("(expected) %+v != %+v (actual)", expected, entries)
+  }
+
+  if err := s.Delete("a"); err != nil {
+    t.Fatal(err)
+  }
+  if entries, _ := s.Load(); len(entries) != 1 || entries[0].ID != "b" {
+    t.Errorf("expected only b, got %+v", entries)
+  }
+}
+
+func TestWatch(t *testing.T) {
+  s := newStore(t)
+  put := func(e cron.StoredEntry) {
+    t.Helper()
+    b, _ := json.Marshal(e)
+    if _, err := s.client.Put(context.Background(), s.prefix+e.ID,
+      string(b)); err != nil {
+      t.Fatal(err)
+    }
+  }
+  put(cron.StoredEntry{ID: "a", Spec: "@hourly", Job: "etcd-echo"})
+
+  c := cron.New(cron.WithStore(s))
+  defer c.Stop()
+  // stale was removed from etcd while c was not watching.
+  stale, err := c.AddRegistered("@hourly", "etcd-echo", nil)
+  if err != nil {
+    t.Fatal(err)
+  }
+  s.Delete(stale)
+  c.AddFunc("@hourly", func() {})
+
+  ctx, cancel := context.WithCancel(context.Background())
+  defer cancel()
+  done := make(chan error, 1)
+  go func() { done <- s.Watch(ctx, c) }()
+
+  // waitFor fails the test unless the entries of c come to satisfy cond.
+  waitFor := func(what string, cond func(map[string]*cron.Entry) bool) {
+    t.Helper()
+    deadline := time.Now().Add(5 * time.Second)
+    for {
+      entries := map[string]*cron.Entry{}
+      for _, e := range c.Entries() {
+        entries[e.ID] = e
+      }
+      if cond(entries) {
+        return
+      }
+      if time.Now().After(deadline) {
+        t.Fatalf("expected %s, got %v", what, entries)
+      }
+      time.Sleep(10 * time.Millisecond)
+    }
+  }
+
+  waitFor("a synced", func(m map[string]*cron.Entry) bool {
+    return len(m) == 2 && m["a"] != nil && m["a"].Spec == "@hourly"
+  })
+
+  put(cron.StoredEntry{ID: "b", Spec: "@daily", Job: "etcd-echo",
+    Payload: []byte("b")})
+  waitFor("b added", func(m map[string]*cron.Entry) bool {
+    return m["b"] != nil
+  })
+
+  put(cron.StoredEntry{ID: "a", Spec: "@weekly", Job: "etcd-echo"})
+  waitFor("a updated", func(m map[string]*cron.Entry) bool {
+    return m["a"] != nil && m["a"].Spec == "@weekly"
+  })
+
+  s.client.Delete(context.Background(), s.prefix+"a")
+  waitFor("a removed", func(m map[string]*cron.Entry) bool {
+    return m["a"] == nil && m["b"] != nil
+  })
+
+  c.RunNow("b")
+  if payload := <-runs; payload != "b" {
+    t.Errorf("expected b to run, got %s", payload)
+  }
+
+  cancel()
+  select {
+  case err := <-done:
+    if err != context.Canceled {
+      t.Errorf("expected the watch canceled, got %v", err)
+    }
+  case <-time.After(5 * time.Second):
+    t.Error("expected the watch to end")
+  }
+}
+
+// TestLogger checks that the errors of Watch are logged to the Store's Logger.
+func TestLogger(t *testing.T) {
+  s := newStore(t)
+  var buf bytes.Buffer
+  s.Logger = cron.PrintfLogger(nil, log.New(&buf, "", 0))
+  s.OnError("a", errors.New("no job"))
+  if expected := "cannot apply entry a: no job\n"; buf.String() != expected {
+    t.Errorf("(expected) %q != %q (actual)", expected, buf.String())
+  }
+}
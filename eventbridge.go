@@ -0,0 +1,169 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements parsing of AWS EventBridge (CloudWatch Events)
+// schedule expressions.
+
+package cron
+
+import (
+  "fmt"
+  "strings"
+  "time"
+)
+
+const (
+  awsCronPrefix = "cron("
+  awsRatePrefix = "rate("
+)
+
+// awsDow is the day of week bounds used by EventBridge, where Sunday is 1 and
+// Saturday is 7.
+var awsDow = bounds{1, 7, map[string]uint{
+  "sun": 1,
+  "mon": 2,
+  "tue": 3,
+  "wed": 4,
+  "thu": 5,
+  "fri": 6,
+  "sat": 7,
+}, false}
+
+// parseEventBridge returns the schedule for an EventBridge "cron(...)" or
+// "rate(...)" expression.
+func parseEventBridge(spec string) (Schedule, error) {
+  if !strings.HasSuffix(spec, ")") {
+    return nil, fmt.Errorf("missing closing parenthesis: %s", spec)
+  }
+  if strings.HasPrefix(spec, awsRatePrefix) {
+    return parseAWSRate(spec, spec[len(awsRatePrefix):len(spec)-1])
+  }
+  return parseAWSCron(spec, spec[len(awsCronPrefix):len(spec)-1])
+}
+
+// parseAWSCron parses the six EventBridge cron fields:
+// (minute) (hour) (day of month) (month) (day of week) (year)
+// The schedule always fires on second zero, in UTC as EventBridge does. Only
+// "*" is accepted for the year.  The day of month accepts "L" and "LW", and the
+// day of week "nL", but the nearest weekday ("nW") and nth day of week ("n#m")
+// are not supported.
+func parseAWSCron(spec, expr string) (Schedule, error) {
+  fields := strings.Fields(expr)
+  if len(fields) != 6 {
+    return nil, fmt.Errorf("expected 6 fields, found %d: %s", len(fields),
+      spec)
+  }
+  if fields[5] != "*" && fields[5] != "?" {
+    return nil, fmt.Errorf("year field %s not supported: %s", fields[5], spec)
+  }
+
+  minute, err := getField(fields[0], minutes)
+  if err != nil {
+    return nil, err
+  }
+  hour, err := getField(fields[1], hours)
+  if err != nil {
+    return nil, err
+  }
+  if strings.ContainsAny(fields[2], "Ww") &&
+    strings.ToUpper(fields[2]) != "LW" {
+    return nil, fmt.Errorf("nearest weekday %s not supported: %s", fields[2],
+      spec)
+  }
+  dom, businessDay, err := getDomField(fields[2])
+  if err != nil {
+    return nil, err
+  }
+  month, err := getField(fields[3], months)
+  if err != nil {
+    return nil, err
+  }
+  if strings.Contains(fields[4], "#") {
+    return nil, fmt.Errorf("nth day of week %s not supported: %s", fields[4],
+      spec)
+  }
+  dow, err := getAWSDowField(fields[4])
+  if err != nil {
+    return nil, err
+  }
+
+  return &SpecSchedule{
+    Second:      1 << seconds.min,
+    Minute:      minute,
+    Hour:        hour,
+    Dom:         dom,
+    Month:       month,
+    Dow:         dow,
+    BusinessDay: businessDay,
+    Location:    time.UTC,
+  }, nil
+}
+
+// getAWSDowField is getField for the EventBridge day of week field, numbered
+// 1-7 starting on Sunday, which additionally accepts "nL" (the last day n of
+// the month).  The returned bits number Sunday as 0.
+func getAWSDowField(field string) (uint64, error) {
+  var bits uint64
+  ranges := strings.FieldsFunc(field, func(r rune) bool { return r == ',' })
+  for _, expr := range ranges {
+    if len(expr) > 1 && strings.HasSuffix(strings.ToUpper(expr), "L") {
+      day, err := parseIntOrName(expr[:len(expr)-1], awsDow.names)
+      if err != nil {
+        return 0, err
+      }
+      if day < awsDow.min || day > awsDow.max {
+        return 0, fmt.Errorf("day of week (%d) outside of range %d-%d: %s",
+          day, awsDow.min, awsDow.max, expr)
+      }
+      bits |= lastDowBit << (day - 1)
+      continue
+    }
+    b, err := getRange(expr, awsDow)
+    if err != nil {
+      return 0, err
+    }
+    // Shift 1-7 down to 0-6, keeping the star bit in place.
+    bits |= (b&^starBit)>>1 | b&starBit
+  }
+  return bits, nil
+}
+
+// parseAWSRate parses an EventBridge "rate(value unit)" expression, where unit
+// is one of minute(s), hour(s) or day(s).
+func parseAWSRate(spec, expr string) (Schedule, error) {
+  fields := strings.Fields(expr)
+  if len(fields) != 2 {
+    return nil, fmt.Errorf("expected value and unit: %s", spec)
+  }
+  value, err := mustParseInt(fields[0])
+  if err != nil {
+    return nil, err
+  }
+  if value == 0 {
+    return nil, fmt.Errorf("rate must be positive: %s", spec)
+  }
+
+  var unit time.Duration
+  switch strings.ToLower(fields[1]) {
+  case "minute", "minutes":
+    unit = time.Minute
+  case "hour", "hours":
+    unit = time.Hour
+  case "day", "days":
+    unit = 24 * time.Hour
+  default:
+    return nil, fmt.Errorf("unrecognized rate unit %s: %s", fields[1], spec)
+  }
+  return Every(time.Duration(value) * unit), nil
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements test for EventBridge expressions.
+
+package cron
+
+import (
+  "reflect"
+  "testing"
+  "time"
+)
+
+func TestEventBridgeNext(t *testing.T) {
+  runs := []struct {
+    time, spec string
+    expected   string
+  }{
+    {"Mon Jul 9 11:45 2012", "cron(0 12 * * ? *)", "Mon Jul 9 12:00 2012"},
+    {"Mon Jul 9 12:00 2012", "cron(0 12 * * ? *)", "Tue Jul 10 12:00 2012"},
+    {"Mon Jul 9 12:00 2012", "cron(15 10 ? * 6 *)", "Fri Jul 13 10:15 2012"},
+    {"Mon Jul 9 12:00 2012", "cron(0/30 8-17 ? * MON-FRI *)", "Mon Jul 9 12:30 2012"},
+    {"Fri Jul 13 18:00 2012", "cron(0 8 ? * 2-6 *)", "Mon Jul 16 08:00 2012"},
+    {"Mon Jul 9 12:00 2012", "cron(0 9 1 * ? *)", "Wed Aug 1 09:00 2012"},
+
+    // Last days of the month and of the week.
+    {"Mon Jul 9 12:00 2012", "cron(0 9 L * ? *)", "Tue Jul 31 09:00 2012"},
+    {"Mon Jul 9 12:00 2012", "cron(0 9 LW * ? *)", "Tue Jul 31 09:00 2012"},
+    {"Mon Jul 9 12:00 2012", "cron(0 9 ? * 6L *)", "Fri Jul 27 09:00 2012"},
+    {"Mon Jul 9 12:00 2012", "cron(0 9 ? * 1L,SATL *)", "Sat Jul 28 09:00 2012"},
+  }
+
+  for _, c := range runs {
+    sched, err := Parse(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    actual := sched.Next(getTime(c.time))
+    expected := getTime(c.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s, \"%s\": (expected) %v != %v (actual)", c.time, c.spec, expected, actual)
+    }
+  }
+}
+
+func TestEventBridgeRate(t *testing.T) {
+  entries := []struct {
+    expr     string
+    expected Schedule
+  }{
+    {"rate(1 minute)", ConstantDelaySchedule{Delay: time.Minute}},
+    {"rate(5 minutes)", ConstantDelaySchedule{Delay: 5 * time.Minute}},
+    {"rate(12 hours)", ConstantDelaySchedule{Delay: 12 * time.Hour}},
+    {"rate(7 days)", ConstantDelaySchedule{Delay: 7 * 24 * time.Hour}},
+  }
+
+  for _, c := range entries {
+    actual, err := Parse(c.expr)
+    if err != nil {
+      t.Error(err)
+    }
+    if !reflect.DeepEqual(actual, c.expected) {
+      t.Errorf("%s => (expected) %v != %v (actual)", c.expr, c.expected, actual)
+    }
+  }
+}
+
+func TestEventBridgeErrors(t *testing.T) {
+  invalidSpecs := []string{
+    "cron(0 12 * * ?)",
+    "cron(0 12 * * ? 2020)",
+    "cron(0 12 * * 8 *)",
+    "cron(0 12 15W * ? *)",
+    "cron(0 12 ? * 6#3 *)",
+    "cron(0 12 ? * 8L *)",
+    "cron(0 12 * * ? *",
+    "rate(5)",
+    "rate(0 minutes)",
+    "rate(5 weeks)",
+  }
+  for _, spec := range invalidSpecs {
+    _, err := Parse(spec)
+    if err == nil {
+      t.Error("expected an error parsing: ", spec)
+    }
+  }
+}
+
+// TestEventBridgeUTC checks that EventBridge cron expressions are in UTC, as on
+// EventBridge, wherever they are evaluated, unless parsed in a location.
+func TestEventBridgeUTC(t *testing.T) {
+  ny, err := time.LoadLocation("America/New_York")
+  if err != nil {
+    t.Skip(err)
+  }
+  sched, err := Parse("cron(0 12 * * ? *)")
+  if err != nil {
+    t.Fatal(err)
+  }
+  now := time.Date(2012, 7, 9, 6, 0, 0, 0, ny)
+  expected := time.Date(2012, 7, 9, 12, 0, 0, 0, time.UTC)
+  if actual := sched.Next(now); !actual.Equal(expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, actual)
+  }
+
+  sched, err = ParseInLocation("cron(0 12 * * ? *)", ny)
+  if err != nil {
+    t.Fatal(err)
+  }
+  expected = time.Date(2012, 7, 9, 12, 0, 0, 0, ny)
+  if actual := sched.Next(now); !actual.Equal(expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, actual)
+  }
+}
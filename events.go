@@ -0,0 +1,188 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements the stream of events in the lifecycle of entries and
+// their runs.
+
+package cron
+
+import (
+  "errors"
+  "fmt"
+  "sync"
+  "time"
+)
+
+// EventType is the kind of an Event.
+type EventType int
+
+const (
+  // EntryAdded is emitted when an entry is added to the Cron.
+  EntryAdded EventType = iota
+  // EntryRemoved is emitted when an entry is deleted, or removed because it
+  // will not run again.
+  EntryRemoved
+  // RunScheduled is emitted when the next run of an entry is scheduled.
+  RunScheduled
+  // RunStarted is emitted when a job starts running.
+  RunStarted
+  // RunCompleted is emitted when a job returns successfully.
+  RunCompleted
+  // RunFailed is emitted when a job panics, or an ErrorJob returns an error.
+  RunFailed
+  // RunSkipped is emitted when a scheduled run does not start.
+  RunSkipped
+)
+
+var eventTypeNames = []string{
+  "EntryAdded",
+  "EntryRemoved",
+  "RunScheduled",
+  "RunStarted",
+  "RunCompleted",
+  "RunFailed",
+  "RunSkipped",
+}
+
+// String returns the name of the event type, e.g. "RunStarted".
+func (t EventType) String() string {
+  if t < 0 || int(t) >= len(eventTypeNames) {
+    return fmt.Sprintf("EventType(%d)", int(t))
+  }
+  return eventTypeNames[t]
+}
+
+// The errors of RunSkipped events, giving the reason the run was skipped.
+var (
+  ErrStillRunning = errors.New("cron: job is still running")
+  ErrQueueFull    = errors.New("cron: too many jobs are waiting")
+  ErrMisfired     = errors.New("cron: run was missed")
+
+  ErrDependencyFailed = errors.New("cron: dependency did not complete")
+  ErrBlackout         = errors.New("cron: run was within a blackout window")
+  ErrAbandoned        = errors.New("cron: claimed run did not finish")
+)
+
+// Event is an event in the lifecycle of an entry or one of its runs.
+type Event struct {
+  Type EventType
+
+  // EntryID is the ID of the entry.
+  EntryID string
+
+  // Time is when the event happened.
+  Time time.Time
+
+  // Scheduled is the time the run was scheduled for, for events of runs.  For
+  // runs started by RunNow it is the time RunNow was called.
+  Scheduled time.Time
+
+  // Duration is how long the job ran, for RunCompleted and RunFailed.
+  Duration time.Duration
+
+  // Err is the error of a RunFailed event, or the reason for a RunSkipped one.
+  Err error
+
+  // Output is the output the job of a RunCompleted or RunFailed event
+  // recorded with SetOutput.
+  Output string
+}
+
+// Subscribe calls handler with every event of the Cron until the returned
+// function is called.  Handlers are called synchronously, from the scheduler,
+// the methods of the Cron and running jobs, so they must not block; a handler
+// that needs to do slow work, such as writing to a database, should hand
+// events off to another goroutine.
+func (c *Cron) Subscribe(handler func(Event)) (unsubscribe func()) {
+  return c.events.subscribe(handler)
+}
+
+// emit queues an event of the entry, timestamped now, to be sent to the
+// subscribers once c.mu is unlocked, so that handlers may call the Cron.  c.mu
+// must be held.
+func (c *Cron) emit(typ EventType, id string, scheduled time.Time,
+  duration time.Duration, err error) {
+  c.queued = append(c.queued, c.event(typ, id, scheduled, duration, err))
+}
+
+// emitNow sends an event of the entry to the subscribers, timestamped now.
+// c.mu must not be held.
+func (c *Cron) emitNow(typ EventType, id string, scheduled time.Time,
+  duration time.Duration, err error) {
+  c.events.emit(c.event(typ, id, scheduled, duration, err))
+}
+
+// emitFinished sends the RunCompleted or RunFailed event of a run, with the
+// output its job recorded, to the subscribers.  c.mu must not be held.
+func (c *Cron) emitFinished(typ EventType, id string, scheduled time.Time,
+  duration time.Duration, err error, output string) {
+  event := c.event(typ, id, scheduled, duration, err)
+  event.Output = output
+  c.events.emit(event)
+}
+
+// event returns an event of the entry, timestamped now.
+func (c *Cron) event(typ EventType, id string, scheduled time.Time,
+  duration time.Duration, err error) Event {
+  return Event{
+    Type:      typ,
+    EntryID:   id,
+    Time:      c.now(),
+    Scheduled: scheduled,
+    Duration:  duration,
+    Err:       err,
+  }
+}
+
+// eventHub holds the subscribers to the events of a Cron.
+type eventHub struct {
+  mu       sync.Mutex
+  next     int
+  handlers []subscriber
+}
+
+// subscriber is a handler added by Subscribe.
+type subscriber struct {
+  id      int
+  handler func(Event)
+}
+
+// subscribe adds the handler, returning a function that removes it.
+func (h *eventHub) subscribe(handler func(Event)) func() {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  id := h.next
+  h.next++
+  h.handlers = append(h.handlers, subscriber{id, handler})
+  return func() {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    for i, s := range h.handlers {
+      if s.id == id {
+        h.handlers = append(h.handlers[:i:i], h.handlers[i+1:]...)
+        return
+      }
+    }
+  }
+}
+
+// emit calls every handler with the event, in the order they subscribed.
+func (h *eventHub) emit(event Event) {
+  h.mu.Lock()
+  handlers := h.handlers
+  h.mu.Unlock()
+  for _, s := range handlers {
+    s.handler(event)
+  }
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the event stream.
+
+package cron
+
+import (
+  "context"
+  "errors"
+  "testing"
+  "time"
+)
+
+// nextEvent returns the next event of the given type, skipping others.
+func nextEvent(t *testing.T, events <-chan Event, typ EventType) Event {
+  for {
+    select {
+    case e := <-events:
+      if e.Type == typ {
+        return e
+      }
+    case <-time.After(cOneSecond):
+      t.Fatalf("expected a %v event", typ)
+    }
+  }
+}
+
+func TestEvents(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC),
+    WithLogger(DiscardLogger))
+  events := make(chan Event, 100)
+  cron.Subscribe(func(e Event) { events <- e })
+
+  boom := errors.New("boom")
+  fail := true
+  id := cron.Schedule(Every(time.Minute), ErrorFuncJob(
+    func(context.Context) error {
+      if fail {
+        return boom
+      }
+      return nil
+    }))
+  cron.Start()
+  defer cron.Stop()
+
+  if e := nextEvent(t, events, EntryAdded); e.EntryID != id {
+    t.Errorf("expected entry %s, got %s", id, e.EntryID)
+  }
+  first := start.Add(time.Minute)
+  if e := nextEvent(t, events, RunScheduled); !e.Scheduled.Equal(first) {
+    t.Errorf("expected run scheduled at %v, got %v", first, e.Scheduled)
+  }
+
+  clock.Advance(time.Minute)
+  if e := nextEvent(t, events, RunStarted); !e.Scheduled.Equal(first) {
+    t.Errorf("expected run scheduled at %v, got %v", first, e.Scheduled)
+  }
+  if e := nextEvent(t, events, RunFailed); e.Err != boom {
+    t.Errorf("expected error %v, got %v", boom, e.Err)
+  }
+
+  fail = false
+  clock.Advance(time.Minute)
+  second := start.Add(2 * time.Minute)
+  if e := nextEvent(t, events, RunCompleted); !e.Scheduled.Equal(second) ||
+    e.Err != nil {
+    t.Errorf("unexpected event %+v", e)
+  }
+
+  cron.DeleteJob(id)
+  if e := nextEvent(t, events, EntryRemoved); e.EntryID != id {
+    t.Errorf("expected entry %s, got %s", id, e.EntryID)
+  }
+}
+
+func TestRunSkippedEvent(t *testing.T) {
+  cron := New(WithLogger(DiscardLogger))
+  events := make(chan Event, 100)
+  cron.Subscribe(func(e Event) { events <- e })
+
+  release := make(chan struct{})
+  defer close(release)
+  id, _ := cron.AddFunc("@yearly", func() { <-release },
+    WithConcurrencyPolicy(ForbidConcurrent))
+  cron.RunNow(id)
+  nextEvent(t, events, RunStarted)
+  cron.RunNow(id)
+  if e := nextEvent(t, events, RunSkipped); e.Err != ErrStillRunning {
+    t.Errorf("expected error %v, got %v", ErrStillRunning, e.Err)
+  }
+}
+
+func TestUnsubscribe(t *testing.T) {
+  cron := New()
+  var first, second int
+  unsubscribe := cron.Subscribe(func(Event) { first++ })
+  cron.Subscribe(func(Event) { second++ })
+
+  cron.AddFunc("@yearly", func() {})
+  unsubscribe()
+  cron.AddFunc("@yearly", func() {})
+  cron.Entries()
+
+  // Each AddFunc emits EntryAdded and RunScheduled.
+  if first != 2 || second != 4 {
+    t.Errorf("expected 2 and 4 events, got %d and %d", first, second)
+  }
+}
+
+func TestEventTypeString(t *testing.T) {
+  if s := RunFailed.String(); s != "RunFailed" {
+    t.Errorf("expected RunFailed, got %s", s)
+  }
+  if s := EventType(42).String(); s != "EventType(42)" {
+    t.Errorf("expected EventType(42), got %s", s)
+  }
+}
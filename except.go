@@ -0,0 +1,48 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements exclusion schedule.
+
+package cron
+
+import "time"
+
+// ExceptSchedule represents the activations of Schedule that are not also
+// activations of Except.
+type ExceptSchedule struct {
+  Schedule Schedule
+  Except   Schedule
+}
+
+// maxExceptActivations bounds the activations of Schedule that ExceptSchedule
+// checks, so that exclusions covering frequent schedules do not spin for years
+// of activations.
+const maxExceptActivations = 1000000
+
+// Next returns the next activation time of Schedule, later than the given time,
+// that Except does not activate at.  If none is found within five years, or
+// among the next million activations of Schedule, it returns the zero time.
+func (s *ExceptSchedule) Next(t time.Time) time.Time {
+  yearLimit := t.Year() + 5
+  for i := 0; i < maxExceptActivations; i++ {
+    t = s.Schedule.Next(t)
+    if t.IsZero() || t.Year() > yearLimit {
+      return time.Time{}
+    }
+    if !s.Except.Next(t.Add(-time.Nanosecond)).Equal(t) {
+      return t
+    }
+  }
+  return time.Time{}
+}
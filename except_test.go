@@ -0,0 +1,67 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements exclusion schedule test.
+
+package cron
+
+import "testing"
+
+func TestExceptNext(t *testing.T) {
+  runs := []struct {
+    time, spec string
+    expected   string
+  }{
+    {"Mon Jul 9 00:30 2012", "@hourly except 0 0 2-4 * * *", "Mon Jul 9 01:00 2012"},
+    {"Mon Jul 9 01:00 2012", "@hourly except 0 0 2-4 * * *", "Mon Jul 9 05:00 2012"},
+    {"Mon Jul 9 01:00 2012", "@hourly except 0 0 2-4 * * * except 0 0 5 * * *", "Mon Jul 9 06:00 2012"},
+    {"Fri Jul 13 09:00 2012", "0 0 9 * * * except 0 0 * * * SAT,SUN", "Mon Jul 16 09:00 2012"},
+    {"Fri Jul 13 09:00 2012", "0 0 9 * * * except 0 0 * * * SAT || 0 0 * * * SUN", "Mon Jul 16 09:00 2012"},
+    {"Fri Jul 13 08:00 2012", "0 0 9 * * * || 0 0 10 * * * except 0 0 9 * * *", "Fri Jul 13 10:00 2012"},
+
+    // Everything excluded.
+    {"Mon Jul 9 01:00 2012", "@hourly except 0 0 * * * *", ""},
+    {"Mon Jul 9 01:00 2012", "* * * * * * except * * * * * *", ""},
+
+    // Long runs of exclusions are skipped.
+    {"Fri Jul 13 09:00 2012", "* * * * * * except * * * * * MON-FRI", "Sat Jul 14 00:00 2012"},
+  }
+
+  for _, c := range runs {
+    sched, err := Parse(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    actual := sched.Next(getTime(c.time))
+    expected := getTime(c.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s, \"%s\": (expected) %v != %v (actual)", c.time, c.spec, expected, actual)
+    }
+  }
+}
+
+func TestExceptErrors(t *testing.T) {
+  invalidSpecs := []string{
+    "@hourly except ",
+    " except @hourly",
+    "@hourly except 0 60 * * * *",
+  }
+  for _, spec := range invalidSpecs {
+    _, err := Parse(spec)
+    if err == nil {
+      t.Error("expected an error parsing: ", spec)
+    }
+  }
+}
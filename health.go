@@ -0,0 +1,136 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements detecting a stalled scheduler.
+
+package cron
+
+import (
+  "fmt"
+  "sync"
+  "time"
+)
+
+// DefaultStallThreshold is how late the scheduler may dispatch due runs before
+// it is considered stalled, unless the Cron is created with WithWatchdog.
+const DefaultStallThreshold = time.Minute
+
+// WithWatchdog checks the health of the scheduler every half threshold, and
+// calls onStall with the error of Healthy when the scheduler stalls, e.g. to
+// exit so that the process is restarted.  If onStall is nil, the error is
+// logged.  Healthy reports a stall once due runs are threshold late.  If
+// threshold is not positive, DefaultStallThreshold is used.
+func WithWatchdog(threshold time.Duration, onStall func(error)) Option {
+  return func(c *Cron) {
+    c.stallThreshold = threshold
+    if threshold <= 0 {
+      c.stallThreshold = DefaultStallThreshold
+    }
+    c.onStall = onStall
+    if c.onStall == nil {
+      c.onStall = func(err error) {
+        c.logger.Warningf("scheduler stalled: %v", err)
+      }
+    }
+  }
+}
+
+// health tracks the progress of the scheduler goroutine.  It has its own lock,
+// so that it can be checked while c.mu is held by a wedged scheduler.
+type health struct {
+  mu sync.Mutex
+
+  // due is the activation the scheduler is waiting for, or the zero time if
+  // none.
+  due time.Time
+
+  // ticking is when the scheduler started dispatching the runs due, or the
+  // zero time if it is not, and lastTick when it last finished.  lag is how
+  // late it last started.
+  ticking, lastTick time.Time
+  lag               time.Duration
+}
+
+// wait records that the scheduler is waiting for the activation due.
+func (h *health) wait(due time.Time) {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  h.due = due
+}
+
+// tick records that the scheduler started dispatching the runs due at the
+// given time.
+func (h *health) tick(now, due time.Time) {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  h.ticking, h.lag = now, now.Sub(due)
+}
+
+// ticked records that the scheduler finished dispatching runs.
+func (h *health) ticked(now time.Time) {
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  h.ticking, h.lastTick = time.Time{}, now
+}
+
+// LastTick returns the time the scheduler last finished dispatching due runs,
+// or the zero time if it never has.  It may be long ago if no runs were due.
+func (c *Cron) LastTick() time.Time {
+  c.health.mu.Lock()
+  defer c.health.mu.Unlock()
+  return c.health.lastTick
+}
+
+// Healthy returns an error if the scheduler is stalled: it has been
+// dispatching due runs for longer than the stall threshold, e.g. because an
+// event handler blocks, runs due longer ago than that were not dispatched, or
+// the runs it dispatched within the threshold were dispatched that late.  A
+// stopped Cron is healthy.  Healthy does not wait for the Cron's lock, so it
+// may be called from readiness and liveness probes while the scheduler is
+// wedged.
+func (c *Cron) Healthy() error {
+  now := c.clock.Now()
+  h := &c.health
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  switch {
+  case !h.ticking.IsZero() && now.Sub(h.ticking) > c.stallThreshold:
+    return fmt.Errorf("cron: dispatching runs for %v", now.Sub(h.ticking))
+  case h.ticking.IsZero() && !h.due.IsZero() &&
+    now.Sub(h.due) > c.stallThreshold:
+    return fmt.Errorf("cron: runs due at %v were not dispatched", h.due)
+  case h.lag > c.stallThreshold && now.Sub(h.lastTick) <= c.stallThreshold:
+    return fmt.Errorf("cron: runs were dispatched %v late", h.lag)
+  }
+  return nil
+}
+
+// watch calls onStall when the scheduler stalls, until quit is closed.
+func (c *Cron) watch(quit <-chan struct{}) {
+  stalled := false
+  for {
+    timer := c.clock.Timer(c.clock.Now().Add(c.stallThreshold / 2))
+    select {
+    case <-timer.C():
+    case <-quit:
+      timer.Stop()
+      return
+    }
+    err := c.Healthy()
+    if err != nil && !stalled {
+      c.onStall(err)
+    }
+    stalled = err != nil
+  }
+}
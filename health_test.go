@@ -0,0 +1,121 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for detecting a stalled scheduler.
+
+package cron
+
+import (
+  "sync/atomic"
+  "testing"
+  "time"
+)
+
+func TestHealthy(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  stalls := make(chan error, 10)
+  cron := New(WithClock(clock), WithLocation(time.UTC),
+    WithWatchdog(time.Minute, func(err error) { stalls <- err }))
+  defer cron.Close()
+
+  // An event handler that blocks wedges the scheduler.
+  var wedge atomic.Bool
+  wedged := make(chan struct{})
+  release := make(chan struct{})
+  cron.Subscribe(func(e Event) {
+    if e.Type == RunScheduled && wedge.Load() {
+      wedge.Store(false)
+      close(wedged)
+      <-release
+    }
+  })
+  ran := make(chan struct{}, 10)
+  cron.AddFunc("@hourly", func() { ran <- struct{}{} })
+  cron.Start()
+  defer cron.Stop()
+
+  if err := cron.Healthy(); err != nil {
+    t.Errorf("expected a healthy Cron, got %v", err)
+  }
+  if !cron.LastTick().IsZero() {
+    t.Errorf("expected no tick, got %v", cron.LastTick())
+  }
+
+  clock.Advance(time.Hour)
+  <-ran
+  waitFor(t, func() bool { return cron.LastTick().Equal(start.Add(time.Hour)) })
+
+  wedge.Store(true)
+  clock.Advance(time.Hour)
+  <-wedged
+  if err := cron.Healthy(); err != nil {
+    t.Errorf("expected a healthy Cron before the threshold, got %v", err)
+  }
+
+  // Advance until the watchdog notices, whenever its timer was set.
+  var stall error
+  for stall == nil {
+    clock.Advance(30 * time.Second)
+    select {
+    case stall = <-stalls:
+    case <-time.After(10 * time.Millisecond):
+    }
+    if clock.Now().Sub(start) > 3*time.Hour {
+      t.Fatal("expected the watchdog to report the stall")
+    }
+  }
+  if err := cron.Healthy(); err == nil {
+    t.Error("expected a stalled Cron")
+  }
+
+  close(release)
+  waitFor(t, func() bool { return cron.Healthy() == nil })
+}
+
+func TestHealthyLag(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  defer cron.Close()
+  ran := make(chan struct{}, 10)
+  cron.AddFunc("@hourly", func() { ran <- struct{}{} })
+  cron.Start()
+  defer cron.Stop()
+
+  // The scheduler wakes up late, as after the machine was suspended.
+  clock.Advance(time.Hour + 2*DefaultStallThreshold)
+  <-ran
+  waitFor(t, func() bool { return !cron.LastTick().IsZero() })
+  if err := cron.Healthy(); err == nil {
+    t.Error("expected the late dispatch to be reported")
+  }
+
+  clock.Advance(DefaultStallThreshold + time.Second)
+  if err := cron.Healthy(); err != nil {
+    t.Errorf("expected the late dispatch to be forgotten, got %v", err)
+  }
+}
+
+// waitFor fails the test unless cond becomes true within a second.
+func waitFor(t *testing.T, cond func() bool) {
+  t.Helper()
+  deadline := time.Now().Add(cOneSecond)
+  for !cond() {
+    if time.Now().After(deadline) {
+      t.Fatal("timed out waiting for condition")
+    }
+    time.Sleep(time.Millisecond)
+  }
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements the queue of entries ordered by their next activation.
+
+package cron
+
+import "container/heap"
+
+// entryHeap is a min-heap of entries by their next activation time, with zero
+// times last, so that the next entry to run is found in constant time and
+// entries are added, removed and rescheduled in logarithmic time.  Each entry
+// records its position in the heap in its index.
+type entryHeap []*Entry
+
+func (h entryHeap) Len() int           { return len(h) }
+func (h entryHeap) Less(i, j int) bool { return byTime(h).Less(i, j) }
+
+func (h entryHeap) Swap(i, j int) {
+  h[i], h[j] = h[j], h[i]
+  h[i].index = i
+  h[j].index = j
+}
+
+// Push adds an entry; use heap.Push.
+func (h *entryHeap) Push(x interface{}) {
+  e := x.(*Entry)
+  e.index = len(*h)
+  *h = append(*h, e)
+}
+
+// Pop removes the last entry; use heap.Pop.
+func (h *entryHeap) Pop() interface{} {
+  old := *h
+  e := old[len(old)-1]
+  old[len(old)-1] = nil
+  *h = old[:len(old)-1]
+  e.index = -1
+  return e
+}
+
+// push adds the entry.
+func (h *entryHeap) push(e *Entry) { heap.Push(h, e) }
+
+// pop removes and returns the entry that runs first.
+func (h *entryHeap) pop() *Entry { return heap.Pop(h).(*Entry) }
+
+// remove removes the entry.
+func (h *entryHeap) remove(e *Entry) { heap.Remove(h, e.index) }
+
+// fix restores the order of the heap after the entry's next time changed.
+func (h *entryHeap) fix(e *Entry) { heap.Fix(h, e.index) }
+
+// reset replaces the entries of the heap, e.g. after their times were
+// recomputed or some were filtered out.
+func (h *entryHeap) reset(entries []*Entry) {
+  *h = entries
+  for i, e := range entries {
+    e.index = i
+  }
+  heap.Init(h)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the entry heap.
+
+package cron
+
+import (
+  "math/rand"
+  "testing"
+  "time"
+)
+
+// checkIndexes fails the test if an entry's index is not its position.
+func checkIndexes(t *testing.T, h entryHeap) {
+  for i, e := range h {
+    if e.index != i {
+      t.Fatalf("entry %s at %d has index %d", e.ID, i, e.index)
+    }
+  }
+}
+
+func TestEntryHeap(t *testing.T) {
+  start := getTime("Mon Jul 9 12:00 2012")
+  var h entryHeap
+  var removed, moved *Entry
+  for i := 0; i < 100; i++ {
+    e := &Entry{ID: string(rune('a' + i%26))}
+    if i%10 != 0 {
+      e.Next = start.Add(time.Duration(rand.Intn(1000)) * time.Second)
+    }
+    h.push(e)
+    switch i {
+    case 42:
+      removed = e
+    case 43:
+      moved = e
+    }
+  }
+  checkIndexes(t, h)
+
+  h.remove(removed)
+  moved.Next = start.Add(-time.Hour)
+  h.fix(moved)
+  checkIndexes(t, h)
+
+  if first := h.pop(); first != moved {
+    t.Errorf("expected the moved entry first, got %+v", first)
+  }
+  var prev time.Time
+  for n := 0; len(h) > 0; n++ {
+    e := h.pop()
+    if e == removed {
+      t.Fatal("popped the removed entry")
+    }
+    switch {
+    case n < 88 && (e.Next.IsZero() || e.Next.Before(prev)):
+      t.Fatalf("entry %d out of order: %v after %v", n, e.Next, prev)
+    case n >= 88 && !e.Next.IsZero():
+      t.Fatalf("expected zero times last, got %v at %d", e.Next, n)
+    }
+    prev = e.Next
+  }
+}
+
+func TestEntryHeapReset(t *testing.T) {
+  start := getTime("Mon Jul 9 12:00 2012")
+  entries := []*Entry{
+    {Next: start.Add(3 * time.Second)},
+    {},
+    {Next: start.Add(time.Second)},
+    {Next: start.Add(2 * time.Second)},
+  }
+  var h entryHeap
+  h.reset(entries)
+  checkIndexes(t, h)
+  for i := 1; i <= 3; i++ {
+    if next := h.pop().Next; !next.Equal(start.Add(time.Duration(i) *
+      time.Second)) {
+      t.Errorf("expected %v, got %v", start.Add(time.Duration(i)*time.Second),
+        next)
+    }
+  }
+  if !h.pop().Next.IsZero() {
+    t.Error("expected the zero time last")
+  }
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements the history of recent runs kept for each entry.
+
+package cron
+
+import (
+  "fmt"
+  "sync"
+  "time"
+)
+
+// DefaultRunHistory is the number of runs recorded per entry, unless the Cron
+// is created with WithRunHistory.
+const DefaultRunHistory = 10
+
+// RunRecord describes a run of an entry's job, or a scheduled run that was
+// skipped.
+type RunRecord struct {
+  // Scheduled is the activation the run was scheduled for, or the time it was
+  // started by RunNow.
+  Scheduled time.Time
+
+  // Started is the time the job started, and Duration how long it ran.  They
+  // are zero for skipped runs.
+  Started  time.Time
+  Duration time.Duration
+
+  // Outcome is RunCompleted, RunFailed or RunSkipped.
+  Outcome EventType
+
+  // Err is the error of a failed run, or the reason a run was skipped.
+  Err error
+
+  // Panic is the value the job panicked with, if it did.
+  Panic interface{}
+
+  // Output is the output the job recorded with SetOutput.
+  Output string
+}
+
+// WithRunHistory records the last n runs of each entry, instead of
+// DefaultRunHistory.  If n is not positive, no runs are recorded.
+func WithRunHistory(n int) Option {
+  return func(c *Cron) {
+    c.historySize = n
+  }
+}
+
+// runHistory is a ring buffer of the most recent runs of an entry.  A nil
+// runHistory records nothing.
+type runHistory struct {
+  mu      sync.Mutex
+  records []RunRecord
+
+  // oldest is the index of the oldest record, once the buffer is full.
+  oldest int
+}
+
+// newRunHistory returns a runHistory keeping the last n runs, or nil if n is
+// not positive.
+func newRunHistory(n int) *runHistory {
+  if n <= 0 {
+    return nil
+  }
+  return &runHistory{records: make([]RunRecord, 0, n)}
+}
+
+// add records the run, replacing the oldest one if the buffer is full.
+func (h *runHistory) add(r RunRecord) {
+  if h == nil {
+    return
+  }
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  if len(h.records) < cap(h.records) {
+    h.records = append(h.records, r)
+    return
+  }
+  h.records[h.oldest] = r
+  h.oldest = (h.oldest + 1) % len(h.records)
+}
+
+// list returns a copy of the records, oldest first.
+func (h *runHistory) list() []RunRecord {
+  if h == nil {
+    return nil
+  }
+  h.mu.Lock()
+  defer h.mu.Unlock()
+  if len(h.records) == 0 {
+    return nil
+  }
+  records := make([]RunRecord, 0, len(h.records))
+  records = append(records, h.records[h.oldest:]...)
+  return append(records, h.records[:h.oldest]...)
+}
+
+// ran records the entry's run in its history.
+func (h *runHistory) ran(scheduled, started time.Time, duration time.Duration,
+  err error, output string) {
+  r := RunRecord{
+    Scheduled: scheduled,
+    Started:   started,
+    Duration:  duration,
+    Outcome:   RunCompleted,
+    Output:    output,
+  }
+  if err != nil {
+    r.Outcome, r.Err = RunFailed, err
+    if p, ok := err.(*panicError); ok {
+      r.Panic = p.value
+    }
+  }
+  h.add(r)
+}
+
+// panicError is the error of a run whose job panicked.
+type panicError struct {
+  value interface{}
+}
+
+func (e *panicError) Error() string {
+  return fmt.Sprintf("panic: %v", e.value)
+}
+
+// skip emits the RunSkipped event of the entry's run scheduled for the given
+// time, and records it in the entry's history and stats, and the Cron's counts.
+// c.mu must be held.
+func (c *Cron) skip(e *Entry, scheduled time.Time, reason error) {
+  c.emit(RunSkipped, e.ID, scheduled, 0, reason)
+  e.stats.skipped()
+  c.skipped++
+  if reason == ErrQueueFull {
+    c.dropped++
+  }
+  e.history.add(RunRecord{
+    Scheduled: scheduled,
+    Outcome:   RunSkipped,
+    Err:       reason,
+  })
+}
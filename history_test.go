@@ -0,0 +1,164 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the history of entries' runs.
+
+package cron
+
+import (
+  "context"
+  "errors"
+  "testing"
+  "time"
+)
+
+func TestRunHistory(t *testing.T) {
+  tests := []struct {
+    size, added int
+    expected    []int
+  }{
+    {0, 3, nil},
+    {3, 0, nil},
+    {3, 2, []int{0, 1}},
+    {3, 3, []int{0, 1, 2}},
+    {3, 4, []int{1, 2, 3}},
+    {3, 8, []int{5, 6, 7}},
+  }
+
+  start := getTime("Mon Jul 9 00:00 2012")
+  for _, c := range tests {
+    h := newRunHistory(c.size)
+    for i := 0; i < c.added; i++ {
+      h.add(RunRecord{Scheduled: start.Add(time.Duration(i) * time.Hour)})
+    }
+    records := h.list()
+    if len(records) != len(c.expected) {
+      t.Errorf("%d of %d: expected %d records, got %d", c.added, c.size,
+        len(c.expected), len(records))
+      continue
+    }
+    for i, r := range records {
+      expected := start.Add(time.Duration(c.expected[i]) * time.Hour)
+      if !r.Scheduled.Equal(expected) {
+        t.Errorf("%d of %d: record %d: (expected) %v != %v (actual)", c.added,
+          c.size, i, expected, r.Scheduled)
+      }
+    }
+  }
+}
+
+func TestEntryHistory(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC), WithRunHistory(3),
+    WithPanicHandler(func(string, interface{}, []byte) {}))
+  done := make(chan struct{}, 10)
+  cron.Subscribe(func(e Event) {
+    if e.Type == RunCompleted || e.Type == RunFailed {
+      done <- struct{}{}
+    }
+  })
+
+  errFailed := errors.New("failed")
+  runs := 0
+  id, _ := cron.AddJob("@hourly", ErrorFuncJob(func(context.Context) error {
+    runs++
+    switch runs {
+    case 2:
+      return errFailed
+    case 3:
+      panic("boom")
+    case 4:
+      clock.Advance(time.Minute)
+    }
+    return nil
+  }))
+  cron.Start()
+  defer cron.Stop()
+
+  for i := 0; i < 4; i++ {
+    clock.Advance(time.Hour)
+    select {
+    case <-done:
+    case <-time.After(cOneSecond):
+      t.Fatalf("expected run %d", i+1)
+    }
+  }
+
+  entry, _ := cron.Entry(id)
+  expected := []RunRecord{
+    {Scheduled: start.Add(2 * time.Hour), Outcome: RunFailed, Err: errFailed},
+    {Scheduled: start.Add(3 * time.Hour), Outcome: RunFailed, Panic: "boom"},
+    {Scheduled: start.Add(4 * time.Hour), Duration: time.Minute,
+      Outcome: RunCompleted},
+  }
+  if len(entry.History) != len(expected) {
+    t.Fatalf("expected %d records, got %+v", len(expected), entry.History)
+  }
+  for i, r := range entry.History {
+    e := expected[i]
+    if !r.Scheduled.Equal(e.Scheduled) || !r.Started.Equal(e.Scheduled) ||
+      r.Duration != e.Duration || r.Outcome != e.Outcome ||
+      (e.Err != nil && r.Err != e.Err) || r.Panic != e.Panic {
+      t.Errorf("record %d: (expected) %+v != %+v (actual)", i, e, r)
+    }
+  }
+}
+
+func TestEntryHistorySkipped(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  started := make(chan struct{}, 10)
+  skipped := make(chan struct{}, 10)
+  cron.Subscribe(func(e Event) {
+    switch e.Type {
+    case RunStarted:
+      started <- struct{}{}
+    case RunSkipped:
+      skipped <- struct{}{}
+    }
+  })
+
+  release := make(chan struct{})
+  id, _ := cron.AddFunc("@hourly", func() { <-release },
+    WithConcurrencyPolicy(ForbidConcurrent))
+  cron.Start()
+  defer cron.Stop()
+  defer close(release)
+
+  clock.Advance(time.Hour)
+  select {
+  case <-started:
+  case <-time.After(cOneSecond):
+    t.Fatal("expected a run")
+  }
+  clock.Advance(time.Hour)
+  select {
+  case <-skipped:
+  case <-time.After(cOneSecond):
+    t.Fatal("expected a skipped run")
+  }
+
+  entry, _ := cron.Entry(id)
+  if len(entry.History) != 1 {
+    t.Fatalf("expected 1 record, got %+v", entry.History)
+  }
+  r := entry.History[0]
+  if r.Outcome != RunSkipped || r.Err != ErrStillRunning ||
+    !r.Scheduled.Equal(start.Add(2*time.Hour)) || !r.Started.IsZero() {
+    t.Errorf("expected the run at %v skipped, got %+v", start.Add(2*time.Hour),
+      r)
+  }
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements holiday calendars, which entries may avoid running on.
+
+package cron
+
+import (
+  "fmt"
+  "time"
+)
+
+// HolidayCalendar reports which days are holidays.
+type HolidayCalendar interface {
+  // IsHoliday returns whether the day of t, in t's location, is a holiday.
+  IsHoliday(t time.Time) bool
+}
+
+// HolidayFunc is a wrapper that turns a func(time.Time) bool into a
+// HolidayCalendar, e.g. to query an external provider.
+type HolidayFunc func(t time.Time) bool
+
+// IsHoliday invokes the function.
+func (f HolidayFunc) IsHoliday(t time.Time) bool { return f(t) }
+
+// StaticHolidays is a HolidayCalendar of a fixed set of dates.
+type StaticHolidays struct {
+  dates  map[civilDate]bool
+  annual map[civilDate]bool
+}
+
+// civilDate is a date without a location.  Its year is 0 for annual holidays.
+type civilDate struct {
+  year  int
+  month time.Month
+  day   int
+}
+
+// NewStaticHolidays returns the HolidayCalendar of the given dates, either
+// "2006-01-02" for a single day or "01-02" for a day every year, e.g.
+// NewStaticHolidays("12-25", "2024-11-28").
+func NewStaticHolidays(dates ...string) (*StaticHolidays, error) {
+  h := &StaticHolidays{
+    dates:  map[civilDate]bool{},
+    annual: map[civilDate]bool{},
+  }
+  for _, date := range dates {
+    if t, err := time.Parse("2006-01-02", date); err == nil {
+      h.dates[civilDate{t.Year(), t.Month(), t.Day()}] = true
+      continue
+    }
+    t, err := time.Parse("01-02", date)
+    if err != nil {
+      return nil, fmt.Errorf("failed to parse holiday %s: %s", date, err)
+    }
+    h.annual[civilDate{0, t.Month(), t.Day()}] = true
+  }
+  return h, nil
+}
+
+// IsHoliday returns whether the day of t is one of the dates.
+func (h *StaticHolidays) IsHoliday(t time.Time) bool {
+  return h.dates[civilDate{t.Year(), t.Month(), t.Day()}] ||
+    h.annual[civilDate{0, t.Month(), t.Day()}]
+}
+
+// BusinessDaysExcept returns the BusinessDays that are weekdays and not
+// holidays, so that the business day fields of spec schedules, e.g. "1B",
+// avoid the holidays too.
+func BusinessDaysExcept(holidays HolidayCalendar) BusinessDays {
+  return BusinessDayFunc(func(t time.Time) bool {
+    return Weekdays.IsBusinessDay(t) && !holidays.IsHoliday(t)
+  })
+}
+
+// HolidayPolicy decides what happens to the activations of an entry that fall
+// on holidays.
+type HolidayPolicy int
+
+const (
+  // HolidaySkip skips the activations.  It is the default.
+  HolidaySkip HolidayPolicy = iota
+
+  // HolidayNextBusinessDay moves the activations to the same time on the next
+  // weekday that is not a holiday.  Activations until the moved one are not
+  // run, so that a daily job runs once on that day.
+  HolidayNextBusinessDay
+)
+
+// WithHolidays makes the entry avoid running on the holidays of the calendar,
+// according to the policy.
+func WithHolidays(holidays HolidayCalendar, policy HolidayPolicy) EntryOption {
+  return func(e *Entry) {
+    e.Holidays = holidays
+    e.HolidayPolicy = policy
+  }
+}
+
+// maxHolidaySearch is how many activations, or days, are searched for one that
+// is not on a holiday.
+const maxHolidaySearch = 5 * 366
+
+// avoidHolidays returns the activation next, skipped or moved according to
+// the entry's holiday policy, or the zero time if none is found.
+func (e *Entry) avoidHolidays(next time.Time) time.Time {
+  for i := 0; i < maxHolidaySearch; i++ {
+    if next.IsZero() || !e.Holidays.IsHoliday(next) {
+      return next
+    }
+    if e.HolidayPolicy == HolidayNextBusinessDay {
+      return e.nextBusinessDay(next)
+    }
+    next = e.Schedule.Next(next)
+  }
+  return time.Time{}
+}
+
+// nextBusinessDay returns t moved to the next weekday that is not a holiday.
+func (e *Entry) nextBusinessDay(t time.Time) time.Time {
+  for i := 1; i <= maxHolidaySearch; i++ {
+    day := t.AddDate(0, 0, i)
+    if Weekdays.IsBusinessDay(day) && !e.Holidays.IsHoliday(day) {
+      return day
+    }
+  }
+  return time.Time{}
+}
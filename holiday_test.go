@@ -0,0 +1,112 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for holiday calendars.
+
+package cron
+
+import (
+  "testing"
+  "time"
+)
+
+func TestStaticHolidays(t *testing.T) {
+  holidays, err := NewStaticHolidays("12-25", "2012-07-04")
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  tests := []struct {
+    time     string
+    expected bool
+  }{
+    {"Wed Jul 4 00:00 2012", true},
+    {"Wed Jul 4 23:59:59 2012", true},
+    {"Thu Jul 4 12:00 2013", false},
+    {"Tue Dec 25 09:00 2012", true},
+    {"Wed Dec 25 09:00 2013", true},
+    {"Wed Dec 26 09:00 2012", false},
+  }
+  for _, c := range tests {
+    if actual := holidays.IsHoliday(getTime(c.time)); actual != c.expected {
+      t.Errorf("%s: (expected) %v != %v (actual)", c.time, c.expected, actual)
+    }
+  }
+
+  for _, date := range []string{"", "12/25", "2012-13-01", "02-30"} {
+    if _, err := NewStaticHolidays(date); err == nil {
+      t.Errorf("expected an error parsing %q", date)
+    }
+  }
+}
+
+func TestWithHolidays(t *testing.T) {
+  holidays, _ := NewStaticHolidays("2012-07-04", "2012-07-05", "2012-07-09")
+  weekdays := MustParse("0 0 9 * * MON-FRI")
+  daily := MustParse("0 0 9 * * *")
+
+  tests := []struct {
+    schedule Schedule
+    policy   HolidayPolicy
+    time     string
+    expected string
+  }{
+    {weekdays, HolidaySkip, "Tue Jul 3 10:00 2012", "Fri Jul 6 09:00 2012"},
+    {weekdays, HolidaySkip, "Fri Jul 6 10:00 2012", "Tue Jul 10 09:00 2012"},
+    {daily, HolidaySkip, "Fri Jul 6 10:00 2012", "Sat Jul 7 09:00 2012"},
+
+    {weekdays, HolidayNextBusinessDay, "Tue Jul 3 10:00 2012", "Fri Jul 6 09:00 2012"},
+    {weekdays, HolidayNextBusinessDay, "Fri Jul 6 10:00 2012", "Tue Jul 10 09:00 2012"},
+
+    // The weekend activations are absorbed by the moved one.
+    {daily, HolidayNextBusinessDay, "Tue Jul 3 10:00 2012", "Fri Jul 6 09:00 2012"},
+    {daily, HolidayNextBusinessDay, "Fri Jul 6 09:00 2012", "Sat Jul 7 09:00 2012"},
+    {daily, HolidayNextBusinessDay, "Sun Jul 8 10:00 2012", "Tue Jul 10 09:00 2012"},
+
+    // Without holidays, nothing changes.
+    {weekdays, HolidaySkip, "Mon Jul 2 10:00 2012", "Tue Jul 3 09:00 2012"},
+  }
+
+  for _, c := range tests {
+    e := &Entry{Schedule: c.schedule}
+    WithHolidays(holidays, c.policy)(e)
+    actual := e.next(getTime(c.time))
+    if !actual.Equal(getTime(c.expected)) {
+      t.Errorf("%s, policy %d: (expected) %s != %v (actual)", c.time,
+        c.policy, c.expected, actual)
+    }
+  }
+
+  // Every activation is on a holiday.
+  independence, _ := NewStaticHolidays("07-04")
+  e := &Entry{Schedule: MustParse("0 0 9 4 7 *")}
+  WithHolidays(independence, HolidaySkip)(e)
+  if next := e.next(getTime("Mon Jul 2 10:00 2012")); !next.IsZero() {
+    t.Errorf("expected no activation, got %v", next)
+  }
+}
+
+func TestBusinessDaysExcept(t *testing.T) {
+  holidays := HolidayFunc(func(t time.Time) bool {
+    return t.Month() == time.January && t.Day() == 1
+  })
+  schedule := MustParse("0 0 9 1B * ?").(*SpecSchedule)
+  schedule.BusinessDays = BusinessDaysExcept(holidays)
+
+  // The first business day of 2013 is Wednesday, since Tuesday is a holiday.
+  next := schedule.Next(getTime("Mon Dec 31 10:00 2012"))
+  if expected := getTime("Wed Jan 2 09:00 2013"); !next.Equal(expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, next)
+  }
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements jobs that send HTTP requests.
+
+package cron
+
+import (
+  "bytes"
+  "context"
+  "encoding/json"
+  "fmt"
+  "io"
+  "net/http"
+  "text/template"
+  "time"
+)
+
+// HTTPJob is an ErrorJob that sends an HTTP request, e.g. to ping an endpoint
+// or trigger a webhook.  The response's body is recorded with SetOutput, and
+// runs fail if the request fails or the response's status is not expected.
+type HTTPJob struct {
+  // Method is the method of the request, or GET if it is empty, and URL its
+  // URL.
+  Method, URL string
+
+  // Header is added to the headers of the request.
+  Header http.Header
+
+  // Body, if set, is a text/template of the body of the request, executed
+  // with the RunInfo of the run, e.g. {"scheduled": "{{.Scheduled}}"}.
+  Body string
+
+  // ExpectedStatus are the expected status codes of the response; if it is
+  // empty, any 2xx status is.
+  ExpectedStatus []int
+
+  // Timeout, if positive, limits each attempt at the request.
+  Timeout time.Duration
+
+  // Retry retries failed requests, like the Retry wrapper.
+  Retry RetryPolicy
+
+  // Client sends the request, or http.DefaultClient if it is nil.
+  Client *http.Client
+}
+
+// httpJobJSON is the JSON form of an HTTPJob.
+type httpJobJSON struct {
+  Method         string            `json:"method,omitempty"`
+  URL            string            `json:"url"`
+  Header         map[string]string `json:"header,omitempty"`
+  Body           string            `json:"body,omitempty"`
+  ExpectedStatus []int             `json:"expectedStatus,omitempty"`
+  Timeout        string            `json:"timeout,omitempty"`
+  Retries        int               `json:"retries,omitempty"`
+  Backoff        string            `json:"backoff,omitempty"`
+  MaxBackoff     string            `json:"maxBackoff,omitempty"`
+}
+
+// NewHTTPJob is a JobFactory of HTTPJobs, to be registered with RegisterJob,
+// e.g. as "http".  The payload is a JSON object with the fields method, url,
+// header, body, expectedStatus, timeout, retries, backoff and maxBackoff, e.g.
+// {"url": "https://example.com/ping", "timeout": "10s", "retries": 3}.
+func NewHTTPJob(payload []byte) (Job, error) {
+  var v httpJobJSON
+  if err := json.Unmarshal(payload, &v); err != nil {
+    return nil, err
+  }
+  if v.URL == "" {
+    return nil, fmt.Errorf("no url")
+  }
+  job := &HTTPJob{
+    Method:         v.Method,
+    URL:            v.URL,
+    Body:           v.Body,
+    ExpectedStatus: v.ExpectedStatus,
+    Retry:          RetryPolicy{Retries: v.Retries},
+  }
+  if len(v.Header) > 0 {
+    job.Header = http.Header{}
+    for k, value := range v.Header {
+      job.Header.Set(k, value)
+    }
+  }
+  for _, d := range []struct {
+    s string
+    d *time.Duration
+  }{
+    {v.Timeout, &job.Timeout},
+    {v.Backoff, &job.Retry.Backoff},
+    {v.MaxBackoff, &job.Retry.MaxBackoff},
+  } {
+    if d.s == "" {
+      continue
+    }
+    parsed, err := time.ParseDuration(d.s)
+    if err != nil {
+      return nil, err
+    }
+    *d.d = parsed
+  }
+  if _, err := template.New("body").Parse(job.Body); err != nil {
+    return nil, err
+  }
+  if _, err := http.NewRequest(job.method(), job.URL, nil); err != nil {
+    return nil, err
+  }
+  return job, nil
+}
+
+// Run sends the request with a background context.
+func (j *HTTPJob) Run() { j.RunContext(context.Background()) }
+
+// RunContext sends the request, logging its failure.
+func (j *HTTPJob) RunContext(ctx context.Context) {
+  if err := j.RunError(ctx); err != nil {
+    loggerFrom(ctx).Warningf("job failed: %v", err)
+  }
+}
+
+// RunError sends the request, retrying it according to the job's policy, and
+// returns the error of the last attempt.
+func (j *HTTPJob) RunError(ctx context.Context) error {
+  if j.Retry.Retries > 0 {
+    return Retry(j.Retry)(ErrorFuncJob(j.send)).(ErrorJob).RunError(ctx)
+  }
+  return j.send(ctx)
+}
+
+// send sends the request once.
+func (j *HTTPJob) send(ctx context.Context) error {
+  var body io.Reader
+  if j.Body != "" {
+    b, err := executeTemplate(ctx, j.Body)
+    if err != nil {
+      return err
+    }
+    body = bytes.NewReader(b)
+  }
+
+  if j.Timeout > 0 {
+    var cancel context.CancelFunc
+    ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+    defer cancel()
+  }
+  req, err := http.NewRequestWithContext(ctx, j.method(), j.URL, body)
+  if err != nil {
+    return err
+  }
+  for k, values := range j.Header {
+    for _, v := range values {
+      req.Header.Add(k, v)
+    }
+  }
+  client := j.Client
+  if client == nil {
+    client = http.DefaultClient
+  }
+  resp, err := client.Do(req)
+  if err != nil {
+    return err
+  }
+  defer resp.Body.Close()
+
+  output := &tailBuffer{}
+  _, err = io.Copy(output, resp.Body)
+  SetOutput(ctx, output.String())
+  if !j.expected(resp.StatusCode) {
+    return fmt.Errorf("unexpected status %s", resp.Status)
+  }
+  return err
+}
+
+// method returns the method of the request.
+func (j *HTTPJob) method() string {
+  if j.Method == "" {
+    return http.MethodGet
+  }
+  return j.Method
+}
+
+// expected returns whether the status code is expected.
+func (j *HTTPJob) expected(code int) bool {
+  if len(j.ExpectedStatus) == 0 {
+    return code >= 200 && code < 300
+  }
+  for _, expected := range j.ExpectedStatus {
+    if code == expected {
+      return true
+    }
+  }
+  return false
+}
+
+// String returns the method and URL of the request.
+func (j *HTTPJob) String() string {
+  return j.method() + " " + j.URL
+}
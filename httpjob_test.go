@@ -0,0 +1,143 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for jobs that send HTTP requests.
+
+package cron
+
+import (
+  "context"
+  "io"
+  "net/http"
+  "net/http/httptest"
+  "strings"
+  "sync"
+  "testing"
+  "time"
+)
+
+func TestHTTPJob(t *testing.T) {
+  var (
+    mu       sync.Mutex
+    requests []string
+    failures int
+  )
+  server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter,
+    r *http.Request) {
+    body, _ := io.ReadAll(r.Body)
+    mu.Lock()
+    requests = append(requests, r.Method+" "+r.URL.Path+" "+
+      r.Header.Get("X-Token")+" "+string(body))
+    fail := failures > 0
+    if fail {
+      failures--
+    }
+    mu.Unlock()
+    switch {
+    case fail || r.URL.Path == "/error":
+      http.Error(w, "broken", http.StatusInternalServerError)
+    case r.URL.Path == "/slow":
+      time.Sleep(100 * time.Millisecond)
+    case r.URL.Path == "/created":
+      w.WriteHeader(http.StatusCreated)
+    default:
+      io.WriteString(w, "pong")
+    }
+  }))
+  defer server.Close()
+
+  scheduled := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  tests := []struct {
+    name     string
+    job      *HTTPJob
+    failures int
+    request  string
+    output   string
+    err      string
+  }{
+    {"get", &HTTPJob{URL: server.URL + "/ping"}, 0, "GET /ping  ", "pong",
+      ""},
+    {"post", &HTTPJob{Method: "POST", URL: server.URL + "/hook",
+      Header: http.Header{"X-Token": {"secret"}},
+      Body:   `{"entry": "{{.Name}}", "at": "{{.Scheduled.Unix}}"}`}, 0,
+      `POST /hook secret {"entry": "report", "at": "1341792000"}`, "pong",
+      ""},
+    {"status", &HTTPJob{URL: server.URL + "/error"}, 0, "GET /error  ",
+      "broken\n", "unexpected status 500 Internal Server Error"},
+    {"expected status", &HTTPJob{URL: server.URL + "/created",
+      ExpectedStatus: []int{200}}, 0, "GET /created  ", "",
+      "unexpected status 201 Created"},
+    {"timeout", &HTTPJob{URL: server.URL + "/slow",
+      Timeout: 20 * time.Millisecond}, 0, "GET /slow  ", "",
+      "context deadline exceeded"},
+    {"retried", &HTTPJob{URL: server.URL + "/ping",
+      Retry: RetryPolicy{Retries: 2}}, 2, "GET /ping  ", "pong", ""},
+    {"retries exhausted", &HTTPJob{URL: server.URL + "/ping",
+      Retry: RetryPolicy{Retries: 1}}, 2, "GET /ping  ", "broken\n",
+      "unexpected status 500 Internal Server Error"},
+  }
+
+  for _, test := range tests {
+    mu.Lock()
+    requests, failures = nil, test.failures
+    mu.Unlock()
+    ctx := withRunInfo(context.Background(), RunInfo{Name: "report",
+      Scheduled: scheduled})
+    ctx, output := withRunOutput(ctx)
+    err := test.job.RunError(ctx)
+    if test.err == "" && err != nil || test.err != "" && (err == nil ||
+      !strings.Contains(err.Error(), test.err)) {
+      t.Errorf("%s: expected error %q, got %v", test.name, test.err, err)
+    }
+    if actual := output.get(); actual != test.output {
+      t.Errorf("%s: expected output %q, got %q", test.name, test.output,
+        actual)
+    }
+    mu.Lock()
+    if len(requests) == 0 || requests[0] != test.request {
+      t.Errorf("%s: expected the request %q, got %q", test.name,
+        test.request, requests)
+    }
+    mu.Unlock()
+  }
+}
+
+func TestNewHTTPJob(t *testing.T) {
+  job, err := NewHTTPJob([]byte(`{"method": "POST",
+    "url": "https://example.com/hook", "header": {"x-token": "secret"},
+    "body": "{{.EntryID}}", "expectedStatus": [200, 204], "timeout": "10s",
+    "retries": 3, "backoff": "1s", "maxBackoff": "1m"}`))
+  if err != nil {
+    t.Fatal(err)
+  }
+  h := job.(*HTTPJob)
+  if h.String() != "POST https://example.com/hook" ||
+    h.Header.Get("X-Token") != "secret" || len(h.ExpectedStatus) != 2 ||
+    h.Timeout != 10*time.Second || h.Retry.Retries != 3 ||
+    h.Retry.Backoff != time.Second || h.Retry.MaxBackoff != time.Minute {
+    t.Errorf("unexpected job %+v", h)
+  }
+
+  for _, payload := range []string{
+    `{}`,
+    `{"url": "https://example.com", "timeout": "soon"}`,
+    `{"url": "https://example.com", "body": "{{.Missing"}`,
+    `{"url": "https://example.com", "method": "BAD METHOD"}`,
+    `{"url": `,
+  } {
+    if _, err := NewHTTPJob([]byte(payload)); err == nil {
+      t.Errorf("%s: expected an error", payload)
+    }
+  }
+}
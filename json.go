@@ -0,0 +1,250 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements JSON marshaling of schedules and entries.
+
+package cron
+
+import (
+  "encoding/json"
+  "fmt"
+  "time"
+)
+
+// specScheduleJSON is the JSON form of a SpecSchedule.  The BusinessDays and
+// Calendar are not marshaled, and the Location is marshaled by name.
+type specScheduleJSON struct {
+  Second      uint64 `json:"second"`
+  Minute      uint64 `json:"minute"`
+  Hour        uint64 `json:"hour"`
+  Dom         uint64 `json:"dom"`
+  Month       uint64 `json:"month"`
+  Dow         uint64 `json:"dow"`
+  BusinessDay uint64 `json:"businessDay,omitempty"`
+  Location    string `json:"location,omitempty"`
+  DSTGap      int    `json:"dstGap,omitempty"`
+  DSTOverlap  int    `json:"dstOverlap,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s *SpecSchedule) MarshalJSON() ([]byte, error) {
+  v := specScheduleJSON{
+    Second:      s.Second,
+    Minute:      s.Minute,
+    Hour:        s.Hour,
+    Dom:         s.Dom,
+    Month:       s.Month,
+    Dow:         s.Dow,
+    BusinessDay: s.BusinessDay,
+    DSTGap:      int(s.DSTGap),
+    DSTOverlap:  int(s.DSTOverlap),
+  }
+  if s.Location != nil {
+    v.Location = s.Location.String()
+  }
+  return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *SpecSchedule) UnmarshalJSON(b []byte) error {
+  var v specScheduleJSON
+  if err := json.Unmarshal(b, &v); err != nil {
+    return err
+  }
+  s.Second = v.Second
+  s.Minute = v.Minute
+  s.Hour = v.Hour
+  s.Dom = v.Dom
+  s.Month = v.Month
+  s.Dow = v.Dow
+  s.BusinessDay = v.BusinessDay
+  s.DSTGap = DSTGapPolicy(v.DSTGap)
+  s.DSTOverlap = DSTOverlapPolicy(v.DSTOverlap)
+  s.Location = nil
+  if v.Location != "" {
+    loc, err := time.LoadLocation(v.Location)
+    if err != nil {
+      return err
+    }
+    s.Location = loc
+  }
+  return nil
+}
+
+// constantDelayScheduleJSON is the JSON form of a ConstantDelaySchedule.
+type constantDelayScheduleJSON struct {
+  Delay     string `json:"delay"`
+  Precision string `json:"precision,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (schedule ConstantDelaySchedule) MarshalJSON() ([]byte, error) {
+  v := constantDelayScheduleJSON{Delay: schedule.Delay.String()}
+  if schedule.Precision != 0 {
+    v.Precision = schedule.Precision.String()
+  }
+  return json.Marshal(v)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (schedule *ConstantDelaySchedule) UnmarshalJSON(b []byte) error {
+  var v constantDelayScheduleJSON
+  if err := json.Unmarshal(b, &v); err != nil {
+    return err
+  }
+  delay, err := time.ParseDuration(v.Delay)
+  if err != nil {
+    return fmt.Errorf("failed to parse delay %s: %s", v.Delay, err)
+  }
+  schedule.Delay = delay
+  schedule.Precision = 0
+  if v.Precision != "" {
+    precision, err := time.ParseDuration(v.Precision)
+    if err != nil {
+      return fmt.Errorf("failed to parse precision %s: %s", v.Precision, err)
+    }
+    schedule.Precision = precision
+  }
+  return nil
+}
+
+// The type tags of the schedules that marshal to JSON.
+const (
+  specScheduleType          = "spec"
+  constantDelayScheduleType = "every"
+  atScheduleType            = "at"
+)
+
+// scheduleJSON is the JSON form of a Schedule, tagged with its type.
+type scheduleJSON struct {
+  Type  string          `json:"type"`
+  Value json.RawMessage `json:"value"`
+}
+
+// entryJSON is the JSON form of an Entry.  The Job is not marshaled, but the
+// name and payload it is created from, for entries added with AddRegistered,
+// are.
+type entryJSON struct {
+  ID          string            `json:"id"`
+  Name        string            `json:"name,omitempty"`
+  Labels      map[string]string `json:"labels,omitempty"`
+  Spec        string            `json:"spec,omitempty"`
+  Schedule    *scheduleJSON     `json:"schedule,omitempty"`
+  Job         string            `json:"job,omitempty"`
+  Payload     []byte            `json:"payload,omitempty"`
+  AtLeastOnce bool              `json:"atLeastOnce,omitempty"`
+  Misfire     MisfirePolicy     `json:"misfire,omitempty"`
+  Next        time.Time         `json:"next"`
+  Prev        time.Time         `json:"prev"`
+}
+
+// MarshalJSON implements json.Marshaler.  The entry's schedule is included if
+// it is a SpecSchedule, ConstantDelaySchedule or AtSchedule; other schedules
+// are only described by the spec they were parsed from, if any, so that
+// entries scheduled without one, e.g. with a custom Schedule, have neither.
+func (e *Entry) MarshalJSON() ([]byte, error) {
+  schedule, err := marshalSchedule(e.Schedule)
+  if err != nil {
+    return nil, err
+  }
+  return json.Marshal(entryJSON{
+    ID:          e.ID,
+    Name:        e.Name,
+    Labels:      e.Labels,
+    Spec:        e.Spec,
+    Schedule:    schedule,
+    Job:         e.JobName,
+    Payload:     e.Payload,
+    AtLeastOnce: e.AtLeastOnce,
+    Misfire:     e.Misfire,
+    Next:        e.Next,
+    Prev:        e.Prev,
+  })
+}
+
+// UnmarshalJSON implements json.Unmarshaler.  The schedule is restored from
+// its marshaled form if present, since re-parsing the spec may not give the
+// same schedule (e.g. with "~"), from the spec otherwise, and left unset if
+// there is neither.  The Job is left unset; that of an entry added with
+// AddRegistered can be created from its JobName and Payload.
+func (e *Entry) UnmarshalJSON(b []byte) error {
+  var v entryJSON
+  if err := json.Unmarshal(b, &v); err != nil {
+    return err
+  }
+
+  var schedule Schedule
+  var err error
+  if v.Schedule != nil {
+    schedule, err = unmarshalSchedule(v.Schedule)
+  } else if v.Spec != "" {
+    schedule, err = Parse(v.Spec)
+  }
+  if err != nil {
+    return err
+  }
+
+  e.ID = v.ID
+  e.Name = v.Name
+  e.Labels = v.Labels
+  e.Spec = v.Spec
+  e.Schedule = schedule
+  e.JobName = v.Job
+  e.Payload = v.Payload
+  e.AtLeastOnce = v.AtLeastOnce
+  e.Misfire = v.Misfire
+  e.Next = v.Next
+  e.Prev = v.Prev
+  return nil
+}
+
+// marshalSchedule returns the tagged JSON form of the schedule, or nil if its
+// type has none.
+func marshalSchedule(schedule Schedule) (*scheduleJSON, error) {
+  var typ string
+  switch schedule.(type) {
+  case *SpecSchedule:
+    typ = specScheduleType
+  case ConstantDelaySchedule:
+    typ = constantDelayScheduleType
+  case AtSchedule:
+    typ = atScheduleType
+  default:
+    return nil, nil
+  }
+  value, err := json.Marshal(schedule)
+  if err != nil {
+    return nil, err
+  }
+  return &scheduleJSON{Type: typ, Value: value}, nil
+}
+
+// unmarshalSchedule returns the schedule for the tagged JSON form.
+func unmarshalSchedule(v *scheduleJSON) (Schedule, error) {
+  switch v.Type {
+  case specScheduleType:
+    schedule := &SpecSchedule{}
+    err := json.Unmarshal(v.Value, schedule)
+    return schedule, err
+  case constantDelayScheduleType:
+    var schedule ConstantDelaySchedule
+    err := json.Unmarshal(v.Value, &schedule)
+    return schedule, err
+  case atScheduleType:
+    var schedule AtSchedule
+    err := json.Unmarshal(v.Value, &schedule)
+    return schedule, err
+  }
+  return nil, fmt.Errorf("unrecognized schedule type: %s", v.Type)
+}
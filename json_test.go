@@ -0,0 +1,149 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements JSON marshaling test.
+
+package cron
+
+import (
+  "encoding/json"
+  "reflect"
+  "testing"
+  "time"
+)
+
+func TestScheduleJSON(t *testing.T) {
+  tests := []struct {
+    schedule Schedule
+    json     string
+  }{
+    {&SpecSchedule{Second: 1, Minute: 1 << 5, Hour: 1 << 2, Dom: all(dom), Month: all(months), Dow: 1 << 3},
+      `{"second":1,"minute":32,"hour":4,"dom":9223372041149743102,"month":9223372036854783998,"dow":8}`},
+    {ConstantDelaySchedule{Delay: 90 * time.Minute}, `{"delay":"1h30m0s"}`},
+    {EveryWithPrecision(250*time.Millisecond, time.Millisecond),
+      `{"delay":"250ms","precision":"1ms"}`},
+  }
+
+  for _, c := range tests {
+    b, err := json.Marshal(c.schedule)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    if string(b) != c.json {
+      t.Errorf("%v => (expected) %s != %s (actual)", c.schedule, c.json, b)
+    }
+
+    actual := reflect.New(reflect.TypeOf(c.schedule)).Interface()
+    if err := json.Unmarshal(b, actual); err != nil {
+      t.Error(err)
+      continue
+    }
+    if !reflect.DeepEqual(reflect.ValueOf(actual).Elem().Interface(), c.schedule) {
+      t.Errorf("%s => (expected) %v != %v (actual)", b, c.schedule, actual)
+    }
+  }
+}
+
+func TestEntryJSON(t *testing.T) {
+  next := getTime("Mon Jul 9 15:00 2012")
+  prev := getTime("Mon Jul 9 14:00 2012")
+  specs := []struct {
+    spec string
+    loc  *time.Location
+  }{
+    {"0 0 * * * *", time.UTC},
+    {"~ ~ * * * *", time.UTC},
+    {"@every 1h", time.UTC},
+    {"@at 2012-07-09T15:00:00Z", time.UTC},
+    {"@hourly || 0 30 * * * *", nil},
+  }
+
+  for _, c := range specs {
+    spec := c.spec
+    schedule, err := ParseInLocation(spec, c.loc)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    entry := &Entry{ID: "id", Spec: spec, Schedule: schedule, Next: next, Prev: prev}
+    b, err := json.Marshal(entry)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+
+    var actual Entry
+    if err := json.Unmarshal(b, &actual); err != nil {
+      t.Error(err)
+      continue
+    }
+    if !reflect.DeepEqual(&actual, entry) {
+      t.Errorf("%s => (expected) %+v != %+v (actual)", b, entry, actual)
+    }
+  }
+
+  // Entries with schedules that have no JSON form, and no spec, are marshaled
+  // without them, along with those that do.
+  entries := []*Entry{
+    {ID: "union", Schedule: &UnionSchedule{}},
+    {ID: "report", Spec: "@daily", Schedule: Every(24 * time.Hour)},
+  }
+  b, err := json.Marshal(entries)
+  if err != nil {
+    t.Fatal(err)
+  }
+  var actual []*Entry
+  if err := json.Unmarshal(b, &actual); err != nil {
+    t.Fatal(err)
+  }
+  if len(actual) != 2 || actual[0].ID != "union" ||
+    actual[0].Schedule != nil || actual[1].ID != "report" {
+    t.Errorf("unexpected entries %s", b)
+  }
+}
+
+// TestEntryJSONDefinition checks that the definition of a registered entry
+// survives JSON.
+func TestEntryJSONDefinition(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  cron := New(WithClock(NewFakeClock(start)), WithLocation(time.UTC))
+  id, err := cron.AddRegistered("@hourly || @daily", "test-echo",
+    []byte("json"), WithName("report"),
+    WithLabels(map[string]string{"team": "infra"}), WithAtLeastOnce(),
+    WithMisfirePolicy(MisfireSkip))
+  if err != nil {
+    t.Fatal(err)
+  }
+  entry, _ := cron.Entry(id)
+  b, err := json.Marshal(&entry)
+  if err != nil {
+    t.Fatal(err)
+  }
+
+  var actual Entry
+  if err := json.Unmarshal(b, &actual); err != nil {
+    t.Fatal(err)
+  }
+  if actual.ID != id || actual.Name != "report" ||
+    !reflect.DeepEqual(actual.Labels, entry.Labels) ||
+    actual.Spec != entry.Spec || actual.JobName != "test-echo" ||
+    string(actual.Payload) != "json" || !actual.AtLeastOnce ||
+    actual.Misfire != MisfireSkip || !actual.Next.Equal(entry.Next) {
+    t.Errorf("%s => (expected) %+v != %+v (actual)", b, entry, actual)
+  }
+  if next := actual.Schedule.Next(start); !next.Equal(start.Add(time.Hour)) {
+    t.Errorf("expected the schedule parsed from the spec, got %v", next)
+  }
+}
@@ -0,0 +1,358 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements a cron.Elector with a Kubernetes Lease.
+
+// Package k8slease implements a cron.Elector with a Lease of the Kubernetes
+// coordination API, the way controllers elect their leader, so that only one
+// of the replicas of a deployment runs jobs:
+//
+// 	elector, err := k8slease.InCluster("", "myapp-cron", os.Getenv("POD_NAME"))
+// 	if err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	c := cron.New(cron.WithElector(elector))
+//
+// It talks to the API server over HTTP, so its service account needs to be
+// allowed to get, create and update the Lease.
+package k8slease
+
+import (
+  "bytes"
+  "context"
+  "crypto/tls"
+  "crypto/x509"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "io"
+  "net"
+  "net/http"
+  "os"
+  "strings"
+  "time"
+
+  "github.com/kiranbond/cron"
+)
+
+const (
+  // DefaultLeaseDuration is how long after its last renewal a lease is held.
+  DefaultLeaseDuration = 15 * time.Second
+
+  // DefaultRenewDeadline is how long a leader keeps leading while it fails to
+  // renew its lease.
+  DefaultRenewDeadline = 10 * time.Second
+
+  // DefaultRetryPeriod is how often Electors try to acquire or renew leases.
+  DefaultRetryPeriod = 2 * time.Second
+)
+
+// serviceAccount is the directory of the credentials of pods.
+const serviceAccount = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// microTime is the layout of the times of Leases.
+const microTime = "2006-01-02T15:04:05.000000Z07:00"
+
+// Elector is a cron.Elector holding a Lease while it leads.  An Elector
+// campaigns for one Cron at a time.
+type Elector struct {
+  client    *http.Client
+  server    string
+  token     string
+  tokenFile string
+
+  namespace, name, identity string
+
+  // LeaseDuration is how long the lease is held after it is renewed,
+  // RenewDeadline how long the leader leads while it cannot renew it, and
+  // RetryPeriod how often it is acquired or renewed.
+  LeaseDuration time.Duration
+  RenewDeadline time.Duration
+  RetryPeriod   time.Duration
+
+  // OnError is called with the errors of requests.  By default they are
+  // logged to Logger.
+  OnError func(err error)
+
+  // Logger logs the errors of requests, by default to cron.DefaultLogger.
+  Logger cron.Logger
+
+  // observed is the last record of the lease seen, at observedAt by the local
+  // clock, which is what its expiry is measured from.
+  observed   leaseSpec
+  observedAt time.Time
+}
+
+// New returns an Elector acquiring the Lease of the given name and namespace
+// from the API server at the URL server, authenticated by the bearer token,
+// as the candidate of the given identity, e.g. its pod name.
+func New(client *http.Client, server, token, namespace, name,
+  identity string) *Elector {
+  e := &Elector{
+    client:        client,
+    server:        strings.TrimSuffix(server, "/"),
+    token:         token,
+    namespace:     namespace,
+    name:          name,
+    identity:      identity,
+    LeaseDuration: DefaultLeaseDuration,
+    RenewDeadline: DefaultRenewDeadline,
+    RetryPeriod:   DefaultRetryPeriod,
+    Logger:        cron.DefaultLogger,
+  }
+  e.OnError = func(err error) {
+    e.Logger.Warningf("cannot renew lease: %v", err)
+  }
+  return e
+}
+
+// InCluster returns an Elector for a pod, talking to the API server of its
+// cluster with the credentials of its service account.  If namespace is
+// empty, the Lease is in the namespace of the pod.
+func InCluster(namespace, name, identity string) (*Elector, error) {
+  host, port := os.Getenv("KUBERNETES_SERVICE_HOST"),
+    os.Getenv("KUBERNETES_SERVICE_PORT")
+  if host == "" || port == "" {
+    return nil, errors.New("not running in a Kubernetes cluster")
+  }
+  ca, err := os.ReadFile(serviceAccount + "/ca.crt")
+  if err != nil {
+    return nil, err
+  }
+  roots := x509.NewCertPool()
+  if !roots.AppendCertsFromPEM(ca) {
+    return nil, fmt.Errorf("no certificates in %s/ca.crt", serviceAccount)
+  }
+  if namespace == "" {
+    b, err := os.ReadFile(serviceAccount + "/namespace")
+    if err != nil {
+      return nil, err
+    }
+    namespace = strings.TrimSpace(string(b))
+  }
+
+  client := &http.Client{Transport: &http.Transport{
+    TLSClientConfig: &tls.Config{RootCAs: roots},
+  }}
+  e := New(client, "https://"+net.JoinHostPort(host, port), "", namespace,
+    name, identity)
+  // The tokens of service accounts are rotated.
+  e.tokenFile = serviceAccount + "/token"
+  return e, nil
+}
+
+// lease is a Lease of the coordination API.
+type lease struct {
+  APIVersion string        `json:"apiVersion"`
+  Kind       string        `json:"kind"`
+  Metadata   leaseMetadata `json:"metadata"`
+  Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+  Name            string `json:"name"`
+  Namespace       string `json:"namespace"`
+  ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+  HolderIdentity       string `json:"holderIdentity,omitempty"`
+  LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+  AcquireTime          string `json:"acquireTime,omitempty"`
+  RenewTime            string `json:"renewTime,omitempty"`
+  LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+}
+
+// Errors of requests for the lease.
+var (
+  errNotFound = errors.New("lease not found")
+  errConflict = errors.New("lease changed")
+)
+
+// Campaign implements cron.Elector, trying to acquire or renew the lease
+// every RetryPeriod until ctx is done, and then releasing it if it leads.
+func (e *Elector) Campaign(ctx context.Context, lead func(bool)) {
+  var (
+    leading bool
+    renewed time.Time
+  )
+  for {
+    ok, err := e.tryAcquireOrRenew(ctx)
+    if err != nil && ctx.Err() == nil {
+      e.OnError(err)
+    }
+    now := time.Now()
+    switch {
+    case ok:
+      renewed = now
+      if !leading {
+        leading = true
+        lead(true)
+      }
+    // Another candidate holds the lease, or it could not be renewed for
+    // too long.
+    case leading && (err == nil || now.Sub(renewed) >= e.RenewDeadline):
+      leading = false
+      lead(false)
+    }
+
+    select {
+    case <-ctx.Done():
+      if leading {
+        e.release()
+        lead(false)
+      }
+      return
+    case <-time.After(e.RetryPeriod):
+    }
+  }
+}
+
+// tryAcquireOrRenew acquires the lease if it is free or expired, or renews it
+// if the Elector holds it, and returns whether it did.
+func (e *Elector) tryAcquireOrRenew(ctx context.Context) (bool, error) {
+  ctx, cancel := context.WithTimeout(ctx, e.RenewDeadline)
+  defer cancel()
+  now := time.Now()
+  l, err := e.do(ctx, http.MethodGet, e.path(), nil)
+  if err == errNotFound {
+    l = &lease{
+      APIVersion: "coordination.k8s.io/v1",
+      Kind:       "Lease",
+      Metadata:   leaseMetadata{Name: e.name, Namespace: e.namespace},
+    }
+    e.hold(l, now)
+    _, err = e.do(ctx, http.MethodPost, e.collectionPath(), l)
+    return e.result(err)
+  }
+  if err != nil {
+    return false, err
+  }
+
+  if l.Spec != e.observed {
+    e.observed, e.observedAt = l.Spec, now
+  }
+  duration := time.Duration(l.Spec.LeaseDurationSeconds) * time.Second
+  if l.Spec.HolderIdentity != "" && l.Spec.HolderIdentity != e.identity &&
+    now.Before(e.observedAt.Add(duration)) {
+    return false, nil
+  }
+  e.hold(l, now)
+  _, err = e.do(ctx, http.MethodPut, e.path(), l)
+  return e.result(err)
+}
+
+// hold makes the Elector the holder of the lease, renewed at now.
+func (e *Elector) hold(l *lease, now time.Time) {
+  if l.Spec.HolderIdentity != e.identity {
+    if l.Metadata.ResourceVersion != "" {
+      l.Spec.LeaseTransitions++
+    }
+    l.Spec.HolderIdentity = e.identity
+    l.Spec.AcquireTime = now.UTC().Format(microTime)
+  }
+  l.Spec.RenewTime = now.UTC().Format(microTime)
+  l.Spec.LeaseDurationSeconds = int(e.LeaseDuration / time.Second)
+  if l.Spec.LeaseDurationSeconds < 1 {
+    l.Spec.LeaseDurationSeconds = 1
+  }
+}
+
+// result returns whether the request writing the lease succeeded: it did not
+// if another candidate wrote it first.
+func (e *Elector) result(err error) (bool, error) {
+  if err == errConflict {
+    return false, nil
+  }
+  return err == nil, err
+}
+
+// release frees the lease, if the Elector holds it, so that another candidate
+// acquires it without waiting for it to expire.
+func (e *Elector) release() {
+  ctx, cancel := context.WithTimeout(context.Background(), e.RenewDeadline)
+  defer cancel()
+  l, err := e.do(ctx, http.MethodGet, e.path(), nil)
+  if err != nil || l.Spec.HolderIdentity != e.identity {
+    return
+  }
+  l.Spec.HolderIdentity = ""
+  l.Spec.LeaseDurationSeconds = 1
+  l.Spec.RenewTime = time.Now().UTC().Format(microTime)
+  if _, err := e.do(ctx, http.MethodPut, e.path(), l); err != nil {
+    e.OnError(err)
+  }
+}
+
+// collectionPath returns the path of the Leases of the Elector's namespace.
+func (e *Elector) collectionPath() string {
+  return "/apis/coordination.k8s.io/v1/namespaces/" + e.namespace + "/leases"
+}
+
+// path returns the path of the Elector's Lease.
+func (e *Elector) path() string {
+  return e.collectionPath() + "/" + e.name
+}
+
+// do sends the request for the path, with the lease as its body if not nil,
+// and returns the lease in the response.
+func (e *Elector) do(ctx context.Context, method, path string,
+  in *lease) (*lease, error) {
+  var body io.Reader
+  if in != nil {
+    b, err := json.Marshal(in)
+    if err != nil {
+      return nil, err
+    }
+    body = bytes.NewReader(b)
+  }
+  req, err := http.NewRequestWithContext(ctx, method, e.server+path, body)
+  if err != nil {
+    return nil, err
+  }
+  req.Header.Set("Accept", "application/json")
+  if in != nil {
+    req.Header.Set("Content-Type", "application/json")
+  }
+  token := e.token
+  if e.tokenFile != "" {
+    b, err := os.ReadFile(e.tokenFile)
+    if err != nil {
+      return nil, err
+    }
+    token = strings.TrimSpace(string(b))
+  }
+  if token != "" {
+    req.Header.Set("Authorization", "Bearer "+token)
+  }
+
+  resp, err := e.client.Do(req)
+  if err != nil {
+    return nil, err
+  }
+  defer resp.Body.Close()
+  switch {
+  case resp.StatusCode == http.StatusNotFound:
+    return nil, errNotFound
+  case resp.StatusCode == http.StatusConflict:
+    return nil, errConflict
+  case resp.StatusCode/100 != 2:
+    return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+  }
+  out := &lease{}
+  if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+    return nil, fmt.Errorf("cannot decode lease: %s", err)
+  }
+  return out, nil
+}
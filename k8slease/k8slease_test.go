@@ -0,0 +1,224 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the Kubernetes Lease Elector.
+
+package k8slease
+
+import (
+  "bytes"
+  "context"
+  "encoding/json"
+  "errors"
+  "log"
+  "net/http"
+  "net/http/httptest"
+  "strconv"
+  "sync"
+  "testing"
+  "time"
+
+  "github.com/kiranbond/cron"
+)
+
+// fakeAPI serves a Lease the way the API server does, rejecting writes of
+// stale versions.
+type fakeAPI struct {
+  mu      sync.Mutex
+  lease   *lease
+  version int
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+  if r.Header.Get("Authorization") != "Bearer secret" {
+    w.WriteHeader(http.StatusUnauthorized)
+    return
+  }
+  f.mu.Lock()
+  defer f.mu.Unlock()
+
+  const path = "/apis/coordination.k8s.io/v1/namespaces/default/leases"
+  var in lease
+  if r.Method != http.MethodGet {
+    if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+      w.WriteHeader(http.StatusBadRequest)
+      return
+    }
+  }
+  switch {
+  case r.Method == http.MethodGet && r.URL.Path == path+"/cron":
+    if f.lease == nil {
+      w.WriteHeader(http.StatusNotFound)
+      return
+    }
+  case r.Method == http.MethodPost && r.URL.Path == path:
+    if f.lease != nil {
+      w.WriteHeader(http.StatusConflict)
+      return
+    }
+    f.write(&in)
+  case r.Method == http.MethodPut && r.URL.Path == path+"/cron":
+    if f.lease == nil ||
+      in.Metadata.ResourceVersion != f.lease.Metadata.ResourceVersion {
+      w.WriteHeader(http.StatusConflict)
+      return
+    }
+    f.write(&in)
+  default:
+    w.WriteHeader(http.StatusNotFound)
+    return
+  }
+  json.NewEncoder(w).Encode(f.lease)
+}
+
+// write stores the lease as a new version.  f.mu must be held.
+func (f *fakeAPI) write(l *lease) {
+  f.version++
+  l.Metadata.ResourceVersion = strconv.Itoa(f.version)
+  f.lease = l
+}
+
+// holder returns the holder of the lease.
+func (f *fakeAPI) holder() string {
+  f.mu.Lock()
+  defer f.mu.Unlock()
+  if f.lease == nil {
+    return ""
+  }
+  return f.lease.Spec.HolderIdentity
+}
+
+// newElector returns an Elector of the given identity, retrying quickly.
+func newElector(server *httptest.Server, identity string) *Elector {
+  e := New(server.Client(), server.URL, "secret", "default", "cron",
+    identity)
+  e.LeaseDuration = time.Second
+  e.RetryPeriod = 10 * time.Millisecond
+  return e
+}
+
+// campaign campaigns with the elector until the returned function is called,
+// sending its leadership changes on the returned channel.
+func campaign(e cron.Elector) (chan bool, func()) {
+  leading := make(chan bool, 10)
+  ctx, cancel := context.WithCancel(context.Background())
+  done := make(chan struct{})
+  go func() {
+    defer close(done)
+    e.Campaign(ctx, func(l bool) { leading <- l })
+  }()
+  return leading, func() {
+    cancel()
+    <-done
+  }
+}
+
+// expectLeading fails the test unless the leadership changes to expected.
+func expectLeading(t *testing.T, leading chan bool, expected bool) {
+  t.Helper()
+  select {
+  case actual := <-leading:
+    if actual != expected {
+      t.Errorf("(expected) %v != %v (actual)", expected, actual)
+    }
+  case <-time.After(5 * time.Second):
+    t.Fatalf("expected leading to become %v", expected)
+  }
+}
+
+func TestCampaign(t *testing.T) {
+  api := &fakeAPI{}
+  server := httptest.NewServer(api)
+  defer server.Close()
+
+  a, stopA := campaign(newElector(server, "a"))
+  defer stopA()
+  expectLeading(t, a, true)
+  if holder := api.holder(); holder != "a" {
+    t.Errorf("expected a to hold the lease, got %q", holder)
+  }
+
+  b, stopB := campaign(newElector(server, "b"))
+  defer stopB()
+  select {
+  case l := <-b:
+    t.Errorf("expected b not to lead while a does, got %v", l)
+  case <-time.After(100 * time.Millisecond):
+  }
+
+  // a releases the lease, which b acquires without waiting for it to expire.
+  start := time.Now()
+  stopA()
+  expectLeading(t, a, false)
+  expectLeading(t, b, true)
+  if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+    t.Errorf("expected b to lead at once, took %v", elapsed)
+  }
+  if holder := api.holder(); holder != "b" {
+    t.Errorf("expected b to hold the lease, got %q", holder)
+  }
+}
+
+func TestExpiredLease(t *testing.T) {
+  api := &fakeAPI{}
+  api.write(&lease{Spec: leaseSpec{HolderIdentity: "dead",
+    LeaseDurationSeconds: 1}})
+  server := httptest.NewServer(api)
+  defer server.Close()
+
+  start := time.Now()
+  a, stopA := campaign(newElector(server, "a"))
+  defer stopA()
+  expectLeading(t, a, true)
+  if elapsed := time.Since(start); elapsed < time.Second {
+    t.Errorf("expected a to wait for the lease to expire, took %v", elapsed)
+  }
+  api.mu.Lock()
+  defer api.mu.Unlock()
+  if transitions := api.lease.Spec.LeaseTransitions; transitions != 1 {
+    t.Errorf("expected 1 transition, got %d", transitions)
+  }
+}
+
+func TestLostLease(t *testing.T) {
+  api := &fakeAPI{}
+  server := httptest.NewServer(api)
+  defer server.Close()
+
+  a, stopA := campaign(newElector(server, "a"))
+  defer stopA()
+  expectLeading(t, a, true)
+
+  // Another candidate took the lease over.
+  api.mu.Lock()
+  l := *api.lease
+  l.Spec.HolderIdentity = "b"
+  l.Spec.LeaseDurationSeconds = 60
+  api.write(&l)
+  api.mu.Unlock()
+  expectLeading(t, a, false)
+}
+
+// TestLogger checks that the errors of requests are logged to the Elector's
+// Logger.
+func TestLogger(t *testing.T) {
+  e := New(http.DefaultClient, "http://localhost", "secret", "default",
+    "cron", "a")
+  var buf bytes.Buffer
+  e.Logger = cron.PrintfLogger(nil, log.New(&buf, "", 0))
+  e.OnError(errors.New("conflict"))
+  if expected := "cannot renew lease: conflict\n"; buf.String() != expected {
+    t.Errorf("(expected) %q != %q (actual)", expected, buf.String())
+  }
+}
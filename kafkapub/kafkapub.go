@@ -0,0 +1,61 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements a Publisher of messages to Kafka.
+
+// Package kafkapub publishes the messages of cron.PublishJobs to Kafka:
+//
+// 	w := &kafka.Writer{Addr: kafka.TCP("localhost:9092")}
+// 	cron.RegisterJob("kafka", cron.PublishJobFactory(kafkapub.New(w)))
+package kafkapub
+
+import (
+  "context"
+
+  "github.com/kiranbond/cron"
+  "github.com/segmentio/kafka-go"
+)
+
+// Writer is the part of a *kafka.Writer that writes messages.
+type Writer interface {
+  WriteMessages(ctx context.Context, messages ...kafka.Message) error
+}
+
+// Publisher is a cron.Publisher that writes messages to Kafka topics.
+type Publisher struct {
+  writer Writer
+
+  // Key, if set, returns the key of the messages of each subject, e.g. so
+  // that they go to the same partition.  By default messages have no key.
+  Key func(subject string) []byte
+}
+
+var _ cron.Publisher = (*Publisher)(nil)
+
+// New returns a Publisher writing with w, typically a *kafka.Writer.  Its
+// subjects are topics, so the Writer must not have a Topic.
+func New(w Writer) *Publisher {
+  return &Publisher{writer: w}
+}
+
+// Publish implements cron.Publisher, writing the message to the topic named
+// subject.
+func (p *Publisher) Publish(ctx context.Context, subject string,
+  message []byte) error {
+  m := kafka.Message{Topic: subject, Value: message}
+  if p.Key != nil {
+    m.Key = p.Key(subject)
+  }
+  return p.writer.WriteMessages(ctx, m)
+}
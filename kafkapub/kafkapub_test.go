@@ -0,0 +1,55 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the Kafka Publisher.
+
+package kafkapub
+
+import (
+  "context"
+  "reflect"
+  "testing"
+
+  "github.com/segmentio/kafka-go"
+)
+
+// Kafka writers are Writers.
+var _ Writer = (*kafka.Writer)(nil)
+
+// fakeWriter is a Writer recording the messages it writes.
+type fakeWriter struct {
+  messages []kafka.Message
+}
+
+func (w *fakeWriter) WriteMessages(ctx context.Context,
+  messages ...kafka.Message) error {
+  w.messages = append(w.messages, messages...)
+  return nil
+}
+
+func TestPublish(t *testing.T) {
+  w := &fakeWriter{}
+  p := New(w)
+  p.Publish(context.Background(), "ticks", []byte("one"))
+  p.Key = func(subject string) []byte { return []byte("key-" + subject) }
+  p.Publish(context.Background(), "reports", []byte("two"))
+
+  expected := []kafka.Message{
+    {Topic: "ticks", Value: []byte("one")},
+    {Topic: "reports", Key: []byte("key-reports"), Value: []byte("two")},
+  }
+  if !reflect.DeepEqual(w.messages, expected) {
+    t.Errorf("(expected) %+v != %+v (actual)", expected, w.messages)
+  }
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements schedules with a bounded number of activations.
+
+package cron
+
+import "time"
+
+// LimitedSchedule activates like Schedule, but only Limit times, counted from
+// Start.  A Cron sets a zero Start to the time the entry is added, or its
+// schedule updated; until then, Next counts from the time it is passed.
+//
+// Each call to Next counts the activations from Start, as RRuleSchedule does
+// for COUNT, so large limits are slow.
+type LimitedSchedule struct {
+  Schedule Schedule
+  Limit    int
+  Start    time.Time
+}
+
+// Next returns the next activation of the schedule after t, or the zero time
+// once Limit activations have passed.
+func (s *LimitedSchedule) Next(t time.Time) time.Time {
+  next := s.Start
+  if next.IsZero() {
+    next = t
+  }
+  for i := 0; i < s.Limit; i++ {
+    if next = s.Schedule.Next(next); next.IsZero() || next.After(t) {
+      return next
+    }
+  }
+  return time.Time{}
+}
+
+// startLimits sets the Start of the LimitedSchedules in the schedule, and in
+// the schedules it combines, that have none to t.
+func startLimits(schedule Schedule, t time.Time) {
+  switch s := schedule.(type) {
+  case *LimitedSchedule:
+    if s.Start.IsZero() {
+      s.Start = t
+    }
+    startLimits(s.Schedule, t)
+  case *UnionSchedule:
+    for _, schedule := range s.Schedules {
+      startLimits(schedule, t)
+    }
+  case *ExceptSchedule:
+    startLimits(s.Schedule, t)
+    startLimits(s.Except, t)
+  }
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for limited schedules.
+
+package cron
+
+import (
+  "testing"
+  "time"
+)
+
+func TestLimitedNext(t *testing.T) {
+  s := Every(time.Hour).Times(3)
+  s.Start = getTime("Mon Jul 9 14:00 2012")
+  runs := []struct {
+    time     string
+    expected string
+  }{
+    {"Mon Jul 9 14:00 2012", "Mon Jul 9 15:00 2012"},
+    {"Mon Jul 9 15:00 2012", "Mon Jul 9 16:00 2012"},
+    {"Mon Jul 9 15:30 2012", "Mon Jul 9 16:00 2012"},
+    {"Mon Jul 9 16:00 2012", "Mon Jul 9 17:00 2012"},
+    {"Mon Jul 9 17:00 2012", ""},
+    {"Mon Jul 9 14:30 2012", "Mon Jul 9 15:00 2012"},
+  }
+
+  for _, c := range runs {
+    actual := s.Next(getTime(c.time))
+    expected := getTime(c.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s: (expected) %v != %v (actual)", c.time, expected, actual)
+    }
+  }
+}
+
+func TestLimitedExhausted(t *testing.T) {
+  s := &LimitedSchedule{
+    Schedule: AtSchedule{getTime("Mon Jul 9 15:00 2012")},
+    Limit:    5,
+  }
+  if next := s.Next(getTime("Mon Jul 9 14:00 2012")); !next.Equal(
+    getTime("Mon Jul 9 15:00 2012")) {
+    t.Errorf("expected the only activation, got %v", next)
+  }
+  if next := s.Next(getTime("Mon Jul 9 15:00 2012")); !next.IsZero() {
+    t.Errorf("expected no more activations, got %v", next)
+  }
+}
+
+// TestLimitedStart checks that Next does not set Start, and that startLimits
+// sets it in the schedules that are combined.
+func TestLimitedStart(t *testing.T) {
+  s := Every(time.Hour).Times(2)
+  if next := s.Next(getTime("Mon Jul 9 14:00 2012")); !next.Equal(
+    getTime("Mon Jul 9 15:00 2012")) {
+    t.Errorf("expected the first activation, got %v", next)
+  }
+  if !s.Start.IsZero() {
+    t.Errorf("expected Next to leave Start zero, got %v", s.Start)
+  }
+
+  union := &UnionSchedule{Schedules: []Schedule{s, Every(time.Minute)}}
+  start := getTime("Mon Jul 9 12:00 2012")
+  startLimits(union, start)
+  if !s.Start.Equal(start) {
+    t.Errorf("(expected) %v != %v (actual)", start, s.Start)
+  }
+  startLimits(union, getTime("Mon Jul 9 13:00 2012"))
+  if !s.Start.Equal(start) {
+    t.Errorf("expected Start to be kept, got %v", s.Start)
+  }
+  if next := s.Next(getTime("Mon Jul 9 14:00 2012")); !next.IsZero() {
+    t.Errorf("expected no more activations, got %v", next)
+  }
+}
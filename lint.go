@@ -0,0 +1,144 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements a linter for schedules that can never fire.
+
+package cron
+
+import (
+  "fmt"
+  "strings"
+  "time"
+)
+
+// daysInGregorianCycle is the number of days after which the Gregorian
+// calendar repeats, including the days of the week.
+const daysInGregorianCycle = 146097
+
+// Lint returns an error describing why the schedule can never fire, or nil if
+// it can.  Next returns the zero time for such schedules only after searching
+// several years, so Lint is meant to catch them when they are added, e.g.
+// "0 0 0 30 2 *" (February 30th) or "0 0 0 1B * */6" (a first business day
+// that falls on a weekend).
+//
+// SpecSchedules and unions of them are checked exactly.  Other schedules are
+// checked by whether they fire after the current time.
+func Lint(schedule Schedule) error {
+  switch s := schedule.(type) {
+  case *SpecSchedule:
+    return lintSpec(s)
+  case *UnionSchedule:
+    var reasons []string
+    for _, part := range s.Schedules {
+      err := Lint(part)
+      if err == nil {
+        return nil
+      }
+      reasons = append(reasons, err.Error())
+    }
+    return fmt.Errorf("none of the schedules fire: %s",
+      strings.Join(reasons, "; "))
+  case *ExceptSchedule:
+    if err := Lint(s.Schedule); err != nil {
+      return err
+    }
+  }
+
+  if now := time.Now(); schedule.Next(now).IsZero() {
+    return fmt.Errorf("schedule does not fire after %v", now)
+  }
+  return nil
+}
+
+// lintSpec returns an error describing why the SpecSchedule can never fire.
+func lintSpec(s *SpecSchedule) error {
+  for _, field := range []struct {
+    name string
+    bits uint64
+    r    bounds
+  }{
+    {"second", s.Second, seconds},
+    {"minute", s.Minute, minutes},
+    {"hour", s.Hour, hours},
+    {"month", s.Month, months},
+  } {
+    if field.bits&getBits(field.r.min, field.r.max, 1) == 0 {
+      return fmt.Errorf("no %s matches", field.name)
+    }
+  }
+
+  days := s.Dom & getBits(dom.min, dom.max, 1)
+  and := s.Dom&starBit > 0 || s.Dow&starBit > 0
+  if s.Calendar == nil && and && days == s.Dom&^starBit &&
+    s.BusinessDay == 0 && days != 0 {
+    occurs := false
+    for day := dom.min; day <= dom.max; day++ {
+      if 1<<day&days > 0 && dayOccurs(day, s.Month) {
+        occurs = true
+        break
+      }
+    }
+    if !occurs {
+      return fmt.Errorf("day of month %s does not occur in month %s",
+        fieldString(days, dom), fieldString(s.Month, months))
+    }
+  }
+
+  // Every day of the week falls on every day of the year within one cycle of
+  // the calendar.
+  t := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+  for i := 0; i < daysInGregorianCycle; i++ {
+    day := t.AddDate(0, 0, i)
+    month, _ := s.calendar().Date(day)
+    if 1<<uint(month)&s.Month > 0 && dayMatches(s, day) {
+      return nil
+    }
+  }
+  if and {
+    return fmt.Errorf("day of month %s and day of week %s never match "+
+      "together in month %s", domString(s),
+      dowString(s), fieldString(s.Month, months))
+  }
+  return fmt.Errorf("neither day of month %s nor day of week %s match in "+
+    "month %s", domString(s), dowString(s),
+    fieldString(s.Month, months))
+}
+
+// fieldString returns the values of a field for messages.
+func fieldString(bits uint64, r bounds) string {
+  if field, _ := formatField(bits&^starBit, r); field != "" {
+    return field
+  }
+  return "(none)"
+}
+
+// domString returns the values of the day of month for messages.
+func domString(s *SpecSchedule) string {
+  days := *s
+  days.Dom &^= starBit
+  if field, _ := formatDom(&days); field != "" {
+    return field
+  }
+  return "(none)"
+}
+
+// dowString returns the values of the day of week for messages.
+func dowString(s *SpecSchedule) string {
+  days := *s
+  days.Dow &^= starBit
+  if field, _ := formatDow(&days); field != "" {
+    return field
+  }
+  return "(none)"
+}
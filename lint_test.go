@@ -0,0 +1,103 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the schedule linter.
+
+package cron
+
+import (
+  "strings"
+  "testing"
+  "time"
+)
+
+func TestLint(t *testing.T) {
+  entries := []struct {
+    spec   string
+    reason string
+  }{
+    {"0 0 0 * * *", ""},
+    {"0 0 0 29 2 *", ""},
+    {"0 0 0 31 * *", ""},
+    {"0 0 0 L FEB *", ""},
+    {"0 0 0 13 * FRI", ""},
+    {"0 0 0 1B * MON", ""},
+    {"0 0 0 30 2 *", "day of month 30 does not occur in month 2"},
+    {"0 0 0 30,31 2 *", "day of month 30,31 does not occur in month 2"},
+    {"0 0 0 31 APR,JUN *", "day of month 31 does not occur in month 4,6"},
+    {"0 0 0 1B * SAT,SUN", ""},
+    {"0 0 0 1B * */6", "day of month 1B and day of week 0,6 never match " +
+      "together in month 1-12"},
+    {"0 0 0 LB * */6", "day of month LB and day of week 0,6 never match"},
+    {"0 0 0 30 2 * || 0 0 0 31 4 *", "none of the schedules fire"},
+    {"0 0 0 30 2 * || @daily", ""},
+    {"@every 1h", ""},
+    {"@at 2000-01-01T00:00:00Z", "does not fire after"},
+    {"@hourly except @hourly", "does not fire after"},
+    {"0 0 0 30 2 * except @hourly", "does not occur"},
+  }
+
+  for _, c := range entries {
+    schedule, err := Parse(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    err = Lint(schedule)
+    switch {
+    case c.reason == "" && err != nil:
+      t.Errorf("%s => unexpected error %v", c.spec, err)
+    case c.reason != "" && err == nil:
+      t.Errorf("%s => expected error containing %q", c.spec, c.reason)
+    case c.reason != "" && !strings.Contains(err.Error(), c.reason):
+      t.Errorf("%s => (expected) %q not in %q (actual)", c.spec, c.reason,
+        err)
+    }
+  }
+}
+
+func TestLintEmptyFields(t *testing.T) {
+  s := &SpecSchedule{
+    Second: 1,
+    Minute: 1,
+    Dom:    all(dom),
+    Month:  all(months),
+    Dow:    all(dow),
+  }
+  if err := Lint(s); err == nil || err.Error() != "no hour matches" {
+    t.Errorf("expected no hour to match, got %v", err)
+  }
+
+  s.Hour = 1
+  s.Dom, s.Dow = 1<<dom.min, 0
+  if err := Lint(s); err != nil {
+    t.Errorf("expected the day of month to match, got %v", err)
+  }
+}
+
+func TestLintBusinessDays(t *testing.T) {
+  // Every sixth day of the week from Sunday is Sunday and Saturday.
+  schedule, err := Parse("0 0 0 1B * */6")
+  if err != nil {
+    t.Fatal(err)
+  }
+  if err := Lint(schedule); err == nil {
+    t.Error("expected weekends never to be business days")
+  }
+  schedule.(*SpecSchedule).BusinessDays = BusinessDayFunc(
+    func(t time.Time) bool { return t.Weekday() != time.Saturday })
+  if err := Lint(schedule); err != nil {
+    t.Errorf("expected Sunday to be a business day, got %v", err)
+  }
+}
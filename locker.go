@@ -0,0 +1,82 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements serializing the runs of entries between Crons.
+
+package cron
+
+import (
+  "errors"
+  "time"
+)
+
+// ErrLocked is the error of RunSkipped events of runs whose lock is held by
+// another Cron.
+var ErrLocked = errors.New("cron: run is locked by another scheduler")
+
+// Locker serializes the runs of entries between Crons, e.g. the replicas of a
+// service that all schedule the same entries, so that each activation runs on
+// only one of them.  Its methods are called from the goroutines of runs, so
+// they must be safe for concurrent use.
+type Locker interface {
+  // TryLock takes the lock of the entry's run scheduled for the given time,
+  // and returns whether it did: false if another Cron holds it, or it cannot
+  // be taken.  The time of a run delayed WithJitter is the activation it was
+  // delayed from, which is the same on all Crons.
+  TryLock(entryID string, scheduled time.Time) bool
+
+  // Unlock releases the lock of the entry's run, once it has finished.
+  // Lockers that hold a lock per activation may instead keep it until it
+  // expires, so that a Cron whose timer fires late, e.g. because of clock
+  // skew, does not take it again and run the activation twice.
+  Unlock(entryID string, scheduled time.Time)
+}
+
+// WithLocker takes the lock of each run from locker before starting its job,
+// and skips the run with ErrLocked if another Cron holds it.  Entries should
+// have the same IDs in all the Crons sharing locker, e.g. by being restored
+// from the same EntryStore.
+func WithLocker(locker Locker) Option {
+  return func(c *Cron) {
+    c.locker = locker
+  }
+}
+
+// tryLock takes the lock of the entry's run scheduled for the given time, by
+// the activation of its schedule the run was jittered from, if the Cron has a
+// Locker, and otherwise records that the run was skipped, and settles it if
+// other entries depend on it.  It is called from the goroutine of the run, and
+// returns whether the run may start.
+func (c *Cron) tryLock(e *Entry, scheduled, activation time.Time,
+  depended bool) bool {
+  if c.locker == nil || c.locker.TryLock(e.ID, activation) {
+    return true
+  }
+  c.logger.Infof("skipping job %s, whose run is locked by another scheduler",
+    e.ID)
+  c.mu.Lock()
+  defer c.unlock()
+  c.skip(e, scheduled, ErrLocked)
+  if depended {
+    c.settle(e, scheduled, ErrLocked)
+  }
+  return false
+}
+
+// releaseLock releases the lock of the entry's activation taken by tryLock.
+func (c *Cron) releaseLock(e *Entry, activation time.Time) {
+  if c.locker != nil {
+    c.locker.Unlock(e.ID, activation)
+  }
+}
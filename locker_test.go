@@ -0,0 +1,180 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for serializing runs between Crons.
+
+package cron
+
+import (
+  "reflect"
+  "sync"
+  "testing"
+  "time"
+)
+
+// memLocker is a Locker keeping the locks it was asked for until the end of
+// the test, and recording the locks released.
+type memLocker struct {
+  mu       sync.Mutex
+  held     map[string]bool
+  unlocked []string
+}
+
+func lockKey(entryID string, scheduled time.Time) string {
+  return entryID + "@" + scheduled.UTC().Format(time.RFC3339)
+}
+
+func (l *memLocker) TryLock(entryID string, scheduled time.Time) bool {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  key := lockKey(entryID, scheduled)
+  if l.held[key] {
+    return false
+  }
+  l.held[key] = true
+  return true
+}
+
+func (l *memLocker) Unlock(entryID string, scheduled time.Time) {
+  l.mu.Lock()
+  defer l.mu.Unlock()
+  l.unlocked = append(l.unlocked, lockKey(entryID, scheduled))
+}
+
+func TestLocker(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  locker := &memLocker{held: map[string]bool{}}
+  skipped := make(chan Event, 10)
+
+  // Two replicas schedule the same entry.
+  for i := 0; i < 2; i++ {
+    cron := New(WithClock(clock), WithLocation(time.UTC), WithLocker(locker))
+    cron.Subscribe(func(e Event) {
+      if e.Type == RunSkipped {
+        skipped <- e
+      }
+    })
+    if err := cron.Apply(StoredEntry{ID: "a", Spec: "@hourly",
+      Job: "test-echo", Payload: []byte("locked")}); err != nil {
+      t.Fatal(err)
+    }
+    cron.Start()
+    defer cron.Stop()
+  }
+
+  clock.Advance(time.Hour)
+  select {
+  case payload := <-storeRuns:
+    if payload != "locked" {
+      t.Errorf("expected the locked job to run, got %s", payload)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatal("expected a replica to run the job")
+  }
+  select {
+  case e := <-skipped:
+    if e.Err != ErrLocked || e.EntryID != "a" ||
+      !e.Scheduled.Equal(start.Add(time.Hour)) {
+      t.Errorf("expected the run skipped with %v, got %+v", ErrLocked, e)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatal("expected the other replica to skip the run")
+  }
+  select {
+  case <-storeRuns:
+    t.Error("expected the job to run once")
+  case <-time.After(10 * time.Millisecond):
+  }
+
+  waitFor(t, func() bool {
+    locker.mu.Lock()
+    defer locker.mu.Unlock()
+    return len(locker.unlocked) > 0
+  })
+  locker.mu.Lock()
+  defer locker.mu.Unlock()
+  expected := []string{lockKey("a", start.Add(time.Hour))}
+  if !reflect.DeepEqual(locker.unlocked, expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, locker.unlocked)
+  }
+}
+
+// TestLockerJitter checks that replicas that jittered an activation
+// differently lock it by the activation.
+func TestLockerJitter(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  locker := &memLocker{held: map[string]bool{}}
+  skipped := make(chan Event, 10)
+
+  for i := 0; i < 2; i++ {
+    cron := New(WithClock(clock), WithLocation(time.UTC), WithLocker(locker))
+    cron.Subscribe(func(e Event) {
+      if e.Type == RunSkipped {
+        skipped <- e
+      }
+    })
+    if err := cron.Apply(StoredEntry{ID: "a", Spec: "@hourly",
+      Job: "test-echo", Payload: []byte("jittered")}); err != nil {
+      t.Fatal(err)
+    }
+    delay := time.Duration(i+1) * time.Minute
+    cron.update("a", func(e *Entry) {
+      e.Jitter = 10 * time.Minute
+      e.Next = e.nominal.Add(delay)
+    })
+    cron.Start()
+    defer cron.Stop()
+  }
+
+  // The first replica runs the activation at 01:01, and the second, whose
+  // jitter delays it to 01:02, finds it locked.
+  clock.Advance(61 * time.Minute)
+  select {
+  case payload := <-storeRuns:
+    if payload != "jittered" {
+      t.Errorf("expected the jittered job to run, got %s", payload)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatal("expected a replica to run the job")
+  }
+  clock.Advance(time.Minute)
+  select {
+  case e := <-skipped:
+    if e.Err != ErrLocked || !e.Scheduled.Equal(start.Add(62*time.Minute)) {
+      t.Errorf("expected the later run skipped with %v, got %+v", ErrLocked,
+        e)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatal("expected the other replica to skip the run")
+  }
+  select {
+  case <-storeRuns:
+    t.Error("expected the job to run once")
+  case <-time.After(10 * time.Millisecond):
+  }
+
+  waitFor(t, func() bool {
+    locker.mu.Lock()
+    defer locker.mu.Unlock()
+    return len(locker.unlocked) > 0
+  })
+  locker.mu.Lock()
+  defer locker.mu.Unlock()
+  expected := []string{lockKey("a", start.Add(time.Hour))}
+  if !reflect.DeepEqual(locker.unlocked, expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, locker.unlocked)
+  }
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements the loggers a Cron reports its diagnostics to.
+
+package cron
+
+import (
+  "context"
+  "fmt"
+  "io"
+  "log"
+  "log/slog"
+  "os"
+)
+
+// Logger is the interface Cron logs through, e.g. skipped activations and
+// jobs that panicked.
+type Logger interface {
+  // Infof logs routine events.
+  Infof(format string, args ...interface{})
+  // Warningf logs problems, such as failed jobs.
+  Warningf(format string, args ...interface{})
+}
+
+// DefaultLogger logs warnings to standard error, and discards routine events.
+var DefaultLogger Logger = PrintfLogger(nil,
+  log.New(os.Stderr, "cron: ", log.LstdFlags))
+
+// DiscardLogger discards everything.
+var DiscardLogger Logger = PrintfLogger(nil, nil)
+
+// Printfer is implemented by *log.Logger, among others.
+type Printfer interface {
+  Printf(format string, args ...interface{})
+}
+
+// PrintfLogger returns a Logger that logs routine events to info and problems
+// to warning.  Either may be nil to discard those messages.
+func PrintfLogger(info, warning Printfer) Logger {
+  if info == nil {
+    info = log.New(io.Discard, "", 0)
+  }
+  if warning == nil {
+    warning = log.New(io.Discard, "", 0)
+  }
+  return printfLogger{info, warning}
+}
+
+// printfLogger is a Logger created by PrintfLogger.
+type printfLogger struct {
+  info, warning Printfer
+}
+
+// Infof logs to the info Printfer.
+func (l printfLogger) Infof(format string, args ...interface{}) {
+  l.info.Printf(format, args...)
+}
+
+// Warningf logs to the warning Printfer.
+func (l printfLogger) Warningf(format string, args ...interface{}) {
+  l.warning.Printf(format, args...)
+}
+
+// SlogLogger returns a Logger that logs routine events at the info level and
+// problems at the warning level of l.
+func SlogLogger(l *slog.Logger) Logger {
+  return slogLogger{l}
+}
+
+// slogLogger is a Logger created by SlogLogger.
+type slogLogger struct {
+  l *slog.Logger
+}
+
+// Infof logs at the info level.
+func (l slogLogger) Infof(format string, args ...interface{}) {
+  l.l.Info(fmt.Sprintf(format, args...))
+}
+
+// Warningf logs at the warning level.
+func (l slogLogger) Warningf(format string, args ...interface{}) {
+  l.l.Warn(fmt.Sprintf(format, args...))
+}
+
+// loggerKey is the context key of the Logger of the Cron running a job.
+type loggerKey struct{}
+
+// withLogger returns a copy of ctx carrying the logger, so that job wrappers
+// log through the Cron running them.
+func withLogger(ctx context.Context, logger Logger) context.Context {
+  return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// loggerFrom returns the logger carried by ctx, or DefaultLogger.
+func loggerFrom(ctx context.Context) Logger {
+  if logger, ok := ctx.Value(loggerKey{}).(Logger); ok {
+    return logger
+  }
+  return DefaultLogger
+}
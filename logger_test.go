@@ -0,0 +1,132 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for loggers.
+
+package cron
+
+import (
+  "bytes"
+  "context"
+  "errors"
+  "fmt"
+  "log"
+  "log/slog"
+  "strings"
+  "sync"
+  "testing"
+  "time"
+)
+
+// recordingLogger records the messages logged to it.
+type recordingLogger struct {
+  mu       sync.Mutex
+  messages []string
+}
+
+func (r *recordingLogger) Infof(format string, args ...interface{}) {
+  r.record("info: " + fmt.Sprintf(format, args...))
+}
+
+func (r *recordingLogger) Warningf(format string, args ...interface{}) {
+  r.record("warning: " + fmt.Sprintf(format, args...))
+}
+
+func (r *recordingLogger) record(msg string) {
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  r.messages = append(r.messages, msg)
+}
+
+// find returns the first recorded message containing substr.
+func (r *recordingLogger) find(substr string) (string, bool) {
+  r.mu.Lock()
+  defer r.mu.Unlock()
+  for _, msg := range r.messages {
+    if strings.Contains(msg, substr) {
+      return msg, true
+    }
+  }
+  return "", false
+}
+
+func TestPrintfLogger(t *testing.T) {
+  var info, warning bytes.Buffer
+  logger := PrintfLogger(log.New(&info, "", 0), log.New(&warning, "", 0))
+  logger.Infof("skipped %d", 1)
+  logger.Warningf("failed %d", 2)
+  if info.String() != "skipped 1\n" {
+    t.Errorf("unexpected info output %q", info.String())
+  }
+  if warning.String() != "failed 2\n" {
+    t.Errorf("unexpected warning output %q", warning.String())
+  }
+
+  // Nil Printfers discard their messages.
+  PrintfLogger(nil, nil).Warningf("dropped")
+}
+
+func TestSlogLogger(t *testing.T) {
+  var buf bytes.Buffer
+  logger := SlogLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+  logger.Warningf("job %s failed", "x")
+  if out := buf.String(); !strings.Contains(out, "level=WARN") ||
+    !strings.Contains(out, `msg="job x failed"`) {
+    t.Errorf("unexpected output %q", out)
+  }
+}
+
+func TestLoggerFrom(t *testing.T) {
+  if loggerFrom(context.Background()) != DefaultLogger {
+    t.Error("expected DefaultLogger without a logger in the context")
+  }
+  logger := &recordingLogger{}
+  if loggerFrom(withLogger(context.Background(), logger)) != logger {
+    t.Error("expected the logger in the context")
+  }
+}
+
+func TestWithLogger(t *testing.T) {
+  logger := &recordingLogger{}
+  wg := &sync.WaitGroup{}
+  wg.Add(2)
+
+  cron := New(WithLogger(logger))
+  cron.AddFunc("* * * * * ?", func() {
+    defer wg.Done()
+    panic("YOLO")
+  })
+  cron.AddJob("* * * * * ?", ErrorFuncJob(func(context.Context) error {
+    defer wg.Done()
+    return errors.New("boom")
+  }))
+  cron.Start()
+  defer cron.Stop()
+
+  select {
+  case <-time.After(cOneSecond):
+    t.Fatal("expected jobs to run")
+  case <-wait(wg):
+  }
+
+  // The jobs log after they return.
+  time.Sleep(10 * time.Millisecond)
+  for _, substr := range []string{"YOLO", "boom"} {
+    if msg, ok := logger.find(substr); !ok {
+      t.Errorf("expected a message containing %q", substr)
+    } else if !strings.HasPrefix(msg, "warning: ") {
+      t.Errorf("expected a warning, got %q", msg)
+    }
+  }
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements a Prometheus collector of a Cron's runs.
+
+// Package metrics exports the entries and runs of a cron.Cron as Prometheus
+// metrics:
+//
+// 	c := cron.New()
+// 	prometheus.MustRegister(metrics.NewCollector(c, "myapp"))
+//
+// Runs are labeled with the name of their entry, or its ID if it has none.
+package metrics
+
+import (
+  "sync"
+
+  "github.com/kiranbond/cron"
+  "github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a prometheus.Collector of the metrics of a Cron:
+//
+// 	cron_runs_total{entry, outcome}     Counter of finished and skipped runs,
+// 	                                    by outcome: completed, failed or skipped.
+// 	cron_run_duration_seconds{entry}    Histogram of the durations of runs.
+// 	cron_schedule_delay_seconds{entry}  Histogram of the delays between the
+// 	                                    times runs were scheduled and started.
+// 	cron_entries                        Gauge of the number of entries.
+//
+// The series of an entry are deleted when it is removed.
+type Collector struct {
+  cron        *cron.Cron
+  unsubscribe func()
+
+  runs     *prometheus.CounterVec
+  duration *prometheus.HistogramVec
+  delay    *prometheus.HistogramVec
+  entries  *prometheus.Desc
+
+  // labels caches the entry label of each entry ID.
+  mu     sync.Mutex
+  labels map[string]string
+}
+
+// NewCollector returns a Collector of the metrics of c, whose names are
+// prefixed by namespace, if it is not empty.  It observes the runs from then
+// on, until it is closed.
+func NewCollector(c *cron.Cron, namespace string) *Collector {
+  m := &Collector{
+    cron: c,
+    runs: prometheus.NewCounterVec(prometheus.CounterOpts{
+      Namespace: namespace,
+      Subsystem: "cron",
+      Name:      "runs_total",
+      Help:      "Number of finished and skipped runs, by entry and outcome.",
+    }, []string{"entry", "outcome"}),
+    duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+      Namespace: namespace,
+      Subsystem: "cron",
+      Name:      "run_duration_seconds",
+      Help:      "Durations of finished runs, by entry.",
+      Buckets:   prometheus.DefBuckets,
+    }, []string{"entry"}),
+    delay: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+      Namespace: namespace,
+      Subsystem: "cron",
+      Name:      "schedule_delay_seconds",
+      Help:      "Delays between the times runs were scheduled and started.",
+      Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+    }, []string{"entry"}),
+    entries: prometheus.NewDesc(
+      prometheus.BuildFQName(namespace, "cron", "entries"),
+      "Number of entries.", nil, nil),
+    labels: map[string]string{},
+  }
+  m.unsubscribe = c.Subscribe(m.observe)
+  return m
+}
+
+// Close stops observing the Cron's runs.
+func (m *Collector) Close() {
+  m.unsubscribe()
+}
+
+// Describe implements prometheus.Collector.
+func (m *Collector) Describe(ch chan<- *prometheus.Desc) {
+  m.runs.Describe(ch)
+  m.duration.Describe(ch)
+  m.delay.Describe(ch)
+  ch <- m.entries
+}
+
+// Collect implements prometheus.Collector.
+func (m *Collector) Collect(ch chan<- prometheus.Metric) {
+  m.runs.Collect(ch)
+  m.duration.Collect(ch)
+  m.delay.Collect(ch)
+  ch <- prometheus.MustNewConstMetric(m.entries, prometheus.GaugeValue,
+    float64(m.cron.Debug().Entries))
+}
+
+// outcomes are the outcome labels of the events of finished and skipped runs.
+var outcomes = map[cron.EventType]string{
+  cron.RunCompleted: "completed",
+  cron.RunFailed:    "failed",
+  cron.RunSkipped:   "skipped",
+}
+
+// observe records the event.
+func (m *Collector) observe(e cron.Event) {
+  switch e.Type {
+  case cron.RunStarted:
+    m.delay.WithLabelValues(m.label(e.EntryID)).Observe(
+      e.Time.Sub(e.Scheduled).Seconds())
+  case cron.RunCompleted, cron.RunFailed:
+    entry := m.label(e.EntryID)
+    m.runs.WithLabelValues(entry, outcomes[e.Type]).Inc()
+    m.duration.WithLabelValues(entry).Observe(e.Duration.Seconds())
+  case cron.RunSkipped:
+    m.runs.WithLabelValues(m.label(e.EntryID), outcomes[e.Type]).Inc()
+  case cron.EntryRemoved:
+    m.forget(e.EntryID)
+  }
+}
+
+// label returns the entry label of the entry with the given ID: its name, or
+// its ID if it has none.
+func (m *Collector) label(id string) string {
+  m.mu.Lock()
+  label, ok := m.labels[id]
+  m.mu.Unlock()
+  if ok {
+    return label
+  }
+
+  label = id
+  if entry, ok := m.cron.Entry(id); ok && entry.Name != "" {
+    label = entry.Name
+  }
+  m.mu.Lock()
+  m.labels[id] = label
+  m.mu.Unlock()
+  return label
+}
+
+// forget deletes the series of the removed entry with the given ID.
+func (m *Collector) forget(id string) {
+  m.mu.Lock()
+  label, ok := m.labels[id]
+  delete(m.labels, id)
+  m.mu.Unlock()
+  if !ok {
+    return
+  }
+  match := prometheus.Labels{"entry": label}
+  m.runs.DeletePartialMatch(match)
+  m.duration.DeletePartialMatch(match)
+  m.delay.DeletePartialMatch(match)
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the Prometheus collector.
+
+package metrics
+
+import (
+  "context"
+  "errors"
+  "strings"
+  "testing"
+  "time"
+
+  "github.com/kiranbond/cron"
+  "github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+  clock := cron.NewFakeClock(time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC))
+  c := cron.New(cron.WithClock(clock), cron.WithLocation(time.UTC))
+  m := NewCollector(c, "test")
+  defer m.Close()
+
+  done := make(chan struct{}, 10)
+  c.Subscribe(func(e cron.Event) {
+    if e.Type == cron.RunCompleted || e.Type == cron.RunFailed {
+      done <- struct{}{}
+    }
+  })
+  backup, _ := c.AddFunc("@hourly", func() { clock.Advance(2 * time.Second) },
+    cron.WithName("backup"))
+  failing, _ := c.AddJob("@hourly", cron.ErrorFuncJob(
+    func(context.Context) error { return errors.New("failed") }))
+  c.Start()
+  defer c.Stop()
+
+  clock.Advance(time.Hour)
+  for i := 0; i < 2; i++ {
+    select {
+    case <-done:
+    case <-time.After(time.Second):
+      t.Fatal("expected two runs")
+    }
+  }
+
+  tests := []struct {
+    entry, outcome string
+    expected       float64
+  }{
+    {"backup", "completed", 1},
+    {"backup", "failed", 0},
+    {failing, "failed", 1},
+  }
+  for _, test := range tests {
+    actual := testutil.ToFloat64(m.runs.WithLabelValues(test.entry,
+      test.outcome))
+    if actual != test.expected {
+      t.Errorf("%s %s: (expected) %v != %v (actual)", test.entry, test.outcome,
+        test.expected, actual)
+    }
+  }
+
+  entries := `
+# HELP test_cron_entries Number of entries.
+# TYPE test_cron_entries gauge
+test_cron_entries 2
+`
+  if err := testutil.CollectAndCompare(m, strings.NewReader(entries),
+    "test_cron_entries"); err != nil {
+    t.Error(err)
+  }
+
+  c.DeleteJob(backup)
+  if n := testutil.CollectAndCount(m, "test_cron_run_duration_seconds"); n != 1 {
+    t.Errorf("expected the series of the removed entry deleted, got %d", n)
+  }
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements policies for activations missed while the scheduler
+// was not running on time.
+
+package cron
+
+import "time"
+
+// misfireThreshold is how late the scheduler must wake up for its due
+// activations to be considered missed.
+const misfireThreshold = time.Second
+
+// MisfirePolicy decides which missed activations of an entry run when the
+// scheduler wakes up late, e.g. after the machine was suspended, the process
+// was paused, or a long garbage collection.
+type MisfirePolicy int
+
+const (
+  // MisfireRunAll runs every missed activation, one after the other.  It is
+  // the default.
+  MisfireRunAll MisfirePolicy = iota
+
+  // MisfireRunOnce runs the job once for all the missed activations.
+  MisfireRunOnce
+
+  // MisfireSkip skips the missed activations.
+  MisfireSkip
+)
+
+// WithMisfirePolicy sets the entry's misfire policy.
+func WithMisfirePolicy(policy MisfirePolicy) EntryOption {
+  return func(e *Entry) {
+    e.Misfire = policy
+  }
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for misfire policies.
+
+package cron
+
+import (
+  "sync"
+  "testing"
+  "time"
+)
+
+func TestMisfirePolicy(t *testing.T) {
+  tests := []struct {
+    policy   MisfirePolicy
+    now      string
+    runs     int
+    expected string
+  }{
+    // On time, every policy runs the activation.
+    {MisfireRunAll, "Mon Jul 9 14:00 2012", 1, "Mon Jul 9 15:00 2012"},
+    {MisfireRunOnce, "Mon Jul 9 14:00 2012", 1, "Mon Jul 9 15:00 2012"},
+    {MisfireSkip, "Mon Jul 9 14:00 2012", 1, "Mon Jul 9 15:00 2012"},
+
+    // Three hours late.
+    {MisfireRunAll, "Mon Jul 9 17:30 2012", 1, "Mon Jul 9 15:00 2012"},
+    {MisfireRunOnce, "Mon Jul 9 17:30 2012", 1, "Mon Jul 9 18:00 2012"},
+    {MisfireSkip, "Mon Jul 9 17:30 2012", 0, "Mon Jul 9 18:00 2012"},
+  }
+
+  for _, c := range tests {
+    wg := &sync.WaitGroup{}
+    wg.Add(c.runs)
+    cron := &Cron{pool: newPool(), logger: DiscardLogger, clock: realClock{},
+      location: time.Local}
+    effective := getTime("Mon Jul 9 14:00 2012")
+    e := &Entry{
+      Schedule: MustParse("@hourly"),
+      Job:      FuncJob(func() { wg.Done() }),
+      Next:     effective,
+      Misfire:  c.policy,
+    }
+    cron.entries = []*Entry{e}
+
+    cron.runDue(getTime(c.now))
+    wg.Wait()
+    if e.Runs != c.runs || !e.Next.Equal(getTime(c.expected)) {
+      t.Errorf("policy %d at %s: (expected) %d runs, next %s != %d, %v "+
+        "(actual)", c.policy, c.now, c.runs, c.expected, e.Runs, e.Next)
+    }
+  }
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements a Publisher of messages to NATS.
+
+// Package natspub publishes the messages of cron.PublishJobs to NATS:
+//
+// 	conn, err := nats.Connect(nats.DefaultURL)
+// 	...
+// 	cron.RegisterJob("nats", cron.PublishJobFactory(natspub.New(conn)))
+package natspub
+
+import (
+  "context"
+
+  "github.com/kiranbond/cron"
+)
+
+// Conn is the part of a *nats.Conn that publishes messages.
+type Conn interface {
+  Publish(subject string, data []byte) error
+  FlushWithContext(ctx context.Context) error
+}
+
+// Publisher is a cron.Publisher that publishes messages on NATS subjects.
+type Publisher struct {
+  conn Conn
+}
+
+var _ cron.Publisher = (*Publisher)(nil)
+
+// New returns a Publisher publishing with conn, typically a *nats.Conn.
+func New(conn Conn) *Publisher {
+  return &Publisher{conn: conn}
+}
+
+// Publish implements cron.Publisher.  It returns once the server has received
+// the message, so that runs fail if it cannot.
+func (p *Publisher) Publish(ctx context.Context, subject string,
+  message []byte) error {
+  if err := p.conn.Publish(subject, message); err != nil {
+    return err
+  }
+  return p.conn.FlushWithContext(ctx)
+}
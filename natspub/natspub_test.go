@@ -0,0 +1,67 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the NATS Publisher.
+
+package natspub
+
+import (
+  "context"
+  "errors"
+  "reflect"
+  "testing"
+
+  "github.com/nats-io/nats.go"
+)
+
+// NATS connections are Conns.
+var _ Conn = (*nats.Conn)(nil)
+
+// fakeConn is a Conn recording the messages it publishes and flushes.
+type fakeConn struct {
+  pending, flushed []string
+  err              error
+}
+
+func (c *fakeConn) Publish(subject string, data []byte) error {
+  c.pending = append(c.pending, subject+" "+string(data))
+  return nil
+}
+
+func (c *fakeConn) FlushWithContext(ctx context.Context) error {
+  if c.err != nil {
+    return c.err
+  }
+  c.flushed, c.pending = append(c.flushed, c.pending...), nil
+  return nil
+}
+
+func TestPublish(t *testing.T) {
+  conn := &fakeConn{}
+  p := New(conn)
+  if err := p.Publish(context.Background(), "ticks.report",
+    []byte("tick")); err != nil {
+    t.Fatal(err)
+  }
+  if expected := []string{"ticks.report tick"}; !reflect.DeepEqual(
+    conn.flushed, expected) {
+    t.Errorf("(expected) %q != %q (actual)", expected, conn.flushed)
+  }
+
+  conn.err = errors.New("disconnected")
+  if err := p.Publish(context.Background(), "ticks.report",
+    []byte("tick")); err != conn.err {
+    t.Errorf("expected the flush to fail, got %v", err)
+  }
+}
@@ -0,0 +1,269 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements spec normalization.
+
+package cron
+
+import (
+  "fmt"
+  "sort"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// Normalize returns the canonical form of the spec, so that specs can be
+// deduplicated and compared as strings.  Specs with the same canonical form
+// have the same schedule.
+//
+// The canonical form of a crontab spec has all six fields, numeric months and
+// days of the week (Sunday is 0), and lists of values in ascending order with
+// runs of three or more collapsed into ranges, e.g. "MON-FRI" is "1-5".
+// Descriptors that are crontab specs, such as "@daily", are expanded into
+// their fields, and EventBridge expressions are converted to the equivalent
+// crontab spec or "@every" descriptor.  The parts of unions are sorted.
+// Schedules bound to a time zone, such as EventBridge expressions, which are
+// in UTC, are prefixed with it, e.g. "TZ=UTC 0 0 12 * * *".
+//
+// Random values ("~") are picked by Normalize, so its result is the canonical
+// form of one of the schedules the spec may produce.
+func Normalize(spec string) (string, error) {
+  return defaultParser.Normalize(spec)
+}
+
+// Normalize returns the canonical form of the spec as interpreted with the
+// parser's options.  See the package level Normalize.
+func (p Parser) Normalize(spec string) (string, error) {
+  schedule, err := p.Parse(spec)
+  if err != nil {
+    return "", err
+  }
+  return p.formatSchedule(schedule)
+}
+
+// formatSchedule returns the canonical spec of the schedule, as read by the
+// parser.
+func (p Parser) formatSchedule(schedule Schedule) (string, error) {
+  switch s := schedule.(type) {
+  case *SpecSchedule:
+    return formatSpecSchedule(s)
+  case ConstantDelaySchedule:
+    return p.formatEvery(s)
+  case *LimitedSchedule:
+    if delay, ok := s.Schedule.(ConstantDelaySchedule); ok {
+      spec, err := p.formatEvery(delay)
+      if err != nil {
+        return "", err
+      }
+      return fmt.Sprintf("%s limit %d", spec, s.Limit), nil
+    }
+  case AtSchedule:
+    return "@at " + s.Time.UTC().Format(time.RFC3339), nil
+  case SolarSchedule:
+    event := "@sunrise"
+    if s.Event == Sunset {
+      event = "@sunset"
+    }
+    spec := fmt.Sprintf("%s %s,%s", event,
+      strconv.FormatFloat(s.Latitude, 'f', -1, 64),
+      strconv.FormatFloat(s.Longitude, 'f', -1, 64))
+    if s.Offset != 0 {
+      spec += " " + s.Offset.String()
+    }
+    return spec, nil
+  case *UnionSchedule:
+    var parts []string
+    for _, schedule := range s.Schedules {
+      part, err := p.formatSchedule(schedule)
+      if err != nil {
+        return "", err
+      }
+      parts = append(parts, part)
+    }
+    sort.Strings(parts)
+    return strings.Join(parts, " "+unionSeparator+" "), nil
+  case *ExceptSchedule:
+    include, err := p.formatSchedule(s.Schedule)
+    if err != nil {
+      return "", err
+    }
+    exclude, err := p.formatSchedule(s.Except)
+    if err != nil {
+      return "", err
+    }
+    return include + exceptSeparator + exclude, nil
+  }
+  return "", fmt.Errorf("cannot format schedule of type %T", schedule)
+}
+
+// formatEvery returns the "@every" descriptor of the schedule.  Specs have no
+// precision, so schedules whose precision differs from the one the parser gives
+// "@every" cannot be formatted.
+func (p Parser) formatEvery(s ConstantDelaySchedule) (string, error) {
+  if parsed := p.every(s.Delay); parsed.Delay != s.Delay ||
+    parsed.precision() != s.precision() {
+    return "", fmt.Errorf("cannot format schedule every %s with a precision "+
+      "of %s", s.Delay, s.Precision)
+  }
+  return "@every " + s.Delay.String(), nil
+}
+
+// formatSpecSchedule returns the canonical spec of the SpecSchedule.
+func formatSpecSchedule(s *SpecSchedule) (string, error) {
+  if s.Calendar != nil || s.BusinessDays != nil ||
+    s.DSTGap != DSTSkipGap || s.DSTOverlap != DSTRunTwice {
+    return "", fmt.Errorf("cannot format schedule with a calendar, business " +
+      "days or DST policy")
+  }
+
+  domField, err := formatDom(s)
+  if err != nil {
+    return "", err
+  }
+  dowField, err := formatDow(s)
+  if err != nil {
+    return "", err
+  }
+
+  fields := []string{"", "", "", domField, "", dowField}
+  for i, field := range []struct {
+    bits uint64
+    r    bounds
+  }{
+    {s.Second, seconds},
+    {s.Minute, minutes},
+    {s.Hour, hours},
+    {0, dom},
+    {s.Month, months},
+  } {
+    if i == 3 {
+      continue
+    }
+    if fields[i], err = formatField(field.bits, field.r); err != nil {
+      return "", err
+    }
+    if fields[i] == "" {
+      return "", fmt.Errorf("cannot format empty field")
+    }
+  }
+  spec := strings.Join(fields, " ")
+  if s.Location != nil {
+    spec = "TZ=" + s.Location.String() + " " + spec
+  }
+  return spec, nil
+}
+
+// formatDom returns the canonical form of the day of month field, including
+// its "L", "LW" and "B" values.
+func formatDom(s *SpecSchedule) (string, error) {
+  domField, err := formatField(s.Dom, dom)
+  if err != nil {
+    return "", err
+  }
+  for offset := uint(0); offset < dom.max; offset++ {
+    if s.Dom&(lastDomBit<<offset) == 0 {
+      continue
+    }
+    token := "L"
+    if offset > 0 {
+      token = fmt.Sprintf("L-%d", offset)
+    }
+    domField = appendToField(domField, token)
+  }
+  if s.Dom&lastWeekdayBit > 0 {
+    domField = appendToField(domField, "LW")
+  }
+  for n := dom.min; n <= dom.max; n++ {
+    if 1<<n&s.BusinessDay > 0 {
+      domField = appendToField(domField, fmt.Sprintf("%dB", n))
+    }
+  }
+  if s.BusinessDay&lastBusinessDayBit > 0 {
+    domField = appendToField(domField, "LB")
+  }
+  if domField == "" {
+    return "", fmt.Errorf("cannot format empty day of month field")
+  }
+  return domField, nil
+}
+
+// formatDow returns the canonical form of the day of week field, including its
+// "nL" values.
+func formatDow(s *SpecSchedule) (string, error) {
+  dowField, err := formatField(s.Dow, dow)
+  if err != nil {
+    return "", err
+  }
+  for day := dow.min; day <= dow.max; day++ {
+    if s.Dow&(lastDowBit<<day) > 0 {
+      dowField = appendToField(dowField, fmt.Sprintf("%dL", day))
+    }
+  }
+  if dowField == "" {
+    return "", fmt.Errorf("cannot format empty day of week field")
+  }
+  return dowField, nil
+}
+
+// appendToField appends an element to a list field.
+func appendToField(field, element string) string {
+  if field == "" {
+    return element
+  }
+  return field + "," + element
+}
+
+// formatField returns the canonical form of the values of a field: "*" for all
+// values, "*/n" for every n values, and a list otherwise.  The star bit is kept
+// only if it can be expressed.
+func formatField(bits uint64, r bounds) (string, error) {
+  values := bits & getBits(r.min, r.max, 1)
+  if bits&starBit > 0 {
+    for step := r.min; step <= r.max; step++ {
+      if step > 0 && values == getBits(r.min, r.max, step) {
+        if step == 1 {
+          return "*", nil
+        }
+        return fmt.Sprintf("*/%d", step), nil
+      }
+    }
+    // Only the day of month and day of week depend on the star bit.
+    if r.max == dom.max || r.max == dow.max {
+      return "", fmt.Errorf("cannot format field with a star and other "+
+        "values: %b", bits)
+    }
+  }
+
+  var elements []string
+  for v := r.min; v <= r.max; v++ {
+    if 1<<v&values == 0 {
+      continue
+    }
+    end := v
+    for end+1 <= r.max && 1<<(end+1)&values > 0 {
+      end++
+    }
+    switch {
+    case end-v >= 2:
+      elements = append(elements, fmt.Sprintf("%d-%d", v, end))
+    case end-v == 1:
+      elements = append(elements, fmt.Sprint(v), fmt.Sprint(end))
+    default:
+      elements = append(elements, fmt.Sprint(v))
+    }
+    v = end
+  }
+  return strings.Join(elements, ","), nil
+}
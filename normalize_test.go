@@ -0,0 +1,134 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for spec normalization.
+
+package cron
+
+import (
+  "testing"
+  "time"
+)
+
+func TestNormalize(t *testing.T) {
+  entries := []struct {
+    spec     string
+    expected string
+  }{
+    {"0 0 9 * * MON-FRI", "0 0 9 * * 1-5"},
+    {"0 0 9 * * mon,tue,wed,thu,fri", "0 0 9 * * 1-5"},
+    {"30,0,15 5 1 * *", "0,15,30 5 1 * * *"},
+    {"0 0 0 1,2,5 jan,feb *", "0 0 0 1,2,5 1,2 *"},
+    {"0 */15 * * * *", "0 */15 * * * *"},
+    {"0 0-59/15 * * * *", "0 0,15,30,45 * * * *"},
+    {"0 0 0 ? * SAT,SUN", "0 0 0 * * 0,6"},
+    {"0 0 0 L-2,1 * *", "0 0 0 1,L-2 * *"},
+    {"0 0 9 LB,3B * *", "0 0 9 3B,LB * *"},
+    {"0 0 9 LW * *", "0 0 9 LW * *"},
+    {"0 0 9 ? * FRIL,1", "0 0 9 * * 1,5L"},
+    {"@daily", "0 0 0 * * *"},
+    {"@every 90m", "@every 1h30m0s"},
+    {"@every 90m limit 3", "@every 1h30m0s limit 3"},
+    {"@at 2025-06-01T05:00:00+02:00", "@at 2025-06-01T03:00:00Z"},
+    {"@sunset 37.7749,-122.4194 -30m", "@sunset 37.7749,-122.4194 -30m0s"},
+    {"cron(0 12 ? * MON-FRI *)", "TZ=UTC 0 0 12 * * 1-5"},
+    {"TZ=Europe/Paris 0 0 9 * * *", "TZ=Europe/Paris 0 0 9 * * *"},
+    {"rate(5 minutes)", "@every 5m0s"},
+    {"@hourly || @daily", "0 0 * * * * || 0 0 0 * * *"},
+    {"@daily || @hourly", "0 0 * * * * || 0 0 0 * * *"},
+    {"@hourly except 0 0 2-4 * * *", "0 0 * * * * except 0 0 2-4 * * *"},
+  }
+
+  for _, c := range entries {
+    actual, err := Normalize(c.spec)
+    if err != nil {
+      t.Errorf("%s => unexpected error %v", c.spec, err)
+      continue
+    }
+    if actual != c.expected {
+      t.Errorf("%s => (expected) %s != %s (actual)", c.spec, c.expected,
+        actual)
+    }
+  }
+}
+
+func TestNormalizeSameSchedule(t *testing.T) {
+  specs := []string{
+    "0 0 9 * * MON-FRI",
+    "0 */15 8-17 1,15 * *",
+    "0 0 0 L,L-3,10B * *",
+    "@weekly",
+    "0 0 9 * * MON-FRI || 0 0 11 * * SAT",
+  }
+
+  for _, spec := range specs {
+    normalized, err := Normalize(spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    expected, err := Parse(spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    actual, err := Parse(normalized)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    for tm := getTime("Mon Jul 9 00:00 2012"); tm.Year() == 2012; {
+      next := expected.Next(tm)
+      if n := actual.Next(tm); !n.Equal(next) {
+        t.Errorf("%s, %s: (expected) %v != %v (actual)", spec, normalized,
+          next, n)
+        break
+      }
+      tm = next
+    }
+  }
+}
+
+func TestNormalizeErrors(t *testing.T) {
+  invalidSpecs := []string{
+    "",
+    "0 0 9 * * MON-SUN-TUE",
+    "0 0 0 */10,5 * MON",
+  }
+  for _, spec := range invalidSpecs {
+    if _, err := Normalize(spec); err == nil {
+      t.Error("expected an error normalizing: ", spec)
+    }
+  }
+}
+
+// TestNormalizePrecision checks that "@every" keeps the precision of the
+// parser, and that other precisions are not dropped.
+func TestNormalizePrecision(t *testing.T) {
+  actual, err := NewParser(SubSecond).Normalize("@every 250ms")
+  if err != nil {
+    t.Fatal(err)
+  }
+  if actual != "@every 250ms" {
+    t.Errorf("(expected) @every 250ms != %s (actual)", actual)
+  }
+
+  schedule := EveryWithPrecision(2*time.Second, 250*time.Millisecond)
+  if spec, err := defaultParser.formatSchedule(schedule); err == nil {
+    t.Errorf("expected an error formatting %+v, got %s", schedule, spec)
+  }
+  if _, err := defaultParser.formatSchedule(Every(2 * time.Second)); err != nil {
+    t.Error(err)
+  }
+}
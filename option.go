@@ -0,0 +1,189 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements options for creating a Cron.
+
+package cron
+
+import (
+  "context"
+  "time"
+)
+
+// Option configures a Cron created with New.
+type Option func(*Cron)
+
+// WithChain wraps every job added to the Cron with the given JobWrappers, the
+// first being the outermost.
+func WithChain(wrappers ...JobWrapper) Option {
+  return func(c *Cron) {
+    c.chain = NewChain(wrappers...)
+  }
+}
+
+// WithParser parses the specs of jobs added to the Cron with p instead of
+// Parse, e.g. to accept sub-second intervals:
+//
+// 	c := cron.New(cron.WithParser(cron.NewParser(cron.SubSecond)))
+// 	c.AddFunc("@every 250ms", poll)
+func WithParser(p Parser) Option {
+  return func(c *Cron) {
+    c.parser = p
+  }
+}
+
+// WithLocation evaluates schedules in the given time zone, e.g. time.UTC,
+// instead of the machine's local time zone.  Entries added with InLocation
+// are still evaluated in their own time zones.
+func WithLocation(loc *time.Location) Option {
+  return func(c *Cron) {
+    c.location = loc
+  }
+}
+
+// WithClock reads the time from clock instead of the time package, e.g. a
+// FakeClock in tests.
+func WithClock(clock Clock) Option {
+  return func(c *Cron) {
+    c.clock = clock
+  }
+}
+
+// WithLogger logs the Cron's diagnostics, including those of its job wrappers,
+// to logger instead of DefaultLogger.
+func WithLogger(logger Logger) Option {
+  return func(c *Cron) {
+    c.logger = logger
+  }
+}
+
+// WithPanicHandler calls handler with the panics of jobs, e.g. to report them
+// to an error tracker, instead of logging them.
+func WithPanicHandler(handler PanicHandler) Option {
+  return func(c *Cron) {
+    c.onPanic = handler
+  }
+}
+
+// EntryOption configures an entry added to a Cron.
+type EntryOption func(*Entry)
+
+// WithMaxRuns removes the entry once its job has been started on schedule n
+// times.
+func WithMaxRuns(n int) EntryOption {
+  return func(e *Entry) {
+    e.MaxRuns = n
+  }
+}
+
+// WithNotBefore skips the activations of the entry before t, e.g. those of a
+// campaign that has not started yet.
+func WithNotBefore(t time.Time) EntryOption {
+  return func(e *Entry) {
+    e.NotBefore = t
+  }
+}
+
+// WithNotAfter skips the activations of the entry after t, after which the
+// entry expires.
+func WithNotAfter(t time.Time) EntryOption {
+  return func(e *Entry) {
+    e.NotAfter = t
+  }
+}
+
+// WithRemoveWhenExpired removes the entry once it expired, after the last of
+// its activations before its NotAfter, instead of keeping it.
+func WithRemoveWhenExpired() EntryOption {
+  return func(e *Entry) {
+    e.RemoveWhenExpired = true
+  }
+}
+
+// WithRunOnAdd runs the entry's job once as soon as the entry is added, as
+// RunNow does, in addition to its scheduled runs, e.g. to run a job now and
+// then every hour.  If the Cron is not running yet, the job runs when it
+// starts.
+func WithRunOnAdd() EntryOption {
+  return func(e *Entry) {
+    e.runOnAdd = true
+  }
+}
+
+// WithName names the entry, e.g. "nightly-backup", so that it can be found or
+// deleted by name.  Names are unique within a Cron: adding an entry whose name
+// is taken adds nothing, and returns the ID of the entry that has it.
+func WithName(name string) EntryOption {
+  return func(e *Entry) {
+    e.Name = name
+  }
+}
+
+// WithLabels adds the labels to those of the entry.
+func WithLabels(labels map[string]string) EntryOption {
+  return func(e *Entry) {
+    if e.Labels == nil {
+      e.Labels = map[string]string{}
+    }
+    for k, v := range labels {
+      e.Labels[k] = v
+    }
+  }
+}
+
+// WithWrappers decorates the entry's job with the wrappers, inside the Cron's
+// chain, e.g. to retry the runs of only some jobs.  The jobs it is replaced
+// with, by ReplaceJob or Apply, are decorated too.
+func WithWrappers(wrappers ...JobWrapper) EntryOption {
+  return func(e *Entry) {
+    e.wrappers = append(e.wrappers, wrappers...)
+  }
+}
+
+// WithContext makes the values of ctx, e.g. a request ID or the baggage of a
+// trace, available from the contexts of the entry's runs, except those the
+// Cron sets, such as the RunInfo.  The runs are not canceled with ctx.
+func WithContext(ctx context.Context) EntryOption {
+  return func(e *Entry) {
+    e.values = ctx
+  }
+}
+
+// WithPriority sets the priority of the entry: of the entries due at the same
+// time, those with higher priorities start first, and so do their runs waiting
+// for the limits of WithMaxConcurrent and WithGroupLimit.
+func WithPriority(priority int) EntryOption {
+  return func(e *Entry) {
+    e.Priority = priority
+  }
+}
+
+// WithJitter delays every activation of the entry by a random duration up to
+// d, so that replicas running the same schedule do not all start at once.  The
+// delay is added to the schedule's activations, rather than being slept by the
+// job, so Next, Prev and events give the time the job actually starts.  d
+// should be less than the shortest interval between activations.
+func WithJitter(d time.Duration) EntryOption {
+  return func(e *Entry) {
+    e.Jitter = d
+  }
+}
+
+// InLocation evaluates the entry's schedule in the given time zone, e.g. so
+// that "0 0 9 * * *" runs at nine in that zone.
+func InLocation(loc *time.Location) EntryOption {
+  return func(e *Entry) {
+    e.Location = loc
+  }
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements recording the output of runs.
+
+package cron
+
+import (
+  "context"
+  "sync"
+)
+
+// MaxOutput is the number of bytes of the output of a run that are kept; the
+// end of longer output is kept.
+const MaxOutput = 64 << 10
+
+// runOutput is the output recorded by a run's job.
+type runOutput struct {
+  mu     sync.Mutex
+  output string
+}
+
+// runOutputKey is the context key of the runOutput of a run.
+type runOutputKey struct{}
+
+// withRunOutput returns a copy of ctx in which the run's job records its
+// output, and the output it records.
+func withRunOutput(ctx context.Context) (context.Context, *runOutput) {
+  output := &runOutput{}
+  return context.WithValue(ctx, runOutputKey{}, output), output
+}
+
+// SetOutput records the output of the run whose ContextJob or ErrorJob was
+// passed ctx, e.g. what a command it ran printed, so that it is reported in
+// the run's RunRecord and its RunCompleted or RunFailed event.  It replaces
+// the output recorded before, and does nothing if ctx is not that of a run.
+func SetOutput(ctx context.Context, output string) {
+  o, ok := ctx.Value(runOutputKey{}).(*runOutput)
+  if !ok {
+    return
+  }
+  if len(output) > MaxOutput {
+    output = output[len(output)-MaxOutput:]
+  }
+  o.mu.Lock()
+  defer o.mu.Unlock()
+  o.output = output
+}
+
+// get returns the recorded output.
+func (o *runOutput) get() string {
+  o.mu.Lock()
+  defer o.mu.Unlock()
+  return o.output
+}
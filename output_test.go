@@ -0,0 +1,65 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for recording the output of runs.
+
+package cron
+
+import (
+  "context"
+  "errors"
+  "strings"
+  "testing"
+)
+
+func TestSetOutput(t *testing.T) {
+  cron := New(WithLogger(DiscardLogger))
+  defer cron.Stop()
+  events := make(chan Event, 100)
+  cron.Subscribe(func(e Event) { events <- e })
+
+  ok, _ := cron.AddJob("@hourly", ErrorFuncJob(func(ctx context.Context) error {
+    SetOutput(ctx, "first")
+    SetOutput(ctx, "done")
+    return nil
+  }))
+  failed, _ := cron.AddJob("@hourly", ErrorFuncJob(
+    func(ctx context.Context) error {
+      SetOutput(ctx, strings.Repeat("x", MaxOutput)+"end")
+      return errors.New("failed")
+    }))
+  silent, _ := cron.AddFunc("@hourly", func() {})
+
+  cron.RunNow(ok)
+  if e := nextEvent(t, events, RunCompleted); e.Output != "done" {
+    t.Errorf("expected the output done, got %q", e.Output)
+  }
+  cron.RunNow(failed)
+  if e := nextEvent(t, events, RunFailed); len(e.Output) != MaxOutput ||
+    !strings.HasSuffix(e.Output, "xend") {
+    t.Errorf("expected the end of the output, got %d bytes", len(e.Output))
+  }
+  cron.RunNow(silent)
+  if e := nextEvent(t, events, RunCompleted); e.Output != "" {
+    t.Errorf("expected no output, got %q", e.Output)
+  }
+
+  if e, _ := cron.Entry(ok); len(e.History) != 1 ||
+    e.History[0].Output != "done" {
+    t.Errorf("expected the output in the history, got %+v", e.History)
+  }
+
+  // Outside of runs, SetOutput does nothing.
+  SetOutput(context.Background(), "ignored")
+}
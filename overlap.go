@@ -0,0 +1,101 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements reporting the overlaps of the activations of entries.
+
+package cron
+
+import "time"
+
+// Overlap is a time at which several entries are activated at once, as
+// reported by Overlaps.
+type Overlap struct {
+  // Time is the time of the activations.
+  Time time.Time
+
+  // EntryIDs are the IDs of the entries activated, ordered by priority.
+  EntryIDs []string
+}
+
+// Overlaps returns the times from from to to, inclusive, at which more than k
+// of the Cron's activations happen at once, as Simulate lists them, e.g. to
+// find the peaks of its load.  Like Simulate, it returns none if to is zero or
+// before from.
+func (c *Cron) Overlaps(from, to time.Time, k int) []Overlap {
+  var overlaps []Overlap
+  activations := c.Simulate(from, to)
+  for i := 0; i < len(activations); {
+    j := i + 1
+    for j < len(activations) &&
+      activations[j].Scheduled.Equal(activations[i].Scheduled) {
+      j++
+    }
+    if j-i > k {
+      ids := make([]string, 0, j-i)
+      for _, a := range activations[i:j] {
+        ids = append(ids, a.EntryID)
+      }
+      overlaps = append(overlaps, Overlap{activations[i].Scheduled, ids})
+    }
+    i = j
+  }
+  return overlaps
+}
+
+// Collision is a pair of activations of two entries within the tolerance of
+// each other, as reported by Collisions.
+type Collision struct {
+  // A and B are the times of the activations of the first and the second
+  // entry.
+  A, B time.Time
+}
+
+// Collisions returns the pairs of activations of the entries with IDs a and b
+// from from to to, inclusive, that are at most tolerance apart, as Simulate
+// lists them, ordered by the activations of a, e.g. to check that a backup
+// does not run close to a batch job.  It returns an error if the Cron has no
+// entry with either ID, and no collisions if to is zero or before from.
+func (c *Cron) Collisions(a, b string, from, to time.Time,
+  tolerance time.Duration) ([]Collision, error) {
+  c.mu.RLock()
+  ea, eb := c.ids[a], c.ids[b]
+  var as, bs []time.Time
+  if ea != nil && eb != nil && validWindow(from, to) {
+    as, bs = c.simulate(ea, from, to, -1), c.simulate(eb, from, to, -1)
+  }
+  c.mu.RUnlock()
+  if ea == nil {
+    return nil, entryRef{id: a}.notFound()
+  }
+  if eb == nil {
+    return nil, entryRef{id: b}.notFound()
+  }
+
+  var collisions []Collision
+  first := 0
+  for _, ta := range as {
+    // The activations of b before the window of ta are before those of the
+    // next activations of a too.
+    for first < len(bs) && ta.Sub(bs[first]) > tolerance {
+      first++
+    }
+    for _, tb := range bs[first:] {
+      if tb.Sub(ta) > tolerance {
+        break
+      }
+      collisions = append(collisions, Collision{ta, tb})
+    }
+  }
+  return collisions, nil
+}
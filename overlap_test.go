@@ -0,0 +1,102 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for reporting the overlaps of the activations of
+// entries.
+
+package cron
+
+import (
+  "reflect"
+  "testing"
+  "time"
+)
+
+func TestOverlaps(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  cron := New(WithClock(NewFakeClock(start)), WithLocation(time.UTC))
+  hourly, _ := cron.AddFunc("@hourly", func() {})
+  even, _ := cron.AddFunc("0 0 */2 * * *", func() {}, WithPriority(1))
+  third, _ := cron.AddFunc("0 0 */3 * * *", func() {}, WithPriority(2))
+
+  tests := []struct {
+    k        int
+    expected []Overlap
+  }{
+    {1, []Overlap{
+      {start.Add(2 * time.Hour), []string{even, hourly}},
+      {start.Add(3 * time.Hour), []string{third, hourly}},
+      {start.Add(4 * time.Hour), []string{even, hourly}},
+      {start.Add(6 * time.Hour), []string{third, even, hourly}},
+    }},
+    {2, []Overlap{
+      {start.Add(6 * time.Hour), []string{third, even, hourly}},
+    }},
+    {3, nil},
+  }
+  for _, test := range tests {
+    overlaps := cron.Overlaps(start.Add(time.Hour), start.Add(6*time.Hour),
+      test.k)
+    if !reflect.DeepEqual(overlaps, test.expected) {
+      t.Errorf("%d: (expected) %+v != %+v (actual)", test.k, test.expected,
+        overlaps)
+    }
+  }
+}
+
+func TestCollisions(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  cron := New(WithClock(NewFakeClock(start)), WithLocation(time.UTC))
+  backup, _ := cron.AddFunc("0 0 */2 * * *", func() {})
+  batch, _ := cron.AddFunc("0 */20 * * * *", func() {})
+
+  at := func(d time.Duration) time.Time { return start.Add(d) }
+  tests := []struct {
+    tolerance time.Duration
+    expected  []Collision
+  }{
+    {0, []Collision{
+      {at(2 * time.Hour), at(2 * time.Hour)},
+      {at(4 * time.Hour), at(4 * time.Hour)},
+    }},
+    {20 * time.Minute, []Collision{
+      {at(2 * time.Hour), at(100 * time.Minute)},
+      {at(2 * time.Hour), at(2 * time.Hour)},
+      {at(2 * time.Hour), at(140 * time.Minute)},
+      {at(4 * time.Hour), at(220 * time.Minute)},
+      {at(4 * time.Hour), at(4 * time.Hour)},
+    }},
+  }
+  for _, test := range tests {
+    collisions, err := cron.Collisions(backup, batch, at(90*time.Minute),
+      at(4*time.Hour), test.tolerance)
+    if err != nil {
+      t.Fatal(err)
+    }
+    if !reflect.DeepEqual(collisions, test.expected) {
+      t.Errorf("%v: (expected) %+v != %+v (actual)", test.tolerance,
+        test.expected, collisions)
+    }
+  }
+
+  if _, err := cron.Collisions(backup, "missing", start, at(time.Hour),
+    0); err == nil {
+    t.Error("expected an error for a missing entry")
+  }
+  if collisions, err := cron.Collisions(backup, batch, start, time.Time{},
+    0); err != nil || len(collisions) != 0 {
+    t.Errorf("expected no collisions without an end, got %+v, %v",
+      collisions, err)
+  }
+}
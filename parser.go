@@ -19,18 +19,120 @@ package cron
 import (
   "fmt"
   "math"
+  "math/rand"
   "strconv"
   "strings"
   "time"
 )
 
+// ParseOption configures how a Parser interprets specs.  Options may be
+// combined with "|".
+type ParseOption int
+
+const (
+  // ISOWeekday numbers the day of week field 1-7 starting on Monday, as in
+  // ISO 8601, instead of 0-6 starting on Sunday.
+  ISOWeekday ParseOption = 1 << iota
+
+  // Strict rejects valid but degenerate fields: empty list elements ("1,,2"),
+  // duplicate values ("1,1-5"), and steps larger than their range ("*/90" in
+  // the minutes field), which only ever match the start of the range.
+  Strict
+
+  // QuartzQuestionMark gives "?" its Quartz meaning of "no specific value":
+  // it must be used, on its own, in exactly one of the day of month and day of
+  // week fields, and the other field alone selects the days.  Without this
+  // option "?" is a synonym for "*".  A missing day of week field is "?".
+  QuartzQuestionMark
+
+  // Standard reads specs as classic 5 field crontabs, starting with the
+  // minute, as found in crontab files.  The second is always 0.
+  Standard
+
+  // SubSecond keeps "@every" durations to the millisecond, e.g. "@every
+  // 250ms", instead of rounding them to whole seconds.  Crontab specs still
+  // activate on the second.
+  SubSecond
+
+  // ShiftDSTGaps sets the DSTShiftGap policy on parsed SpecSchedules, so that
+  // activations at wall times skipped when clocks spring forward run at the
+  // shifted time, instead of not at all.
+  ShiftDSTGaps
+
+  // RunDSTOverlapsOnce sets the DSTRunOnce policy on parsed SpecSchedules, so
+  // that activations at wall times repeated when clocks fall back run once,
+  // instead of twice.
+  RunDSTOverlapsOnce
+)
+
+// Parser parses cron specs according to a set of ParseOptions.
+type Parser struct {
+  options  ParseOption
+  location *time.Location
+}
+
+// NewParser returns a Parser with the given options.
+func NewParser(options ParseOption) Parser {
+  return Parser{options: options}
+}
+
+// defaultParser is the Parser used by Parse.
+var defaultParser = Parser{}
+
+// standardParser is the Parser used by ParseStandard.
+var standardParser = Parser{options: Standard}
+
 // Parse returns a new crontab schedule representing the given spec.
 // It returns a descriptive error if the spec is not valid.
 //
 // It accepts
 //   - Full crontab specs, e.g. "* * * * * ?"
 //   - Descriptors, e.g. "@midnight", "@every 1h30m"
-func Parse(spec string) (_ Schedule, err error) {
+//   - EventBridge expressions, e.g. "cron(0 12 * * ? *)", "rate(5 minutes)"
+//   - Unions of the above, e.g. "0 0 9 * * MON-FRI || 0 0 11 * * SAT"
+//   - Exclusions of the above, e.g. "@hourly except 0 0 2-4 * * *"
+//   - Any of the above prefixed with a time zone, which the schedule is bound
+//     to, e.g. "TZ=America/New_York 0 0 9 * * *" (or "CRON_TZ=...")
+func Parse(spec string) (Schedule, error) {
+  return defaultParser.Parse(spec)
+}
+
+// MustParse is like Parse but panics if the spec is not valid.  It simplifies
+// initialization of package level variables holding static schedules.
+func MustParse(spec string) Schedule {
+  schedule, err := Parse(spec)
+  if err != nil {
+    panic(fmt.Sprintf("cron: Parse(%q): %s", spec, err))
+  }
+  return schedule
+}
+
+// ParseStandard is Parse, but reads crontab specs as classic 5 field crontabs:
+// (minute) (hour) (day of month) (month) (day of week)
+// Such specs passed to Parse are silently read as starting with the second.
+func ParseStandard(spec string) (Schedule, error) {
+  return standardParser.Parse(spec)
+}
+
+// ParseInLocation is Parse, but binds the returned schedule to the given
+// location, so that it is evaluated in that location rather than in the
+// location of the times passed to Next.
+func ParseInLocation(spec string, loc *time.Location) (Schedule, error) {
+  return defaultParser.ParseInLocation(spec, loc)
+}
+
+// ParseInLocation is Parse, but binds the returned schedule to the given
+// location.  See the package level ParseInLocation.
+func (p Parser) ParseInLocation(spec string, loc *time.Location) (Schedule,
+  error) {
+  p.location = loc
+  return p.Parse(spec)
+}
+
+// Parse returns a new crontab schedule representing the given spec, as
+// interpreted with the parser's options.  See the package level Parse for the
+// accepted specs.
+func (p Parser) Parse(spec string) (_ Schedule, err error) {
   // Convert panics into errors
   defer func() {
     if recovered := recover(); recovered != nil {
@@ -38,21 +140,51 @@ func Parse(spec string) (_ Schedule, err error) {
     }
   }()
 
+  if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+    return p.parseTimeZone(spec)
+  }
+
+  if strings.Contains(spec, exceptSeparator) {
+    return p.parseExcept(spec)
+  }
+
+  if strings.Contains(spec, unionSeparator) {
+    return p.parseUnion(spec)
+  }
+
   if spec[0] == '@' {
-    return parseDescriptor(spec)
+    return p.inLocation(p.parseDescriptor(spec))
   }
 
-  // Split on whitespace.  We require 5 or 6 fields.
-  // (second) (minute) (hour) (day of month) (month) (day of week, optional)
-  fields := strings.Fields(spec)
-  if len(fields) != 5 && len(fields) != 6 {
-    return nil, fmt.Errorf("expected 5 or 6 fields, found %d: %s", len(fields),
-      spec)
+  if strings.HasPrefix(spec, awsCronPrefix) ||
+    strings.HasPrefix(spec, awsRatePrefix) {
+    return p.inLocation(parseEventBridge(spec))
+  }
+
+  fields, err := p.fields(spec)
+  if err != nil {
+    return nil, err
   }
 
   // If a sixth field is not provided (DayOfWeek), then it is equivalent to star.
   if len(fields) == 5 {
-    fields = append(fields, "*")
+    if p.options&QuartzQuestionMark > 0 {
+      fields = append(fields, "?")
+    } else {
+      fields = append(fields, "*")
+    }
+  }
+
+  if p.options&QuartzQuestionMark > 0 {
+    if err := checkQuestionMarks(fields); err != nil {
+      return nil, err
+    }
+  }
+
+  if p.options&Strict > 0 {
+    if err := p.checkStrict(fields); err != nil {
+      return nil, err
+    }
   }
 
   second, err := getField(fields[0], seconds)
@@ -67,7 +199,7 @@ func Parse(spec string) (_ Schedule, err error) {
   if err != nil {
     return nil, err
   }
-  dom, err := getField(fields[3], dom)
+  dom, businessDay, err := getDomField(fields[3])
   if err != nil {
     return nil, err
   }
@@ -75,23 +207,190 @@ func Parse(spec string) (_ Schedule, err error) {
   if err != nil {
     return nil, err
   }
-  dow, err := getField(fields[5], dow)
+  dow, err := p.getDowField(fields[5])
   if err != nil {
     return nil, err
   }
 
   schedule := &SpecSchedule{
-    Second: second,
-    Minute: minute,
-    Hour:   hour,
-    Dom:    dom,
-    Month:  month,
-    Dow:    dow,
+    Second:      second,
+    Minute:      minute,
+    Hour:        hour,
+    Dom:         dom,
+    Month:       month,
+    Dow:         dow,
+    BusinessDay: businessDay,
+    Location:    p.location,
   }
+  p.setDST(schedule)
 
   return schedule, nil
 }
 
+// fields splits a crontab spec into its fields, adding the second if the
+// parser reads standard specs.
+func (p Parser) fields(spec string) ([]string, error) {
+  fields := strings.Fields(spec)
+  if p.options&Standard > 0 {
+    // (minute) (hour) (day of month) (month) (day of week)
+    if len(fields) != 5 {
+      return nil, fmt.Errorf("expected 5 fields, found %d: %s", len(fields),
+        spec)
+    }
+    return append([]string{"0"}, fields...), nil
+  }
+
+  // Split on whitespace.  We require 5 or 6 fields.
+  // (second) (minute) (hour) (day of month) (month) (day of week, optional)
+  if len(fields) != 5 && len(fields) != 6 {
+    return nil, fmt.Errorf("expected 5 or 6 fields, found %d: %s", len(fields),
+      spec)
+  }
+  return fields, nil
+}
+
+// parseTimeZone parses a spec prefixed with its time zone, "TZ=<zone> <spec>"
+// or "CRON_TZ=<zone> <spec>", binding the schedule to that zone.
+func (p Parser) parseTimeZone(spec string) (Schedule, error) {
+  i := strings.IndexAny(spec, " \t")
+  if i < 0 {
+    return nil, fmt.Errorf("missing spec after time zone: %s", spec)
+  }
+  name := spec[strings.Index(spec, "=")+1 : i]
+  loc, err := time.LoadLocation(name)
+  if err != nil {
+    return nil, fmt.Errorf("failed to load time zone %s: %s", name, err)
+  }
+  p.location = loc
+  return p.Parse(strings.TrimSpace(spec[i:]))
+}
+
+// checkQuestionMarks returns an error unless "?" is used on its own in exactly
+// one of the day of month and day of week fields.
+func checkQuestionMarks(fields []string) error {
+  for i, field := range fields {
+    if !strings.Contains(field, "?") {
+      continue
+    }
+    if (i != 3 && i != 5) || field != "?" {
+      return fmt.Errorf("? may only be used on its own in the day of month "+
+        "or day of week field: %s", field)
+    }
+  }
+  if (fields[3] == "?") == (fields[5] == "?") {
+    return fmt.Errorf("? must be used in exactly one of the day of month and "+
+      "day of week fields: %s", strings.Join(fields, " "))
+  }
+  return nil
+}
+
+// checkStrict returns an error for the first degenerate field rejected by the
+// Strict option.
+func (p Parser) checkStrict(fields []string) error {
+  dowBounds := dow
+  if p.options&ISOWeekday > 0 {
+    dowBounds = isoDow
+  }
+
+  for i, r := range []bounds{seconds, minutes, hours, dom, months, dowBounds} {
+    var bits uint64
+    for _, expr := range strings.Split(fields[i], ",") {
+      if expr == "" {
+        return fmt.Errorf("empty element in list: %s", fields[i])
+      }
+      if steps := stepWarnings(expr, r); len(steps) > 0 {
+        return fmt.Errorf("%s", steps[0])
+      }
+
+      // Random values, and the special days of the month and of the week,
+      // are not checked for duplicates.
+      upper := strings.ToUpper(expr)
+      if strings.Contains(expr, "~") || (i == 3 &&
+        (strings.HasPrefix(upper, "L") || strings.HasSuffix(upper, "B"))) ||
+        (i == 5 && strings.HasSuffix(upper, "L")) {
+        continue
+      }
+      b, err := getRange(expr, r)
+      if err != nil {
+        return err
+      }
+      if b&bits&^starBit > 0 {
+        return fmt.Errorf("duplicate values in list: %s", fields[i])
+      }
+      bits |= b
+    }
+  }
+  return nil
+}
+
+// inLocation binds the parsed schedule to the parser's location, if it has one
+// and the schedule is a SpecSchedule.
+func (p Parser) inLocation(schedule Schedule, err error) (Schedule, error) {
+  if s, ok := schedule.(*SpecSchedule); ok {
+    if p.location != nil {
+      s.Location = p.location
+    }
+    p.setDST(s)
+  }
+  return schedule, err
+}
+
+// setDST sets the DST policies of the parser's options on the schedule.
+func (p Parser) setDST(s *SpecSchedule) {
+  if p.options&ShiftDSTGaps > 0 {
+    s.DSTGap = DSTShiftGap
+  }
+  if p.options&RunDSTOverlapsOnce > 0 {
+    s.DSTOverlap = DSTRunOnce
+  }
+}
+
+// unionSeparator separates the specs of a union.
+const unionSeparator = "||"
+
+// parseUnion returns a UnionSchedule of each of the "||" separated specs.
+func (p Parser) parseUnion(spec string) (Schedule, error) {
+  union := &UnionSchedule{}
+  for _, part := range strings.Split(spec, unionSeparator) {
+    part = strings.TrimSpace(part)
+    if part == "" {
+      return nil, fmt.Errorf("empty schedule in union: %s", spec)
+    }
+    schedule, err := p.Parse(part)
+    if err != nil {
+      return nil, err
+    }
+    union.Schedules = append(union.Schedules, schedule)
+  }
+  return union, nil
+}
+
+// exceptSeparator separates a spec from the spec of the activations it
+// excludes.
+const exceptSeparator = " except "
+
+// parseExcept returns an ExceptSchedule for "spec except spec".  Exclusions
+// bind looser than unions and chain to the left, so "a || b except c except d"
+// is ((a || b) except c) except d.
+func (p Parser) parseExcept(spec string) (Schedule, error) {
+  idx := strings.LastIndex(spec, exceptSeparator)
+  include := strings.TrimSpace(spec[:idx])
+  exclude := strings.TrimSpace(spec[idx+len(exceptSeparator):])
+  if include == "" || exclude == "" {
+    return nil, fmt.Errorf("empty schedule in exclusion: %s", spec)
+  }
+
+  schedule, err := p.Parse(include)
+  if err != nil {
+    return nil, err
+  }
+  except, err := p.Parse(exclude)
+  if err != nil {
+    return nil, err
+  }
+  return &ExceptSchedule{Schedule: schedule, Except: except}, nil
+}
+
 // getField returns an Int with the bits set representing all of the times that
 // the field represents.  A "field" is a comma-separated list of "ranges".
 func getField(field string, r bounds) (uint64, error) {
@@ -108,9 +407,129 @@ func getField(field string, r bounds) (uint64, error) {
   return bits, nil
 }
 
+// getDowField is getField for the day of week field, honoring the ISOWeekday
+// option, which additionally accepts "nL" (the last day n of the month, e.g.
+// "5L" or "FRIL" for the last Friday).  The returned bits always number Sunday
+// as 0.
+func (p Parser) getDowField(field string) (uint64, error) {
+  r := dow
+  if p.options&ISOWeekday > 0 {
+    r = isoDow
+  }
+
+  var bits uint64
+  ranges := strings.FieldsFunc(field, func(r rune) bool { return r == ',' })
+  for _, expr := range ranges {
+    if len(expr) > 1 && strings.HasSuffix(strings.ToUpper(expr), "L") {
+      b, err := getLastDow(expr, r)
+      if err != nil {
+        return 0, err
+      }
+      bits |= b
+      continue
+    }
+    b, err := getRange(expr, r)
+    if err != nil {
+      return 0, err
+    }
+    bits |= b
+  }
+  if p.options&ISOWeekday > 0 {
+    // Move Sunday from 7 down to 0.
+    bits = bits&^(1<<7) | bits>>7&1
+  }
+  return bits, nil
+}
+
+// getLastDow returns the bit indicated by a last day of week expression:
+//   (number | name) "L"
+func getLastDow(expr string, r bounds) (uint64, error) {
+  day, err := parseIntOrName(expr[:len(expr)-1], r.names)
+  if err != nil {
+    return 0, err
+  }
+  if day < r.min || day > r.max {
+    return 0, fmt.Errorf("day of week (%d) outside of range %d-%d: %s", day,
+      r.min, r.max, expr)
+  }
+  return lastDowBit << (day % 7), nil
+}
+
+// getDomField is getField for the day of month field, which additionally
+// accepts "L" (the last day of the month), "L-n" (n days before the last day of
+// the month), "LW" (the last weekday of the month), "nB" (the nth business day
+// of the month) and "LB" (the last business day of the month).  Business days
+// are returned separately, in the form of SpecSchedule.BusinessDay.
+func getDomField(field string) (days, businessDays uint64, err error) {
+  ranges := strings.FieldsFunc(field, func(r rune) bool { return r == ',' })
+  for _, expr := range ranges {
+    if strings.HasSuffix(strings.ToUpper(expr), "B") {
+      b, err := getBusinessDay(expr)
+      if err != nil {
+        return 0, 0, err
+      }
+      businessDays |= b
+      continue
+    }
+    b, err := getDomRange(expr)
+    if err != nil {
+      return 0, 0, err
+    }
+    days |= b
+  }
+  return days, businessDays, nil
+}
+
+// getBusinessDay returns the business day bit indicated by the expression:
+//   "LB" | number "B"
+func getBusinessDay(expr string) (uint64, error) {
+  if strings.ToUpper(expr) == "LB" {
+    return lastBusinessDayBit, nil
+  }
+  n, err := mustParseInt(expr[:len(expr)-1])
+  if err != nil {
+    return 0, err
+  }
+  if n < dom.min || n > dom.max {
+    return 0, fmt.Errorf("business day (%d) outside of range %d-%d: %s", n,
+      dom.min, dom.max, expr)
+  }
+  return 1 << n, nil
+}
+
+// getDomRange returns the bits indicated by a day of month expression:
+//   "L" | "L-" number | "LW" | range
+func getDomRange(expr string) (uint64, error) {
+  upper := strings.ToUpper(expr)
+  if !strings.HasPrefix(upper, "L") {
+    return getRange(expr, dom)
+  }
+  switch upper {
+  case "L":
+    return lastDomBit, nil
+  case "LW":
+    return lastWeekdayBit, nil
+  }
+  if !strings.HasPrefix(upper, "L-") {
+    return 0, fmt.Errorf("failed to parse last day of month: %s", expr)
+  }
+  offset, err := mustParseInt(expr[2:])
+  if err != nil {
+    return 0, err
+  }
+  if offset > dom.max-1 {
+    return 0, fmt.Errorf("offset from last day of month (%d) above maximum "+
+      "(%d): %s", offset, dom.max-1, expr)
+  }
+  return lastDomBit << offset, nil
+}
+
 // getRange returns the bits indicated by the given expression:
 //   number | number "-" number [ "/" number ]
 func getRange(expr string, r bounds) (uint64, error) {
+  if strings.Contains(expr, "~") {
+    return getRandom(expr, r)
+  }
 
   var (
     start, end, step uint
@@ -151,6 +570,9 @@ func getRange(expr string, r bounds) (uint64, error) {
     if err != nil {
       return 0, err
     }
+    if step == 0 {
+      return 0, fmt.Errorf("step of range should be positive: %s", expr)
+    }
 
     // Special handling: "N/step" means "N-max/step".
     if singleDigit {
@@ -160,6 +582,66 @@ func getRange(expr string, r bounds) (uint64, error) {
     return 0, fmt.Errorf("too many slashes: %s", expr)
   }
 
+  if start < r.min {
+    return 0, fmt.Errorf("beginning of range (%d) below minimum (%d): %s",
+      start, r.min, expr)
+  }
+  if end > r.max {
+    return 0, fmt.Errorf("End of range (%d) above maximum (%d): %s",
+      end, r.max, expr)
+  }
+  if start > end {
+    if !r.wraps {
+      return 0, fmt.Errorf("Beginning of range (%d) beyond end of range (%d): %s",
+        start, end, expr)
+    }
+    if start > r.max {
+      return 0, fmt.Errorf("beginning of range (%d) above maximum (%d): %s",
+        start, r.max, expr)
+    }
+    return getWrappedBits(start, end, step, r), nil
+  }
+
+  return getBits(start, end, step) | extraStar, nil
+}
+
+// getWrappedBits sets the bits of a range that wraps from r.max back to r.min,
+// stepping across the wrap, e.g. hours 22-2/2 is 22, 0 and 2.
+func getWrappedBits(start, end, step uint, r bounds) uint64 {
+  var (
+    size   = r.max - r.min + 1
+    length = end + size - start
+    bits   uint64
+  )
+  for n := uint(0); n <= length; n += step {
+    bits |= 1 << (r.min + (start-r.min+n)%size)
+  }
+  return bits
+}
+
+// getRandom returns a single bit picked at random from the given expression:
+//   "~" | number "~" number
+// A bare "~" picks from the whole field.
+func getRandom(expr string, r bounds) (uint64, error) {
+  var (
+    start, end = r.min, r.max
+    lowAndHigh = strings.Split(expr, "~")
+    err        error
+  )
+  if len(lowAndHigh) != 2 {
+    return 0, fmt.Errorf("too many tildes: %s", expr)
+  }
+  if expr != "~" {
+    start, err = parseIntOrName(lowAndHigh[0], r.names)
+    if err != nil {
+      return 0, err
+    }
+    end, err = parseIntOrName(lowAndHigh[1], r.names)
+    if err != nil {
+      return 0, err
+    }
+  }
+
   if start < r.min {
     return 0, fmt.Errorf("beginning of range (%d) below minimum (%d): %s",
       start, r.min, expr)
@@ -173,7 +655,7 @@ func getRange(expr string, r bounds) (uint64, error) {
       start, end, expr)
   }
 
-  return getBits(start, end, step) | extraStar, nil
+  return 1 << (start + uint(rand.Intn(int(end-start+1)))), nil
 }
 
 // parseIntOrName returns the (possibly-named) integer contained in expr.
@@ -220,9 +702,17 @@ func all(r bounds) uint64 {
   return getBits(r.min, r.max, 1) | starBit
 }
 
+// every returns the schedule of "@every duration".
+func (p Parser) every(duration time.Duration) ConstantDelaySchedule {
+  if p.options&SubSecond > 0 {
+    return EveryWithPrecision(duration, time.Millisecond)
+  }
+  return Every(duration)
+}
+
 // parseDescriptor returns a pre-defined schedule for the expression, or panics
 // if none matches.
-func parseDescriptor(spec string) (Schedule, error) {
+func (p Parser) parseDescriptor(spec string) (Schedule, error) {
   switch spec {
   case "@yearly", "@annually":
     return &SpecSchedule{
@@ -244,6 +734,26 @@ func parseDescriptor(spec string) (Schedule, error) {
       Dow:    all(dow),
     }, nil
 
+  case "@end_of_month":
+    return &SpecSchedule{
+      Second: 1 << seconds.min,
+      Minute: 1 << minutes.min,
+      Hour:   1 << hours.min,
+      Dom:    lastDomBit,
+      Month:  all(months),
+      Dow:    all(dow),
+    }, nil
+
+  case "@end_of_quarter":
+    return &SpecSchedule{
+      Second: 1 << seconds.min,
+      Minute: 1 << minutes.min,
+      Hour:   1 << hours.min,
+      Dom:    lastDomBit,
+      Month:  1<<3 | 1<<6 | 1<<9 | 1<<12,
+      Dow:    all(dow),
+    }, nil
+
   case "@weekly":
     return &SpecSchedule{
       Second: 1 << seconds.min,
@@ -277,11 +787,40 @@ func parseDescriptor(spec string) (Schedule, error) {
 
   const every string = "@every "
   if strings.HasPrefix(spec, every) {
-    duration, err := time.ParseDuration(spec[len(every):])
+    // "@every <duration> limit <n>" stops after n activations.
+    expr := strings.Fields(spec[len(every):])
+    if len(expr) != 1 && (len(expr) != 3 || expr[1] != "limit") {
+      return nil, fmt.Errorf("expected duration and optional limit: %s", spec)
+    }
+    duration, err := time.ParseDuration(expr[0])
     if err != nil {
       return nil, fmt.Errorf("failed to parse duration %s: %s", spec, err)
     }
-    return Every(duration), nil
+    if len(expr) == 1 {
+      return p.every(duration), nil
+    }
+    limit, err := mustParseInt(expr[2])
+    if err != nil {
+      return nil, err
+    }
+    if limit == 0 {
+      return nil, fmt.Errorf("limit must be positive: %s", spec)
+    }
+    return p.every(duration).Times(int(limit)), nil
+  }
+
+  if strings.HasPrefix(spec, "@sunrise ") ||
+    strings.HasPrefix(spec, "@sunset ") {
+    return parseSolar(spec)
+  }
+
+  const at string = "@at "
+  if strings.HasPrefix(spec, at) {
+    t, err := time.Parse(time.RFC3339, spec[len(at):])
+    if err != nil {
+      return nil, fmt.Errorf("failed to parse time %s: %s", spec, err)
+    }
+    return AtSchedule{t}, nil
   }
 
   return nil, fmt.Errorf("unrecognized descriptor: %s", spec)
@@ -45,7 +45,7 @@ func TestRange(t *testing.T) {
   }
 
   for _, c := range ranges {
-    actual, err := getRange(c.expr, bounds{c.min, c.max, nil})
+    actual, err := getRange(c.expr, bounds{c.min, c.max, nil, false})
     if err != nil {
       t.Error(err)
     }
@@ -55,6 +55,87 @@ func TestRange(t *testing.T) {
   }
 }
 
+func TestWrapRange(t *testing.T) {
+  ranges := []struct {
+    expr     string
+    r        bounds
+    expected uint64
+  }{
+    {"22-2", hours, 1<<22 | 1<<23 | 1<<0 | 1<<1 | 1<<2},
+    {"22-2/2", hours, 1<<22 | 1<<0 | 1<<2},
+    {"23-0", hours, 1<<23 | 1<<0},
+    {"55-5/5", minutes, 1<<55 | 1<<0 | 1<<5},
+    {"58-1", seconds, 1<<58 | 1<<59 | 1<<0 | 1<<1},
+    {"NOV-FEB", months, 1<<11 | 1<<12 | 1<<1 | 1<<2},
+    {"dec-jan", months, 1<<12 | 1<<1},
+    {"FRI-MON", dow, 1<<5 | 1<<6 | 1<<0 | 1<<1},
+    {"6-0", dow, 1<<6 | 1<<0},
+    {"FRI-MON", isoDow, 1<<5 | 1<<6 | 1<<7 | 1<<1},
+  }
+
+  for _, c := range ranges {
+    actual, err := getRange(c.expr, c.r)
+    if err != nil {
+      t.Error(err)
+    }
+    if actual != c.expected {
+      t.Errorf("%s => (expected) %d != %d (actual)", c.expr, c.expected, actual)
+    }
+  }
+
+  invalid := []struct {
+    expr string
+    r    bounds
+  }{
+    {"25-2", hours},
+    {"22-24", hours},
+    {"20-5", dom},
+    {"13-2", months},
+    {"7-1", dow},
+  }
+  for _, c := range invalid {
+    if _, err := getRange(c.expr, c.r); err == nil {
+      t.Error("expected an error parsing: ", c.expr)
+    }
+  }
+}
+
+func TestRandomRange(t *testing.T) {
+  ranges := []struct {
+    expr     string
+    min, max uint
+    low      uint
+    high     uint
+  }{
+    {"~", 0, 59, 0, 59},
+    {"~", 1, 7, 1, 7},
+    {"30~59", 0, 59, 30, 59},
+    {"5~5", 0, 7, 5, 5},
+  }
+
+  for _, c := range ranges {
+    for i := 0; i < 100; i++ {
+      actual, err := getRange(c.expr, bounds{c.min, c.max, nil, false})
+      if err != nil {
+        t.Error(err)
+        break
+      }
+      if actual&(actual-1) != 0 || actual&getBits(c.low, c.high, 1) == 0 {
+        t.Errorf("%s => (expected) one of %d-%d != %b (actual)",
+          c.expr, c.low, c.high, actual)
+        break
+      }
+    }
+  }
+
+  invalid := []string{"~~", "5~", "~5", "6~5", "0~8", "a~b"}
+  for _, expr := range invalid {
+    if _, err := getRange(expr, bounds{1, 7, nil, false}); err == nil {
+      t.Error("expected an error parsing: ", expr)
+    }
+  }
+}
+
 func TestField(t *testing.T) {
   fields := []struct {
     expr     string
@@ -68,7 +149,7 @@ func TestField(t *testing.T) {
   }
 
   for _, c := range fields {
-    actual, err := getField(c.expr, bounds{c.min, c.max, nil})
+    actual, err := getField(c.expr, bounds{c.min, c.max, nil, false})
     if err != nil {
       t.Error(err)
     }
@@ -123,8 +204,10 @@ func TestSpecSchedule(t *testing.T) {
     expr     string
     expected Schedule
   }{
-    {"* 5 * * * *", &SpecSchedule{all(seconds), 1 << 5, all(hours), all(dom), all(months), all(dow)}},
-    {"@every 5m", ConstantDelaySchedule{5 * time.Minute}},
+    {"* 5 * * * *", &SpecSchedule{Second: all(seconds), Minute: 1 << 5, Hour: all(hours), Dom: all(dom), Month: all(months), Dow: all(dow)}},
+    {"0 0 0 1,3B,LB * ?", &SpecSchedule{Second: 1, Minute: 1, Hour: 1, Dom: 1 << 1, Month: all(months), Dow: all(dow), BusinessDay: 1<<3 | lastBusinessDayBit}},
+    {"@every 5m", ConstantDelaySchedule{Delay: 5 * time.Minute}},
+    {"@every 1h limit 24", &LimitedSchedule{Schedule: ConstantDelaySchedule{Delay: time.Hour}, Limit: 24}},
   }
 
   for _, c := range entries {
@@ -137,3 +220,268 @@ func TestSpecSchedule(t *testing.T) {
     }
   }
 }
+
+func TestMustParse(t *testing.T) {
+  expected, _ := Parse("0 0 9 * * MON-FRI")
+  if actual := MustParse("0 0 9 * * MON-FRI"); !reflect.DeepEqual(actual, expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, actual)
+  }
+
+  defer func() {
+    if recover() == nil {
+      t.Error("expected a panic parsing an invalid spec")
+    }
+  }()
+  MustParse("0 60 * * * *")
+}
+
+func TestISOWeekday(t *testing.T) {
+  entries := []struct {
+    expr     string
+    expected uint64
+  }{
+    {"0 0 0 * * 1", 1 << 1},
+    {"0 0 0 * * 7", 1 << 0},
+    {"0 0 0 * * 1-5", getBits(1, 5, 1)},
+    {"0 0 0 * * 6-7", 1<<6 | 1<<0},
+    {"0 0 0 * * MON,SUN", 1<<1 | 1<<0},
+    {"0 0 0 * * *", all(dow)},
+    {"0 0 0 * * */2", 1<<1 | 1<<3 | 1<<5 | 1<<0 | starBit},
+    {"0 0 0 * * SAT-MON", 1<<6 | 1<<0 | 1<<1},
+    {"0 0 0 * * 5L", lastDowBit << 5},
+    {"0 0 0 * * 7L,1", lastDowBit<<0 | 1<<1},
+  }
+
+  p := NewParser(ISOWeekday)
+  for _, c := range entries {
+    actual, err := p.Parse(c.expr)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    if actual.(*SpecSchedule).Dow != c.expected {
+      t.Errorf("%s => (expected) %b != %b (actual)",
+        c.expr, c.expected, actual.(*SpecSchedule).Dow)
+    }
+  }
+
+  if _, err := p.Parse("0 0 0 * * 0"); err == nil {
+    t.Error("expected an error parsing day of week 0 with ISOWeekday")
+  }
+}
+
+func TestParseInLocation(t *testing.T) {
+  ny, err := time.LoadLocation("America/New_York")
+  if err != nil {
+    t.Skip(err)
+  }
+
+  runs := []struct {
+    time, spec string
+    expected   string
+  }{
+    // Nine in New York is one in the afternoon UTC during daylight savings.
+    {"2012-07-09T00:00:00-0000", "0 0 9 * * *", "2012-07-09T13:00:00-0000"},
+    {"2012-07-09T13:00:00-0000", "0 0 9 * * *", "2012-07-10T13:00:00-0000"},
+    {"2012-01-09T00:00:00-0000", "0 0 9 * * *", "2012-01-09T14:00:00-0000"},
+    {"2012-07-09T00:00:00-0000", "@daily", "2012-07-09T04:00:00-0000"},
+    {"2012-07-09T00:00:00-0000", "cron(0 9 * * ? *)", "2012-07-09T13:00:00-0000"},
+    {"2012-07-09T00:00:00-0000", "0 0 9 * * * || 0 0 10 * * *", "2012-07-09T13:00:00-0000"},
+    {"2012-07-09T00:00:00-0000", "0 0 0 30 Feb ?", ""},
+  }
+
+  for _, c := range runs {
+    sched, err := ParseInLocation(c.spec, ny)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    actual := sched.Next(getTime(c.time).UTC())
+    expected := getTime(c.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s, \"%s\": (expected) %v != %v (actual)", c.time, c.spec, expected, actual)
+    }
+    if !actual.IsZero() && actual.Location() != time.UTC {
+      t.Errorf("%s, \"%s\": (expected) UTC != %v (actual)", c.time, c.spec, actual.Location())
+    }
+  }
+}
+
+func TestStrict(t *testing.T) {
+  valid := []string{
+    "0 0 9 * * MON-FRI",
+    "0 */15 * * * *",
+    "0 0,30 8-17 * * *",
+    "0 0 0 1,L,LB * ?",
+    "~ ~ 3 * * *",
+    "0 0 0 * * 1-7",
+  }
+  invalid := []string{
+    "0 */0 * * * *",
+    "0 */90 * * * *",
+    "0 10-20/15 * * * *",
+    "0 0 1,,2 * * *",
+    "0 0 ,2 * * *",
+    "0 0 2, * * *",
+    "0 0 1,1 * * *",
+    "0 0 1-5,3 * * *",
+    "0 0 0 * * MON,1",
+  }
+
+  p := NewParser(Strict | ISOWeekday)
+  for _, spec := range valid {
+    if _, err := p.Parse(spec); err != nil {
+      t.Error(err)
+    }
+  }
+  for _, spec := range invalid {
+    if _, err := p.Parse(spec); err == nil {
+      t.Error("expected an error parsing: ", spec)
+    }
+  }
+
+  // Without Strict, only the zero step is an error.
+  if _, err := Parse("0 */0 * * * *"); err == nil {
+    t.Error("expected an error parsing: 0 */0 * * * *")
+  }
+  for _, spec := range invalid[1:] {
+    if _, err := Parse(spec); err != nil {
+      t.Error(err)
+    }
+  }
+}
+
+func TestQuartzQuestionMark(t *testing.T) {
+  entries := []struct {
+    expr     string
+    expected Schedule
+  }{
+    {"0 0 12 ? * WED", &SpecSchedule{Second: 1, Minute: 1, Hour: 1 << 12, Dom: all(dom), Month: all(months), Dow: 1 << 3}},
+    {"0 0 12 15 * ?", &SpecSchedule{Second: 1, Minute: 1, Hour: 1 << 12, Dom: 1 << 15, Month: all(months), Dow: all(dow)}},
+    {"0 0 12 15 *", &SpecSchedule{Second: 1, Minute: 1, Hour: 1 << 12, Dom: 1 << 15, Month: all(months), Dow: all(dow)}},
+  }
+
+  p := NewParser(QuartzQuestionMark)
+  for _, c := range entries {
+    actual, err := p.Parse(c.expr)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    if !reflect.DeepEqual(actual, c.expected) {
+      t.Errorf("%s => (expected) %v != %v (actual)", c.expr, c.expected, actual)
+    }
+  }
+
+  invalid := []string{
+    "0 0 12 * * *",
+    "0 0 12 15 * WED",
+    "0 0 12 ? * ?",
+    "0 0 12 ? *",
+    "0 0 ? 15 * *",
+    "0 0 12 ?,1 * *",
+    "0 0 12 ?/2 * *",
+  }
+  for _, spec := range invalid {
+    if _, err := p.Parse(spec); err == nil {
+      t.Error("expected an error parsing: ", spec)
+    }
+  }
+}
+
+func TestParseStandard(t *testing.T) {
+  entries := []struct {
+    expr     string
+    expected Schedule
+  }{
+    {"5 * * * *", &SpecSchedule{Second: 1, Minute: 1 << 5, Hour: all(hours), Dom: all(dom), Month: all(months), Dow: all(dow)}},
+    {"30 9 * * MON-FRI", &SpecSchedule{Second: 1, Minute: 1 << 30, Hour: 1 << 9, Dom: all(dom), Month: all(months), Dow: 0x3e}},
+    {"0 0 1 JAN *", &SpecSchedule{Second: 1, Minute: 1, Hour: 1, Dom: 1 << 1, Month: 1 << 1, Dow: all(dow)}},
+    {"@hourly", &SpecSchedule{Second: 1, Minute: 1, Hour: all(hours), Dom: all(dom), Month: all(months), Dow: all(dow)}},
+  }
+
+  for _, c := range entries {
+    actual, err := ParseStandard(c.expr)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    if !reflect.DeepEqual(actual, c.expected) {
+      t.Errorf("%s => (expected) %v != %v (actual)", c.expr, c.expected, actual)
+    }
+  }
+
+  invalid := []string{
+    "0 5 * * * *",
+    "* * * *",
+    "60 * * * *",
+  }
+  for _, spec := range invalid {
+    if _, err := ParseStandard(spec); err == nil {
+      t.Error("expected an error parsing: ", spec)
+    }
+  }
+}
+
+func TestSubSecond(t *testing.T) {
+  entries := []struct {
+    options  ParseOption
+    expr     string
+    expected Schedule
+  }{
+    {0, "@every 250ms", ConstantDelaySchedule{Delay: time.Second}},
+    {0, "@every 1500ms", ConstantDelaySchedule{Delay: time.Second}},
+    {SubSecond, "@every 250ms", ConstantDelaySchedule{Delay: 250 * time.Millisecond, Precision: time.Millisecond}},
+    {SubSecond, "@every 1.5s", ConstantDelaySchedule{Delay: 1500 * time.Millisecond, Precision: time.Millisecond}},
+    {SubSecond, "@every 100us", ConstantDelaySchedule{Delay: time.Millisecond, Precision: time.Millisecond}},
+    {SubSecond, "@every 250ms limit 4", &LimitedSchedule{Schedule: ConstantDelaySchedule{Delay: 250 * time.Millisecond, Precision: time.Millisecond}, Limit: 4}},
+    {SubSecond, "* * * * * *", &SpecSchedule{Second: all(seconds), Minute: all(minutes), Hour: all(hours), Dom: all(dom), Month: all(months), Dow: all(dow)}},
+  }
+
+  for _, c := range entries {
+    actual, err := NewParser(c.options).Parse(c.expr)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    if !reflect.DeepEqual(actual, c.expected) {
+      t.Errorf("%s => (expected) %v != %v (actual)", c.expr, c.expected, actual)
+    }
+  }
+}
+
+func TestParseTimeZone(t *testing.T) {
+  ny, err := time.LoadLocation("America/New_York")
+  if err != nil {
+    t.Skip(err)
+  }
+
+  for _, spec := range []string{
+    "TZ=America/New_York 0 0 9 * * *",
+    "CRON_TZ=America/New_York 0 0 9 * * *",
+    "TZ=America/New_York  @daily || 0 0 9 * * *",
+  } {
+    schedule, err := Parse(spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    // Nine in New York is 13:00 UTC in July.
+    next := schedule.Next(getTime("2012-07-09T10:00:00-0000"))
+    if expected := getTime("2012-07-09T13:00:00-0000"); !next.Equal(expected) {
+      t.Errorf("%s: (expected) %v != %v (actual)", spec, expected, next)
+    }
+    if s, ok := schedule.(*SpecSchedule); ok && s.Location.String() != ny.String() {
+      t.Errorf("%s: (expected) %v != %v (actual)", spec, ny, s.Location)
+    }
+  }
+
+  for _, spec := range []string{
+    "TZ=Nowhere/Else 0 0 9 * * *",
+    "TZ=America/New_York",
+  } {
+    if _, err := Parse(spec); err == nil {
+      t.Error("expected an error parsing: ", spec)
+    }
+  }
+}
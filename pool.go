@@ -0,0 +1,185 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements bounding the number of concurrently running jobs.
+
+package cron
+
+import (
+  "sort"
+  "sync"
+  "time"
+)
+
+// WithMaxConcurrent runs at most n jobs at once.  Activations beyond that wait
+// in a queue, in order, until a running job returns.
+func WithMaxConcurrent(n int) Option {
+  return func(c *Cron) {
+    c.pool.limit = n
+  }
+}
+
+// WithMaxQueued lets at most n activations wait for a running job to return;
+// further activations are skipped.  By default the queue is unbounded.
+func WithMaxQueued(n int) Option {
+  return func(c *Cron) {
+    c.pool.maxQueued = n
+  }
+}
+
+// WithGroupLimit runs at most n jobs of the group at once.  Activations beyond
+// that wait in the same queue as those beyond WithMaxConcurrent.
+func WithGroupLimit(group string, n int) Option {
+  return func(c *Cron) {
+    if c.pool.groups == nil {
+      c.pool.groups = make(map[string]*poolGroup)
+    }
+    c.pool.groups[group] = &poolGroup{limit: n}
+  }
+}
+
+// InGroup puts the entry in the group, whose concurrent runs may be limited
+// with WithGroupLimit.
+func InGroup(group string) EntryOption {
+  return func(e *Entry) {
+    e.Group = group
+  }
+}
+
+// pool starts jobs in their own goroutines, limiting how many run at once, and
+// how often they start if it has a bucket.  A zero limit is unlimited, and a
+// negative maxQueued is unbounded.
+type pool struct {
+  mu        sync.Mutex
+  limit     int
+  maxQueued int
+  running   int
+  groups    map[string]*poolGroup
+  queued    []poolTask
+
+  // bucket, if set, holds the tokens tasks take to start, and clock reads the
+  // time for it.  timer is set while queued tasks wait for a token.
+  bucket *tokenBucket
+  clock  Clock
+  timer  Timer
+}
+
+// poolGroup is the limit and number of running jobs of a group.
+type poolGroup struct {
+  limit, running int
+}
+
+// poolTask is a job run waiting to start.
+type poolTask struct {
+  group    string
+  priority int
+  run      func()
+}
+
+// newPool returns a pool without limits.
+func newPool() *pool {
+  return &pool{maxQueued: -1, clock: realClock{}}
+}
+
+// submit starts run in its own goroutine if the limits allow, and otherwise
+// queues it, behind the tasks already queued with the same or a higher
+// priority.  It returns false if the queue is full.
+func (p *pool) submit(group string, priority int, run func()) bool {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+
+  i := sort.Search(len(p.queued), func(i int) bool {
+    return p.queued[i].priority < priority
+  })
+  p.queued = append(p.queued, poolTask{})
+  copy(p.queued[i+1:], p.queued[i:])
+  p.queued[i] = poolTask{group, priority, run}
+  p.dispatch()
+  if p.maxQueued >= 0 && len(p.queued) > p.maxQueued {
+    // Nothing started, so the new task is still at i.
+    p.queued = append(p.queued[:i], p.queued[i+1:]...)
+    return false
+  }
+  return true
+}
+
+// dispatch starts the queued tasks the limits allow, in order, and sets the
+// timer if some wait for a token.  p.mu must be held.
+func (p *pool) dispatch() {
+  var now time.Time
+  if p.bucket != nil {
+    now = p.clock.Now()
+  }
+  waiting := false
+  queued := p.queued[:0]
+  for _, q := range p.queued {
+    if !p.canStart(q) {
+      queued = append(queued, q)
+      continue
+    }
+    if p.bucket != nil && !p.bucket.take(now) {
+      waiting = true
+      queued = append(queued, q)
+      continue
+    }
+    p.start(q)
+  }
+  p.queued = queued
+
+  if waiting && p.timer == nil {
+    timer := p.clock.Timer(p.bucket.ready(now))
+    p.timer = timer
+    go func() {
+      <-timer.C()
+      p.mu.Lock()
+      defer p.mu.Unlock()
+      p.timer = nil
+      p.dispatch()
+    }()
+  }
+}
+
+// canStart returns true if the task may start without exceeding the limits.
+func (p *pool) canStart(t poolTask) bool {
+  if p.limit > 0 && p.running >= p.limit {
+    return false
+  }
+  g := p.groups[t.group]
+  return g == nil || g.limit <= 0 || g.running < g.limit
+}
+
+// start runs the task in its own goroutine.  p.mu must be held.
+func (p *pool) start(t poolTask) {
+  p.running++
+  if g := p.groups[t.group]; g != nil {
+    g.running++
+  }
+  go func() {
+    defer p.finish(t)
+    t.run()
+  }()
+}
+
+// finish records the end of the task, and starts the first queued tasks the
+// limits now allow.
+func (p *pool) finish(t poolTask) {
+  p.mu.Lock()
+  defer p.mu.Unlock()
+
+  p.running--
+  if g := p.groups[t.group]; g != nil {
+    g.running--
+  }
+  p.dispatch()
+}
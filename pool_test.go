@@ -0,0 +1,148 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for limiting concurrently running jobs.
+
+package cron
+
+import (
+  "reflect"
+  "sync"
+  "testing"
+  "time"
+)
+
+// poolRecorder records the most tasks running at once, by group and for "all".
+type poolRecorder struct {
+  mu      sync.Mutex
+  wg      sync.WaitGroup
+  running map[string]int
+  max     map[string]int
+}
+
+func newPoolRecorder() *poolRecorder {
+  return &poolRecorder{running: map[string]int{}, max: map[string]int{}}
+}
+
+// task returns a task of the group that runs for a while.
+func (r *poolRecorder) task(group string) func() {
+  r.wg.Add(1)
+  return func() {
+    defer r.wg.Done()
+    r.mu.Lock()
+    for _, g := range []string{"all", group} {
+      r.running[g]++
+      if r.running[g] > r.max[g] {
+        r.max[g] = r.running[g]
+      }
+    }
+    r.mu.Unlock()
+
+    time.Sleep(20 * time.Millisecond)
+
+    r.mu.Lock()
+    r.running["all"]--
+    r.running[group]--
+    r.mu.Unlock()
+  }
+}
+
+func TestPoolLimit(t *testing.T) {
+  p := newPool()
+  p.limit = 2
+  r := newPoolRecorder()
+  for i := 0; i < 6; i++ {
+    if !p.submit("", 0, r.task("")) {
+      t.Error("expected an unbounded queue")
+    }
+  }
+  r.wg.Wait()
+
+  if r.max["all"] != 2 {
+    t.Errorf("(expected) 2 != %d (actual) running at once", r.max["all"])
+  }
+}
+
+func TestPoolMaxQueued(t *testing.T) {
+  p := newPool()
+  p.limit = 1
+  p.maxQueued = 1
+  r := newPoolRecorder()
+  for i, expected := range []bool{true, true, false} {
+    task := r.task("")
+    if ok := p.submit("", 0, task); ok != expected {
+      t.Errorf("task %d: (expected) %v != %v (actual)", i, expected, ok)
+    }
+    if !expected {
+      r.wg.Done()
+    }
+  }
+  r.wg.Wait()
+}
+
+func TestPoolGroupLimit(t *testing.T) {
+  p := newPool()
+  p.groups = map[string]*poolGroup{"db": {limit: 1}}
+  r := newPoolRecorder()
+  for i := 0; i < 3; i++ {
+    p.submit("db", 0, r.task("db"))
+    p.submit("web", 0, r.task("web"))
+  }
+  r.wg.Wait()
+
+  if r.max["db"] != 1 || r.max["web"] != 3 {
+    t.Errorf("(expected) 1 db and 3 web != %d db and %d web (actual) "+
+      "running at once", r.max["db"], r.max["web"])
+  }
+}
+
+func TestPoolPriority(t *testing.T) {
+  p := newPool()
+  p.limit = 1
+  release := make(chan struct{})
+  p.submit("", 0, func() { <-release })
+
+  var mu sync.Mutex
+  var order []string
+  var wg sync.WaitGroup
+  for _, task := range []struct {
+    name     string
+    priority int
+  }{{"low", 0}, {"high", 2}, {"medium", 1}, {"high again", 2}} {
+    name := task.name
+    wg.Add(1)
+    p.submit("", task.priority, func() {
+      defer wg.Done()
+      mu.Lock()
+      order = append(order, name)
+      mu.Unlock()
+    })
+  }
+  close(release)
+  wg.Wait()
+
+  expected := []string{"high", "high again", "medium", "low"}
+  if !reflect.DeepEqual(order, expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, order)
+  }
+}
+
+func TestWithMaxConcurrent(t *testing.T) {
+  c := New(WithMaxConcurrent(3), WithMaxQueued(10),
+    WithGroupLimit("db", 1))
+  if c.pool.limit != 3 || c.pool.maxQueued != 10 ||
+    c.pool.groups["db"].limit != 1 {
+    t.Errorf("unexpected pool %+v", c.pool)
+  }
+}
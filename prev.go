@@ -0,0 +1,138 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements computing the previous activations of schedules.
+
+package cron
+
+import "time"
+
+// PrevSchedule is a Schedule that can compute its previous activations.
+type PrevSchedule interface {
+  Schedule
+
+  // Prev returns the latest activation time at or before the given time, or
+  // the zero time if there is none.
+  Prev(time.Time) time.Time
+}
+
+// prevSearchLimit is how far back Prev searches for an activation of a
+// schedule that is not a PrevSchedule.
+const prevSearchLimit = 5 * 366 * 24 * time.Hour
+
+// Prev returns the latest activation of the schedule at or before t, e.g. to
+// check whether a run was due already, or the zero time if there is none
+// within five years.  Schedules that are not PrevSchedules are searched
+// backwards, in windows twice as long each time, with Next.
+func Prev(schedule Schedule, t time.Time) time.Time {
+  if s, ok := schedule.(PrevSchedule); ok {
+    return s.Prev(t)
+  }
+  for window := time.Second; window <= prevSearchLimit; window *= 2 {
+    var prev time.Time
+    from := t.Add(-window)
+    for next := schedule.Next(from.Add(-time.Nanosecond)); !next.IsZero() &&
+      !next.After(t); next = schedule.Next(next) {
+      prev = next
+    }
+    if !prev.IsZero() {
+      return prev
+    }
+  }
+  return time.Time{}
+}
+
+// Prev returns the latest time this schedule is activated, at or before the
+// given time.  If no time within five years satisfies the schedule, it returns
+// the zero time.
+func (s *SpecSchedule) Prev(t time.Time) time.Time {
+  // Evaluate in the schedule's location, and convert the result back.
+  if s.Location != nil && t.Location() != s.Location {
+    prev := s.Prev(t.In(s.Location))
+    if prev.IsZero() {
+      return prev
+    }
+    return prev.In(t.Location())
+  }
+  // Other calendars and DST policies are only implemented going forward.
+  if s.Calendar != nil || s.DSTGap != DSTSkipGap ||
+    s.DSTOverlap != DSTRunTwice {
+    return Prev(nextOnly{s}, t)
+  }
+  return s.prev(t)
+}
+
+// nextOnly hides the Prev method of a schedule.
+type nextOnly struct {
+  Schedule
+}
+
+// prev returns the latest time this schedule is activated, at or before the
+// given time, in the given time's location.  It mirrors next: when a field
+// does not match, the time moves to the last second of the previous value of
+// the field, and moving past the start of a larger field's value starts over
+// from the month.
+func (s *SpecSchedule) prev(t time.Time) time.Time {
+  // Start at the latest possible time (the current second).
+  t = t.Add(-time.Duration(t.Nanosecond()))
+
+  // If no time is found within five years, return zero.
+  yearLimit := t.Year() - 5
+
+WRAP:
+  if t.Year() < yearLimit {
+    return time.Time{}
+  }
+
+  for 1<<uint(t.Month())&s.Month == 0 {
+    t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).
+      Add(-time.Second)
+    if t.Month() == time.December {
+      goto WRAP
+    }
+  }
+
+  for !dayMatches(s, t) {
+    month := t.Month()
+    t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).
+      Add(-time.Second)
+    if t.Month() != month {
+      goto WRAP
+    }
+  }
+
+  for 1<<uint(t.Hour())&s.Hour == 0 {
+    t = t.Add(-time.Duration(t.Minute())*time.Minute -
+      time.Duration(t.Second()+1)*time.Second)
+    if t.Hour() == 23 {
+      goto WRAP
+    }
+  }
+
+  for 1<<uint(t.Minute())&s.Minute == 0 {
+    t = t.Add(-time.Duration(t.Second()+1) * time.Second)
+    if t.Minute() == 59 {
+      goto WRAP
+    }
+  }
+
+  for 1<<uint(t.Second())&s.Second == 0 {
+    t = t.Add(-time.Second)
+    if t.Second() == 59 {
+      goto WRAP
+    }
+  }
+
+  return t
+}
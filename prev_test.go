@@ -0,0 +1,108 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for computing the previous activations of
+// schedules.
+
+package cron
+
+import (
+  "testing"
+  "time"
+)
+
+func TestPrev(t *testing.T) {
+  tests := []struct {
+    time, spec string
+    expected   string
+  }{
+    // At or before the time.
+    {"Mon Jul 9 15:00 2012", "0 0/15 * * *", "Mon Jul 9 15:00 2012"},
+    {"Mon Jul 9 15:14:59 2012", "0 0/15 * * *", "Mon Jul 9 15:00 2012"},
+
+    // Wrap around hours, days, months and years.
+    {"Mon Jul 9 15:10 2012", "0 20-35/15 * * *", "Mon Jul 9 14:35 2012"},
+    {"Mon Jul 9 00:10 2012", "0 20 * * *", "Sun Jul 8 23:20 2012"},
+    {"Sun Jul 1 00:10 2012", "0 0 12 * * *", "Sat Jun 30 12:00 2012"},
+    {"Sun Jan 1 00:00 2012", "0 0 0 1 Dec *", "Thu Dec 1 00:00 2011"},
+
+    // Days of the week and of the month.
+    {"Mon Jul 9 08:00 2012", "0 30 08 ? Jul Sun", "Sun Jul 8 08:30 2012"},
+    {"Mon Jul 9 08:00 2012", "0 0 0 L * *", "Sat Jun 30 00:00 2012"},
+    {"Mon Jul 9 08:00 2012", "0 0 0 29 Feb *", "Wed Feb 29 00:00 2012"},
+
+    // Never within five years.
+    {"Mon Jul 9 08:00 2012", "0 0 0 30 Feb *", ""},
+
+    // Around daylight saving time transitions: the skipped hour does not
+    // run, and the repeated one runs twice.
+    {"2012-03-11T03:30:00-0400", "0 30 2 * * *", "2012-03-10T02:30:00-0500"},
+    {"2012-11-04T01:45:00-0500", "0 30 1 * * *", "2012-11-04T01:30:00-0500"},
+    {"2012-11-04T01:15:00-0500", "0 30 1 * * *", "2012-11-04T01:30:00-0400"},
+  }
+
+  for _, test := range tests {
+    sched, err := Parse(test.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    actual := sched.(*SpecSchedule).Prev(getTime(test.time))
+    expected := getTime(test.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s, %s: (expected) %v != %v (actual)", test.spec, test.time,
+        expected, actual)
+    }
+  }
+}
+
+// TestPrevSearch checks the Prev of SpecSchedules against searching backwards
+// with Next.
+func TestPrevSearch(t *testing.T) {
+  ny, err := time.LoadLocation("America/New_York")
+  if err != nil {
+    t.Skip(err)
+  }
+  specs := []string{"* * * * * *", "0 */7 * * * *", "0 30 1,2 * * *",
+    "0 0 9 ? * MON-FRI", "0 0 0 LW * *", "0 0 0 1 */4 *",
+    "TZ=Asia/Tokyo 0 0 23 * * *"}
+  start := time.Date(2012, 3, 1, 0, 0, 0, 0, ny)
+  for _, spec := range specs {
+    sched, err := Parse(spec)
+    if err != nil {
+      t.Fatal(err)
+    }
+    for at := start; at.Before(start.AddDate(1, 0, 0)); at = at.Add(
+      37*time.Hour + 17*time.Minute + 500*time.Millisecond) {
+      expected := Prev(nextOnly{sched}, at)
+      if actual := Prev(sched, at); !actual.Equal(expected) {
+        t.Fatalf("%s, %v: (expected) %v != %v (actual)", spec, at, expected,
+          actual)
+      }
+    }
+  }
+}
+
+func TestPrevOfSchedule(t *testing.T) {
+  at := time.Date(2012, 7, 9, 15, 10, 0, 0, time.UTC)
+  union := &UnionSchedule{[]Schedule{MustParse("0 0 9 * * *"),
+    MustParse("0 5 15 * * *")}}
+  if prev, expected := Prev(union, at), at.Add(-5*time.Minute); !prev.Equal(
+    expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, prev)
+  }
+  if prev := Prev(&UnionSchedule{}, at); !prev.IsZero() {
+    t.Errorf("expected no activation of an empty union, got %v", prev)
+  }
+}
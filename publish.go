@@ -0,0 +1,135 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements jobs that publish messages.
+
+package cron
+
+import (
+  "bytes"
+  "context"
+  "encoding/json"
+  "fmt"
+  "text/template"
+  "time"
+)
+
+// Publisher publishes messages to a message broker.  The natspub, kafkapub and
+// amqppub packages adapt the clients of NATS, Kafka and AMQP brokers.
+type Publisher interface {
+  // Publish publishes the message on the subject: the subject, topic or
+  // routing key, depending on the broker.
+  Publish(ctx context.Context, subject string, message []byte) error
+}
+
+// PublishJob is an ErrorJob that publishes a message on each run, making the
+// Cron a source of ticks for event-driven pipelines.
+type PublishJob struct {
+  // Publisher publishes the message on Subject.
+  Publisher Publisher
+  Subject   string
+
+  // Message is a text/template of the message, executed with the RunInfo of
+  // the run.  If it is empty, the message is the RunInfo as JSON, e.g.
+  // {"entryId":"...","name":"report","scheduled":"2012-07-09T00:00:00Z"}.
+  Message string
+}
+
+// tickJSON is the JSON form of the RunInfo of a run, the default message of
+// PublishJobs.
+type tickJSON struct {
+  EntryID   string    `json:"entryId"`
+  Name      string    `json:"name,omitempty"`
+  Scheduled time.Time `json:"scheduled"`
+}
+
+// publishJobJSON is the JSON form of a PublishJob.
+type publishJobJSON struct {
+  Subject string `json:"subject"`
+  Message string `json:"message,omitempty"`
+}
+
+// PublishJobFactory returns a JobFactory of PublishJobs publishing with the
+// publisher, to be registered with RegisterJob, e.g. as "nats".  The payload
+// is a JSON object with the fields subject and message, e.g.
+// {"subject": "ticks.report"}.
+func PublishJobFactory(publisher Publisher) JobFactory {
+  return func(payload []byte) (Job, error) {
+    var v publishJobJSON
+    if err := json.Unmarshal(payload, &v); err != nil {
+      return nil, err
+    }
+    if v.Subject == "" {
+      return nil, fmt.Errorf("no subject")
+    }
+    if _, err := template.New("message").Parse(v.Message); err != nil {
+      return nil, err
+    }
+    return &PublishJob{Publisher: publisher, Subject: v.Subject,
+      Message: v.Message}, nil
+  }
+}
+
+// Run publishes the message with a background context.
+func (j *PublishJob) Run() { j.RunContext(context.Background()) }
+
+// RunContext publishes the message, logging its failure.
+func (j *PublishJob) RunContext(ctx context.Context) {
+  if err := j.RunError(ctx); err != nil {
+    loggerFrom(ctx).Warningf("job failed: %v", err)
+  }
+}
+
+// RunError publishes the message of the run.
+func (j *PublishJob) RunError(ctx context.Context) error {
+  var message []byte
+  var err error
+  if j.Message == "" {
+    info, _ := RunInfoFrom(ctx)
+    message, err = json.Marshal(tickJSON{
+      EntryID:   info.EntryID,
+      Name:      info.Name,
+      Scheduled: info.Scheduled,
+    })
+  } else {
+    message, err = executeTemplate(ctx, j.Message)
+  }
+  if err != nil {
+    return err
+  }
+  if err := j.Publisher.Publish(ctx, j.Subject, message); err != nil {
+    return fmt.Errorf("cannot publish to %s: %s", j.Subject, err)
+  }
+  return nil
+}
+
+// String returns the subject of the messages.
+func (j *PublishJob) String() string {
+  return "publish " + j.Subject
+}
+
+// executeTemplate executes the text/template with the RunInfo of the run
+// whose job was passed ctx.
+func executeTemplate(ctx context.Context, text string) ([]byte, error) {
+  tmpl, err := template.New("").Parse(text)
+  if err != nil {
+    return nil, err
+  }
+  info, _ := RunInfoFrom(ctx)
+  var buf bytes.Buffer
+  if err := tmpl.Execute(&buf, info); err != nil {
+    return nil, err
+  }
+  return buf.Bytes(), nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for jobs that publish messages.
+
+package cron
+
+import (
+  "context"
+  "errors"
+  "testing"
+  "time"
+)
+
+// message is a message published by a fakePublisher.
+type message struct {
+  subject, message string
+}
+
+// fakePublisher is a Publisher recording the messages it publishes, or
+// failing with err if it is set.
+type fakePublisher struct {
+  published []message
+  err       error
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, subject string,
+  msg []byte) error {
+  if p.err != nil {
+    return p.err
+  }
+  p.published = append(p.published, message{subject, string(msg)})
+  return nil
+}
+
+func TestPublishJob(t *testing.T) {
+  scheduled := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  ctx := withRunInfo(context.Background(), RunInfo{EntryID: "1",
+    Name: "report", Scheduled: scheduled})
+  tests := []struct {
+    job      PublishJob
+    expected message
+  }{
+    {PublishJob{Subject: "ticks"}, message{"ticks",
+      `{"entryId":"1","name":"report","scheduled":"2012-07-09T00:00:00Z"}`}},
+    {PublishJob{Subject: "reports", Message: "run {{.Name}} for " +
+      `{{.Scheduled.Format "2006-01-02"}}`}, message{"reports",
+      "run report for 2012-07-09"}},
+  }
+  for _, test := range tests {
+    p := &fakePublisher{}
+    test.job.Publisher = p
+    if err := test.job.RunError(ctx); err != nil {
+      t.Fatal(err)
+    }
+    if len(p.published) != 1 || p.published[0] != test.expected {
+      t.Errorf("(expected) %v != %v (actual)", test.expected, p.published)
+    }
+  }
+
+  failing := &PublishJob{Publisher: &fakePublisher{err: errors.New("down")},
+    Subject: "ticks"}
+  if err := failing.RunError(ctx); err == nil ||
+    err.Error() != "cannot publish to ticks: down" {
+    t.Errorf("expected the publisher's error, got %v", err)
+  }
+}
+
+func TestPublishJobFactory(t *testing.T) {
+  p := &fakePublisher{}
+  factory := PublishJobFactory(p)
+  job, err := factory([]byte(`{"subject": "ticks", "message": "{{.Name}}"}`))
+  if err != nil {
+    t.Fatal(err)
+  }
+  if j := job.(*PublishJob); j.Publisher != p || j.Subject != "ticks" ||
+    j.Message != "{{.Name}}" {
+    t.Errorf("unexpected job %+v", j)
+  }
+  for _, payload := range []string{`{}`, `{"subject": "a", "message": "{{"}`,
+    `{`} {
+    if _, err := factory([]byte(payload)); err == nil {
+      t.Errorf("%s: expected an error", payload)
+    }
+  }
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements querying the entries of a Cron.
+
+package cron
+
+import (
+  "sort"
+  "strings"
+  "time"
+)
+
+// EntriesOption selects, orders or pages the entries returned by Entries.
+type EntriesOption func(*entriesQuery)
+
+// entriesQuery is the query of the entries returned by Entries.
+type entriesQuery struct {
+  labels        map[string]string
+  prefix        string
+  before        time.Time
+  byName        bool
+  offset, limit int
+}
+
+// FilterLabels selects the entries that have all of the labels.
+func FilterLabels(labels map[string]string) EntriesOption {
+  return func(q *entriesQuery) {
+    q.labels = labels
+  }
+}
+
+// FilterNamePrefix selects the entries whose names start with prefix.
+func FilterNamePrefix(prefix string) EntriesOption {
+  return func(q *entriesQuery) {
+    q.prefix = prefix
+  }
+}
+
+// FilterNextBefore selects the entries whose next activations are before t,
+// leaving out those that will not be activated again.
+func FilterNextBefore(t time.Time) EntriesOption {
+  return func(q *entriesQuery) {
+    q.before = t
+  }
+}
+
+// SortByName orders the entries by name, and entries with the same name, such
+// as unnamed ones, by ID, instead of by their next activations.
+func SortByName() EntriesOption {
+  return func(q *entriesQuery) {
+    q.byName = true
+  }
+}
+
+// Page returns at most limit of the selected entries, if limit is positive,
+// after skipping the first offset of them.  Negative offsets are treated as
+// zero, and limits of zero or less return all remaining entries.
+func Page(offset, limit int) EntriesOption {
+  return func(q *entriesQuery) {
+    q.offset, q.limit = offset, limit
+  }
+}
+
+// matches returns whether the entry is selected by the query.
+func (q *entriesQuery) matches(e *Entry) bool {
+  if !strings.HasPrefix(e.Name, q.prefix) {
+    return false
+  }
+  if !q.before.IsZero() && (e.Next.IsZero() || !e.Next.Before(q.before)) {
+    return false
+  }
+  return q.labels == nil || MatchLabels(q.labels)(*e)
+}
+
+// query returns snapshots of the entries selected, ordered and paged by the
+// query; only those on the page are copied.  c.mu must be held.
+func (c *Cron) query(q *entriesQuery) []*Entry {
+  matched := make([]*Entry, 0, len(c.entries))
+  for _, e := range c.entries {
+    if q.matches(e) {
+      matched = append(matched, e)
+    }
+  }
+  if q.byName {
+    sort.Slice(matched, func(i, j int) bool {
+      if matched[i].Name != matched[j].Name {
+        return matched[i].Name < matched[j].Name
+      }
+      return matched[i].ID < matched[j].ID
+    })
+  } else {
+    // Entries due at the same time with the same priority are ordered by ID,
+    // so that pages neither repeat nor miss them.
+    less := byTime(matched).Less
+    sort.Slice(matched, func(i, j int) bool {
+      if less(i, j) != less(j, i) {
+        return less(i, j)
+      }
+      return matched[i].ID < matched[j].ID
+    })
+  }
+
+  if q.offset < 0 {
+    q.offset = 0
+  } else if q.offset > len(matched) {
+    q.offset = len(matched)
+  }
+  matched = matched[q.offset:]
+  if q.limit > 0 && q.limit < len(matched) {
+    matched = matched[:q.limit]
+  }
+  entries := make([]*Entry, 0, len(matched))
+  for _, e := range matched {
+    entries = append(entries, e.snapshot())
+  }
+  return entries
+}
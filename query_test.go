@@ -0,0 +1,93 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for querying the entries of a Cron.
+
+package cron
+
+import (
+  "reflect"
+  "sort"
+  "testing"
+  "time"
+)
+
+func TestEntriesQuery(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  cron := New(WithClock(NewFakeClock(start)), WithLocation(time.UTC))
+  defer cron.Stop()
+  add := func(spec, name, team string) {
+    cron.AddFunc(spec, func() {}, WithName(name),
+      WithLabels(map[string]string{"team": team}))
+  }
+  add("0 0 3 * * *", "db-backup", "infra")
+  add("0 0 1 * * *", "web-report", "web")
+  add("0 0 2 * * *", "db-vacuum", "infra")
+  add("0 0 4 * * *", "db-report", "data")
+  add("0 0 0 30 2 *", "db-never", "infra")
+
+  tests := []struct {
+    opts     []EntriesOption
+    expected []string
+  }{
+    {nil, []string{"web-report", "db-vacuum", "db-backup", "db-report",
+      "db-never"}},
+    {[]EntriesOption{SortByName()}, []string{"db-backup", "db-never",
+      "db-report", "db-vacuum", "web-report"}},
+    {[]EntriesOption{FilterLabels(map[string]string{"team": "infra"})},
+      []string{"db-vacuum", "db-backup", "db-never"}},
+    {[]EntriesOption{FilterNamePrefix("db-"), FilterNextBefore(
+      start.Add(3 * time.Hour))}, []string{"db-vacuum"}},
+    {[]EntriesOption{SortByName(), Page(1, 2)}, []string{"db-never",
+      "db-report"}},
+    {[]EntriesOption{Page(3, 0)}, []string{"db-report", "db-never"}},
+    {[]EntriesOption{Page(10, 2)}, nil},
+    {[]EntriesOption{SortByName(), Page(-1, 2)}, []string{"db-backup",
+      "db-never"}},
+    {[]EntriesOption{Page(3, -1)}, []string{"db-report", "db-never"}},
+  }
+  for i, test := range tests {
+    var names []string
+    for _, e := range cron.Entries(test.opts...) {
+      names = append(names, e.Name)
+    }
+    if !reflect.DeepEqual(names, test.expected) {
+      t.Errorf("%d: (expected) %v != %v (actual)", i, test.expected, names)
+    }
+  }
+}
+
+// TestEntriesPages checks that paging through entries with the same schedule
+// returns each of them once.
+func TestEntriesPages(t *testing.T) {
+  cron := New(WithClock(NewFakeClock(time.Date(2012, 7, 9, 0, 0, 0, 0,
+    time.UTC))), WithLocation(time.UTC))
+  defer cron.Stop()
+  var expected []string
+  for i := 0; i < 20; i++ {
+    id, _ := cron.AddFunc("@hourly", func() {})
+    expected = append(expected, id)
+  }
+  sort.Strings(expected)
+
+  var ids []string
+  for offset := 0; offset < 20; offset += 3 {
+    for _, e := range cron.Entries(Page(offset, 3)) {
+      ids = append(ids, e.ID)
+    }
+  }
+  if !reflect.DeepEqual(ids, expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, ids)
+  }
+}
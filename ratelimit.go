@@ -0,0 +1,81 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements limiting the rate at which jobs start.
+
+package cron
+
+import "time"
+
+// WithRateLimit starts at most perSecond jobs a second, on average, and at
+// most burst at once, e.g. so that schedules aligned on the top of the hour do
+// not overwhelm a shared service.  Activations beyond that wait in the same
+// queue as those beyond WithMaxConcurrent, in order, until they may start.  A
+// rate that is not positive is unlimited.
+func WithRateLimit(perSecond float64, burst int) Option {
+  return func(c *Cron) {
+    if perSecond <= 0 {
+      c.pool.bucket = nil
+      return
+    }
+    if burst < 1 {
+      burst = 1
+    }
+    c.pool.bucket = &tokenBucket{rate: perSecond, burst: float64(burst)}
+  }
+}
+
+// tokenBucket holds up to burst tokens, refilled at rate tokens a second.
+type tokenBucket struct {
+  rate, burst float64
+
+  // tokens is the number of tokens left at last, the time of the last refill.
+  tokens float64
+  last   time.Time
+}
+
+// take takes a token, and returns false if there is none at now.
+func (b *tokenBucket) take(now time.Time) bool {
+  b.refill(now)
+  if b.tokens < 1 {
+    return false
+  }
+  b.tokens--
+  return true
+}
+
+// ready returns when, at the earliest, a token can be taken.
+func (b *tokenBucket) ready(now time.Time) time.Time {
+  b.refill(now)
+  if b.tokens >= 1 {
+    return now
+  }
+  wait := (1 - b.tokens) / b.rate * float64(time.Second)
+  return now.Add(time.Duration(wait))
+}
+
+// refill adds the tokens earned since the last refill.  A new bucket is full.
+func (b *tokenBucket) refill(now time.Time) {
+  if b.last.IsZero() {
+    b.tokens = b.burst
+  } else if elapsed := now.Sub(b.last); elapsed > 0 {
+    b.tokens += elapsed.Seconds() * b.rate
+    if b.tokens > b.burst {
+      b.tokens = b.burst
+    }
+  }
+  if now.After(b.last) {
+    b.last = now
+  }
+}
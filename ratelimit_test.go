@@ -0,0 +1,119 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for limiting the rate at which jobs start.
+
+package cron
+
+import (
+  "sort"
+  "testing"
+  "time"
+)
+
+func TestTokenBucket(t *testing.T) {
+  start := getTime("Mon Jul 9 14:00 2012")
+  b := &tokenBucket{rate: 2, burst: 3}
+
+  tests := []struct {
+    after    time.Duration
+    expected bool
+  }{
+    // A new bucket is full.
+    {0, true},
+    {0, true},
+    {0, true},
+    {0, false},
+
+    // Tokens are refilled at the rate.
+    {400 * time.Millisecond, false},
+    {500 * time.Millisecond, true},
+    {500 * time.Millisecond, false},
+
+    // The bucket holds at most burst tokens.
+    {time.Hour, true},
+    {time.Hour, true},
+    {time.Hour, true},
+    {time.Hour, false},
+  }
+
+  for i, c := range tests {
+    if actual := b.take(start.Add(c.after)); actual != c.expected {
+      t.Errorf("take %d after %s: (expected) %v != %v (actual)", i, c.after,
+        c.expected, actual)
+    }
+  }
+
+  now := start.Add(time.Hour)
+  if ready := b.ready(now); !ready.Equal(now.Add(500 * time.Millisecond)) {
+    t.Errorf("expected a token at %v, got %v", now.Add(500*time.Millisecond),
+      ready)
+  }
+}
+
+func TestPoolRateLimit(t *testing.T) {
+  start := getTime("Mon Jul 9 14:00 2012")
+  clock := NewFakeClock(start)
+  p := newPool()
+  p.clock = clock
+  p.bucket = &tokenBucket{rate: 1, burst: 2}
+
+  started := make(chan int, 5)
+  for i := 0; i < 5; i++ {
+    i := i
+    p.submit("", 0, func() { started <- i })
+  }
+
+  // The first two start at once, and the rest one a second, in order.
+  next := 0
+  for _, step := range []struct {
+    after time.Duration
+    tasks int
+  }{{0, 2}, {time.Second, 1}, {500 * time.Millisecond, 0},
+    {500 * time.Millisecond, 1}, {time.Second, 1}} {
+    clock.Advance(step.after)
+    // Tasks started together run in their own goroutines, in any order.
+    var tasks []int
+    for n := 0; n < step.tasks; n++ {
+      select {
+      case actual := <-started:
+        tasks = append(tasks, actual)
+      case <-time.After(cOneSecond):
+        t.Fatalf("expected task %d to start", next+n)
+      }
+    }
+    sort.Ints(tasks)
+    for _, actual := range tasks {
+      if actual != next {
+        t.Errorf("(expected) task %d != task %d (actual) started", next, actual)
+      }
+      next++
+    }
+    select {
+    case actual := <-started:
+      t.Fatalf("task %d started early", actual)
+    case <-time.After(10 * time.Millisecond):
+    }
+  }
+}
+
+func TestWithRateLimit(t *testing.T) {
+  c := New(WithRateLimit(10, 0))
+  if b := c.pool.bucket; b == nil || b.rate != 10 || b.burst != 1 {
+    t.Errorf("unexpected bucket %+v", c.pool.bucket)
+  }
+  if c := New(WithRateLimit(0, 5)); c.pool.bucket != nil {
+    t.Errorf("expected no bucket, got %+v", c.pool.bucket)
+  }
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements an EntryStore in Redis.
+
+// Package redisstore implements a cron.EntryStore in Redis, so that several
+// stateless replicas can share one set of entries, and pick it back up when
+// they restart:
+//
+// 	client := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+// 	store := redisstore.New(client, "myapp:cron:")
+// 	c := cron.New(cron.WithStore(store))
+// 	c.Restore()
+//
+// A Store is also a cron.Locker, whose locks let the replicas agree on which
+// of them runs each activation:
+//
+// 	c := cron.New(cron.WithStore(store), cron.WithLocker(store))
+//
+// and a cron.Cluster, so that the replicas split the entries between them:
+//
+// 	c := cron.New(cron.WithStore(store),
+// 		cron.WithCluster(store, hostname, 0))
+package redisstore
+
+import (
+  "context"
+  "crypto/rand"
+  "encoding/hex"
+  "encoding/json"
+  "fmt"
+  "sort"
+  "strconv"
+  "time"
+
+  "github.com/kiranbond/cron"
+  "github.com/redis/go-redis/v9"
+)
+
+// DefaultTimeout bounds each request a Store makes.
+const DefaultTimeout = 5 * time.Second
+
+// DefaultLockTTL is how long the lock of an activation is held.
+const DefaultLockTTL = time.Minute
+
+// Store is a cron.EntryStore keeping each entry as a JSON document in a Redis
+// hash, keyed by its ID.  Its keys all start with a prefix:
+//
+// 	<prefix>entries                   the hash of entries
+// 	<prefix>lock:<id>:<unix nanos>    the lock of an activation
+type Store struct {
+  client redis.UniversalClient
+  prefix string
+
+  // Timeout bounds each request, and LockTTL is how long locks are held.
+  Timeout time.Duration
+  LockTTL time.Duration
+
+  // token identifies the locks held by this Store.
+  token string
+}
+
+// New returns a Store keeping entries in client under keys starting with
+// prefix, e.g. "myapp:cron:".
+func New(client redis.UniversalClient, prefix string) *Store {
+  b := make([]byte, 16)
+  rand.Read(b)
+  return &Store{
+    client:  client,
+    prefix:  prefix,
+    Timeout: DefaultTimeout,
+    LockTTL: DefaultLockTTL,
+    token:   hex.EncodeToString(b),
+  }
+}
+
+// requestContext returns the context of a request.
+func (s *Store) requestContext() (context.Context, context.CancelFunc) {
+  return context.WithTimeout(context.Background(), s.Timeout)
+}
+
+// entries returns the key of the hash of entries.
+func (s *Store) entries() string {
+  return s.prefix + "entries"
+}
+
+// Save implements cron.EntryStore.
+func (s *Store) Save(entry cron.StoredEntry) error {
+  b, err := json.Marshal(entry)
+  if err != nil {
+    return err
+  }
+  ctx, cancel := s.requestContext()
+  defer cancel()
+  return s.client.HSet(ctx, s.entries(), entry.ID, b).Err()
+}
+
+// Load implements cron.EntryStore, returning the entries ordered by ID.
+func (s *Store) Load() ([]cron.StoredEntry, error) {
+  ctx, cancel := s.requestContext()
+  defer cancel()
+  values, err := s.client.HGetAll(ctx, s.entries()).Result()
+  if err != nil {
+    return nil, err
+  }
+  entries := make([]cron.StoredEntry, 0, len(values))
+  for id, v := range values {
+    var entry cron.StoredEntry
+    if err := json.Unmarshal([]byte(v), &entry); err != nil {
+      return nil, fmt.Errorf("cannot decode entry %s: %s", id, err)
+    }
+    entries = append(entries, entry)
+  }
+  sort.Slice(entries, func(i, j int) bool {
+    return entries[i].ID < entries[j].ID
+  })
+  return entries, nil
+}
+
+// Delete implements cron.EntryStore.
+func (s *Store) Delete(id string) error {
+  ctx, cancel := s.requestContext()
+  defer cancel()
+  return s.client.HDel(ctx, s.entries(), id).Err()
+}
+
+// lock returns the key of the lock of the entry's activation.
+func (s *Store) lock(entryID string, scheduled time.Time) string {
+  return s.prefix + "lock:" + entryID + ":" +
+    strconv.FormatInt(scheduled.UnixNano(), 10)
+}
+
+// TryLock takes the lock of the entry's activation at the given time for
+// LockTTL, and returns whether it did: false if another Store holds it, or
+// Redis cannot be reached.
+func (s *Store) TryLock(entryID string, scheduled time.Time) bool {
+  ctx, cancel := s.requestContext()
+  defer cancel()
+  ok, err := s.client.SetNX(ctx, s.lock(entryID, scheduled), s.token,
+    s.LockTTL).Result()
+  return err == nil && ok
+}
+
+// Unlock implements cron.Locker.  It does nothing: the lock of an activation
+// is kept until it expires, after LockTTL, so that a replica whose timer fires
+// after the run finished does not run the activation again.
+func (s *Store) Unlock(entryID string, scheduled time.Time) {}
+
+// members returns the key of the sorted set of the members of the cluster,
+// scored by when they expire.
+func (s *Store) members() string {
+  return s.prefix + "members"
+}
+
+// Heartbeat implements cron.Cluster.
+func (s *Store) Heartbeat(member string, ttl time.Duration) error {
+  ctx, cancel := s.requestContext()
+  defer cancel()
+  return s.client.ZAdd(ctx, s.members(), redis.Z{
+    Score:  float64(time.Now().Add(ttl).UnixMilli()),
+    Member: member,
+  }).Err()
+}
+
+// Leave implements cron.Cluster.
+func (s *Store) Leave(member string) error {
+  ctx, cancel := s.requestContext()
+  defer cancel()
+  return s.client.ZRem(ctx, s.members(), member).Err()
+}
+
+// Members implements cron.Cluster, returning the members ordered, and
+// removing those that expired.
+func (s *Store) Members() ([]string, error) {
+  ctx, cancel := s.requestContext()
+  defer cancel()
+  now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+  if err := s.client.ZRemRangeByScore(ctx, s.members(), "-inf",
+    now).Err(); err != nil {
+    return nil, err
+  }
+  members, err := s.client.ZRange(ctx, s.members(), 0, -1).Result()
+  if err != nil {
+    return nil, err
+  }
+  sort.Strings(members)
+  return members, nil
+}
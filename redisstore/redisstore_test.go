@@ -0,0 +1,122 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the Redis EntryStore.
+
+package redisstore
+
+import (
+  "reflect"
+  "testing"
+  "time"
+
+  "github.com/alicebob/miniredis/v2"
+  "github.com/kiranbond/cron"
+  "github.com/redis/go-redis/v9"
+)
+
+// newStore returns a Store on a fresh in-memory Redis server.
+func newStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+  server := miniredis.RunT(t)
+  client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+  t.Cleanup(func() { client.Close() })
+  return New(client, "test:"), server
+}
+
+func TestStore(t *testing.T) {
+  s, _ := newStore(t)
+
+  prev := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  a := cron.StoredEntry{ID: "a", Name: "report", Spec: "@daily",
+    Labels: map[string]string{"team": "infra"}, Job: "report",
+    Payload: []byte(`{"to":"ops"}`), Prev: prev, Next: prev.Add(24 * time.Hour)}
+  b := cron.StoredEntry{ID: "b", Spec: "@hourly", Job: "ping"}
+  for _, e := range []cron.StoredEntry{b, a, b} {
+    if err := s.Save(e); err != nil {
+      t.Fatal(err)
+    }
+  }
+  if err := s.Delete("c"); err != nil {
+    t.Error(err)
+  }
+
+  // Another replica sees the same entries.
+  other := New(s.client, "test:")
+  entries, err := other.Load()
+  if err != nil {
+    t.Fatal(err)
+  }
+  if expected := []cron.StoredEntry{a, b}; !reflect.DeepEqual(entries,
+    expected) {
+    t.Errorf("(expected) %+v != %+v (actual)", expected, entries)
+  }
+
+  if err := s.Delete("a"); err != nil {
+    t.Fatal(err)
+  }
+  if entries, _ := other.Load(); len(entries) != 1 || entries[0].ID != "b" {
+    t.Errorf("expected only b, got %+v", entries)
+  }
+}
+
+func TestLock(t *testing.T) {
+  s, server := newStore(t)
+  // Stores are the Lockers of Crons.
+  var other cron.Locker = New(s.client, "test:")
+  at := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+
+  if !s.TryLock("a", at) {
+    t.Fatal("expected to take the lock")
+  }
+  if other.TryLock("a", at) {
+    t.Error("expected the lock to be held")
+  }
+  if !other.TryLock("a", at.Add(time.Hour)) || !other.TryLock("b", at) {
+    t.Error("expected the locks of other activations to be free")
+  }
+
+  // Locks are kept after the run, until they expire.
+  s.Unlock("a", at)
+  if other.TryLock("a", at) {
+    t.Error("expected the unlocked lock to still be held")
+  }
+
+  // Locks expire.
+  server.FastForward(DefaultLockTTL + time.Second)
+  if !other.TryLock("a", at) {
+    t.Error("expected the expired lock to be free")
+  }
+}
+
+func TestMembers(t *testing.T) {
+  s, _ := newStore(t)
+  var cluster cron.Cluster = s
+
+  cluster.Heartbeat("b", time.Minute)
+  cluster.Heartbeat("a", time.Minute)
+  cluster.Heartbeat("expired", -time.Second)
+  members, err := cluster.Members()
+  if err != nil {
+    t.Fatal(err)
+  }
+  if expected := []string{"a", "b"}; !reflect.DeepEqual(members, expected) {
+    t.Errorf("(expected) %v != %v (actual)", expected, members)
+  }
+
+  cluster.Leave("a")
+  if members, _ := cluster.Members(); !reflect.DeepEqual(members,
+    []string{"b"}) {
+    t.Errorf("expected only b, got %v", members)
+  }
+}
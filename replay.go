@@ -0,0 +1,79 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements replaying, or catching up with, the activations of
+// stored entries missed while the process was down.
+
+package cron
+
+import (
+  "time"
+)
+
+// WithReplay makes Restore replay the activations of the stored entries missed
+// while no process ran them, e.g. during an outage: instead of following now,
+// the next activation of a restored entry follows the scheduled time of its
+// last run that succeeded, or, if none did, its last activation.  The missed
+// activations then run according to the entry's misfire policy: all of them
+// by default, once with MisfireRunOnce, or none with MisfireSkip.
+func WithReplay() Option {
+  return func(c *Cron) {
+    c.replay = true
+  }
+}
+
+// WithCatchUp makes Restore catch up with the activations of each stored
+// entry missed while no process ran it, e.g. during an outage: unlike with
+// WithReplay, the entry runs once, for the last of them, before following its
+// schedule from now; and only if that activation is at most maxAge old, unless
+// maxAge is zero.  Entries that never ran do not catch up.  If the Cron is not
+// running yet, the runs start when it starts.  WithReplay takes precedence.
+func WithCatchUp(maxAge time.Duration) Option {
+  return func(c *Cron) {
+    c.catchUp, c.maxCatchUpAge = true, maxAge
+  }
+}
+
+// missed returns the last activation of the entry's schedule after prev, up to
+// now, that the entry catches up with, or the zero time if there is none or
+// it is too old.
+func (c *Cron) missed(e *Entry, prev time.Time) time.Time {
+  if prev.IsZero() {
+    return time.Time{}
+  }
+  now := c.now()
+  if e.Location != nil {
+    now = now.In(e.Location)
+  }
+  missed := Prev(e.Schedule, now)
+  if !missed.After(prev) ||
+    c.maxCatchUpAge > 0 && now.Sub(missed) > c.maxCatchUpAge {
+    return time.Time{}
+  }
+  return missed
+}
+
+// succeeded records that the run of the stored entry scheduled for the given
+// time succeeded.  It is called from the goroutine of the run.
+func (c *Cron) succeeded(e *Entry, scheduled time.Time) {
+  c.mu.Lock()
+  defer c.unlock()
+  if !scheduled.After(e.lastSuccess) {
+    return
+  }
+  e.lastSuccess = scheduled
+  if c.ids[e.ID] == e {
+    c.persist(e)
+  }
+}
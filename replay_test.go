@@ -0,0 +1,192 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for replaying missed activations.
+
+package cron
+
+import (
+  "reflect"
+  "sort"
+  "sync"
+  "testing"
+  "time"
+)
+
+func TestReplay(t *testing.T) {
+  start := time.Date(2012, 7, 9, 12, 0, 0, 0, time.UTC)
+  tests := []struct {
+    name     string
+    replay   bool
+    stored   StoredEntry
+    expected []time.Time
+  }{
+    {"not replayed", false,
+      StoredEntry{LastSuccess: start.Add(-3 * time.Hour)}, nil},
+    {"all", true, StoredEntry{LastSuccess: start.Add(-3 * time.Hour)},
+      []time.Time{start.Add(-2 * time.Hour), start.Add(-time.Hour), start}},
+    {"after the last activation", true,
+      StoredEntry{Prev: start.Add(-2 * time.Hour)},
+      []time.Time{start.Add(-time.Hour), start}},
+    {"once", true, StoredEntry{LastSuccess: start.Add(-3 * time.Hour),
+      Misfire: MisfireRunOnce}, []time.Time{start.Add(-2 * time.Hour)}},
+    {"skipped", true, StoredEntry{LastSuccess: start.Add(-3 * time.Hour),
+      Misfire: MisfireSkip}, nil},
+    {"never run", true, StoredEntry{}, nil},
+  }
+
+  for _, test := range tests {
+    store := NewMemoryStore()
+    stored := test.stored
+    stored.ID, stored.Spec, stored.Job = "a", "@hourly", "test-echo"
+    store.Save(stored)
+
+    opts := []Option{WithClock(NewFakeClock(start)), WithLocation(time.UTC),
+      WithStore(store)}
+    if test.replay {
+      opts = append(opts, WithReplay())
+    }
+    cron := New(opts...)
+    var (
+      mu  sync.Mutex
+      ran []time.Time
+    )
+    cron.Subscribe(func(e Event) {
+      if e.Type == RunCompleted {
+        mu.Lock()
+        defer mu.Unlock()
+        ran = append(ran, e.Scheduled)
+      }
+    })
+    if err := cron.Restore(); err != nil {
+      t.Fatal(err)
+    }
+    cron.Start()
+
+    waitFor(t, func() bool {
+      e, _ := cron.Entry("a")
+      return e.Next.Equal(start.Add(time.Hour))
+    })
+    for range test.expected {
+      <-storeRuns
+    }
+    waitFor(t, func() bool {
+      mu.Lock()
+      defer mu.Unlock()
+      return len(ran) == len(test.expected)
+    })
+    if len(test.expected) > 0 {
+      waitFor(t, func() bool {
+        entries, _ := store.Load()
+        return entries[0].LastSuccess.Equal(
+          test.expected[len(test.expected)-1])
+      })
+    }
+    cron.Stop()
+
+    // The replayed runs run concurrently.
+    mu.Lock()
+    sort.Slice(ran, func(i, j int) bool { return ran[i].Before(ran[j]) })
+    if !reflect.DeepEqual(ran, test.expected) {
+      t.Errorf("%s: (expected) %v != %v (actual)", test.name, test.expected,
+        ran)
+    }
+    mu.Unlock()
+  }
+}
+
+func TestCatchUp(t *testing.T) {
+  start := time.Date(2012, 7, 9, 12, 30, 0, 0, time.UTC)
+  missed := start.Add(-30 * time.Minute)
+  tests := []struct {
+    name     string
+    maxAge   time.Duration
+    prev     time.Time
+    expected bool
+  }{
+    {"caught up", 0, start.Add(-3 * time.Hour), true},
+    {"within the maximum age", time.Hour, start.Add(-3 * time.Hour), true},
+    {"too old", 20 * time.Minute, start.Add(-3 * time.Hour), false},
+    {"not missed", 0, missed, false},
+    {"never run", 0, time.Time{}, false},
+  }
+
+  for _, test := range tests {
+    store := NewMemoryStore()
+    store.Save(StoredEntry{ID: "a", Spec: "@hourly", Job: "test-echo",
+      Prev: test.prev})
+    cron := New(WithClock(NewFakeClock(start)), WithLocation(time.UTC),
+      WithStore(store), WithCatchUp(test.maxAge))
+    runs := make(chan time.Time, 10)
+    cron.Subscribe(func(e Event) {
+      if e.Type == RunCompleted {
+        runs <- e.Scheduled
+      }
+    })
+    if err := cron.Restore(); err != nil {
+      t.Fatal(err)
+    }
+
+    e, _ := cron.Entry("a")
+    if !e.Next.Equal(start.Add(30 * time.Minute)) {
+      t.Errorf("%s: expected the next run at 13:00, got %v", test.name,
+        e.Next)
+    }
+    prev := test.prev
+    if test.expected {
+      prev = missed
+    }
+    if !e.Prev.Equal(prev) {
+      t.Errorf("%s: (expected) %v != %v (actual) previous run", test.name,
+        prev, e.Prev)
+    }
+
+    // The run starts with the Cron.
+    select {
+    case <-runs:
+      t.Errorf("%s: unexpected run before the Cron started", test.name)
+    case <-time.After(10 * time.Millisecond):
+    }
+    cron.Start()
+    if test.expected {
+      select {
+      case scheduled := <-runs:
+        <-storeRuns
+        if !scheduled.Equal(missed) {
+          t.Errorf("%s: expected the run at %v, got %v", test.name, missed,
+            scheduled)
+        }
+      case <-time.After(cOneSecond):
+        t.Fatalf("%s: expected the missed run", test.name)
+      }
+    }
+    select {
+    case scheduled := <-runs:
+      t.Errorf("%s: unexpected run at %v", test.name, scheduled)
+    case <-time.After(10 * time.Millisecond):
+    }
+    cron.Stop()
+  }
+}
+
+func TestStoredMisfire(t *testing.T) {
+  store := NewMemoryStore()
+  cron := New(WithStore(store))
+  cron.AddRegistered("@hourly", "test-echo", nil,
+    WithMisfirePolicy(MisfireSkip))
+  entries, _ := store.Load()
+  if entries[0].Misfire != MisfireSkip {
+    t.Errorf("expected the misfire policy stored, got %+v", entries[0])
+  }
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements retrying failed job runs with backoff.
+
+package cron
+
+import (
+  "context"
+  "fmt"
+  "math/rand"
+  "time"
+)
+
+// ErrorJob is a Job whose runs may fail.  Cron, and wrappers such as Retry, call
+// RunError instead of Run, and report the errors it returns.
+type ErrorJob interface {
+  Job
+  RunError(ctx context.Context) error
+}
+
+// ErrorFuncJob is a wrapper that turns a func(context.Context) error into a
+// cron.ErrorJob.
+type ErrorFuncJob func(ctx context.Context) error
+
+// Run invokes the function with a background context, logging any error.
+func (f ErrorFuncJob) Run() { f.RunContext(context.Background()) }
+
+// RunContext invokes the function, logging any error.
+func (f ErrorFuncJob) RunContext(ctx context.Context) {
+  if err := f(ctx); err != nil {
+    loggerFrom(ctx).Warningf("job failed: %v", err)
+  }
+}
+
+// RunError invokes the function.
+func (f ErrorFuncJob) RunError(ctx context.Context) error { return f(ctx) }
+
+// RetryPolicy configures Retry.
+type RetryPolicy struct {
+  // Retries is the number of times a failed run is retried.
+  Retries int
+
+  // Backoff is the delay before the first retry, doubled for each following
+  // retry up to MaxBackoff, if set.
+  Backoff, MaxBackoff time.Duration
+
+  // Jitter randomizes each delay by up to this fraction of it, e.g. 0.1 for
+  // +/-10%, so that many failing jobs do not retry in lockstep.
+  Jitter float64
+
+  // OnDone, if set, is called with the outcome of each run once it succeeds
+  // or its retries are exhausted: nil, or the error of the last attempt.
+  OnDone func(err error)
+}
+
+// Retry retries runs of a job that fail, by returning an error if it is an
+// ErrorJob or by panicking, according to the policy.  Retries happen within
+// the run, independently of the job's schedule, and stop early if the run's
+// context is canceled.  The returned job is an ErrorJob whose runs fail with
+// the error of the last attempt.
+func Retry(policy RetryPolicy) JobWrapper {
+  return func(j Job) Job {
+    return &retryJob{job: j, policy: policy}
+  }
+}
+
+// retryJob is a Job wrapped by Retry.
+type retryJob struct {
+  job    Job
+  policy RetryPolicy
+}
+
+// Run runs the job with a background context.
+func (r *retryJob) Run() { r.RunContext(context.Background()) }
+
+// RunContext runs the job, logging the final error.
+func (r *retryJob) RunContext(ctx context.Context) {
+  if err := r.RunError(ctx); err != nil {
+    loggerFrom(ctx).Warningf("job failed: %v", err)
+  }
+}
+
+// RunError runs the job until it succeeds, its retries are exhausted or the
+// context is canceled.
+func (r *retryJob) RunError(ctx context.Context) error {
+  err := runOnce(ctx, r.job)
+  backoff := r.policy.Backoff
+  for retry := 0; err != nil && retry < r.policy.Retries; retry++ {
+    if !sleep(ctx, jitter(backoff, r.policy.Jitter)) {
+      err = fmt.Errorf("retries canceled: %s", err)
+      break
+    }
+    err = runOnce(ctx, r.job)
+
+    backoff *= 2
+    if r.policy.MaxBackoff > 0 && backoff > r.policy.MaxBackoff {
+      backoff = r.policy.MaxBackoff
+    }
+  }
+
+  if r.policy.OnDone != nil {
+    r.policy.OnDone(err)
+  }
+  return err
+}
+
+// runOnce runs the job, returning its error, or an error if it panicked.
+func runOnce(ctx context.Context, j Job) (err error) {
+  defer func() {
+    if r := recover(); r != nil {
+      err = fmt.Errorf("panic: %v", r)
+    }
+  }()
+  if ej, ok := j.(ErrorJob); ok {
+    return ej.RunError(ctx)
+  }
+  runJob(ctx, j)
+  return nil
+}
+
+// sleep waits for the duration, returning false if the context is canceled
+// first.
+func sleep(ctx context.Context, d time.Duration) bool {
+  timer := time.NewTimer(d)
+  defer timer.Stop()
+  select {
+  case <-ctx.Done():
+    return false
+  case <-timer.C:
+    return true
+  }
+}
+
+// jitter returns d randomized by up to the given fraction of it.
+func jitter(d time.Duration, fraction float64) time.Duration {
+  if fraction <= 0 || d <= 0 {
+    return d
+  }
+  return d + time.Duration((rand.Float64()*2-1)*fraction*float64(d))
+}
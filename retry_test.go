@@ -0,0 +1,130 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for retrying failed jobs.
+
+package cron
+
+import (
+  "context"
+  "errors"
+  "testing"
+  "time"
+)
+
+func TestRetry(t *testing.T) {
+  tests := []struct {
+    failures int
+    retries  int
+    runs     int
+    fails    bool
+  }{
+    {0, 3, 1, false},
+    {2, 3, 3, false},
+    {3, 3, 4, false},
+    {4, 3, 4, true},
+    {1, 0, 1, true},
+  }
+
+  for _, c := range tests {
+    runs := 0
+    var outcome error
+    job := Retry(RetryPolicy{
+      Retries: c.retries,
+      Backoff: time.Millisecond,
+      OnDone:  func(err error) { outcome = err },
+    })(ErrorFuncJob(func(ctx context.Context) error {
+      runs++
+      if runs <= c.failures {
+        return errors.New("failed")
+      }
+      return nil
+    }))
+
+    err := job.(ErrorJob).RunError(context.Background())
+    if runs != c.runs || (err != nil) != c.fails || outcome != err {
+      t.Errorf("%d failures, %d retries: (expected) %d runs, failed %v != "+
+        "%d runs, %v, outcome %v (actual)", c.failures, c.retries, c.runs,
+        c.fails, runs, err, outcome)
+    }
+  }
+}
+
+func TestRetryPanic(t *testing.T) {
+  runs := 0
+  job := Retry(RetryPolicy{Retries: 2})(FuncJob(func() {
+    runs++
+    if runs == 1 {
+      panic("failed")
+    }
+  }))
+
+  if err := job.(ErrorJob).RunError(context.Background()); err != nil ||
+    runs != 2 {
+    t.Errorf("expected success on the second run, got %v after %d runs", err,
+      runs)
+  }
+}
+
+func TestRetryBackoff(t *testing.T) {
+  var times []time.Time
+  job := Retry(RetryPolicy{
+    Retries:    3,
+    Backoff:    20 * time.Millisecond,
+    MaxBackoff: 30 * time.Millisecond,
+  })(ErrorFuncJob(func(ctx context.Context) error {
+    times = append(times, time.Now())
+    return errors.New("failed")
+  }))
+  job.Run()
+
+  expected := []time.Duration{
+    20 * time.Millisecond, 30 * time.Millisecond, 30 * time.Millisecond}
+  if len(times) != 4 {
+    t.Fatalf("expected 4 runs, got %d", len(times))
+  }
+  for i, e := range expected {
+    if d := times[i+1].Sub(times[i]); d < e || d > e+20*time.Millisecond {
+      t.Errorf("retry %d: (expected) %v != %v (actual)", i, e, d)
+    }
+  }
+}
+
+func TestRetryCanceled(t *testing.T) {
+  ctx, cancel := context.WithCancel(context.Background())
+  runs := 0
+  job := Retry(RetryPolicy{Retries: 5, Backoff: time.Hour})(ErrorFuncJob(
+    func(ctx context.Context) error {
+      runs++
+      cancel()
+      return errors.New("failed")
+    }))
+
+  if err := job.(ErrorJob).RunError(ctx); err == nil || runs != 1 {
+    t.Errorf("expected a canceled failure after 1 run, got %v after %d "+
+      "runs", err, runs)
+  }
+}
+
+func TestJitter(t *testing.T) {
+  for i := 0; i < 100; i++ {
+    d := jitter(time.Second, 0.1)
+    if d < 900*time.Millisecond || d > 1100*time.Millisecond {
+      t.Fatalf("expected 1s +/-10%%, got %v", d)
+    }
+  }
+  if d := jitter(time.Second, 0); d != time.Second {
+    t.Errorf("expected no jitter, got %v", d)
+  }
+}
@@ -0,0 +1,428 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements RFC 5545 recurrence rule schedule.
+
+package cron
+
+import (
+  "fmt"
+  "sort"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// Frequency is the FREQ of a recurrence rule.
+type Frequency int
+
+// The recurrence rule frequencies, from the finest to the coarsest.
+const (
+  FreqSecondly Frequency = iota
+  FreqMinutely
+  FreqHourly
+  FreqDaily
+  FreqWeekly
+  FreqMonthly
+  FreqYearly
+)
+
+var frequencies = map[string]Frequency{
+  "SECONDLY": FreqSecondly,
+  "MINUTELY": FreqMinutely,
+  "HOURLY":   FreqHourly,
+  "DAILY":    FreqDaily,
+  "WEEKLY":   FreqWeekly,
+  "MONTHLY":  FreqMonthly,
+  "YEARLY":   FreqYearly,
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+  "SU": time.Sunday,
+  "MO": time.Monday,
+  "TU": time.Tuesday,
+  "WE": time.Wednesday,
+  "TH": time.Thursday,
+  "FR": time.Friday,
+  "SA": time.Saturday,
+}
+
+// WeekdayNum is an element of BYDAY: a weekday, and for monthly and yearly
+// rules an optional ordinal N (e.g. -1 for the last one, 2 for the second one).
+// An N of zero matches every such weekday.
+type WeekdayNum struct {
+  N       int
+  Weekday time.Weekday
+}
+
+// RRuleSchedule is a schedule defined by an iCalendar recurrence rule (RFC
+// 5545), e.g. "FREQ=MONTHLY;BYDAY=-1FR" for the last Friday of every month.
+//
+// It supports FREQ, INTERVAL, COUNT, UNTIL, WKST, BYMONTH, BYMONTHDAY, BYDAY,
+// BYHOUR, BYMINUTE and BYSECOND.  BYSETPOS, BYYEARDAY and BYWEEKNO are not
+// supported.  As in RFC 5545, parts of the DTStart not restricted by the rule
+// (e.g. the time of day of a daily rule) are taken from the DTStart.
+type RRuleSchedule struct {
+  Freq       Frequency
+  Interval   int
+  Count      int
+  Until      time.Time
+  WeekStart  time.Weekday
+  ByMonth    []int
+  ByMonthDay []int
+  ByDay      []WeekdayNum
+  ByHour     []int
+  ByMinute   []int
+  BySecond   []int
+
+  // DTStart is the first activation, and anchors the interval.  Times are
+  // evaluated in its location.
+  DTStart time.Time
+}
+
+// ParseRRule returns the schedule of the recurrence rule, e.g.
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", starting at dtstart.  An "RRULE:"
+// prefix is accepted.
+func ParseRRule(rule string, dtstart time.Time) (*RRuleSchedule, error) {
+  s := &RRuleSchedule{
+    Freq:      -1,
+    Interval:  1,
+    WeekStart: time.Monday,
+    DTStart:   dtstart.Truncate(time.Second),
+  }
+
+  rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+  for _, part := range strings.Split(rule, ";") {
+    nameAndValue := strings.Split(part, "=")
+    if len(nameAndValue) != 2 {
+      return nil, fmt.Errorf("expected NAME=VALUE, found %s: %s", part, rule)
+    }
+    name, value := strings.ToUpper(nameAndValue[0]), nameAndValue[1]
+
+    var err error
+    switch name {
+    case "FREQ":
+      freq, ok := frequencies[strings.ToUpper(value)]
+      if !ok {
+        return nil, fmt.Errorf("unrecognized frequency %s: %s", value, rule)
+      }
+      s.Freq = freq
+    case "INTERVAL":
+      s.Interval, err = strconv.Atoi(value)
+      if err == nil && s.Interval < 1 {
+        err = fmt.Errorf("interval must be positive")
+      }
+    case "COUNT":
+      s.Count, err = strconv.Atoi(value)
+      if err == nil && s.Count < 1 {
+        err = fmt.Errorf("count must be positive")
+      }
+    case "UNTIL":
+      s.Until, err = parseRRuleTime(value, dtstart.Location())
+    case "WKST":
+      weekday, ok := rruleWeekdays[strings.ToUpper(value)]
+      if !ok {
+        err = fmt.Errorf("unrecognized weekday")
+      }
+      s.WeekStart = weekday
+    case "BYMONTH":
+      s.ByMonth, err = parseRRuleInts(value, 1, 12, false)
+    case "BYMONTHDAY":
+      s.ByMonthDay, err = parseRRuleInts(value, 1, 31, true)
+    case "BYDAY":
+      s.ByDay, err = parseRRuleWeekdays(value)
+    case "BYHOUR":
+      s.ByHour, err = parseRRuleInts(value, 0, 23, false)
+    case "BYMINUTE":
+      s.ByMinute, err = parseRRuleInts(value, 0, 59, false)
+    case "BYSECOND":
+      s.BySecond, err = parseRRuleInts(value, 0, 59, false)
+    default:
+      return nil, fmt.Errorf("unsupported rule part %s: %s", name, rule)
+    }
+    if err != nil {
+      return nil, fmt.Errorf("failed to parse %s: %s: %s", part, err, rule)
+    }
+  }
+
+  if s.Freq < 0 {
+    return nil, fmt.Errorf("missing FREQ: %s", rule)
+  }
+  if s.Count > 0 && !s.Until.IsZero() {
+    return nil, fmt.Errorf("COUNT and UNTIL are mutually exclusive: %s", rule)
+  }
+  return s, nil
+}
+
+// parseRRuleTime parses an UNTIL date or date-time.
+func parseRRuleTime(value string, loc *time.Location) (time.Time, error) {
+  if strings.HasSuffix(value, "Z") {
+    return time.Parse("20060102T150405Z", value)
+  }
+  if len(value) == len("20060102") {
+    t, err := time.ParseInLocation("20060102", value, loc)
+    // A date UNTIL includes the whole day.
+    return t.AddDate(0, 0, 1).Add(-time.Second), err
+  }
+  return time.ParseInLocation("20060102T150405", value, loc)
+}
+
+// parseRRuleInts parses a comma separated list of integers within [min, max],
+// or [-max, -min] as well if negative is true.
+func parseRRuleInts(value string, min, max int, negative bool) ([]int, error) {
+  var ints []int
+  for _, s := range strings.Split(value, ",") {
+    n, err := strconv.Atoi(s)
+    if err != nil {
+      return nil, err
+    }
+    if (n < min || n > max) && (!negative || n < -max || n > -min) {
+      return nil, fmt.Errorf("%d out of range", n)
+    }
+    ints = append(ints, n)
+  }
+  sort.Ints(ints)
+  return ints, nil
+}
+
+// parseRRuleWeekdays parses a comma separated list of optionally numbered
+// weekdays, e.g. "MO,-1FR".
+func parseRRuleWeekdays(value string) ([]WeekdayNum, error) {
+  var weekdays []WeekdayNum
+  for _, s := range strings.Split(value, ",") {
+    if len(s) < 2 {
+      return nil, fmt.Errorf("unrecognized weekday %s", s)
+    }
+    weekday, ok := rruleWeekdays[strings.ToUpper(s[len(s)-2:])]
+    if !ok {
+      return nil, fmt.Errorf("unrecognized weekday %s", s)
+    }
+    var n int
+    if len(s) > 2 {
+      var err error
+      if n, err = strconv.Atoi(s[:len(s)-2]); err != nil {
+        return nil, err
+      }
+      if n == 0 || n < -53 || n > 53 {
+        return nil, fmt.Errorf("%d out of range", n)
+      }
+    }
+    weekdays = append(weekdays, WeekdayNum{N: n, Weekday: weekday})
+  }
+  return weekdays, nil
+}
+
+// Next returns the next activation of the rule later than the given time, or
+// the zero time if the rule has ended.
+func (s *RRuleSchedule) Next(t time.Time) time.Time {
+  if s.Count == 0 {
+    return s.next(t)
+  }
+
+  // Count the activations from the start.
+  next := s.DTStart.Add(-time.Second)
+  for i := 0; i < s.Count; i++ {
+    next = s.next(next)
+    if next.IsZero() || next.After(t) {
+      return next
+    }
+  }
+  return time.Time{}
+}
+
+// next is Next, disregarding Count.
+func (s *RRuleSchedule) next(t time.Time) time.Time {
+  t = t.In(s.DTStart.Location())
+  if t.Before(s.DTStart) {
+    t = s.DTStart.Add(-time.Second)
+  }
+
+  // If no time is found within five years (or intervals), return zero.
+  limit := t.AddDate(5, 0, 0)
+  switch s.Freq {
+  case FreqYearly:
+    limit = limit.AddDate(s.Interval, 0, 0)
+  case FreqMonthly:
+    limit = limit.AddDate(0, s.Interval, 0)
+  }
+
+  day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+  for ; day.Before(limit); day = day.AddDate(0, 0, 1) {
+    if !s.dayMatches(day) {
+      continue
+    }
+    next := s.nextInDay(day, t)
+    if next.IsZero() {
+      continue
+    }
+    if !s.Until.IsZero() && next.After(s.Until) {
+      return time.Time{}
+    }
+    return next
+  }
+  return time.Time{}
+}
+
+// dayMatches returns true if the day is in the rule's interval and matches its
+// day level parts.
+func (s *RRuleSchedule) dayMatches(day time.Time) bool {
+  start := s.DTStart
+  daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0,
+    day.Location()).Day()
+
+  switch s.Freq {
+  case FreqYearly:
+    if (day.Year()-start.Year())%s.Interval != 0 {
+      return false
+    }
+  case FreqMonthly:
+    months := (day.Year()-start.Year())*12 + int(day.Month()-start.Month())
+    if months%s.Interval != 0 {
+      return false
+    }
+  case FreqWeekly:
+    weeks := daysBetween(s.weekStart(start), s.weekStart(day)) / 7
+    if weeks%s.Interval != 0 {
+      return false
+    }
+  case FreqDaily:
+    if daysBetween(start, day)%s.Interval != 0 {
+      return false
+    }
+  }
+
+  if len(s.ByMonth) > 0 {
+    if !containsInt(s.ByMonth, int(day.Month())) {
+      return false
+    }
+  } else if s.Freq == FreqYearly && len(s.ByMonthDay) == 0 &&
+    len(s.ByDay) == 0 && day.Month() != start.Month() {
+    return false
+  }
+
+  if len(s.ByMonthDay) > 0 {
+    if !containsInt(s.ByMonthDay, day.Day()) &&
+      !containsInt(s.ByMonthDay, day.Day()-daysInMonth-1) {
+      return false
+    }
+  } else if (s.Freq == FreqMonthly || s.Freq == FreqYearly) &&
+    len(s.ByDay) == 0 && day.Day() != start.Day() {
+    return false
+  }
+
+  if len(s.ByDay) > 0 {
+    return s.weekdayMatches(day, daysInMonth)
+  }
+  if s.Freq == FreqWeekly {
+    return day.Weekday() == start.Weekday()
+  }
+  return true
+}
+
+// weekdayMatches returns true if the day matches one of the BYDAY weekdays.
+func (s *RRuleSchedule) weekdayMatches(day time.Time, daysInMonth int) bool {
+  for _, weekday := range s.ByDay {
+    if weekday.Weekday != day.Weekday() {
+      continue
+    }
+    if weekday.N == 0 ||
+      (s.Freq != FreqMonthly && s.Freq != FreqYearly) {
+      return true
+    }
+
+    // Numbered weekdays count within the month, or within the year for yearly
+    // rules without BYMONTH.
+    index, length := day.Day(), daysInMonth
+    if s.Freq == FreqYearly && len(s.ByMonth) == 0 {
+      index = day.YearDay()
+      length = time.Date(day.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+    }
+    if weekday.N > 0 && (index-1)/7+1 == weekday.N {
+      return true
+    }
+    if weekday.N < 0 && -((length-index)/7+1) == weekday.N {
+      return true
+    }
+  }
+  return false
+}
+
+// nextInDay returns the first time on the given day, later than t, that
+// matches the rule's time of day, or the zero time if there is none.
+func (s *RRuleSchedule) nextInDay(day, t time.Time) time.Time {
+  start := s.DTStart
+  hours := s.timeParts(s.ByHour, FreqHourly, start.Hour(), 23)
+  minutes := s.timeParts(s.ByMinute, FreqMinutely, start.Minute(), 59)
+  seconds := s.timeParts(s.BySecond, FreqSecondly, start.Second(), 59)
+
+  for _, hour := range hours {
+    for _, minute := range minutes {
+      for _, second := range seconds {
+        next := time.Date(day.Year(), day.Month(), day.Day(), hour, minute,
+          second, 0, day.Location())
+        // Skip times that fall in a daylight savings gap, and those already
+        // passed.
+        if next.Hour() != hour || !next.After(t) {
+          continue
+        }
+        if s.Freq < FreqDaily {
+          unit := [...]time.Duration{time.Second, time.Minute, time.Hour}[s.Freq]
+          if int64(next.Sub(start.Truncate(unit))/unit)%int64(s.Interval) != 0 {
+            continue
+          }
+        }
+        return next
+      }
+    }
+  }
+  return time.Time{}
+}
+
+// timeParts returns the values of a time of day part: the BYxxx values if
+// given, every value if the rule is at least as fine as freq, and the DTStart
+// value otherwise.
+func (s *RRuleSchedule) timeParts(by []int, freq Frequency, start,
+  max int) []int {
+  if len(by) > 0 {
+    return by
+  }
+  if s.Freq > freq {
+    return []int{start}
+  }
+  parts := make([]int, max+1)
+  for i := range parts {
+    parts[i] = i
+  }
+  return parts
+}
+
+// weekStart returns the first day of the week containing t.
+func (s *RRuleSchedule) weekStart(t time.Time) time.Time {
+  offset := (int(t.Weekday()) - int(s.WeekStart) + 7) % 7
+  return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0,
+    t.Location())
+}
+
+// daysBetween returns the number of calendar days from a to b.
+func daysBetween(a, b time.Time) int {
+  a = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+  b = time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+  return int(b.Sub(a) / (24 * time.Hour))
+}
+
+// containsInt returns true if the sorted ints contain n.
+func containsInt(ints []int, n int) bool {
+  i := sort.SearchInts(ints, n)
+  return i < len(ints) && ints[i] == n
+}
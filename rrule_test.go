@@ -0,0 +1,101 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements recurrence rule schedule test.
+
+package cron
+
+import (
+  "testing"
+  "time"
+)
+
+func TestRRuleNext(t *testing.T) {
+  runs := []struct {
+    dtstart, rule string
+    time          string
+    expected      string
+  }{
+    // Last Friday of the month.
+    {"Sun Jul 1 09:00 2012", "FREQ=MONTHLY;BYDAY=-1FR", "Mon Jul 9 14:45 2012", "Fri Jul 27 09:00 2012"},
+    {"Sun Jul 1 09:00 2012", "FREQ=MONTHLY;BYDAY=-1FR", "Fri Jul 27 09:00 2012", "Fri Aug 31 09:00 2012"},
+
+    // Second Tuesday of the month.
+    {"Sun Jul 1 09:00 2012", "RRULE:FREQ=MONTHLY;BYDAY=2TU", "Mon Jul 9 14:45 2012", "Tue Jul 10 09:00 2012"},
+    {"Sun Jul 1 09:00 2012", "RRULE:FREQ=MONTHLY;BYDAY=2TU", "Tue Jul 10 09:00 2012", "Tue Aug 14 09:00 2012"},
+
+    // Every third week.
+    {"Mon Jul 2 10:00 2012", "FREQ=WEEKLY;INTERVAL=3;BYDAY=MO", "Sun Jul 1 00:00 2012", "Mon Jul 2 10:00 2012"},
+    {"Mon Jul 2 10:00 2012", "FREQ=WEEKLY;INTERVAL=3;BYDAY=MO", "Mon Jul 2 10:00 2012", "Mon Jul 23 10:00 2012"},
+    {"Mon Jul 2 10:00 2012", "FREQ=WEEKLY;INTERVAL=3", "Mon Jul 23 10:00 2012", "Mon Aug 13 10:00 2012"},
+    {"Mon Jul 2 10:00 2012", "FREQ=WEEKLY;BYDAY=MO,FR;BYHOUR=8,17", "Mon Jul 2 10:00 2012", "Mon Jul 2 17:00 2012"},
+    {"Mon Jul 2 10:00 2012", "FREQ=WEEKLY;BYDAY=MO,FR;BYHOUR=8,17", "Mon Jul 2 17:00 2012", "Fri Jul 6 08:00 2012"},
+
+    // Daily, with a count.
+    {"Mon Jul 9 08:00 2012", "FREQ=DAILY;COUNT=3", "Mon Jul 9 07:00 2012", "Mon Jul 9 08:00 2012"},
+    {"Mon Jul 9 08:00 2012", "FREQ=DAILY;COUNT=3", "Tue Jul 10 08:00 2012", "Wed Jul 11 08:00 2012"},
+    {"Mon Jul 9 08:00 2012", "FREQ=DAILY;COUNT=3", "Wed Jul 11 08:00 2012", ""},
+
+    // Every other hour at quarter past.
+    {"Mon Jul 9 09:00 2012", "FREQ=HOURLY;INTERVAL=2;BYMINUTE=15", "Mon Jul 9 09:00 2012", "Mon Jul 9 09:15 2012"},
+    {"Mon Jul 9 09:00 2012", "FREQ=HOURLY;INTERVAL=2;BYMINUTE=15", "Mon Jul 9 09:15 2012", "Mon Jul 9 11:15 2012"},
+    {"Mon Jul 9 09:00 2012", "FREQ=MINUTELY;INTERVAL=20", "Mon Jul 9 23:50 2012", "Tue Jul 10 00:00 2012"},
+
+    // Leap days.
+    {"Wed Feb 29 00:00 2012", "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=29", "Wed Feb 29 00:00 2012", "Mon Feb 29 00:00 2016"},
+    {"Wed Feb 29 00:00 2012", "FREQ=YEARLY", "Wed Feb 29 00:00 2012", "Mon Feb 29 00:00 2016"},
+
+    // First Monday of the year.
+    {"Mon Jan 2 06:00 2012", "FREQ=YEARLY;BYDAY=1MO", "Mon Jan 2 06:00 2012", "Mon Jan 7 06:00 2013"},
+
+    // Last day of the month, until October.
+    {"Sun Jul 1 00:00 2012", "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20121001T000000Z", "Mon Jul 9 14:45 2012", "Tue Jul 31 00:00 2012"},
+    {"Sun Jul 1 00:00 2012", "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20121001T000000Z", "Fri Aug 31 00:00 2012", "Sun Sep 30 00:00 2012"},
+    {"Sun Jul 1 00:00 2012", "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20121001T000000Z", "Sun Sep 30 00:00 2012", ""},
+    {"Sun Jul 1 00:00 2012", "FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20120930", "Fri Aug 31 00:00 2012", "Sun Sep 30 00:00 2012"},
+  }
+
+  for _, c := range runs {
+    sched, err := ParseRRule(c.rule, getTime(c.dtstart))
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    actual := sched.Next(getTime(c.time))
+    expected := getTime(c.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s, \"%s\": (expected) %v != %v (actual)", c.time, c.rule, expected, actual)
+    }
+  }
+}
+
+func TestRRuleErrors(t *testing.T) {
+  invalidRules := []string{
+    "",
+    "INTERVAL=2",
+    "FREQ=FORTNIGHTLY",
+    "FREQ=DAILY;INTERVAL=0",
+    "FREQ=DAILY;COUNT=2;UNTIL=20121001T000000Z",
+    "FREQ=MONTHLY;BYMONTHDAY=32",
+    "FREQ=MONTHLY;BYDAY=0FR",
+    "FREQ=MONTHLY;BYDAY=XX",
+    "FREQ=MONTHLY;BYSETPOS=-1",
+    "FREQ=DAILY;BYHOUR",
+  }
+  for _, rule := range invalidRules {
+    if _, err := ParseRRule(rule, time.Now()); err == nil {
+      t.Error("expected an error parsing: ", rule)
+    }
+  }
+}
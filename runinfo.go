@@ -0,0 +1,75 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements the description of a run carried by its context.
+
+package cron
+
+import (
+  "context"
+  "time"
+)
+
+// RunInfo describes a run of an entry's job.
+type RunInfo struct {
+  // EntryID and Name are the ID and name of the entry.
+  EntryID, Name string
+
+  // Scheduled is the time the run was scheduled for, or the time it was
+  // started by RunNow.
+  Scheduled time.Time
+
+  // Labels are those of the entry, e.g. to tag the run's logs and traces
+  // with; they must not be modified.
+  Labels map[string]string
+}
+
+// runInfoKey is the context key of the RunInfo of a run.
+type runInfoKey struct{}
+
+// withRunInfo returns a copy of ctx carrying the run's RunInfo.
+func withRunInfo(ctx context.Context, info RunInfo) context.Context {
+  return context.WithValue(ctx, runInfoKey{}, info)
+}
+
+// RunInfoFrom returns the RunInfo of the run whose ContextJob or ErrorJob was
+// passed ctx, e.g. so that a job wrapper can log or trace it.
+func RunInfoFrom(ctx context.Context) (RunInfo, bool) {
+  info, ok := ctx.Value(runInfoKey{}).(RunInfo)
+  return info, ok
+}
+
+// valuesContext is the context of a run of an entry added WithContext: it
+// looks up the values it does not carry in the entry's context.
+type valuesContext struct {
+  context.Context
+  values context.Context
+}
+
+// Value implements context.Context.
+func (c valuesContext) Value(key interface{}) interface{} {
+  if v := c.Context.Value(key); v != nil {
+    return v
+  }
+  return c.values.Value(key)
+}
+
+// withValues returns a copy of ctx that also carries the values of the
+// entry's context, if it has one, but not its deadline or cancellation.
+func withValues(ctx, values context.Context) context.Context {
+  if values == nil {
+    return ctx
+  }
+  return valuesContext{ctx, values}
+}
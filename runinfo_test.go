@@ -0,0 +1,92 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the description of runs carried by their
+// contexts.
+
+package cron
+
+import (
+  "context"
+  "reflect"
+  "testing"
+  "time"
+)
+
+func TestRunInfoFrom(t *testing.T) {
+  if _, ok := RunInfoFrom(context.Background()); ok {
+    t.Error("expected no RunInfo outside of a run")
+  }
+
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  infos := make(chan RunInfo, 1)
+  id, _ := cron.AddJob("@hourly", ContextFuncJob(func(ctx context.Context) {
+    info, _ := RunInfoFrom(ctx)
+    infos <- info
+  }), WithName("backup"), WithLabels(map[string]string{"team": "infra"}))
+  cron.Start()
+  defer cron.Stop()
+
+  clock.Advance(time.Hour)
+  select {
+  case info := <-infos:
+    expected := RunInfo{EntryID: id, Name: "backup",
+      Scheduled: start.Add(time.Hour),
+      Labels:    map[string]string{"team": "infra"}}
+    if !reflect.DeepEqual(info, expected) {
+      t.Errorf("(expected) %+v != %+v (actual)", expected, info)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatal("expected a run")
+  }
+}
+
+// tenantKey is the context key of the tenant of TestWithContext's entry.
+type tenantKey struct{}
+
+func TestWithContext(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  base, cancel := context.WithCancel(context.WithValue(context.Background(),
+    tenantKey{}, "acme"))
+  // The runs are not canceled with the entry's context.
+  cancel()
+
+  type run struct {
+    tenant interface{}
+    info   bool
+    err    error
+  }
+  runs := make(chan run, 1)
+  cron.AddJob("@hourly", ContextFuncJob(func(ctx context.Context) {
+    _, ok := RunInfoFrom(ctx)
+    runs <- run{ctx.Value(tenantKey{}), ok, ctx.Err()}
+  }), WithContext(base))
+  cron.Start()
+  defer cron.Stop()
+
+  clock.Advance(time.Hour)
+  select {
+  case r := <-runs:
+    if r.tenant != "acme" || !r.info || r.err != nil {
+      t.Errorf("expected the entry's values, the RunInfo and no error, got "+
+        "%+v", r)
+    }
+  case <-time.After(cOneSecond):
+    t.Fatal("expected a run")
+  }
+}
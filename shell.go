@@ -0,0 +1,184 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements jobs that run commands.
+
+package cron
+
+import (
+  "context"
+  "encoding/json"
+  "fmt"
+  "os"
+  "os/exec"
+  "strings"
+  "sync"
+  "time"
+)
+
+// DefaultShell is the shell that runs the command lines of ShellJobs.
+const DefaultShell = "/bin/sh"
+
+// ShellJob is an ErrorJob that runs a command, like the lines of a crontab.
+// The output of the command, its standard output and error interleaved, is
+// recorded with SetOutput, and runs fail if the command exits with a non-zero
+// status.
+type ShellJob struct {
+  // Args are the command and its arguments, run without a shell.
+  Args []string
+
+  // Line, if Args are empty, is a command line run by Shell, or DefaultShell
+  // if it is empty, with -c.
+  Line  string
+  Shell string
+
+  // Env are "key=value" variables added to the environment of the Cron's
+  // process, and Dir the working directory of the command, or that of the
+  // process if it is empty.
+  Env []string
+  Dir string
+
+  // Timeout, if positive, kills the command once it has run for that long.
+  // The command is also killed when the context of the run is canceled.
+  Timeout time.Duration
+}
+
+// shellJobJSON is the JSON form of a ShellJob.
+type shellJobJSON struct {
+  Args    []string `json:"args,omitempty"`
+  Line    string   `json:"line,omitempty"`
+  Shell   string   `json:"shell,omitempty"`
+  Env     []string `json:"env,omitempty"`
+  Dir     string   `json:"dir,omitempty"`
+  Timeout string   `json:"timeout,omitempty"`
+}
+
+// NewShellJob is a JobFactory of ShellJobs, to be registered with RegisterJob,
+// e.g. as "shell", so that crontab files and stored entries run commands.  A
+// payload that is a JSON object, with the fields args, line, shell, env, dir
+// and timeout, e.g. {"args": ["backup", "--full"], "timeout": "1h"}, is
+// decoded as a ShellJob; other payloads are command lines.
+func NewShellJob(payload []byte) (Job, error) {
+  trimmed := strings.TrimSpace(string(payload))
+  if !strings.HasPrefix(trimmed, "{") {
+    if trimmed == "" {
+      return nil, fmt.Errorf("no command")
+    }
+    return &ShellJob{Line: trimmed}, nil
+  }
+
+  var v shellJobJSON
+  if err := json.Unmarshal(payload, &v); err != nil {
+    return nil, err
+  }
+  if len(v.Args) == 0 && v.Line == "" {
+    return nil, fmt.Errorf("no command")
+  }
+  job := &ShellJob{Args: v.Args, Line: v.Line, Shell: v.Shell, Env: v.Env,
+    Dir: v.Dir}
+  if v.Timeout != "" {
+    timeout, err := time.ParseDuration(v.Timeout)
+    if err != nil {
+      return nil, err
+    }
+    job.Timeout = timeout
+  }
+  return job, nil
+}
+
+// Run runs the command with a background context.
+func (j *ShellJob) Run() { j.RunContext(context.Background()) }
+
+// RunContext runs the command, logging its failure.
+func (j *ShellJob) RunContext(ctx context.Context) {
+  if err := j.RunError(ctx); err != nil {
+    loggerFrom(ctx).Warningf("job failed: %v", err)
+  }
+}
+
+// RunError runs the command, returning an error if it could not be started,
+// was killed, or exited with a non-zero status.
+func (j *ShellJob) RunError(ctx context.Context) error {
+  if j.Timeout > 0 {
+    var cancel context.CancelFunc
+    ctx, cancel = context.WithTimeout(ctx, j.Timeout)
+    defer cancel()
+  }
+
+  var cmd *exec.Cmd
+  switch {
+  case len(j.Args) > 0:
+    cmd = exec.CommandContext(ctx, j.Args[0], j.Args[1:]...)
+  case j.Line != "":
+    shell := j.Shell
+    if shell == "" {
+      shell = DefaultShell
+    }
+    cmd = exec.CommandContext(ctx, shell, "-c", j.Line)
+  default:
+    return fmt.Errorf("no command")
+  }
+  if len(j.Env) > 0 {
+    cmd.Env = append(os.Environ(), j.Env...)
+  }
+  cmd.Dir = j.Dir
+  output := &tailBuffer{}
+  cmd.Stdout, cmd.Stderr = output, output
+  // Children of the command that keep its output open do not block the run
+  // for long after it exits.
+  cmd.WaitDelay = time.Second
+
+  err := cmd.Run()
+  SetOutput(ctx, output.String())
+  if err != nil {
+    if j.Timeout > 0 && ctx.Err() == context.DeadlineExceeded {
+      return fmt.Errorf("command timed out after %v", j.Timeout)
+    }
+    return fmt.Errorf("command failed: %s", err)
+  }
+  return nil
+}
+
+// String returns the command.
+func (j *ShellJob) String() string {
+  if len(j.Args) > 0 {
+    return strings.Join(j.Args, " ")
+  }
+  return j.Line
+}
+
+// tailBuffer is an io.Writer that keeps the last MaxOutput bytes written to
+// it.  It is safe for concurrent use.
+type tailBuffer struct {
+  mu  sync.Mutex
+  buf []byte
+}
+
+// Write implements io.Writer.
+func (b *tailBuffer) Write(p []byte) (int, error) {
+  b.mu.Lock()
+  defer b.mu.Unlock()
+  b.buf = append(b.buf, p...)
+  if len(b.buf) > MaxOutput {
+    b.buf = append(b.buf[:0], b.buf[len(b.buf)-MaxOutput:]...)
+  }
+  return len(p), nil
+}
+
+// String returns the bytes kept.
+func (b *tailBuffer) String() string {
+  b.mu.Lock()
+  defer b.mu.Unlock()
+  return string(b.buf)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for jobs that run commands.
+
+package cron
+
+import (
+  "context"
+  "strings"
+  "testing"
+  "time"
+)
+
+func TestShellJob(t *testing.T) {
+  dir := t.TempDir()
+  tests := []struct {
+    name   string
+    job    *ShellJob
+    output string
+    err    string
+  }{
+    {"args", &ShellJob{Args: []string{"echo", "hello", "world"}},
+      "hello world\n", ""},
+    {"line", &ShellJob{Line: "echo out; echo err >&2"}, "out\nerr\n", ""},
+    {"env", &ShellJob{Line: "echo $GREETING", Env: []string{"GREETING=hi"}},
+      "hi\n", ""},
+    {"dir", &ShellJob{Args: []string{"pwd"}, Dir: dir}, dir + "\n", ""},
+    {"status", &ShellJob{Line: "echo oops; exit 3"}, "oops\n",
+      "command failed: exit status 3"},
+    {"timeout", &ShellJob{Line: "sleep 5", Timeout: 50 * time.Millisecond},
+      "", "command timed out after 50ms"},
+    {"missing", &ShellJob{Args: []string{"/no/such/command"}}, "",
+      "command failed"},
+    {"empty", &ShellJob{}, "", "no command"},
+  }
+
+  for _, test := range tests {
+    ctx, output := withRunOutput(context.Background())
+    err := test.job.RunError(ctx)
+    if test.err == "" && err != nil || test.err != "" && (err == nil ||
+      !strings.HasPrefix(err.Error(), test.err)) {
+      t.Errorf("%s: expected error %q, got %v", test.name, test.err, err)
+    }
+    if actual := output.get(); actual != test.output {
+      t.Errorf("%s: expected output %q, got %q", test.name, test.output,
+        actual)
+    }
+  }
+}
+
+func TestNewShellJob(t *testing.T) {
+  tests := []struct {
+    payload  string
+    expected *ShellJob
+    err      bool
+  }{
+    {"backup.sh --full", &ShellJob{Line: "backup.sh --full"}, false},
+    {`{"args": ["backup", "--full"], "env": ["A=1"], "dir": "/tmp",
+      "timeout": "1h"}`, &ShellJob{Args: []string{"backup", "--full"},
+      Env: []string{"A=1"}, Dir: "/tmp", Timeout: time.Hour}, false},
+    {`{"line": "make", "shell": "/bin/bash"}`, &ShellJob{Line: "make",
+      Shell: "/bin/bash"}, false},
+    {"", nil, true},
+    {"{}", nil, true},
+    {`{"line": "make", "timeout": "soon"}`, nil, true},
+    {`{"line": `, nil, true},
+  }
+  for _, test := range tests {
+    job, err := NewShellJob([]byte(test.payload))
+    if (err != nil) != test.err {
+      t.Errorf("%s: unexpected error %v", test.payload, err)
+      continue
+    }
+    if !test.err && job.(*ShellJob).String() != test.expected.String() ||
+      !test.err && job.(*ShellJob).Timeout != test.expected.Timeout {
+      t.Errorf("%s: (expected) %+v != %+v (actual)", test.payload,
+        test.expected, job)
+    }
+  }
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements simulating the activations of entries.
+
+package cron
+
+import (
+  "sort"
+  "time"
+)
+
+// Activation is an activation of an entry, as listed by Simulate.
+type Activation struct {
+  // EntryID and Name are the ID and name of the entry.
+  EntryID, Name string
+
+  // Scheduled is the time the activation runs at.
+  Scheduled time.Time
+}
+
+// Simulate returns the activations of the Cron's entries from from to to,
+// inclusive, ordered by time and, at the same time, by priority, without
+// running any job, e.g. to check a set of schedules before enabling it.  It
+// follows the entries' schedules, validity windows, holidays, blackouts and
+// remaining runs, but not their jitter, nor the Cron's limits, the entries'
+// concurrency policies or failures of their dependencies, which only happen
+// when jobs run.  It returns no activations if to is zero or before from.
+func (c *Cron) Simulate(from, to time.Time) []Activation {
+  if !validWindow(from, to) {
+    return nil
+  }
+  c.mu.RLock()
+  defer c.mu.RUnlock()
+  var activations []Activation
+  priorities := map[string]int{}
+  for _, e := range c.entries {
+    priorities[e.ID] = e.Priority
+    for _, t := range c.simulate(e, from, to, -1) {
+      activations = append(activations, Activation{e.ID, e.Name, t})
+    }
+  }
+  sort.Slice(activations, func(i, j int) bool {
+    a, b := activations[i], activations[j]
+    if !a.Scheduled.Equal(b.Scheduled) {
+      return a.Scheduled.Before(b.Scheduled)
+    }
+    if priorities[a.EntryID] != priorities[b.EntryID] {
+      return priorities[a.EntryID] > priorities[b.EntryID]
+    }
+    return a.EntryID < b.EntryID
+  })
+  return activations
+}
+
+// validWindow reports whether the activations from from to to can be listed:
+// to must be set, and not before from.
+func validWindow(from, to time.Time) bool {
+  return !to.IsZero() && !to.Before(from)
+}
+
+// simulate returns the times of the entry's activations from from to to, or
+// without end if to is zero, up to n of them unless n is negative.  c.mu must
+// be held.
+func (c *Cron) simulate(e *Entry, from, to time.Time, n int) []time.Time {
+  if e.MaxRuns > 0 && (n < 0 || e.MaxRuns-e.Runs < n) {
+    n = e.MaxRuns - e.Runs
+  }
+  var times []time.Time
+  t := from.Add(-time.Nanosecond)
+  for n < 0 || len(times) < n {
+    next := e.next(t)
+    if next.IsZero() || !to.IsZero() && next.After(to) {
+      break
+    }
+    t = next
+    if policy, end, ok := c.blackedOut(e, next); ok {
+      if policy == BlackoutSkip {
+        continue
+      }
+      // The deferred run is the activation the next ones follow.
+      if !to.IsZero() && end.After(to) {
+        break
+      }
+      next, t = end, end
+    }
+    times = append(times, next)
+  }
+  return times
+}
+
+// NextRuns returns the times of the next n activations of the entry with the
+// given ID, e.g. to show its upcoming runs, following its schedule, validity
+// window, holidays, blackouts and remaining runs as Simulate does.  The times
+// are not delayed by the entry's jitter.  It returns no times while the Cron
+// is suspended, since the entry does not run until the Cron is resumed, and
+// an error if the Cron has no entry with the ID.
+func (c *Cron) NextRuns(id string, n int) ([]time.Time, error) {
+  c.mu.RLock()
+  defer c.mu.RUnlock()
+  e := c.ids[id]
+  if e == nil {
+    return nil, entryRef{id: id}.notFound()
+  }
+  if e.Next.IsZero() || n <= 0 || c.suspended {
+    return nil, nil
+  }
+  // The next activation may have been deferred already, so it is not
+  // recomputed from the schedule.
+  next := e.activation()
+  var times []time.Time
+  if policy, end, ok := c.blackedOut(e, next); !ok {
+    times = append(times, next)
+  } else if policy == BlackoutDefer {
+    next = end
+    times = append(times, next)
+  }
+  times = append(times, c.simulate(e, next.Add(time.Nanosecond), time.Time{},
+    n-len(times))...)
+  if e.MaxRuns > 0 && len(times) > e.MaxRuns-e.Runs {
+    times = times[:e.MaxRuns-e.Runs]
+  }
+  return times, nil
+}
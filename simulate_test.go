@@ -0,0 +1,150 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for simulating the activations of entries.
+
+package cron
+
+import (
+  "reflect"
+  "testing"
+  "time"
+)
+
+func TestSimulate(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  ran := make(chan struct{}, 10)
+  job := func() { ran <- struct{}{} }
+
+  hourly, _ := cron.AddFunc("@hourly", job, WithName("hourly"),
+    BlackoutDuring(Between(start.Add(90*time.Minute),
+      start.Add(150*time.Minute)), BlackoutSkip))
+  deferred, _ := cron.AddFunc("0 30 * * * *", job, WithPriority(1),
+    BlackoutDuring(Between(start.Add(90*time.Minute),
+      start.Add(2*time.Hour)), BlackoutDefer))
+  limited, _ := cron.AddFunc("0 0 * * * *", job, WithMaxRuns(2),
+    WithPriority(-1), WithNotBefore(start.Add(2*time.Hour)))
+
+  activations := cron.Simulate(start.Add(time.Hour), start.Add(4*time.Hour))
+  at := func(id string, d time.Duration) Activation {
+    a := Activation{EntryID: id, Scheduled: start.Add(d)}
+    if id == hourly {
+      a.Name = "hourly"
+    }
+    return a
+  }
+  expected := []Activation{
+    at(hourly, time.Hour),
+    // The activation at 01:30 is deferred, and the higher priority entry
+    // comes first.
+    at(deferred, 2*time.Hour),
+    at(limited, 2*time.Hour),
+    at(deferred, 150*time.Minute),
+    at(hourly, 3*time.Hour),
+    at(limited, 3*time.Hour),
+    at(deferred, 210*time.Minute),
+    at(hourly, 4*time.Hour),
+  }
+  if !reflect.DeepEqual(activations, expected) {
+    t.Errorf("(expected) %+v != %+v (actual)", expected, activations)
+  }
+
+  // Nothing runs.
+  cron.Start()
+  defer cron.Stop()
+  select {
+  case <-ran:
+    t.Error("expected no job to run")
+  case <-time.After(10 * time.Millisecond):
+  }
+  if activations := cron.Simulate(start, start.Add(-time.Hour)); len(
+    activations) != 0 {
+    t.Errorf("expected no activations in an empty window, got %+v",
+      activations)
+  }
+  if activations := cron.Simulate(start, time.Time{}); len(
+    activations) != 0 {
+    t.Errorf("expected no activations without an end, got %+v",
+      activations)
+  }
+}
+
+func TestNextRuns(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  skipped, _ := cron.AddFunc("0 30 * * * *", func() {},
+    BlackoutDuring(Between(start.Add(time.Hour), start.Add(2*time.Hour)),
+      BlackoutSkip))
+  limited, _ := cron.AddFunc("@hourly", func() {}, WithMaxRuns(2))
+  expiring, _ := cron.AddFunc("@hourly", func() {},
+    WithNotAfter(start.Add(2*time.Hour)))
+  deferred, _ := cron.AddFunc("0 30 * * * *", func() {},
+    BlackoutDuring(Between(start.Add(30*time.Minute), start.Add(time.Hour)),
+      BlackoutDefer))
+  cron.Start()
+  defer cron.Stop()
+
+  // The run at 00:30 is deferred to 01:00.
+  clock.Advance(30 * time.Minute)
+  waitFor(t, func() bool {
+    e, _ := cron.Entry(deferred)
+    return e.Next.Equal(start.Add(time.Hour))
+  })
+
+  at := func(ds ...time.Duration) []time.Time {
+    var times []time.Time
+    for _, d := range ds {
+      times = append(times, start.Add(d))
+    }
+    return times
+  }
+  tests := []struct {
+    id       string
+    n        int
+    expected []time.Time
+  }{
+    {skipped, 3, at(150*time.Minute, 210*time.Minute, 270*time.Minute)},
+    {limited, 5, at(time.Hour, 2*time.Hour)},
+    {expiring, 5, at(time.Hour, 2*time.Hour)},
+    {deferred, 3, at(time.Hour, 90*time.Minute, 150*time.Minute)},
+    {deferred, 0, nil},
+  }
+  for _, test := range tests {
+    times, err := cron.NextRuns(test.id, test.n)
+    if err != nil {
+      t.Fatal(err)
+    }
+    if !reflect.DeepEqual(times, test.expected) {
+      t.Errorf("%s: (expected) %v != %v (actual)", test.id, test.expected,
+        times)
+    }
+  }
+
+  if _, err := cron.NextRuns("missing", 1); err == nil {
+    t.Error("expected an error for a missing entry")
+  }
+
+  // A suspended Cron runs nothing until it is resumed.
+  cron.Suspend()
+  if times, err := cron.NextRuns(limited, 5); err != nil || times != nil {
+    t.Errorf("expected no runs while suspended, got %v, %v", times, err)
+  }
+  cron.Resume()
+  if times, _ := cron.NextRuns(limited, 5); len(times) != 2 {
+    t.Errorf("expected 2 runs after resuming, got %v", times)
+  }
+}
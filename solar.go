@@ -0,0 +1,148 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements solar schedule.
+
+package cron
+
+import (
+  "fmt"
+  "math"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// SolarEvent is the position of the sun a SolarSchedule activates relative to.
+type SolarEvent int
+
+const (
+  // Sunrise is when the upper edge of the sun rises above the horizon.
+  Sunrise SolarEvent = iota
+  // Sunset is when the upper edge of the sun sets below the horizon.
+  Sunset
+)
+
+// SolarSchedule represents a daily duty cycle relative to sunrise or sunset at
+// a position, e.g. "30 minutes before sunset".  Days on which the sun does not
+// rise or set (in polar regions) are skipped.  Activations are accurate to
+// about a minute.
+type SolarSchedule struct {
+  Event SolarEvent
+
+  // Latitude and Longitude of the position in degrees, north and east
+  // positive.
+  Latitude, Longitude float64
+
+  // Offset is added to the time of the event, e.g. -30 * time.Minute for half
+  // an hour before.
+  Offset time.Duration
+}
+
+// Next returns the next activation time, later than the given time.  Days are
+// those of the given time's location.
+func (s SolarSchedule) Next(t time.Time) time.Time {
+  // Start a day early, since the offset may carry an event into the next day.
+  day := time.Date(t.Year(), t.Month(), t.Day()-1, 0, 0, 0, 0, t.Location())
+
+  // Polar nights last at most half a year.
+  for i := 0; i < 366; i++ {
+    event, ok := s.eventOn(day.AddDate(0, 0, i))
+    if !ok {
+      continue
+    }
+    next := event.Add(s.Offset).Truncate(time.Second).In(t.Location())
+    if next.After(t) {
+      return next
+    }
+  }
+  return time.Time{}
+}
+
+// eventOn returns the time of the schedule's event on the given day, using the
+// sunrise equation, or false if the sun does not rise or set that day.
+func (s SolarSchedule) eventOn(day time.Time) (time.Time, bool) {
+  const (
+    j2000      = 2451545.0
+    unixEpoch  = 2440587.5
+    secsPerDay = 24 * 60 * 60
+    rad        = math.Pi / 180
+  )
+
+  // The Julian day number of the day, counted from J2000.
+  noon := time.Date(day.Year(), day.Month(), day.Day(), 12, 0, 0, 0, time.UTC)
+  n := math.Round(float64(noon.Unix())/secsPerDay + unixEpoch - j2000)
+
+  // Mean solar noon, solar mean anomaly, equation of the center and ecliptic
+  // longitude.
+  jStar := n - s.Longitude/360
+  m := math.Mod(357.5291+0.98560028*jStar, 360)
+  c := 1.9148*math.Sin(m*rad) + 0.02*math.Sin(2*m*rad) +
+    0.0003*math.Sin(3*m*rad)
+  lambda := math.Mod(m+c+180+102.9372, 360)
+
+  // Solar transit, declination of the sun and hour angle.
+  transit := j2000 + jStar + 0.0053*math.Sin(m*rad) -
+    0.0069*math.Sin(2*lambda*rad)
+  sinDelta := math.Sin(lambda*rad) * math.Sin(23.4397*rad)
+  cosDelta := math.Cos(math.Asin(sinDelta))
+  cosOmega := (math.Sin(-0.833*rad) - math.Sin(s.Latitude*rad)*sinDelta) /
+    (math.Cos(s.Latitude*rad) * cosDelta)
+  if cosOmega < -1 || cosOmega > 1 {
+    return time.Time{}, false
+  }
+  omega := math.Acos(cosOmega) / rad
+
+  j := transit - omega/360
+  if s.Event == Sunset {
+    j = transit + omega/360
+  }
+  secs := (j - unixEpoch) * secsPerDay
+  return time.Unix(int64(secs), 0), true
+}
+
+// parseSolar parses the "@sunrise" and "@sunset" descriptors:
+//   ("@sunrise" | "@sunset") latitude "," longitude [duration]
+func parseSolar(spec string) (Schedule, error) {
+  fields := strings.Fields(spec)
+  if len(fields) != 2 && len(fields) != 3 {
+    return nil, fmt.Errorf("expected position and optional offset: %s", spec)
+  }
+
+  s := SolarSchedule{Event: Sunrise}
+  if fields[0] == "@sunset" {
+    s.Event = Sunset
+  }
+
+  position := strings.Split(fields[1], ",")
+  if len(position) != 2 {
+    return nil, fmt.Errorf("expected latitude,longitude: %s", spec)
+  }
+  var err error
+  if s.Latitude, err = strconv.ParseFloat(position[0], 64); err != nil ||
+    math.Abs(s.Latitude) > 90 {
+    return nil, fmt.Errorf("invalid latitude %s: %s", position[0], spec)
+  }
+  if s.Longitude, err = strconv.ParseFloat(position[1], 64); err != nil ||
+    math.Abs(s.Longitude) > 180 {
+    return nil, fmt.Errorf("invalid longitude %s: %s", position[1], spec)
+  }
+
+  if len(fields) == 3 {
+    if s.Offset, err = time.ParseDuration(fields[2]); err != nil {
+      return nil, fmt.Errorf("failed to parse offset %s: %s", spec, err)
+    }
+  }
+  return s, nil
+}
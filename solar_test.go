@@ -0,0 +1,86 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements solar schedule test.
+
+package cron
+
+import (
+  "testing"
+  "time"
+)
+
+func TestSolarNext(t *testing.T) {
+  runs := []struct {
+    time, spec string
+    expected   string
+  }{
+    // San Francisco, in PDT.
+    {"2012-07-09T00:00:00-0700", "@sunrise 37.7749,-122.4194", "2012-07-09T05:57:00-0700"},
+    {"2012-07-09T06:00:00-0700", "@sunrise 37.7749,-122.4194", "2012-07-10T05:58:00-0700"},
+    {"2012-07-09T00:00:00-0700", "@sunset 37.7749,-122.4194", "2012-07-09T20:34:00-0700"},
+    {"2012-07-09T00:00:00-0700", "@sunset 37.7749,-122.4194 -30m", "2012-07-09T20:04:00-0700"},
+    {"2012-07-09T20:10:00-0700", "@sunset 37.7749,-122.4194 -30m", "2012-07-10T20:04:00-0700"},
+    {"2012-07-09T23:00:00-0700", "@sunset 37.7749,-122.4194 4h", "2012-07-10T00:34:00-0700"},
+
+    // London, on the solstice.
+    {"2012-06-21T00:00:00+0100", "@sunrise 51.5074,-0.1278", "2012-06-21T04:43:00+0100"},
+    {"2012-06-21T00:00:00+0100", "@sunset 51.5074,-0.1278", "2012-06-21T21:21:00+0100"},
+  }
+
+  for _, c := range runs {
+    sched, err := Parse(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    actual := sched.Next(getTime(c.time))
+    expected := getTime(c.expected)
+    if diff := actual.Sub(expected); diff < -2*time.Minute || diff > 2*time.Minute {
+      t.Errorf("%s, \"%s\": (expected) %v != %v (actual)", c.time, c.spec, expected, actual)
+    }
+  }
+}
+
+// TestSolarPolarNight checks that days without a sunrise are skipped.
+func TestSolarPolarNight(t *testing.T) {
+  // Tromso, where the sun does not rise from late November to mid January.
+  sched, err := Parse("@sunrise 69.6492,18.9553")
+  if err != nil {
+    t.Fatal(err)
+  }
+  actual := sched.Next(getTime("2012-12-01T00:00:00+0100"))
+  if actual.Before(getTime("2013-01-10T00:00:00+0100")) ||
+    actual.After(getTime("2013-01-20T00:00:00+0100")) {
+    t.Errorf("(expected) mid January != %v (actual)", actual)
+  }
+}
+
+func TestSolarErrors(t *testing.T) {
+  invalidSpecs := []string{
+    "@sunrise",
+    "@sunrise 37.7749",
+    "@sunrise 91,0",
+    "@sunset 0,181",
+    "@sunset x,y",
+    "@sunset 0,0 soon",
+    "@sunset 0,0 1h 2h",
+  }
+  for _, spec := range invalidSpecs {
+    _, err := Parse(spec)
+    if err == nil {
+      t.Error("expected an error parsing: ", spec)
+    }
+  }
+}
@@ -22,20 +22,62 @@ import "time"
 // traditional crontab specification. It is computed initially and stored as bit sets.
 type SpecSchedule struct {
   Second, Minute, Hour, Dom, Month, Dow uint64
+
+  // BusinessDay has bit n set for the nth business day of the month, and
+  // lastBusinessDayBit set for the last one.  Business days are matched as
+  // part of the day of month.
+  BusinessDay uint64
+
+  // BusinessDays defines which days are business days.  If nil, Monday through
+  // Friday are.
+  BusinessDays BusinessDays
+
+  // Calendar decomposes times into the months and days that Month and Dom are
+  // matched against.  If nil, the Gregorian calendar is used.
+  Calendar Calendar
+
+  // Location is the time zone the schedule is evaluated in.  If nil, the
+  // location of the time passed to Next is used.
+  Location *time.Location
+
+  // DSTGap and DSTOverlap decide what happens to activations at wall clock
+  // times that daylight saving time transitions skip or repeat.  By default
+  // skipped times do not run, and repeated times run twice.
+  DSTGap     DSTGapPolicy
+  DSTOverlap DSTOverlapPolicy
+}
+
+// BusinessDays reports which days are business days.
+type BusinessDays interface {
+  IsBusinessDay(t time.Time) bool
 }
 
+// BusinessDayFunc is a wrapper that turns a func(time.Time) bool into a
+// BusinessDays.
+type BusinessDayFunc func(t time.Time) bool
+
+// IsBusinessDay invokes the function.
+func (f BusinessDayFunc) IsBusinessDay(t time.Time) bool { return f(t) }
+
+// Weekdays is the default BusinessDays, Monday through Friday.
+var Weekdays BusinessDays = BusinessDayFunc(func(t time.Time) bool {
+  return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+})
+
 // bounds provides a range of acceptable values (plus a map of name to value).
+// Ranges of fields that wrap may run past max back to min, e.g. hours 22-2.
 type bounds struct {
   min, max uint
   names    map[string]uint
+  wraps    bool
 }
 
 // The bounds for each field.
 var (
-  seconds = bounds{0, 59, nil}
-  minutes = bounds{0, 59, nil}
-  hours   = bounds{0, 23, nil}
-  dom     = bounds{1, 31, nil}
+  seconds = bounds{0, 59, nil, true}
+  minutes = bounds{0, 59, nil, true}
+  hours   = bounds{0, 23, nil, true}
+  dom     = bounds{1, 31, nil, false}
   months  = bounds{1, 12, map[string]uint{
     "jan": 1,
     "feb": 2,
@@ -49,7 +91,7 @@ var (
     "oct": 10,
     "nov": 11,
     "dec": 12,
-  }}
+  }, true}
   dow = bounds{0, 6, map[string]uint{
     "sun": 0,
     "mon": 1,
@@ -58,17 +100,60 @@ var (
     "thu": 4,
     "fri": 5,
     "sat": 6,
-  }}
+  }, true}
+  isoDow = bounds{1, 7, map[string]uint{
+    "mon": 1,
+    "tue": 2,
+    "wed": 3,
+    "thu": 4,
+    "fri": 5,
+    "sat": 6,
+    "sun": 7,
+  }, true}
 )
 
 const (
   // Set the top bit if a star was included in the expression.
   starBit = 1 << 63
+
+  // Set in Dom for "L", the last day of the month.  The bit lastDomBit<<n is
+  // set for "L-n", n days before the last day of the month.  Day of month
+  // values only use bits 1-31, so offsets 0-30 fit in bits 32-62.
+  lastDomBit = 1 << 32
+
+  // Set in Dom for "LW", the last weekday of the month.  Day of month values
+  // start at 1, so bit 0 is free.
+  lastWeekdayBit = 1 << 0
+
+  // Set in Dow for "nL", the last day n of the week in the month.  The bit
+  // lastDowBit<<n is set for day n, where Sunday is 0.
+  lastDowBit = 1 << 8
+
+  // Set in BusinessDay for "LB", the last business day of the month.
+  lastBusinessDayBit = 1 << 0
 )
 
 // Next returns the next time this schedule is activated, greater than the given
 // time.  If no time can be found to satisfy the schedule, return the zero time.
 func (s *SpecSchedule) Next(t time.Time) time.Time {
+  // Evaluate in the schedule's location, and convert the result back.
+  if s.Location != nil && t.Location() != s.Location {
+    next := s.Next(t.In(s.Location))
+    if next.IsZero() {
+      return next
+    }
+    return next.In(t.Location())
+  }
+  return s.applyDST(t, s.next(t))
+}
+
+// next returns the next time this schedule is activated, greater than the
+// given time, in the given time's location, regardless of its DST policies.
+func (s *SpecSchedule) next(t time.Time) time.Time {
+  if s.Calendar != nil {
+    return s.nextInCalendar(t)
+  }
+
   // General approach:
   // For Month, Day, Hour, Minute, Second:
   // Check if the time value matches.  If yes, continue to the next field.
@@ -163,9 +248,11 @@ WRAP:
 // dayMatches returns true if the schedule's day-of-week and day-of-month
 // restrictions are satisfied by the given time.
 func dayMatches(s *SpecSchedule, t time.Time) bool {
+  _, day := s.calendar().Date(t)
   var (
-    domMatch = 1<<uint(t.Day())&s.Dom > 0
-    dowMatch = 1<<uint(t.Weekday())&s.Dow > 0
+    domMatch = 1<<uint(day)&s.Dom > 0 || lastDomMatches(s, t) ||
+      lastWeekdayMatches(s, t) || businessDayMatches(s, t)
+    dowMatch = 1<<uint(t.Weekday())&s.Dow > 0 || lastDowMatches(s, t)
   )
 
   if s.Dom&starBit > 0 || s.Dow&starBit > 0 {
@@ -173,3 +260,83 @@ func dayMatches(s *SpecSchedule, t time.Time) bool {
   }
   return domMatch || dowMatch
 }
+
+// lastDomMatches returns true if the schedule's "L" or "L-n" day-of-month
+// restrictions are satisfied by the given time.
+func lastDomMatches(s *SpecSchedule, t time.Time) bool {
+  _, day := s.calendar().Date(t)
+  last := s.calendar().DaysInMonth(t)
+  return lastDomBit<<uint(last-day)&s.Dom > 0
+}
+
+// lastWeekdayMatches returns true if the schedule's "LW" day-of-month
+// restriction is satisfied by the given time.
+func lastWeekdayMatches(s *SpecSchedule, t time.Time) bool {
+  if s.Dom&lastWeekdayBit == 0 || !Weekdays.IsBusinessDay(t) {
+    return false
+  }
+  _, day := s.calendar().Date(t)
+  last := s.calendar().DaysInMonth(t)
+  for d := day + 1; d <= last; d++ {
+    if Weekdays.IsBusinessDay(t.AddDate(0, 0, d-day)) {
+      return false
+    }
+  }
+  return true
+}
+
+// lastDowMatches returns true if the schedule's "nL" day-of-week restrictions
+// are satisfied by the given time.
+func lastDowMatches(s *SpecSchedule, t time.Time) bool {
+  if lastDowBit<<uint(t.Weekday())&s.Dow == 0 {
+    return false
+  }
+  _, day := s.calendar().Date(t)
+  return day+7 > s.calendar().DaysInMonth(t)
+}
+
+// businessDays returns the schedule's business days.
+func (s *SpecSchedule) businessDays() BusinessDays {
+  if s.BusinessDays == nil {
+    return Weekdays
+  }
+  return s.BusinessDays
+}
+
+// businessDayMatches returns true if the schedule's business day restrictions
+// are satisfied by the given time.
+func businessDayMatches(s *SpecSchedule, t time.Time) bool {
+  if s.BusinessDay == 0 {
+    return false
+  }
+  days := s.businessDays()
+  if !days.IsBusinessDay(t) {
+    return false
+  }
+
+  // Count the business days of the month up to and including t.
+  _, day := s.calendar().Date(t)
+  last := s.calendar().DaysInMonth(t)
+  first := time.Date(t.Year(), t.Month(), t.Day()-(day-1), 0, 0, 0, 0,
+    t.Location())
+  n := 0
+  for earlier := 0; earlier < day; earlier++ {
+    if days.IsBusinessDay(first.AddDate(0, 0, earlier)) {
+      n++
+    }
+  }
+  if 1<<uint(n)&s.BusinessDay > 0 {
+    return true
+  }
+  if s.BusinessDay&lastBusinessDayBit == 0 {
+    return false
+  }
+
+  // Look for a later business day in the month.
+  for later := day; later < last; later++ {
+    if days.IsBusinessDay(first.AddDate(0, 0, later)) {
+      return false
+    }
+  }
+  return true
+}
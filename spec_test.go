@@ -56,6 +56,12 @@ func TestActivation(t *testing.T) {
     {"Sun Jul 8 01:00 2012", "@weekly", false},
     {"Sun Jul 8 00:00 2012", "@monthly", false},
     {"Sun Jul 1 00:00 2012", "@monthly", true},
+    {"Sun Jul 1 00:00 2012", "@end_of_month", false},
+    {"Tue Jul 31 00:00 2012", "@end_of_month", true},
+    {"Wed Feb 29 00:00 2012", "@end_of_month", true},
+    {"Tue Jul 31 00:00 2012", "@end_of_quarter", false},
+    {"Sat Jun 30 00:00 2012", "@end_of_quarter", true},
+    {"Mon Dec 31 00:00 2012", "@end_of_quarter", true},
 
     // Test interaction of DOW and DOM.
     // If both are specified, then only one needs to match.
@@ -110,6 +116,25 @@ func TestNext(t *testing.T) {
     {"Mon Jul 9 23:35:51 2012", "15/35 20-35/15 * 9-20 * *", "Wed Jul 10 00:20:15 2012"},
     {"Mon Jul 9 23:35:51 2012", "15/35 20-35/15 * 9-20 Jul *", "Wed Jul 10 00:20:15 2012"},
 
+    // Last day of the week and last weekday of the month
+    {"Mon Jul 9 14:00 2012", "0 0 0 ? * 5L", "Fri Jul 27 00:00 2012"},
+    {"Fri Jul 27 00:00 2012", "0 0 0 ? * FRIL", "Fri Aug 31 00:00 2012"},
+    {"Mon Jul 9 14:00 2012", "0 0 0 ? * 1L,3L", "Wed Jul 25 00:00 2012"},
+    {"Mon Jul 9 14:00 2012", "0 0 0 LW * ?", "Tue Jul 31 00:00 2012"},
+    {"Tue Jul 31 00:00 2012", "0 0 0 LW * ?", "Fri Aug 31 00:00 2012"},
+    {"Fri Aug 31 00:00 2012", "0 0 0 LW * ?", "Fri Sep 28 00:00 2012"},
+    {"Mon Jul 9 14:00 2012", "0 0 0 1,LW * ?", "Tue Jul 31 00:00 2012"},
+
+    // Ranges wrapping midnight
+    {"Mon Jul 9 14:00 2012", "0 0 22-2 * * *", "Mon Jul 9 22:00 2012"},
+    {"Mon Jul 9 23:00 2012", "0 0 22-2 * * *", "Tue Jul 10 00:00 2012"},
+    {"Tue Jul 10 02:00 2012", "0 0 22-2 * * *", "Tue Jul 10 22:00 2012"},
+    {"Mon Jul 9 23:58 2012", "0 58-2 * * * *", "Mon Jul 9 23:59 2012"},
+    {"Mon Jul 9 14:00 2012", "0 0 0 1 NOV-FEB *", "Thu Nov 1 00:00 2012"},
+    {"Tue Jan 1 00:00 2013", "0 0 0 1 NOV-FEB *", "Fri Feb 1 00:00 2013"},
+    {"Tue Jul 10 14:00 2012", "0 0 0 * * FRI-MON", "Fri Jul 13 00:00 2012"},
+    {"Sun Jul 15 14:00 2012", "0 0 0 * * FRI-MON", "Mon Jul 16 00:00 2012"},
+
     // Wrap around months
     {"Mon Jul 9 23:35 2012", "0 0 0 9 Apr-Oct ?", "Thu Aug 9 00:00 2012"},
     {"Mon Jul 9 23:35 2012", "0 0 0 */5 Apr,Aug,Oct Mon", "Mon Aug 6 00:00 2012"},
@@ -163,6 +188,32 @@ func TestNext(t *testing.T) {
     {"2012-11-04T00:00:00-0400", "0 0 3 * * ?", "2012-11-04T03:00:00-0500"},
     {"2012-11-04T03:00:00-0500", "0 0 3 * * ?", "2012-11-05T03:00:00-0500"},
 
+    // Last day of the month
+    {"Mon Jul 9 23:35 2012", "0 0 0 L * ?", "Tue Jul 31 00:00 2012"},
+    {"Tue Jul 31 00:00 2012", "0 0 0 L * ?", "Fri Aug 31 00:00 2012"},
+    {"Mon Jan 31 00:00 2011", "0 0 0 L * ?", "Mon Feb 28 00:00 2011"},
+    {"Tue Jan 31 00:00 2012", "0 0 0 L * ?", "Wed Feb 29 00:00 2012"},
+    {"Mon Jul 9 23:35 2012", "0 0 0 L-2 * ?", "Sun Jul 29 00:00 2012"},
+    {"Sun Jul 29 00:00 2012", "0 0 0 L-2 * ?", "Wed Aug 29 00:00 2012"},
+    {"Wed Aug 29 00:00 2012", "0 0 0 L-2 * ?", "Fri Sep 28 00:00 2012"},
+    {"Mon Jul 9 23:35 2012", "0 0 0 1,L * ?", "Tue Jul 31 00:00 2012"},
+    {"Tue Jul 31 00:00 2012", "0 0 0 1,L * ?", "Wed Aug 1 00:00 2012"},
+    {"Mon Jul 9 23:35 2012", "0 0 0 L-30 * ?", "Wed Aug 1 00:00 2012"},
+
+    // Business days
+    {"Sun Jul 1 00:00 2012", "0 0 0 1B * ?", "Mon Jul 2 00:00 2012"},
+    {"Mon Jul 2 00:00 2012", "0 0 0 1B * ?", "Wed Aug 1 00:00 2012"},
+    {"Sun Jul 1 00:00 2012", "0 0 0 3B * ?", "Wed Jul 4 00:00 2012"},
+    {"Wed Aug 1 00:00 2012", "0 0 0 3B * ?", "Fri Aug 3 00:00 2012"},
+    {"Wed Aug 1 00:00 2012", "0 0 0 5B * ?", "Tue Aug 7 00:00 2012"},
+    {"Sun Jul 1 00:00 2012", "0 0 0 LB * ?", "Tue Jul 31 00:00 2012"},
+    {"Tue Jul 31 00:00 2012", "0 0 0 LB * ?", "Fri Aug 31 00:00 2012"},
+    {"Fri Aug 31 00:00 2012", "0 0 0 LB * ?", "Fri Sep 28 00:00 2012"},
+    {"Sun Jul 1 00:00 2012", "0 0 0 1B,LB * ?", "Mon Jul 2 00:00 2012"},
+    {"Mon Jul 2 00:00 2012", "0 0 0 1B,LB * ?", "Tue Jul 31 00:00 2012"},
+    {"Sun Jul 1 00:00 2012", "0 0 0 1B * MON", "Mon Jul 2 00:00 2012"},
+    {"Mon Jul 2 00:00 2012", "0 0 0 1B * MON", "Mon Jul 9 00:00 2012"},
+
     // Unsatisfiable
     {"Mon Jul 9 23:35 2012", "0 0 0 30 Feb ?", ""},
     {"Mon Jul 9 23:35 2012", "0 0 0 31 Apr ?", ""},
@@ -188,6 +239,20 @@ func TestErrors(t *testing.T) {
     "60 0 * * *",
     "0 60 * * *",
     "0 0 * * XYZ",
+    "0 0 0 L-31 * ?",
+    "0 0 0 L2 * ?",
+    "0 0 0 L-x * ?",
+    "0 0 0 0B * ?",
+    "0 0 0 32B * ?",
+    "0 0 0 ? * 7L",
+    "0 0 0 ? * XL",
+    "0 0 0 ? * L",
+    "0 0 0 ? * 5L-6",
+    "@every 1h limit",
+    "@every 1h limit 0",
+    "@every 1h limit x",
+    "@every 1h times 2",
+    "0 0 0 XB * ?",
   }
   for _, spec := range invalidSpecs {
     _, err := Parse(spec)
@@ -197,6 +262,34 @@ func TestErrors(t *testing.T) {
   }
 }
 
+func TestBusinessDays(t *testing.T) {
+  sched, err := Parse("0 0 0 1B,LB * ?")
+  if err != nil {
+    t.Fatal(err)
+  }
+  // Independence day and the last day of July are holidays.
+  sched.(*SpecSchedule).BusinessDays = BusinessDayFunc(func(t time.Time) bool {
+    if t.Month() == time.July && (t.Day() == 4 || t.Day() == 31) {
+      return false
+    }
+    return Weekdays.IsBusinessDay(t)
+  })
+
+  runs := []struct {
+    time, expected string
+  }{
+    {"Sun Jul 1 00:00 2012", "Mon Jul 2 00:00 2012"},
+    {"Mon Jul 2 00:00 2012", "Mon Jul 30 00:00 2012"},
+  }
+  for _, c := range runs {
+    actual := sched.Next(getTime(c.time))
+    expected := getTime(c.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s: (expected) %v != %v (actual)", c.time, expected, actual)
+    }
+  }
+}
+
 func getTime(value string) time.Time {
   if value == "" {
     return time.Time{}
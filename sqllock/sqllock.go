@@ -0,0 +1,169 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements a Locker in a SQL database.
+
+// Package sqllock implements a cron.Locker keeping its locks as the rows of a
+// table in a SQL database, so that replicas sharing a database agree on which
+// of them runs each activation of their entries:
+//
+// 	locker := sqllock.New(db, "cron_locks")
+// 	if err := locker.CreateTable(); err != nil {
+// 		log.Fatal(err)
+// 	}
+// 	c := cron.New(cron.WithLocker(locker))
+//
+// Statements use "?" placeholders, as MySQL and SQLite do; set Placeholder to
+// Dollar for PostgreSQL.
+package sqllock
+
+import (
+  "context"
+  "crypto/rand"
+  "database/sql"
+  "encoding/hex"
+  "fmt"
+  "strconv"
+  "time"
+)
+
+const (
+  // DefaultTimeout bounds each statement a Locker executes.
+  DefaultTimeout = 5 * time.Second
+
+  // DefaultLockTTL is how long a lock is held, so that a replica whose timer
+  // fires late does not run the activation again.
+  DefaultLockTTL = time.Minute
+)
+
+// Locker is a cron.Locker keeping each lock as a row of a table, whose
+// primary key is the entry and scheduled time of the run.
+type Locker struct {
+  db    *sql.DB
+  table string
+
+  // Timeout bounds each statement, and LockTTL is how long locks are held.
+  Timeout time.Duration
+  LockTTL time.Duration
+
+  // Placeholder returns the placeholder of the nth parameter of a statement,
+  // counting from 1.
+  Placeholder func(n int) string
+
+  // owner identifies the locks held by this Locker.
+  owner string
+}
+
+// Question returns the "?" placeholders of MySQL and SQLite.
+func Question(n int) string {
+  return "?"
+}
+
+// Dollar returns the "$n" placeholders of PostgreSQL.
+func Dollar(n int) string {
+  return "$" + strconv.Itoa(n)
+}
+
+// New returns a Locker keeping locks in the table of db.
+func New(db *sql.DB, table string) *Locker {
+  b := make([]byte, 16)
+  rand.Read(b)
+  return &Locker{
+    db:          db,
+    table:       table,
+    Timeout:     DefaultTimeout,
+    LockTTL:     DefaultLockTTL,
+    Placeholder: Question,
+    owner:       hex.EncodeToString(b),
+  }
+}
+
+// CreateTable creates the table of the locks, unless it exists.
+func (l *Locker) CreateTable() error {
+  return l.exec(`CREATE TABLE IF NOT EXISTS ` + l.table + ` (
+  entry_id VARCHAR(255) NOT NULL,
+  scheduled BIGINT NOT NULL,
+  owner VARCHAR(32) NOT NULL,
+  expires BIGINT NOT NULL,
+  PRIMARY KEY (entry_id, scheduled)
+)`)
+}
+
+// exec executes the statement, whose placeholders are written as "?", with
+// the arguments.
+func (l *Locker) exec(query string, args ...interface{}) error {
+  _, err := l.execRows(query, args...)
+  return err
+}
+
+// execRows executes the statement, whose placeholders are written as "?",
+// with the arguments, and returns the number of rows it affected.
+func (l *Locker) execRows(query string, args ...interface{}) (int64, error) {
+  ctx, cancel := context.WithTimeout(context.Background(), l.Timeout)
+  defer cancel()
+  result, err := l.db.ExecContext(ctx, l.bind(query), args...)
+  if err != nil {
+    return 0, err
+  }
+  return result.RowsAffected()
+}
+
+// bind replaces the "?" placeholders of the query with Placeholder's.
+func (l *Locker) bind(query string) string {
+  var b []byte
+  n := 0
+  for i := 0; i < len(query); i++ {
+    if query[i] == '?' {
+      n++
+      b = append(b, l.Placeholder(n)...)
+    } else {
+      b = append(b, query[i])
+    }
+  }
+  return string(b)
+}
+
+// TryLock implements cron.Locker, inserting the row of the lock after
+// deleting it if it expired.  It returns false if the row exists, or the
+// database cannot be reached.
+func (l *Locker) TryLock(entryID string, scheduled time.Time) bool {
+  now := time.Now()
+  err := l.exec(`DELETE FROM `+l.table+
+    ` WHERE entry_id = ? AND scheduled = ? AND expires <= ?`,
+    entryID, scheduled.UnixNano(), now.UnixNano())
+  if err != nil {
+    return false
+  }
+  err = l.exec(`INSERT INTO `+l.table+
+    ` (entry_id, scheduled, owner, expires) VALUES (?, ?, ?, ?)`,
+    entryID, scheduled.UnixNano(), l.owner, now.Add(l.LockTTL).UnixNano())
+  return err == nil
+}
+
+// Unlock implements cron.Locker.  It does nothing: the row of the lock of an
+// activation is kept until it expires, after LockTTL, so that a replica whose
+// timer fires after the run finished does not run the activation again.
+// Expire deletes the rows of expired locks.
+func (l *Locker) Unlock(entryID string, scheduled time.Time) {}
+
+// Expire deletes the rows of the locks that expired, which TryLock otherwise
+// leaves in the table, and returns how many it deleted.
+func (l *Locker) Expire() (int64, error) {
+  n, err := l.execRows(`DELETE FROM `+l.table+` WHERE expires <= ?`,
+    time.Now().UnixNano())
+  if err != nil {
+    return 0, fmt.Errorf("cannot delete expired locks: %s", err)
+  }
+  return n, nil
+}
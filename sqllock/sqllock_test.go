@@ -0,0 +1,101 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the SQL Locker.
+
+package sqllock
+
+import (
+  "database/sql"
+  "path/filepath"
+  "testing"
+  "time"
+
+  "github.com/kiranbond/cron"
+  _ "github.com/mattn/go-sqlite3"
+)
+
+// newLocker returns a Locker on a fresh SQLite database.
+func newLocker(t *testing.T) *Locker {
+  db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "locks.db"))
+  if err != nil {
+    t.Fatal(err)
+  }
+  t.Cleanup(func() { db.Close() })
+  l := New(db, "cron_locks")
+  if err := l.CreateTable(); err != nil {
+    t.Fatal(err)
+  }
+  // Creating it again is harmless.
+  if err := l.CreateTable(); err != nil {
+    t.Fatal(err)
+  }
+  return l
+}
+
+func TestLock(t *testing.T) {
+  l := newLocker(t)
+  var other cron.Locker = New(l.db, l.table)
+  at := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+
+  if !l.TryLock("a", at) {
+    t.Fatal("expected to take the lock")
+  }
+  if other.TryLock("a", at) || l.TryLock("a", at) {
+    t.Error("expected the lock to be held")
+  }
+  if !other.TryLock("a", at.Add(time.Hour)) || !other.TryLock("b", at) {
+    t.Error("expected the locks of other activations to be free")
+  }
+
+  // Locks are kept after the run, until they expire.
+  l.Unlock("a", at)
+  if other.TryLock("a", at) {
+    t.Error("expected the unlocked lock to still be held")
+  }
+}
+
+func TestExpire(t *testing.T) {
+  l := newLocker(t)
+  other := New(l.db, l.table)
+  other.LockTTL = -time.Second
+  at := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+
+  // The lock of other expired as soon as it was taken.
+  if !other.TryLock("a", at) || !other.TryLock("b", at) {
+    t.Fatal("expected to take the locks")
+  }
+  if !l.TryLock("a", at) {
+    t.Error("expected the expired lock to be free")
+  }
+  if n, err := l.Expire(); err != nil || n != 1 {
+    t.Errorf("expected 1 expired lock, got %d, %v", n, err)
+  }
+  if l.TryLock("a", at) {
+    t.Error("expected the unexpired lock to be held")
+  }
+}
+
+func TestBind(t *testing.T) {
+  l := New(nil, "locks")
+  query := "DELETE FROM locks WHERE entry_id = ? AND scheduled = ?"
+  if actual := l.bind(query); actual != query {
+    t.Errorf("(expected) %s != %s (actual)", query, actual)
+  }
+  l.Placeholder = Dollar
+  expected := "DELETE FROM locks WHERE entry_id = $1 AND scheduled = $2"
+  if actual := l.bind(query); actual != expected {
+    t.Errorf("(expected) %s != %s (actual)", expected, actual)
+  }
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements the statistics of entries' runs.
+
+package cron
+
+import (
+  "sync"
+  "time"
+)
+
+// EntryStats summarizes the runs of an entry since it was added.
+type EntryStats struct {
+  // Runs is the number of runs that finished, Failures the number of those
+  // that failed, and Skipped the number of scheduled runs that did not start.
+  Runs, Failures, Skipped int
+
+  // ConsecutiveFailures is the number of runs that failed since the last one
+  // that completed successfully.
+  ConsecutiveFailures int
+
+  // MinDuration, AvgDuration and MaxDuration are the shortest, mean and
+  // longest durations of the finished runs.
+  MinDuration, AvgDuration, MaxDuration time.Duration
+
+  // LastError is the error of the last run that failed, and LastFailure the
+  // time it started.
+  LastError   error
+  LastFailure time.Time
+}
+
+// entryStats accumulates the EntryStats of an entry.  A nil entryStats
+// accumulates nothing.
+type entryStats struct {
+  mu    sync.Mutex
+  stats EntryStats
+  total time.Duration
+}
+
+// ran records a finished run, which started at the given time.
+func (s *entryStats) ran(started time.Time, duration time.Duration,
+  err error) {
+  if s == nil {
+    return
+  }
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  st := &s.stats
+  st.Runs++
+  s.total += duration
+  st.AvgDuration = s.total / time.Duration(st.Runs)
+  if st.Runs == 1 || duration < st.MinDuration {
+    st.MinDuration = duration
+  }
+  if duration > st.MaxDuration {
+    st.MaxDuration = duration
+  }
+  if err != nil {
+    st.Failures++
+    st.ConsecutiveFailures++
+    st.LastError, st.LastFailure = err, started
+  } else {
+    st.ConsecutiveFailures = 0
+  }
+}
+
+// skipped records a scheduled run that did not start.
+func (s *entryStats) skipped() {
+  if s == nil {
+    return
+  }
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  s.stats.Skipped++
+}
+
+// get returns the stats accumulated so far.
+func (s *entryStats) get() EntryStats {
+  if s == nil {
+    return EntryStats{}
+  }
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  return s.stats
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for the statistics of entries' runs.
+
+package cron
+
+import (
+  "errors"
+  "testing"
+  "time"
+)
+
+func TestEntryStats(t *testing.T) {
+  errFailed := errors.New("failed")
+  type run struct {
+    duration time.Duration
+    err      error
+  }
+  tests := []struct {
+    runs     []run
+    skipped  int
+    expected EntryStats
+  }{
+    {nil, 0, EntryStats{}},
+    {nil, 2, EntryStats{Skipped: 2}},
+    {[]run{{time.Second, nil}}, 0, EntryStats{Runs: 1,
+      MinDuration: time.Second, AvgDuration: time.Second,
+      MaxDuration: time.Second}},
+    {[]run{{3 * time.Second, nil}, {time.Second, errFailed},
+      {2 * time.Second, errFailed}}, 1, EntryStats{Runs: 3, Failures: 2,
+      Skipped: 1, ConsecutiveFailures: 2, MinDuration: time.Second,
+      AvgDuration: 2 * time.Second, MaxDuration: 3 * time.Second,
+      LastError: errFailed, LastFailure: getTime("Mon Jul 9 02:00 2012")}},
+    {[]run{{time.Second, errFailed}, {time.Second, nil}}, 0, EntryStats{
+      Runs: 2, Failures: 1, MinDuration: time.Second,
+      AvgDuration: time.Second, MaxDuration: time.Second,
+      LastError: errFailed, LastFailure: getTime("Mon Jul 9 00:00 2012")}},
+  }
+
+  start := getTime("Mon Jul 9 00:00 2012")
+  for i, c := range tests {
+    s := &entryStats{}
+    for j, r := range c.runs {
+      s.ran(start.Add(time.Duration(j)*time.Hour), r.duration, r.err)
+    }
+    for j := 0; j < c.skipped; j++ {
+      s.skipped()
+    }
+    if actual := s.get(); actual != c.expected {
+      t.Errorf("%d: (expected) %+v != %+v (actual)", i, c.expected, actual)
+    }
+  }
+}
+
+func TestEntryStatsSnapshot(t *testing.T) {
+  clock := NewFakeClock(time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC))
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  done := make(chan struct{}, 10)
+  cron.Subscribe(func(e Event) {
+    if e.Type == RunCompleted {
+      done <- struct{}{}
+    }
+  })
+  id, _ := cron.AddFunc("@hourly", func() { clock.Advance(time.Second) })
+  cron.Start()
+  defer cron.Stop()
+
+  clock.Advance(time.Hour)
+  select {
+  case <-done:
+  case <-time.After(cOneSecond):
+    t.Fatal("expected a run")
+  }
+
+  entry, _ := cron.Entry(id)
+  expected := EntryStats{Runs: 1, MinDuration: time.Second,
+    AvgDuration: time.Second, MaxDuration: time.Second}
+  if entry.Stats != expected {
+    t.Errorf("(expected) %+v != %+v (actual)", expected, entry.Stats)
+  }
+}
@@ -0,0 +1,405 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements persisting entries in an EntryStore.
+
+package cron
+
+import (
+  "bytes"
+  "fmt"
+  "sort"
+  "sync"
+  "time"
+)
+
+// StoredEntry is the persistent form of an entry added with AddRegistered.
+type StoredEntry struct {
+  // ID, Name, Spec and Labels are those of the entry.
+  ID, Name, Spec string
+  Labels         map[string]string
+
+  // Job is the name the job's JobFactory is registered as, and Payload what
+  // it creates the job from.
+  Job     string
+  Payload []byte
+
+  // Prev and Next are the entry's last and next activations.
+  Prev, Next time.Time
+
+  // AtLeastOnce is that of the entry, and Claimed the scheduled times of its
+  // runs that started but did not finish, e.g. because the process running
+  // them exited.  ClaimedBy is the instance ID of the Cron that claimed them.
+  AtLeastOnce bool
+  Claimed     []time.Time
+  ClaimedBy   string
+
+  // Misfire is the entry's misfire policy, and LastSuccess the scheduled time
+  // of its last run that succeeded, which WithReplay replays the missed
+  // activations after.
+  Misfire     MisfirePolicy
+  LastSuccess time.Time
+}
+
+// EntryStore persists the definitions and state of entries, so that they
+// survive restarts.  Its methods are called by the Cron, in the order of the
+// changes they record, but without holding the Cron's lock; they must not call
+// the Cron, and should return promptly.
+type EntryStore interface {
+  // Save creates or replaces the entry with the ID of the given one.
+  Save(entry StoredEntry) error
+
+  // Load returns the stored entries.
+  Load() ([]StoredEntry, error)
+
+  // Delete deletes the entry with the given ID, if there is one.
+  Delete(id string) error
+}
+
+// WithStore persists the entries added with AddRegistered in store: they are
+// saved when added, changed and run, and deleted when removed.  Restore adds
+// the stored entries back to the Cron, e.g. after a restart.
+func WithStore(store EntryStore) Option {
+  return func(c *Cron) {
+    c.store = store
+  }
+}
+
+// JobFactory creates the Job of an entry from its payload, e.g. a JSON
+// document of its parameters.
+type JobFactory func(payload []byte) (Job, error)
+
+// factories are the registered JobFactories, by name.
+var factories = struct {
+  sync.RWMutex
+  m map[string]JobFactory
+}{m: map[string]JobFactory{}}
+
+// RegisterJob registers the factory of the jobs named name, which stored
+// entries refer to.  Like sql.Register, it is meant to be called from init
+// functions, and panics if the name is taken.
+func RegisterJob(name string, factory JobFactory) {
+  factories.Lock()
+  defer factories.Unlock()
+  if factory == nil {
+    panic("cron: RegisterJob factory is nil")
+  }
+  if _, ok := factories.m[name]; ok {
+    panic("cron: RegisterJob called twice for job " + name)
+  }
+  factories.m[name] = factory
+}
+
+// newJob creates the job registered as name from the payload.
+func newJob(name string, payload []byte) (Job, error) {
+  factories.RLock()
+  factory := factories.m[name]
+  factories.RUnlock()
+  if factory == nil {
+    return nil, fmt.Errorf("no job registered as %s", name)
+  }
+  return factory(payload)
+}
+
+// AddRegistered adds the job the factory registered as job creates from the
+// payload to the Cron, to be run on the given schedule.  Unlike those of other
+// entries, its definition can be stored, so the entry is persisted in the
+// Cron's EntryStore, if it has one.  The name, labels, AtLeastOnce and misfire
+// policy of the entry are stored with it, but not its other options.
+func (c *Cron) AddRegistered(spec, job string, payload []byte,
+  opts ...EntryOption) (string, error) {
+  cmd, err := newJob(job, payload)
+  if err != nil {
+    return "", err
+  }
+  schedule, err := c.parser.Parse(spec)
+  if err != nil {
+    return "", err
+  }
+  entry := c.newEntry(spec, schedule, cmd, opts)
+  entry.JobName, entry.Payload = job, payload
+  if taken, err := c.add([]*Entry{entry}); err != nil {
+    return taken, err
+  }
+  return entry.ID, nil
+}
+
+// ValidateRegistered returns the error AddRegistered would return for the
+// spec, job and payload, other than for a taken name, without adding an entry.
+func (c *Cron) ValidateRegistered(spec, job string, payload []byte) error {
+  if _, err := newJob(job, payload); err != nil {
+    return err
+  }
+  _, err := c.parser.Parse(spec)
+  return err
+}
+
+// Restore adds the entries of the Cron's EntryStore that it does not have,
+// with their IDs, names, labels and last activations.  Their next activations
+// are computed from now, unless the Cron replays missed activations, or
+// catches up with them, and the claimed runs of AtLeastOnce entries are run
+// again, or skipped, according to the Cron's ClaimPolicy.  Entries that cannot
+// be restored, e.g. because their job is not
+// registered, are left in the store, and the first error is returned after
+// the others are restored.
+func (c *Cron) Restore() error {
+  if c.store == nil {
+    return fmt.Errorf("cron has no store")
+  }
+  stored, err := c.store.Load()
+  if err != nil {
+    return err
+  }
+  var first error
+  for _, s := range stored {
+    if _, ok := c.Entry(s.ID); ok {
+      continue
+    }
+    if err := c.restore(s); err != nil {
+      if first == nil {
+        first = fmt.Errorf("cannot restore entry %s: %s", s.ID, err)
+      }
+      continue
+    }
+    if s.AtLeastOnce && len(s.Claimed) > 0 {
+      c.abandonClaims(s.ID, s.Claimed)
+    }
+  }
+  return first
+}
+
+// Apply makes the Cron's entry with the ID of the stored entry match it, e.g.
+// when the definitions in a store shared by several processes change: it adds
+// the entry if the Cron has none with its ID, and otherwise updates the spec,
+// job and labels of the entry, keeping its last activation, history and
+// stats.  An entry whose name changed is replaced.  The runs other Crons
+// claimed are theirs, and are only run again once abandoned, if the Cron was
+// created WithClaimTimeout.
+func (c *Cron) Apply(s StoredEntry) error {
+  defer c.observeClaims(s)
+  existing, ok := c.Entry(s.ID)
+  if !ok || existing.Name != s.Name {
+    if ok {
+      c.DeleteJob(s.ID)
+    }
+    restored := s
+    restored.Claimed, restored.ClaimedBy = nil, ""
+    return c.restore(restored)
+  }
+  if existing.Spec == s.Spec && existing.JobName == s.Job &&
+    bytes.Equal(existing.Payload, s.Payload) &&
+    existing.AtLeastOnce == s.AtLeastOnce && existing.Misfire == s.Misfire &&
+    len(existing.Labels) == len(s.Labels) &&
+    MatchLabels(s.Labels)(existing) {
+    return nil
+  }
+
+  cmd, err := newJob(s.Job, s.Payload)
+  if err != nil {
+    return err
+  }
+  schedule, err := c.parser.Parse(s.Spec)
+  if err != nil {
+    return err
+  }
+  return c.update(s.ID, func(e *Entry) {
+    if e.Spec != s.Spec {
+      e.Schedule, e.Spec = schedule, s.Spec
+      now := c.now()
+      startLimits(e.Schedule, now)
+      e.advance(now)
+      c.scheduled(e)
+    }
+    e.Job = c.wrap(e, cmd)
+    e.JobName, e.Payload = s.Job, s.Payload
+    e.AtLeastOnce, e.Misfire = s.AtLeastOnce, s.Misfire
+    e.Labels = copyLabels(s.Labels)
+  })
+}
+
+// restore adds the stored entry.
+func (c *Cron) restore(s StoredEntry) error {
+  cmd, err := newJob(s.Job, s.Payload)
+  if err != nil {
+    return err
+  }
+  schedule, err := c.parser.Parse(s.Spec)
+  if err != nil {
+    return err
+  }
+  entry := c.newEntry(s.Spec, schedule, cmd, []EntryOption{
+    WithName(s.Name),
+    WithLabels(s.Labels),
+  })
+  entry.ID, entry.Prev = s.ID, s.Prev
+  entry.JobName, entry.Payload = s.Job, s.Payload
+  entry.AtLeastOnce = s.AtLeastOnce
+  entry.claims = append([]time.Time(nil), s.Claimed...)
+  entry.Misfire, entry.lastSuccess = s.Misfire, s.LastSuccess
+  if c.replay {
+    entry.resume = s.LastSuccess
+    if entry.resume.IsZero() {
+      entry.resume = s.Prev
+    }
+  } else if c.catchUp {
+    if entry.catchUp = c.missed(entry, s.Prev); !entry.catchUp.IsZero() {
+      entry.Prev = entry.catchUp
+    }
+  }
+  _, err = c.add([]*Entry{entry})
+  return err
+}
+
+// stored returns the persistent form of the entry.
+func (e *Entry) stored() *StoredEntry {
+  return &StoredEntry{
+    ID:      e.ID,
+    Name:    e.Name,
+    Spec:    e.Spec,
+    Labels:  copyLabels(e.Labels),
+    Job:     e.JobName,
+    Payload: e.Payload,
+    Prev:    e.Prev,
+    Next:    e.Next,
+
+    AtLeastOnce: e.AtLeastOnce,
+    Claimed:     append([]time.Time(nil), e.claims...),
+    Misfire:     e.Misfire,
+    LastSuccess: e.lastSuccess,
+  }
+}
+
+// storeOp is a change to the store: saving entry or, if it is nil, deleting the
+// entry with the given id.
+type storeOp struct {
+  id    string
+  entry *StoredEntry
+}
+
+// persist queues saving the entry, if it is stored, once c.mu is unlocked.
+// c.mu must be held.
+func (c *Cron) persist(e *Entry) {
+  if c.store != nil && e.JobName != "" {
+    s := e.stored()
+    if len(s.Claimed) > 0 {
+      s.ClaimedBy = c.instanceID
+    }
+    c.storeOps = append(c.storeOps, storeOp{e.ID, s})
+  }
+}
+
+// unpersist queues deleting the entry, if it is stored, once c.mu is unlocked.
+// c.mu must be held.
+func (c *Cron) unpersist(e *Entry) {
+  if c.store != nil && e.JobName != "" {
+    c.storeOps = append(c.storeOps, storeOp{id: e.ID})
+  }
+}
+
+// applyStoreOps applies the changes to the store, logging failures.
+func (c *Cron) applyStoreOps(ops []storeOp) {
+  for _, op := range ops {
+    var err error
+    if op.entry != nil {
+      err = c.store.Save(*op.entry)
+    } else {
+      err = c.store.Delete(op.id)
+    }
+    if err != nil {
+      c.logger.Warningf("cannot store entry %s: %v", op.id, err)
+    }
+  }
+}
+
+// MemoryStore is an EntryStore that keeps entries in memory, e.g. for tests,
+// or to move entries from one Cron to another.  It is also a Cluster of the
+// Crons of a process.
+type MemoryStore struct {
+  mu      sync.Mutex
+  entries map[string]StoredEntry
+
+  // members are the members of the Cluster, and when they expire.
+  members map[string]time.Time
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+  return &MemoryStore{
+    entries: map[string]StoredEntry{},
+    members: map[string]time.Time{},
+  }
+}
+
+// Save implements EntryStore.
+func (s *MemoryStore) Save(entry StoredEntry) error {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  s.entries[entry.ID] = entry
+  return nil
+}
+
+// Load implements EntryStore, returning the entries ordered by ID.
+func (s *MemoryStore) Load() ([]StoredEntry, error) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  entries := make([]StoredEntry, 0, len(s.entries))
+  for _, e := range s.entries {
+    entries = append(entries, e)
+  }
+  sort.Slice(entries, func(i, j int) bool {
+    return entries[i].ID < entries[j].ID
+  })
+  return entries, nil
+}
+
+// Delete implements EntryStore.
+func (s *MemoryStore) Delete(id string) error {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  delete(s.entries, id)
+  return nil
+}
+
+// Heartbeat implements Cluster.
+func (s *MemoryStore) Heartbeat(member string, ttl time.Duration) error {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  s.members[member] = time.Now().Add(ttl)
+  return nil
+}
+
+// Leave implements Cluster.
+func (s *MemoryStore) Leave(member string) error {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  delete(s.members, member)
+  return nil
+}
+
+// Members implements Cluster, returning the members ordered.
+func (s *MemoryStore) Members() ([]string, error) {
+  s.mu.Lock()
+  defer s.mu.Unlock()
+  now := time.Now()
+  var members []string
+  for member, expires := range s.members {
+    if expires.After(now) {
+      members = append(members, member)
+    } else {
+      delete(s.members, member)
+    }
+  }
+  sort.Strings(members)
+  return members, nil
+}
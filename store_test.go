@@ -0,0 +1,205 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for persisting entries.
+
+package cron
+
+import (
+  "reflect"
+  "strings"
+  "testing"
+  "time"
+)
+
+// storeRuns receives the payloads of the runs of "test-echo" jobs.
+var storeRuns = make(chan string, 10)
+
+func init() {
+  RegisterJob("test-echo", func(payload []byte) (Job, error) {
+    return FuncJob(func() { storeRuns <- string(payload) }), nil
+  })
+}
+
+func TestMemoryStore(t *testing.T) {
+  s := NewMemoryStore()
+  s.Save(StoredEntry{ID: "b", Spec: "@hourly"})
+  s.Save(StoredEntry{ID: "a", Spec: "@daily"})
+  s.Save(StoredEntry{ID: "b", Spec: "@weekly"})
+  s.Delete("c")
+
+  expected := []StoredEntry{{ID: "a", Spec: "@daily"},
+    {ID: "b", Spec: "@weekly"}}
+  if entries, _ := s.Load(); !reflect.DeepEqual(entries, expected) {
+    t.Errorf("(expected) %+v != %+v (actual)", expected, entries)
+  }
+
+  s.Delete("a")
+  if entries, _ := s.Load(); len(entries) != 1 || entries[0].ID != "b" {
+    t.Errorf("expected only b, got %+v", entries)
+  }
+}
+
+func TestStorePersists(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  store := NewMemoryStore()
+  cron := New(WithClock(clock), WithLocation(time.UTC), WithStore(store))
+  defer cron.Stop()
+
+  if _, err := cron.AddRegistered("@hourly", "missing", nil); err == nil {
+    t.Error("expected an error adding an unregistered job")
+  }
+  cron.AddFunc("@hourly", func() {})
+  id, err := cron.AddRegistered("@hourly", "test-echo", []byte("hello"),
+    WithName("echo"), WithLabels(map[string]string{"team": "infra"}))
+  if err != nil {
+    t.Fatal(err)
+  }
+  cron.Start()
+
+  expected := []StoredEntry{{
+    ID:      id,
+    Name:    "echo",
+    Spec:    "@hourly",
+    Labels:  map[string]string{"team": "infra"},
+    Job:     "test-echo",
+    Payload: []byte("hello"),
+    Next:    start.Add(time.Hour),
+  }}
+  if entries, _ := store.Load(); !reflect.DeepEqual(entries, expected) {
+    t.Errorf("(expected) %+v != %+v (actual)", expected, entries)
+  }
+
+  clock.Advance(time.Hour)
+  if payload := <-storeRuns; payload != "hello" {
+    t.Errorf("expected the payload hello, got %s", payload)
+  }
+  waitFor(t, func() bool {
+    entries, _ := store.Load()
+    return entries[0].Prev.Equal(start.Add(time.Hour)) &&
+      entries[0].Next.Equal(start.Add(2*time.Hour))
+  })
+
+  cron.DeleteJob(id)
+  if entries, _ := store.Load(); len(entries) != 0 {
+    t.Errorf("expected the removed entry deleted, got %+v", entries)
+  }
+}
+
+func TestRestore(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  store := NewMemoryStore()
+  store.Save(StoredEntry{ID: "1", Name: "echo", Spec: "@hourly",
+    Job: "test-echo", Payload: []byte("restored"),
+    Prev: start.Add(-time.Hour)})
+  store.Save(StoredEntry{ID: "2", Spec: "@hourly", Job: "missing"})
+  store.Save(StoredEntry{ID: "3", Spec: "bad spec", Job: "test-echo"})
+
+  cron := New(WithClock(clock), WithLocation(time.UTC), WithStore(store))
+  defer cron.Stop()
+  err := cron.Restore()
+  if err == nil || !strings.Contains(err.Error(), "entry 2") {
+    t.Errorf("expected an error restoring entry 2, got %v", err)
+  }
+  cron.Restore()
+
+  entries := cron.Entries()
+  if len(entries) != 1 {
+    t.Fatalf("expected 1 entry, got %d", len(entries))
+  }
+  e := entries[0]
+  if e.ID != "1" || e.Name != "echo" || !e.Prev.Equal(start.Add(-time.Hour)) ||
+    !e.Next.Equal(start.Add(time.Hour)) {
+    t.Errorf("expected entry 1 restored, got %+v", e)
+  }
+  if stored, _ := store.Load(); len(stored) != 3 {
+    t.Errorf("expected entries that were not restored kept, got %+v", stored)
+  }
+
+  cron.Start()
+  clock.Advance(time.Hour)
+  if payload := <-storeRuns; payload != "restored" {
+    t.Errorf("expected the payload restored, got %s", payload)
+  }
+}
+
+func TestApply(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  defer cron.Stop()
+
+  s := StoredEntry{ID: "1", Name: "echo", Spec: "@hourly", Job: "test-echo",
+    Payload: []byte("one")}
+  if err := cron.Apply(s); err != nil {
+    t.Fatal(err)
+  }
+
+  // Changes to the definition update the entry in place.
+  s.Spec, s.Payload = "@daily", []byte("two")
+  s.Labels = map[string]string{"team": "infra"}
+  if err := cron.Apply(s); err != nil {
+    t.Fatal(err)
+  }
+  e, _ := cron.Entry("1")
+  if e.Spec != "@daily" || string(e.Payload) != "two" ||
+    e.Labels["team"] != "infra" || !e.Next.Equal(start.Add(24*time.Hour)) {
+    t.Errorf("expected the entry updated, got %+v", e)
+  }
+  cron.RunNow("1")
+  if payload := <-storeRuns; payload != "two" {
+    t.Errorf("expected the new job to run, got %s", payload)
+  }
+
+  // Renamed entries are replaced.
+  s.Name = "renamed"
+  if err := cron.Apply(s); err != nil {
+    t.Fatal(err)
+  }
+  if id, ok := cron.EntryID("renamed"); !ok || id != "1" {
+    t.Errorf("expected the entry renamed, got %s %v", id, ok)
+  }
+  if entries := cron.Entries(); len(entries) != 1 {
+    t.Errorf("expected 1 entry, got %d", len(entries))
+  }
+
+  s.Spec = "bad spec"
+  if err := cron.Apply(s); err == nil {
+    t.Error("expected an error applying a bad spec")
+  }
+}
+
+func TestValidateRegistered(t *testing.T) {
+  cron := New()
+  tests := []struct {
+    spec, job string
+    valid     bool
+  }{
+    {"@hourly", "test-echo", true},
+    {"bad spec", "test-echo", false},
+    {"@hourly", "unregistered", false},
+  }
+  for _, test := range tests {
+    if err := cron.ValidateRegistered(test.spec, test.job,
+      nil); (err == nil) != test.valid {
+      t.Errorf("%s %s: expected valid %v, got %v", test.spec, test.job,
+        test.valid, err)
+    }
+  }
+  if entries := cron.Entries(); len(entries) != 0 {
+    t.Errorf("expected no entries, got %d", len(entries))
+  }
+}
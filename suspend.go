@@ -0,0 +1,50 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements suspending the dispatch of runs.
+
+package cron
+
+// Suspend stops the Cron from starting runs on schedule until Resume is
+// called.  Unlike Stop, it does not cancel the running jobs, give up the
+// Cron's leadership or cluster membership, or prevent RunNow; and the
+// activations missed while suspended are not forgotten, but run on Resume
+// according to the misfire policies of their entries.  Suspending a suspended
+// Cron does nothing.
+func (c *Cron) Suspend() {
+  c.mu.Lock()
+  defer c.unlock()
+  c.suspended = true
+  c.wakeup()
+}
+
+// Resume lets a suspended Cron start runs on schedule again, starting with
+// the activations missed while it was suspended: each entry runs every missed
+// activation, once for all of them or none, as its MisfirePolicy decides.  A
+// Cron that is stopped stays so.  Resuming a Cron that is not suspended does
+// nothing.
+func (c *Cron) Resume() {
+  c.mu.Lock()
+  defer c.unlock()
+  c.suspended = false
+  c.wakeup()
+}
+
+// IsSuspended returns whether the Cron is suspended, i.e. Suspend has been
+// called and Resume not since.
+func (c *Cron) IsSuspended() bool {
+  c.mu.RLock()
+  defer c.mu.RUnlock()
+  return c.suspended
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for suspending the dispatch of runs.
+
+package cron
+
+import (
+  "context"
+  "testing"
+  "time"
+)
+
+func TestSuspend(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  runs := make(chan MisfirePolicy, 10)
+  ids := map[MisfirePolicy]string{}
+  for _, policy := range []MisfirePolicy{MisfireRunAll, MisfireRunOnce,
+    MisfireSkip} {
+    policy := policy
+    ids[policy], _ = cron.AddFunc("@hourly", func() { runs <- policy },
+      WithMisfirePolicy(policy))
+  }
+  running := make(chan context.Context, 1)
+  cron.AddJob("@every 30m", ContextFuncJob(func(ctx context.Context) {
+    running <- ctx
+    <-ctx.Done()
+  }), WithMaxRuns(1))
+  cron.Start()
+  defer cron.Stop()
+
+  clock.Advance(30 * time.Minute)
+  ctx := <-running
+  cron.Suspend()
+  if !cron.IsSuspended() || !cron.IsRunning() {
+    t.Error("expected the Cron suspended but running")
+  }
+  if ctx.Err() != nil {
+    t.Error("expected the running job not to be canceled")
+  }
+
+  clock.Advance(3 * time.Hour)
+  select {
+  case policy := <-runs:
+    t.Fatalf("unexpected run of the entry with policy %v", policy)
+  case <-time.After(50 * time.Millisecond):
+  }
+
+  // The missed activations at 01:00, 02:00 and 03:00 run by policy.
+  cron.Resume()
+  counts := map[MisfirePolicy]int{}
+  for i := 0; i < 4; i++ {
+    select {
+    case policy := <-runs:
+      counts[policy]++
+    case <-time.After(cOneSecond):
+      t.Fatalf("expected 4 runs, got %v", counts)
+    }
+  }
+  if counts[MisfireRunAll] != 3 || counts[MisfireRunOnce] != 1 {
+    t.Errorf("expected 3 runs of all and 1 of once, got %v", counts)
+  }
+  if cron.IsSuspended() {
+    t.Error("expected the Cron resumed")
+  }
+  if e, _ := cron.Entry(ids[MisfireSkip]); e.Runs != 0 ||
+    !e.Next.Equal(start.Add(4*time.Hour)) {
+    t.Errorf("expected the missed activations skipped, got %+v", e)
+  }
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tracing the runs of jobs with OpenTelemetry.
+
+// Package tracing traces the runs of a cron.Cron's jobs with OpenTelemetry:
+//
+// 	c := cron.New(cron.WithChain(tracing.Wrap(otel.GetTracerProvider())))
+//
+// Each run is a span, whose context is passed to ContextJobs and ErrorJobs, so
+// that the spans of the work they do are children of it.
+package tracing
+
+import (
+  "context"
+  "fmt"
+  "time"
+
+  "github.com/kiranbond/cron"
+  "go.opentelemetry.io/otel/attribute"
+  "go.opentelemetry.io/otel/codes"
+  "go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation is the name of the tracer.
+const instrumentation = "github.com/kiranbond/cron/tracing"
+
+// The attributes of run spans.
+const (
+  EntryIDKey   = attribute.Key("cron.entry.id")
+  EntryNameKey = attribute.Key("cron.entry.name")
+  ScheduledKey = attribute.Key("cron.run.scheduled")
+  DelayKey     = attribute.Key("cron.run.delay")
+  OutcomeKey   = attribute.Key("cron.run.outcome")
+)
+
+// Wrap returns a JobWrapper running each run of a job in a span of a tracer of
+// provider.  Spans are named "cron " followed by the name of the entry, or
+// "cron job" if it has none, and have attributes giving:
+//
+// 	cron.entry.id       the ID of the entry
+// 	cron.entry.name     the name of the entry, if it has one
+// 	cron.run.scheduled  the time the run was scheduled for, in RFC 3339
+// 	cron.run.delay      the seconds the run started after it was scheduled
+// 	cron.run.outcome    "completed" or "failed"
+//
+// The spans of failed runs record their error, or panic, and have an error
+// status.
+func Wrap(provider trace.TracerProvider) cron.JobWrapper {
+  tracer := provider.Tracer(instrumentation)
+  return func(j cron.Job) cron.Job {
+    return &tracedJob{job: j, tracer: tracer}
+  }
+}
+
+// tracedJob runs its job in a span.
+type tracedJob struct {
+  job    cron.Job
+  tracer trace.Tracer
+}
+
+// Run runs the job with a background context.
+func (t *tracedJob) Run() { t.RunError(context.Background()) }
+
+// RunContext runs the job with the context.
+func (t *tracedJob) RunContext(ctx context.Context) { t.RunError(ctx) }
+
+// RunError runs the job in a span, child of any span of the context, and
+// returns the job's error, if it is an ErrorJob.
+func (t *tracedJob) RunError(ctx context.Context) (err error) {
+  name := "cron job"
+  var attrs []attribute.KeyValue
+  if info, ok := cron.RunInfoFrom(ctx); ok {
+    if info.Name != "" {
+      name = "cron " + info.Name
+      attrs = append(attrs, EntryNameKey.String(info.Name))
+    }
+    attrs = append(attrs,
+      EntryIDKey.String(info.EntryID),
+      ScheduledKey.String(info.Scheduled.Format(time.RFC3339Nano)),
+      DelayKey.Float64(time.Since(info.Scheduled).Seconds()))
+  }
+  ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+  defer span.End()
+
+  defer func() {
+    if r := recover(); r != nil {
+      fail(span, fmt.Errorf("panic: %v", r))
+      panic(r)
+    }
+    if err != nil {
+      fail(span, err)
+    } else {
+      span.SetAttributes(OutcomeKey.String("completed"))
+    }
+  }()
+  switch j := t.job.(type) {
+  case cron.ErrorJob:
+    return j.RunError(ctx)
+  case cron.ContextJob:
+    j.RunContext(ctx)
+  default:
+    j.Run()
+  }
+  return nil
+}
+
+// fail records the error of a failed run in its span.
+func fail(span trace.Span, err error) {
+  span.SetAttributes(OutcomeKey.String("failed"))
+  span.RecordError(err)
+  span.SetStatus(codes.Error, err.Error())
+}
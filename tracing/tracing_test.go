@@ -0,0 +1,110 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for tracing the runs of jobs.
+
+package tracing
+
+import (
+  "context"
+  "errors"
+  "testing"
+  "time"
+
+  "github.com/kiranbond/cron"
+  "go.opentelemetry.io/otel/attribute"
+  "go.opentelemetry.io/otel/codes"
+  sdktrace "go.opentelemetry.io/otel/sdk/trace"
+  "go.opentelemetry.io/otel/sdk/trace/tracetest"
+  "go.opentelemetry.io/otel/trace"
+)
+
+func TestWrap(t *testing.T) {
+  recorder := tracetest.NewSpanRecorder()
+  provider := sdktrace.NewTracerProvider(
+    sdktrace.WithSpanProcessor(recorder))
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := cron.NewFakeClock(start)
+  c := cron.New(cron.WithClock(clock), cron.WithLocation(time.UTC),
+    cron.WithChain(Wrap(provider)),
+    cron.WithPanicHandler(func(string, interface{}, []byte) {}))
+
+  done := make(chan struct{}, 10)
+  c.Subscribe(func(e cron.Event) {
+    if e.Type == cron.RunCompleted || e.Type == cron.RunFailed {
+      done <- struct{}{}
+    }
+  })
+  traced := make(chan bool, 1)
+  backup, _ := c.AddJob("@hourly", cron.ContextFuncJob(
+    func(ctx context.Context) {
+      traced <- trace.SpanFromContext(ctx).SpanContext().IsValid()
+    }), cron.WithName("backup"))
+  errFailed := errors.New("failed")
+  failing, _ := c.AddErrorFunc("@hourly", func(context.Context) error {
+    return errFailed
+  })
+  panicking, _ := c.AddFunc("@hourly", func() { panic("boom") })
+  c.Start()
+  defer c.Stop()
+
+  clock.Advance(time.Hour)
+  for i := 0; i < 3; i++ {
+    select {
+    case <-done:
+    case <-time.After(time.Second):
+      t.Fatal("expected three runs")
+    }
+  }
+  if !<-traced {
+    t.Error("expected the job's context to carry the span")
+  }
+
+  tests := []struct {
+    id, name, outcome string
+    status            codes.Code
+  }{
+    {backup, "cron backup", "completed", codes.Unset},
+    {failing, "cron job", "failed", codes.Error},
+    {panicking, "cron job", "failed", codes.Error},
+  }
+  spans := map[string]sdktrace.ReadOnlySpan{}
+  for _, span := range recorder.Ended() {
+    for _, attr := range span.Attributes() {
+      if attr.Key == EntryIDKey {
+        spans[attr.Value.AsString()] = span
+      }
+    }
+  }
+  for _, test := range tests {
+    span, ok := spans[test.id]
+    if !ok {
+      t.Errorf("%s: expected a span", test.name)
+      continue
+    }
+    attrs := map[attribute.Key]attribute.Value{}
+    for _, attr := range span.Attributes() {
+      attrs[attr.Key] = attr.Value
+    }
+    scheduled := start.Add(time.Hour).Format(time.RFC3339Nano)
+    if span.Name() != test.name ||
+      attrs[OutcomeKey].AsString() != test.outcome ||
+      attrs[ScheduledKey].AsString() != scheduled ||
+      span.Status().Code != test.status {
+      t.Errorf("%s: expected %s span, scheduled at %s, got %s %v %v",
+        test.name, test.outcome, scheduled, span.Name(), attrs,
+        span.Status())
+    }
+  }
+}
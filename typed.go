@@ -0,0 +1,85 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements jobs of funcs taking typed payloads.
+
+package cron
+
+import (
+  "context"
+  "encoding/json"
+  "fmt"
+)
+
+// AddTypedFunc adds a func to the Cron to be run on the given schedule with
+// the payload, whose type the compiler checks against that of the func.  The
+// payload is also encoded as JSON as the Payload of the entry, so it is
+// visible in snapshots of the entry; to have the entry stored, register the
+// func with RegisterTypedFunc and add the entry with AddTyped instead.
+func AddTypedFunc[T any](c *Cron, spec string, payload T,
+  fn func(ctx context.Context, payload T) error,
+  opts ...EntryOption) (string, error) {
+  encoded, err := json.Marshal(payload)
+  if err != nil {
+    return "", fmt.Errorf("cannot encode payload: %s", err)
+  }
+  schedule, err := c.parser.Parse(spec)
+  if err != nil {
+    return "", err
+  }
+  entry := c.newEntry(spec, schedule, typedJob(fn, payload), opts)
+  entry.Payload = encoded
+  if taken, err := c.add([]*Entry{entry}); err != nil {
+    return taken, err
+  }
+  return entry.ID, nil
+}
+
+// RegisterTypedFunc registers the jobs named name as runs of the func with
+// payloads of type T, decoded from the JSON payloads of stored entries.  Like
+// RegisterJob, it is meant to be called from init functions, and panics if
+// the name is taken.
+func RegisterTypedFunc[T any](name string,
+  fn func(ctx context.Context, payload T) error) {
+  if fn == nil {
+    panic("cron: RegisterTypedFunc func is nil")
+  }
+  RegisterJob(name, func(data []byte) (Job, error) {
+    var payload T
+    if err := json.Unmarshal(data, &payload); err != nil {
+      return nil, fmt.Errorf("cannot decode payload: %s", err)
+    }
+    return typedJob(fn, payload), nil
+  })
+}
+
+// AddTyped adds the func registered as job with RegisterTypedFunc to the Cron,
+// to be run on the given schedule with the payload.  Like AddRegistered, which
+// it calls with the payload encoded as JSON, the entry is persisted in the
+// Cron's EntryStore, if it has one.
+func AddTyped[T any](c *Cron, spec, job string, payload T,
+  opts ...EntryOption) (string, error) {
+  encoded, err := json.Marshal(payload)
+  if err != nil {
+    return "", fmt.Errorf("cannot encode payload: %s", err)
+  }
+  return c.AddRegistered(spec, job, encoded, opts...)
+}
+
+// typedJob returns the ErrorJob running fn with the payload.
+func typedJob[T any](fn func(context.Context, T) error, payload T) Job {
+  return ErrorFuncJob(func(ctx context.Context) error {
+    return fn(ctx, payload)
+  })
+}
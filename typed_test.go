@@ -0,0 +1,114 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements tests for jobs of funcs taking typed payloads.
+
+package cron
+
+import (
+  "context"
+  "errors"
+  "testing"
+  "time"
+)
+
+// report is the payload of "test-typed" jobs.
+type report struct {
+  To    string `json:"to"`
+  Pages int    `json:"pages"`
+}
+
+// typedRuns receives the payloads of the runs of "test-typed" jobs.
+var typedRuns = make(chan report, 10)
+
+func init() {
+  RegisterTypedFunc("test-typed", func(ctx context.Context, r report) error {
+    typedRuns <- r
+    return nil
+  })
+}
+
+func TestAddTypedFunc(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  cron := New(WithClock(clock), WithLocation(time.UTC))
+  defer cron.Stop()
+
+  if _, err := AddTypedFunc(cron, "@hourly", make(chan int),
+    func(context.Context, chan int) error { return nil }); err == nil {
+    t.Error("expected an error adding a payload that cannot be encoded")
+  }
+
+  runs := make(chan report, 1)
+  failed := errors.New("failed")
+  errs := make(chan error, 1)
+  id, err := AddTypedFunc(cron, "@hourly", report{"ops", 3},
+    func(ctx context.Context, r report) error {
+      runs <- r
+      return failed
+    }, OnError(func(id string, err error) { errs <- err }))
+  if err != nil {
+    t.Fatal(err)
+  }
+  e, _ := cron.Entry(id)
+  if expected := `{"to":"ops","pages":3}`; string(e.Payload) != expected {
+    t.Errorf("(expected) %s != %s (actual)", expected, e.Payload)
+  }
+
+  cron.Start()
+  clock.Advance(time.Hour)
+  if r := <-runs; r != (report{"ops", 3}) {
+    t.Errorf("expected the payload, got %+v", r)
+  }
+  if err := <-errs; err != failed {
+    t.Errorf("expected the error of the func, got %v", err)
+  }
+}
+
+func TestAddTyped(t *testing.T) {
+  start := time.Date(2012, 7, 9, 0, 0, 0, 0, time.UTC)
+  clock := NewFakeClock(start)
+  store := NewMemoryStore()
+  cron := New(WithClock(clock), WithLocation(time.UTC), WithStore(store))
+  defer cron.Stop()
+
+  if _, err := AddTyped(cron, "@hourly", "missing", report{}); err == nil {
+    t.Error("expected an error adding an unregistered job")
+  }
+  if _, err := cron.AddRegistered("@hourly", "test-typed",
+    []byte(`{"pages":"many"}`)); err == nil {
+    t.Error("expected an error adding a payload of the wrong type")
+  }
+  if _, err := AddTyped(cron, "@hourly", "test-typed",
+    report{"ops", 3}); err != nil {
+    t.Fatal(err)
+  }
+
+  // The entry is restored with its payload by another Cron.
+  stored, _ := store.Load()
+  if len(stored) != 1 || string(stored[0].Payload) !=
+    `{"to":"ops","pages":3}` {
+    t.Fatalf("expected the entry stored with its payload, got %+v", stored)
+  }
+  other := New(WithClock(clock), WithLocation(time.UTC), WithStore(store))
+  defer other.Stop()
+  if err := other.Restore(); err != nil {
+    t.Fatal(err)
+  }
+  other.Start()
+  clock.Advance(time.Hour)
+  if r := <-typedRuns; r != (report{"ops", 3}) {
+    t.Errorf("expected the payload, got %+v", r)
+  }
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements union schedule.
+
+package cron
+
+import "time"
+
+// UnionSchedule represents the union of several schedules: it activates
+// whenever any of them does.
+type UnionSchedule struct {
+  Schedules []Schedule
+}
+
+// Next returns the earliest next activation time of all the schedules, or the
+// zero time if none of them can be satisfied.
+func (s *UnionSchedule) Next(t time.Time) time.Time {
+  var next time.Time
+  for _, schedule := range s.Schedules {
+    n := schedule.Next(t)
+    if n.IsZero() {
+      continue
+    }
+    if next.IsZero() || n.Before(next) {
+      next = n
+    }
+  }
+  return next
+}
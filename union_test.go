@@ -0,0 +1,63 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements union schedule test.
+
+package cron
+
+import "testing"
+
+func TestUnionNext(t *testing.T) {
+  runs := []struct {
+    time, spec string
+    expected   string
+  }{
+    {"Fri Jul 13 08:00 2012", "0 0 9 * * MON-FRI || 0 0 11 * * SAT", "Fri Jul 13 09:00 2012"},
+    {"Fri Jul 13 09:00 2012", "0 0 9 * * MON-FRI || 0 0 11 * * SAT", "Sat Jul 14 11:00 2012"},
+    {"Sat Jul 14 11:00 2012", "0 0 9 * * MON-FRI || 0 0 11 * * SAT", "Mon Jul 16 09:00 2012"},
+    {"Mon Jul 9 14:45 2012", "@hourly||0 30 * * * *", "Mon Jul 9 15:00 2012"},
+    {"Mon Jul 9 15:00 2012", "@hourly||0 30 * * * *", "Mon Jul 9 15:30 2012"},
+
+    // Unsatisfiable parts are ignored.
+    {"Mon Jul 9 14:45 2012", "0 0 0 30 Feb ? || @daily", "Tue Jul 10 00:00 2012"},
+    {"Mon Jul 9 14:45 2012", "0 0 0 30 Feb ? || 0 0 0 31 Apr ?", ""},
+  }
+
+  for _, c := range runs {
+    sched, err := Parse(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    actual := sched.Next(getTime(c.time))
+    expected := getTime(c.expected)
+    if !actual.Equal(expected) {
+      t.Errorf("%s, \"%s\": (expected) %v != %v (actual)", c.time, c.spec, expected, actual)
+    }
+  }
+}
+
+func TestUnionErrors(t *testing.T) {
+  invalidSpecs := []string{
+    "@hourly ||",
+    "|| @hourly",
+    "@hourly || 0 60 * * * *",
+  }
+  for _, spec := range invalidSpecs {
+    _, err := Parse(spec)
+    if err == nil {
+      t.Error("expected an error parsing: ", spec)
+    }
+  }
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements parse-time warnings for suspicious specs.
+
+package cron
+
+import (
+  "fmt"
+  "strings"
+)
+
+// maxDaysInMonth is the largest number of days of each month, indexed by
+// month.
+var maxDaysInMonth = [...]uint{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// ParseWithWarnings is Parse, but also returns warnings about parts of the spec
+// that are valid but likely wrong.
+func ParseWithWarnings(spec string) (Schedule, []string, error) {
+  return defaultParser.ParseWithWarnings(spec)
+}
+
+// ParseWithWarnings is Parse, but also returns warnings about parts of the spec
+// that are valid but likely wrong:
+//   - both the day of month and the day of week are restricted, so the job
+//     runs when either matches
+//   - days of the month that do not occur in any of the months, e.g. Feb 30
+//   - steps larger than their range, which only ever match the range's start
+//   - five field specs, whose first field is the second rather than the minute
+func (p Parser) ParseWithWarnings(spec string) (Schedule, []string, error) {
+  schedule, err := p.Parse(spec)
+  if err != nil {
+    return nil, nil, err
+  }
+
+  var warnings []string
+  for _, part := range specParts(spec) {
+    warnings = append(warnings, p.specWarnings(part)...)
+  }
+  return schedule, warnings, nil
+}
+
+// specParts splits a spec into the specs of its unions and exclusions, without
+// its time zone.
+func specParts(spec string) []string {
+  if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+    if i := strings.IndexAny(spec, " \t"); i >= 0 {
+      spec = spec[i:]
+    }
+  }
+
+  var parts []string
+  for _, except := range strings.Split(spec, exceptSeparator) {
+    for _, part := range strings.Split(except, unionSeparator) {
+      parts = append(parts, strings.TrimSpace(part))
+    }
+  }
+  return parts
+}
+
+// specWarnings returns the warnings for a single, valid spec.
+func (p Parser) specWarnings(spec string) []string {
+  if spec[0] == '@' || strings.HasPrefix(spec, awsCronPrefix) ||
+    strings.HasPrefix(spec, awsRatePrefix) {
+    return nil
+  }
+
+  fields, err := p.fields(spec)
+  if err != nil {
+    return nil
+  }
+
+  var warnings []string
+  if len(fields) == 5 {
+    warnings = append(warnings, fmt.Sprintf("5 fields are read as second, "+
+      "minute, hour, day of month and month, not as a standard crontab "+
+      "starting with the minute (see ParseStandard): %s", spec))
+  }
+
+  for i, r := range []bounds{seconds, minutes, hours, dom, months, dow} {
+    if i >= len(fields) {
+      break
+    }
+    warnings = append(warnings, stepWarnings(fields[i], r)...)
+  }
+
+  schedule, err := p.Parse(spec)
+  if err != nil {
+    return warnings
+  }
+  s, ok := schedule.(*SpecSchedule)
+  if !ok {
+    return warnings
+  }
+
+  if s.Dom&starBit == 0 && s.Dow&starBit == 0 {
+    warnings = append(warnings, fmt.Sprintf("day of month and day of week "+
+      "are both restricted, so the job runs on days matching either: %s",
+      spec))
+  }
+
+  if s.Calendar == nil {
+    for day := dom.min; day <= dom.max; day++ {
+      if 1<<day&s.Dom == 0 || dayOccurs(day, s.Month) {
+        continue
+      }
+      warnings = append(warnings, fmt.Sprintf("day of month %d does not "+
+        "occur in any of the months: %s", day, spec))
+    }
+  }
+  return warnings
+}
+
+// stepWarnings returns warnings for the steps in a field that are larger than
+// the range they apply to.
+func stepWarnings(field string, r bounds) []string {
+  var warnings []string
+  for _, expr := range strings.Split(field, ",") {
+    rangeAndStep := strings.Split(expr, "/")
+    if len(rangeAndStep) != 2 {
+      continue
+    }
+    step, err := mustParseInt(rangeAndStep[1])
+    if err != nil {
+      continue
+    }
+
+    start, end := r.min, r.max
+    lowAndHigh := strings.Split(rangeAndStep[0], "-")
+    if lowAndHigh[0] != "*" && lowAndHigh[0] != "?" {
+      if start, err = parseIntOrName(lowAndHigh[0], r.names); err != nil {
+        continue
+      }
+      if len(lowAndHigh) == 2 {
+        if end, err = parseIntOrName(lowAndHigh[1], r.names); err != nil {
+          continue
+        }
+      }
+    }
+    if end >= start && step > end-start {
+      warnings = append(warnings, fmt.Sprintf("step %d is larger than the "+
+        "range %d-%d, so only %d matches: %s", step, start, end, start, expr))
+    }
+  }
+  return warnings
+}
+
+// dayOccurs returns true if the day of month occurs in any of the months.
+func dayOccurs(day uint, months uint64) bool {
+  for month := uint(1); month < uint(len(maxDaysInMonth)); month++ {
+    if 1<<month&months > 0 && day <= maxDaysInMonth[month] {
+      return true
+    }
+  }
+  return false
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2016 ZeroStack, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// This file implements parse-time warnings test.
+
+package cron
+
+import (
+  "strings"
+  "testing"
+)
+
+func TestParseWithWarnings(t *testing.T) {
+  tests := []struct {
+    spec     string
+    expected []string
+  }{
+    {"0 0 9 * * MON-FRI", nil},
+    {"0 0 0 29 Feb ?", nil},
+    {"0 0 0 31 * ?", nil},
+    {"0 */30 * * * *", nil},
+    {"@every 1h", nil},
+    {"cron(0 12 1 * MON *)", nil},
+    {"0 0 0 1,15 * MON", []string{"both restricted"}},
+    {"0 0 0 30 Feb ?", []string{"day of month 30"}},
+    {"0 0 0 30,31 Feb ?", []string{"day of month 30", "day of month 31"}},
+    {"0 0 0 31 Apr,Jun ?", []string{"day of month 31"}},
+    {"0 */90 * * * *", []string{"step 90"}},
+    {"0 10-20/15 * * * *", []string{"step 15"}},
+    {"30 2 * * 1", []string{"5 fields"}},
+    {"@hourly || 0 0 0 30 Feb ?", []string{"day of month 30"}},
+    {"@hourly except 0 */90 * * * *", []string{"step 90"}},
+  }
+
+  for _, c := range tests {
+    _, warnings, err := ParseWithWarnings(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    if len(warnings) != len(c.expected) {
+      t.Errorf("%s => (expected) %q != %q (actual)", c.spec, c.expected, warnings)
+      continue
+    }
+    for i, expected := range c.expected {
+      if !strings.Contains(warnings[i], expected) {
+        t.Errorf("%s => (expected) %q != %q (actual)", c.spec, c.expected, warnings)
+      }
+    }
+  }
+
+  if _, _, err := ParseWithWarnings("0 60 * * * *"); err == nil {
+    t.Error("expected an error parsing: 0 60 * * * *")
+  }
+}
+
+func TestParseStandardWarnings(t *testing.T) {
+  p := NewParser(Standard)
+  tests := []struct {
+    spec     string
+    expected []string
+  }{
+    {"30 2 * * 1", nil},
+    {"*/90 * * * *", []string{"step 90"}},
+    {"0 0 31 Apr,Jun ?", []string{"day of month 31"}},
+  }
+
+  for _, c := range tests {
+    _, warnings, err := p.ParseWithWarnings(c.spec)
+    if err != nil {
+      t.Error(err)
+      continue
+    }
+    if len(warnings) != len(c.expected) {
+      t.Errorf("%s => (expected) %q != %q (actual)", c.spec, c.expected, warnings)
+      continue
+    }
+    for i, expected := range c.expected {
+      if !strings.Contains(warnings[i], expected) {
+        t.Errorf("%s => (expected) %q != %q (actual)", c.spec, c.expected, warnings)
+      }
+    }
+  }
+}